@@ -2,43 +2,123 @@ package main
 
 import (
 	"context"
+	"errors"
+	"flag"
+	"fmt"
 	"github.com/maksemen2/pvz-service/config"
 	"os"
 	"os/signal"
+	"strings"
 	"syscall"
-	"time"
 
 	"github.com/maksemen2/pvz-service/internal/app"
 	"go.uber.org/zap"
 )
 
 func main() {
-	cfg, err := config.Load()
+	configPath := flag.String("config", os.Getenv(config.FileEnv), "path to YAML config file")
+	flag.Parse()
 
+	var err error
+
+	switch args := flag.Args(); {
+	case len(args) == 0:
+		err = run(*configPath)
+	case len(args) == 2 && args[0] == "config" && args[1] == "print":
+		err = printConfig(*configPath)
+	default:
+		err = fmt.Errorf("unknown command %q, expected no command or \"config print\"", strings.Join(args, " "))
+	}
+
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+}
+
+// printConfig выводит итоговый конфиг со скрытыми секретами.
+func printConfig(configPath string) error {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("configuration load failed: %w", err)
+	}
+
+	return cfg.Redacted().WriteYAML(os.Stdout)
+}
+
+// run запускает приложение и блокируется до сигнала завершения или аварийной остановки компонента.
+func run(configPath string) error {
+	cfg, err := config.Load(configPath)
 	if err != nil {
-		panic("configuration load failed: " + err.Error())
+		return fmt.Errorf("configuration load failed: %w", err)
 	}
 
 	application, err := app.Initialize(cfg)
 	if err != nil {
-		panic("failed to initialize application" + err.Error())
+		return fmt.Errorf("failed to initialize application: %w", err)
 	}
 
-	servers, err := application.StartServers()
+	lifecycle, err := application.BuildLifecycle()
 	if err != nil {
-		application.Logger.Fatal("Failed to start servers", zap.Error(err))
+		return fmt.Errorf("failed to build application: %w", err)
 	}
 
+	if err := lifecycle.Start(); err != nil {
+		return fmt.Errorf("failed to start application: %w", err)
+	}
+
+	runErr := waitForShutdown(application, lifecycle, configPath)
+
+	if err := errors.Join(runErr, lifecycle.Stop(context.Background())); err != nil {
+		return err
+	}
+
+	application.Logger.Info("Application stopped")
+
+	return nil
+}
+
+// waitForShutdown блокируется до сигнала завершения или аварийной остановки компонента.
+// По SIGHUP перечитывает конфигурацию и применяет уровни логирования, остальные настройки требуют перезапуска.
+func waitForShutdown(application *app.Application, lifecycle *app.Lifecycle, configPath string) error {
 	sigChan := make(chan os.Signal, 1)
-	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM)
-	<-sigChan
+	signal.Notify(sigChan, syscall.SIGINT, syscall.SIGTERM, syscall.SIGHUP)
 
-	cancelTimeout := 5 * time.Second
+	defer signal.Stop(sigChan)
 
-	ctx, cancel := context.WithTimeout(context.Background(), cancelTimeout)
-	defer cancel()
+	for {
+		select {
+		case sig := <-sigChan:
+			if sig == syscall.SIGHUP {
+				reloadLogLevels(application, configPath)
+				continue
+			}
 
-	servers.Stop(ctx)
+			application.Logger.Info("Shutdown signal received", zap.String("signal", sig.String()))
 
-	application.Logger.Info("Application stopped")
+			return nil
+		case err := <-lifecycle.Errors():
+			application.Logger.Error("Component failed, shutting down", zap.Error(err))
+
+			return err
+		}
+	}
+}
+
+func reloadLogLevels(application *app.Application, configPath string) {
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		application.Logger.Error("Failed to reload configuration", zap.Error(err))
+		return
+	}
+
+	if err := application.LogLevels.Apply(cfg.Logging); err != nil {
+		application.Logger.Error("Failed to apply log levels", zap.Error(err))
+		return
+	}
+
+	application.Logger.Warn("Log levels reloaded",
+		zap.String("level", cfg.Logging.Level),
+		zap.Any("packages", cfg.Logging.PackageLevels),
+	)
 }
@@ -3,22 +3,34 @@ package config
 import "fmt"
 
 // Config объединяет в себе все другие
-// конфиги для отдельных сервисов
+// конфиги для отдельных сервисов.
+// Поля с тегом secret:"true" можно передать через файл (переменная с суффиксом _FILE),
+// при выводе конфига они скрываются.
 type Config struct {
-	HTTP     HTTPConfig
-	Database DatabaseConfig
-	Auth     AuthConfig
-	Metrics  MetricsConfig
-	Logging  LoggingConfig
-	GRPC     GRPCConfig
+	HTTP          HTTPConfig               `yaml:"http"`
+	Storage       StorageConfig            `yaml:"storage"`
+	Database      DatabaseConfig           `yaml:"database"`
+	Auth          AuthConfig               `yaml:"auth"`
+	Metrics       MetricsConfig            `yaml:"metrics"`
+	Logging       LoggingConfig            `yaml:"logging"`
+	GRPC          GRPCConfig               `yaml:"grpc"`
+	AutoClose     ReceptionAutoCloseConfig `yaml:"auto_close"`
+	Reception     ReceptionConfig          `yaml:"reception"`
+	Product       ProductConfig            `yaml:"product"`
+	Pickup        PickupConfig             `yaml:"pickup"`
+	StoragePeriod StoragePeriodConfig      `yaml:"storage_period"`
+	Idempotency   IdempotencyConfig        `yaml:"idempotency"`
+	Tracing       TracingConfig            `yaml:"tracing"`
+	Health        HealthConfig             `yaml:"health"`
+	Shutdown      ShutdownConfig           `yaml:"shutdown"`
 }
 
 // HTTPConfig содержит конфигурацию
 // сервера
 type HTTPConfig struct {
-	Host string `env:"HTTP_HOST" env-default:"0.0.0.0"`
-	Port int    `env:"HTTP_PORT" env-default:"8080"`
-	Env  string `env:"ENV" env-default:"dev"` // dev или prod.
+	Host string `yaml:"host" env:"HTTP_HOST" envDefault:"0.0.0.0"`
+	Port int    `yaml:"port" env:"HTTP_PORT" envDefault:"8080"`
+	Env  string `yaml:"env" env:"ENV" envDefault:"dev"` // dev или prod.
 }
 
 // GetAddr возвращает адрес сервера
@@ -30,18 +42,31 @@ func (c *HTTPConfig) GetAddr() string {
 	return fmt.Sprintf("%s:%d", c.Host, c.Port)
 }
 
+// Типы хранилища данных (см. StorageConfig).
+const (
+	StoragePostgres = "postgres"
+	StorageMemory   = "memory"
+)
+
+// StorageConfig выбирает хранилище данных: postgres или memory.
+// Хранилище в памяти не требует базы данных, но теряет данные при остановке
+// и не разделяется между репликами, поэтому в prod запрещено.
+type StorageConfig struct {
+	Type string `yaml:"type" env:"STORAGE" envDefault:"postgres"`
+}
+
 // DatabaseConfig содержит конфигурацию
 // подключения к БД. Дефолтные значения
 // указаны для Postgres
 type DatabaseConfig struct {
-	Host               string `env:"DB_HOST" env-default:"localhost"`
-	Port               int    `env:"DB_PORT" env-default:"5432"`
-	User               string `env:"DB_USER" env-default:"postgres"`
-	Password           string `env:"DB_PASSWORD" env-default:"postgres"`
-	DBName             string `env:"DB_NAME" env-default:"pvz_service"`
-	SSLMode            string `env:"DB_SSLMODE" env-default:"disable"`
-	MaxOpenConnections int    `env:"DB_MAX_OPEN_CONNS" env-default:"25"`
-	MaxIdleConnections int    `env:"DB_MAX_IDLE_CONNS" env-default:"5"`
+	Host               string `yaml:"host" env:"DB_HOST" envDefault:"localhost"`
+	Port               int    `yaml:"port" env:"DB_PORT" envDefault:"5432"`
+	User               string `yaml:"user" env:"DB_USER" envDefault:"postgres"`
+	Password           string `yaml:"password" env:"DB_PASSWORD" envDefault:"postgres" secret:"true"`
+	DBName             string `yaml:"db_name" env:"DB_NAME" envDefault:"pvz_service"`
+	SSLMode            string `yaml:"ssl_mode" env:"DB_SSLMODE" envDefault:"disable"`
+	MaxOpenConnections int    `yaml:"max_open_connections" env:"DB_MAX_OPEN_CONNS" envDefault:"25"`
+	MaxIdleConnections int    `yaml:"max_idle_connections" env:"DB_MAX_IDLE_CONNS" envDefault:"5"`
 }
 
 func (c *DatabaseConfig) DSN() string {
@@ -52,18 +77,23 @@ func (c *DatabaseConfig) DSN() string {
 }
 
 // AuthConfig содержит конфигурацию
-// Для JWT аутентификации
+// Для JWT аутентификации.
+// JWTSecret обязателен, в prod он должен быть не короче 32 символов.
 type AuthConfig struct {
-	JWTSecret              string `env:"JWT_SECRET" env-required:"true"`
-	TokenExpirationSeconds int    `env:"TOKEN_EXPIRATION" env-default:"3600"` // Время в секундах
+	JWTSecret              string `yaml:"jwt_secret" env:"JWT_SECRET" secret:"true"`
+	TokenExpirationSeconds int    `yaml:"token_expiration_seconds" env:"TOKEN_EXPIRATION" envDefault:"3600"` // Время в секундах
 }
 
 // MetricsConfig содержит конфигурацию для
 // Сбора метрик из Prometheus. Дефолтные значения
 // взяты из описания задания.
+// BusinessIntervalSeconds задает период пересчета бизнес-метрик текущего состояния из БД, 0 выключает пересчет.
+// AdminToken - токен для служебных ручек /admin на этом порту, без него ручки отвечают 401.
 type MetricsConfig struct {
-	Port int    `env:"METRICS_PORT" env-default:"9000"`
-	Path string `env:"METRICS_PATH" env-default:"/metrics"`
+	Port                    int    `yaml:"port" env:"METRICS_PORT" envDefault:"9000"`
+	Path                    string `yaml:"path" env:"METRICS_PATH" envDefault:"/metrics"`
+	BusinessIntervalSeconds int    `yaml:"business_interval_seconds" env:"METRICS_BUSINESS_INTERVAL" envDefault:"60"` // Время в секундах
+	AdminToken              string `yaml:"admin_token" env:"ADMIN_TOKEN" secret:"true"`
 }
 
 func (m *MetricsConfig) GetAddr() string {
@@ -71,13 +101,113 @@ func (m *MetricsConfig) GetAddr() string {
 }
 
 // LoggingConfig содержит конфигурацию для
-// логгера.
+// логгера. Уровни можно менять во время работы через /admin/log-level,
+// а по сигналу SIGHUP они перечитываются из конфигурации.
+// Encoding - json или console (удобен для локальной разработки).
+// Из каждой секунды в лог попадают первые SamplingInitial одинаковых сообщений,
+// затем каждое SamplingThereafter-е, 0 в SamplingInitial выключает сэмплирование.
+// PackageLevels переопределяет уровень для логгеров пакетов (database, postgresqlrepo, service),
+// например LOG_PACKAGE_LEVELS="postgresqlrepo:debug,service:warn".
 type LoggingConfig struct {
-	Level string `env:"LOG_LEVEL" env-default:"info"` // debug, info, warn, error или silent
+	Level              string            `yaml:"level" env:"LOG_LEVEL" envDefault:"info"` // debug, info, warn, error или silent
+	Encoding           string            `yaml:"encoding" env:"LOG_ENCODING" envDefault:"json"`
+	SamplingInitial    int               `yaml:"sampling_initial" env:"LOG_SAMPLING_INITIAL" envDefault:"100"`
+	SamplingThereafter int               `yaml:"sampling_thereafter" env:"LOG_SAMPLING_THEREAFTER" envDefault:"100"`
+	PackageLevels      map[string]string `yaml:"package_levels" env:"LOG_PACKAGE_LEVELS"` // Имя логгера: уровень
 }
 
 // GRPCConfig содержит конфигурацию для gRPC сервера.
 // Дефолтный порт взят из описания задания.
 type GRPCConfig struct {
-	Port int `env:"GRPC_PORT" env-default:"3000"`
+	Port int `yaml:"port" env:"GRPC_PORT" envDefault:"3000"`
+}
+
+// ReceptionAutoCloseConfig содержит конфигурацию задачи
+// автоматического закрытия забытых приемок.
+// Максимальный возраст приемки можно переопределить для отдельных городов,
+// например RECEPTION_AUTO_CLOSE_CITY_MAX_OPEN_AGE="Москва:3600,Казань:7200".
+type ReceptionAutoCloseConfig struct {
+	Enabled               bool           `yaml:"enabled" env:"RECEPTION_AUTO_CLOSE_ENABLED" envDefault:"true"`
+	IntervalSeconds       int            `yaml:"interval_seconds" env:"RECEPTION_AUTO_CLOSE_INTERVAL" envDefault:"300"`           // Время в секундах
+	MaxOpenAgeSeconds     int            `yaml:"max_open_age_seconds" env:"RECEPTION_AUTO_CLOSE_MAX_OPEN_AGE" envDefault:"43200"` // Время в секундах
+	CityMaxOpenAgeSeconds map[string]int `yaml:"city_max_open_age_seconds" env:"RECEPTION_AUTO_CLOSE_CITY_MAX_OPEN_AGE"`          // Город: время в секундах
+}
+
+// ReceptionConfig содержит конфигурацию работы с приемками.
+// В течение ReopenGracePeriodSeconds после закрытия сотрудник,
+// закрывший приемку, может открыть её повторно.
+type ReceptionConfig struct {
+	ReopenGracePeriodSeconds int `yaml:"reopen_grace_period_seconds" env:"RECEPTION_REOPEN_GRACE_PERIOD" envDefault:"600"` // Время в секундах
+}
+
+// ProductConfig содержит конфигурацию работы с товарами.
+type ProductConfig struct {
+	BatchMaxItems int `yaml:"batch_max_items" env:"PRODUCTS_BATCH_MAX_ITEMS" envDefault:"100"` // Максимальное количество товаров в одном пакете
+	IssueMaxItems int `yaml:"issue_max_items" env:"PRODUCTS_ISSUE_MAX_ITEMS" envDefault:"50"`  // Максимальное количество товаров в одной выдаче
+}
+
+// PickupConfig содержит конфигурацию кодов получения заказов.
+// После MaxAttempts неудачных попыток код блокируется, и заказу нужен новый код.
+// Если за последние PVZFailureWindowSeconds в ПВЗ было PVZMaxFailures неудачных попыток
+// ввода кодов, проверка кодов в этом ПВЗ временно отклоняется.
+type PickupConfig struct {
+	CodeTTLHours            int `yaml:"code_ttl_hours" env:"PICKUP_CODE_TTL_HOURS" envDefault:"168"`                 // Время в часах
+	MaxAttempts             int `yaml:"max_attempts" env:"PICKUP_CODE_MAX_ATTEMPTS" envDefault:"5"`                  // Неудачных попыток на код
+	PVZMaxFailures          int `yaml:"pvz_max_failures" env:"PICKUP_PVZ_MAX_FAILURES" envDefault:"20"`              // Неудачных попыток в ПВЗ за окно
+	PVZFailureWindowSeconds int `yaml:"pvz_failure_window_seconds" env:"PICKUP_PVZ_FAILURE_WINDOW" envDefault:"600"` // Время в секундах
+}
+
+// StoragePeriodConfig содержит конфигурацию сроков хранения товаров в ПВЗ.
+// Срок отсчитывается от времени приемки товара. Срок для типа товара важнее срока для города,
+// например STORAGE_PERIOD_TYPE_DAYS="электроника:7" и STORAGE_PERIOD_CITY_DAYS="Москва:10".
+// Товары, срок хранения которых истекает в ближайшие WarningDays дней, попадают в список истекающих.
+type StoragePeriodConfig struct {
+	Days        int            `yaml:"days" env:"STORAGE_PERIOD_DAYS" envDefault:"14"`                // Время в днях
+	TypeDays    map[string]int `yaml:"type_days" env:"STORAGE_PERIOD_TYPE_DAYS"`                      // Тип товара: время в днях
+	CityDays    map[string]int `yaml:"city_days" env:"STORAGE_PERIOD_CITY_DAYS"`                      // Город: время в днях
+	WarningDays int            `yaml:"warning_days" env:"STORAGE_PERIOD_WARNING_DAYS" envDefault:"2"` // Время в днях
+}
+
+// IdempotencyConfig содержит конфигурацию хранения ответов
+// на запросы с заголовком Idempotency-Key.
+// Если запрос с ключом не завершился за LockTimeoutSeconds
+// (например, процесс упал), ключ можно использовать повторно.
+// Истекшие ключи удаляются фоновой задачей раз в CleanupIntervalSeconds (0 - задача отключена).
+type IdempotencyConfig struct {
+	TTLSeconds             int `yaml:"ttl_seconds" env:"IDEMPOTENCY_TTL" envDefault:"86400"`                          // Время в секундах
+	LockTimeoutSeconds     int `yaml:"lock_timeout_seconds" env:"IDEMPOTENCY_LOCK_TIMEOUT" envDefault:"60"`           // Время в секундах
+	CleanupIntervalSeconds int `yaml:"cleanup_interval_seconds" env:"IDEMPOTENCY_CLEANUP_INTERVAL" envDefault:"3600"` // Время в секундах
+}
+
+// TracingConfig содержит конфигурацию трассировки OpenTelemetry.
+// Exporter - куда отправлять спаны: otlp (OTLP/gRPC на OTLPEndpoint), stdout или none.
+// При none спаны не экспортируются, но айди трассировок по-прежнему попадают в логи и ответы с ошибкой.
+// SampleRatio - доля трассировок, которые будут записаны (от 0 до 1).
+type TracingConfig struct {
+	Exporter     string  `yaml:"exporter" env:"TRACING_EXPORTER" envDefault:"none"`
+	OTLPEndpoint string  `yaml:"otlp_endpoint" env:"TRACING_OTLP_ENDPOINT" envDefault:"localhost:4317"`
+	OTLPInsecure bool    `yaml:"otlp_insecure" env:"TRACING_OTLP_INSECURE" envDefault:"true"`
+	SampleRatio  float64 `yaml:"sample_ratio" env:"TRACING_SAMPLE_RATIO" envDefault:"1"`
+	ServiceName  string  `yaml:"service_name" env:"TRACING_SERVICE_NAME" envDefault:"pvz-service"`
+}
+
+// HealthConfig содержит конфигурацию проверки готовности сервиса.
+type HealthConfig struct {
+	DBPingTimeoutSeconds int `yaml:"db_ping_timeout_seconds" env:"HEALTH_DB_PING_TIMEOUT" envDefault:"2"` // Время в секундах
+}
+
+// ShutdownConfig содержит ограничения времени остановки компонентов приложения.
+// Серверы дожидаются завершения текущих запросов, фоновые задачи - текущего прохода.
+// По истечении времени остановка компонента прерывается и приложение переходит к следующему.
+// Значение 0 означает ограничение по умолчанию (5 секунд).
+// Перед остановкой серверов сервис отмечается неготовым и ждет DrainDelaySeconds,
+// чтобы балансировщик успел увидеть это и перестать направлять на него новые запросы (0 - без ожидания).
+type ShutdownConfig struct {
+	DrainDelaySeconds      int `yaml:"drain_delay_seconds" env:"SHUTDOWN_DRAIN_DELAY" envDefault:"5"`           // Время в секундах
+	HTTPTimeoutSeconds     int `yaml:"http_timeout_seconds" env:"SHUTDOWN_HTTP_TIMEOUT" envDefault:"10"`        // Время в секундах
+	GRPCTimeoutSeconds     int `yaml:"grpc_timeout_seconds" env:"SHUTDOWN_GRPC_TIMEOUT" envDefault:"10"`        // Время в секундах
+	MetricsTimeoutSeconds  int `yaml:"metrics_timeout_seconds" env:"SHUTDOWN_METRICS_TIMEOUT" envDefault:"5"`   // Время в секундах
+	WorkersTimeoutSeconds  int `yaml:"workers_timeout_seconds" env:"SHUTDOWN_WORKERS_TIMEOUT" envDefault:"30"`  // Время в секундах
+	TracingTimeoutSeconds  int `yaml:"tracing_timeout_seconds" env:"SHUTDOWN_TRACING_TIMEOUT" envDefault:"5"`   // Время в секундах
+	DatabaseTimeoutSeconds int `yaml:"database_timeout_seconds" env:"SHUTDOWN_DATABASE_TIMEOUT" envDefault:"5"` // Время в секундах
 }
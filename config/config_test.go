@@ -0,0 +1,258 @@
+//go:build unit
+// +build unit
+
+package config_test
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/maksemen2/pvz-service/config"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const strongSecret = "3f9c1a7e5b2d4f6a8c0e1b3d5f7a9c2e"
+
+func writeFile(t *testing.T, name, content string) string {
+	t.Helper()
+
+	path := filepath.Join(t.TempDir(), name)
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+
+	return path
+}
+
+func TestLoad_Defaults(t *testing.T) {
+	t.Setenv("JWT_SECRET", "dev-secret")
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, 8080, cfg.HTTP.Port)
+	assert.Equal(t, "dev", cfg.HTTP.Env)
+	assert.Equal(t, config.StoragePostgres, cfg.Storage.Type)
+	assert.Equal(t, "pvz_service", cfg.Database.DBName)
+	assert.Equal(t, 3600, cfg.Auth.TokenExpirationSeconds)
+	assert.Equal(t, "info", cfg.Logging.Level)
+	assert.True(t, cfg.AutoClose.Enabled)
+	assert.Equal(t, 14, cfg.StoragePeriod.Days)
+	assert.Equal(t, 1.0, cfg.Tracing.SampleRatio)
+	assert.Equal(t, 10, cfg.Shutdown.HTTPTimeoutSeconds)
+	assert.Equal(t, 5, cfg.Shutdown.DrainDelaySeconds)
+}
+
+func TestLoad_FileAndEnvPriority(t *testing.T) {
+	path := writeFile(t, "config.yaml", `
+http:
+  port: 8090
+  env: prod
+auth:
+  jwt_secret: `+strongSecret+`
+database:
+  host: db.internal
+  port: 6432
+auto_close:
+  city_max_open_age_seconds:
+    Москва: 3600
+logging:
+  level: debug
+`)
+
+	t.Setenv("DB_PORT", "7432")
+	t.Setenv("LOG_LEVEL", "warn")
+
+	cfg, err := config.Load(path)
+	require.NoError(t, err)
+
+	// Значения из файла
+	assert.Equal(t, 8090, cfg.HTTP.Port)
+	assert.Equal(t, "db.internal", cfg.Database.Host)
+	assert.Equal(t, map[string]int{"Москва": 3600}, cfg.AutoClose.CityMaxOpenAgeSeconds)
+
+	// Переменные окружения имеют приоритет над файлом
+	assert.Equal(t, 7432, cfg.Database.Port)
+	assert.Equal(t, "warn", cfg.Logging.Level)
+
+	// Не указанные в файле и окружении значения берутся по умолчанию
+	assert.Equal(t, 3000, cfg.GRPC.Port)
+}
+
+func TestLoad_UnknownFileKey(t *testing.T) {
+	path := writeFile(t, "config.yaml", "http:\n  prot: 8090\n")
+	t.Setenv("JWT_SECRET", "dev-secret")
+
+	_, err := config.Load(path)
+	assert.ErrorContains(t, err, "prot")
+}
+
+func TestLoad_SecretFiles(t *testing.T) {
+	t.Setenv("JWT_SECRET_FILE", writeFile(t, "jwt", strongSecret+"\n"))
+	t.Setenv("DB_PASSWORD_FILE", writeFile(t, "db", "db-password\n"))
+	t.Setenv("ADMIN_TOKEN_FILE", writeFile(t, "admin", "admin-token\n"))
+
+	cfg, err := config.Load("")
+	require.NoError(t, err)
+
+	assert.Equal(t, strongSecret, cfg.Auth.JWTSecret)
+	assert.Equal(t, "db-password", cfg.Database.Password)
+	assert.Equal(t, "admin-token", cfg.Metrics.AdminToken)
+
+	t.Run("Both variable and file are set", func(t *testing.T) {
+		t.Setenv("JWT_SECRET", strongSecret)
+
+		_, err := config.Load("")
+		assert.ErrorContains(t, err, "JWT_SECRET_FILE")
+	})
+
+	t.Run("Missing file", func(t *testing.T) {
+		t.Setenv("DB_PASSWORD_FILE", filepath.Join(t.TempDir(), "missing"))
+
+		_, err := config.Load("")
+		assert.ErrorContains(t, err, "DB_PASSWORD_FILE")
+	})
+}
+
+func validConfig() config.Config {
+	return config.Config{
+		HTTP:     config.HTTPConfig{Port: 8080, Env: "prod"},
+		Storage:  config.StorageConfig{Type: config.StoragePostgres},
+		GRPC:     config.GRPCConfig{Port: 3000},
+		Metrics:  config.MetricsConfig{Port: 9000},
+		Database: config.DatabaseConfig{Port: 5432},
+		Auth:     config.AuthConfig{JWTSecret: strongSecret},
+		Logging:  config.LoggingConfig{Level: "info", Encoding: "json"},
+		Tracing:  config.TracingConfig{SampleRatio: 1},
+	}
+}
+
+func TestConfig_Validate(t *testing.T) {
+	tests := []struct {
+		name          string
+		modify        func(cfg *config.Config)
+		expectedError string
+	}{
+		{name: "Valid config", modify: func(*config.Config) {}},
+		{
+			name:   "Short secret in dev",
+			modify: func(cfg *config.Config) { cfg.HTTP.Env = "dev"; cfg.Auth.JWTSecret = "very_secret_key" },
+		},
+		{
+			name:          "Missing secret",
+			modify:        func(cfg *config.Config) { cfg.Auth.JWTSecret = "" },
+			expectedError: "jwt secret is required",
+		},
+		{
+			name:          "Short secret in prod",
+			modify:        func(cfg *config.Config) { cfg.Auth.JWTSecret = "short" },
+			expectedError: "at least 32 characters",
+		},
+		{
+			name:          "Well-known secret in prod",
+			modify:        func(cfg *config.Config) { cfg.Auth.JWTSecret = "my_very_secret_key_for_production_use" },
+			expectedError: "well-known",
+		},
+		{
+			name:          "Repeated character secret in prod",
+			modify:        func(cfg *config.Config) { cfg.Auth.JWTSecret = strings.Repeat("a", 40) },
+			expectedError: "well-known",
+		},
+		{
+			name:          "Short admin token in prod",
+			modify:        func(cfg *config.Config) { cfg.Metrics.AdminToken = "admin" },
+			expectedError: "admin token must be at least 32 characters",
+		},
+		{
+			name:   "Short admin token in dev",
+			modify: func(cfg *config.Config) { cfg.HTTP.Env = "dev"; cfg.Metrics.AdminToken = "admin" },
+		},
+		{
+			name:          "Port out of range",
+			modify:        func(cfg *config.Config) { cfg.HTTP.Port = 70000 },
+			expectedError: "http port must be between 1 and 65535",
+		},
+		{
+			name:          "Port conflict",
+			modify:        func(cfg *config.Config) { cfg.Metrics.Port = cfg.GRPC.Port },
+			expectedError: "same port",
+		},
+		{
+			name:          "Unknown log level",
+			modify:        func(cfg *config.Config) { cfg.Logging.Level = "verbose" },
+			expectedError: "unknown log level",
+		},
+		{
+			name:          "Unknown log encoding",
+			modify:        func(cfg *config.Config) { cfg.Logging.Encoding = "text" },
+			expectedError: "unknown log encoding",
+		},
+		{
+			name:          "Unknown package log level",
+			modify:        func(cfg *config.Config) { cfg.Logging.PackageLevels = map[string]string{"postgresqlrepo": "trace"} },
+			expectedError: "package postgresqlrepo",
+		},
+		{
+			name:          "Unknown env",
+			modify:        func(cfg *config.Config) { cfg.HTTP.Env = "staging" },
+			expectedError: "unknown env",
+		},
+		{
+			name:   "Memory storage in dev",
+			modify: func(cfg *config.Config) { cfg.HTTP.Env = "dev"; cfg.Storage.Type = config.StorageMemory },
+		},
+		{
+			name:          "Memory storage in prod",
+			modify:        func(cfg *config.Config) { cfg.Storage.Type = config.StorageMemory },
+			expectedError: "memory storage must not be used in prod",
+		},
+		{
+			name:          "Unknown storage",
+			modify:        func(cfg *config.Config) { cfg.Storage.Type = "redis" },
+			expectedError: "unknown storage",
+		},
+		{
+			name:          "Sample ratio out of range",
+			modify:        func(cfg *config.Config) { cfg.Tracing.SampleRatio = 2 },
+			expectedError: "sample ratio",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig()
+			tt.modify(&cfg)
+
+			err := cfg.Validate()
+			if tt.expectedError == "" {
+				assert.NoError(t, err)
+				return
+			}
+
+			assert.ErrorContains(t, err, tt.expectedError)
+		})
+	}
+}
+
+func TestConfig_Redacted(t *testing.T) {
+	cfg := validConfig()
+	cfg.Database.Password = "db-password"
+	cfg.Metrics.AdminToken = "admin-token"
+
+	redacted := cfg.Redacted()
+
+	assert.Equal(t, "[REDACTED]", redacted.Auth.JWTSecret)
+	assert.Equal(t, "[REDACTED]", redacted.Database.Password)
+	assert.Equal(t, "[REDACTED]", redacted.Metrics.AdminToken)
+	assert.Equal(t, strongSecret, cfg.Auth.JWTSecret, "original config must not be modified")
+
+	var buf bytes.Buffer
+
+	require.NoError(t, redacted.WriteYAML(&buf))
+	assert.NotContains(t, buf.String(), strongSecret)
+	assert.NotContains(t, buf.String(), "db-password")
+	assert.NotContains(t, buf.String(), "admin-token")
+	assert.Contains(t, buf.String(), "jwt_secret: '[REDACTED]'")
+}
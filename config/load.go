@@ -1,15 +1,192 @@
 package config
 
 import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"strings"
+
 	"github.com/caarlos0/env/v6"
+	"gopkg.in/yaml.v3"
 )
 
-// Load загружает конфиг из переменных окружения
-func Load() (*Config, error) {
-	var cfg Config
-	if err := env.Parse(&cfg); err != nil {
+// FileEnv - переменная окружения с путем к YAML-файлу конфигурации.
+const FileEnv = "CONFIG_FILE"
+
+// Суффикс переменной окружения с путем к файлу, содержащему секрет.
+const secretFileSuffix = "_FILE"
+
+// Значение, которым заменяются секреты при выводе конфига.
+const redactedValue = "[REDACTED]"
+
+// Load загружает конфиг. Источники по возрастанию приоритета:
+// значения по умолчанию, YAML-файл path (если указан) и переменные окружения.
+// Секреты можно передать через файл, указав путь в переменной с суффиксом _FILE, например JWT_SECRET_FILE.
+// Загруженный конфиг проверяется Validate.
+func Load(path string) (*Config, error) {
+	environment := environ()
+	if err := readSecretFiles(environment); err != nil {
 		return nil, err
 	}
 
+	var cfg Config
+
+	// Переменные окружения применяются после файла, поэтому здесь выставляются только значения по умолчанию
+	if err := env.Parse(&cfg, env.Options{Environment: map[string]string{}}); err != nil {
+		return nil, fmt.Errorf("failed to apply config defaults: %w", err)
+	}
+
+	if path != "" {
+		if err := readFile(path, &cfg); err != nil {
+			return nil, err
+		}
+	}
+
+	// env.Parse выставляет значения по умолчанию для незаданных переменных,
+	// поэтому из fromEnv в конфиг переносятся только поля, переменные которых заданы
+	var fromEnv Config
+	if err := env.Parse(&fromEnv, env.Options{Environment: environment}); err != nil {
+		return nil, fmt.Errorf("failed to parse environment: %w", err)
+	}
+
+	overrideFromEnv(reflect.ValueOf(&cfg).Elem(), reflect.ValueOf(fromEnv), environment)
+
+	if err := cfg.Validate(); err != nil {
+		return nil, fmt.Errorf("invalid config: %w", err)
+	}
+
 	return &cfg, nil
 }
+
+// Redacted возвращает копию конфига, в которой значения секретов заменены заглушкой.
+func (c Config) Redacted() Config {
+	redactSecrets(reflect.ValueOf(&c).Elem())
+	return c
+}
+
+// WriteYAML записывает конфиг в w в формате YAML, принимаемом Load.
+func (c Config) WriteYAML(w io.Writer) error {
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+
+	if err := encoder.Encode(c); err != nil {
+		return fmt.Errorf("failed to encode config: %w", err)
+	}
+
+	return encoder.Close()
+}
+
+func environ() map[string]string {
+	environment := make(map[string]string)
+
+	for _, pair := range os.Environ() {
+		if key, value, ok := strings.Cut(pair, "="); ok {
+			environment[key] = value
+		}
+	}
+
+	return environment
+}
+
+// readFile читает YAML-файл конфигурации поверх уже заполненного конфига.
+// Неизвестные ключи считаются ошибкой, чтобы опечатка не приводила к молчаливому использованию значения по умолчанию.
+func readFile(path string, cfg *Config) error {
+	content, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	decoder := yaml.NewDecoder(bytes.NewReader(content))
+	decoder.KnownFields(true)
+
+	if err := decoder.Decode(cfg); err != nil && !errors.Is(err, io.EOF) {
+		return fmt.Errorf("failed to parse config file %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// readSecretFiles подставляет в environment содержимое файлов из переменных с суффиксом _FILE.
+// Завершающий перевод строки отбрасывается.
+func readSecretFiles(environment map[string]string) error {
+	for _, key := range secretKeys(reflect.TypeOf(Config{})) {
+		path, ok := environment[key+secretFileSuffix]
+		if !ok {
+			continue
+		}
+
+		if _, ok := environment[key]; ok {
+			return fmt.Errorf("both %s and %s%s are set", key, key, secretFileSuffix)
+		}
+
+		content, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read %s%s: %w", key, secretFileSuffix, err)
+		}
+
+		environment[key] = strings.TrimRight(string(content), "\r\n")
+	}
+
+	return nil
+}
+
+func overrideFromEnv(dst, src reflect.Value, environment map[string]string) {
+	for i := 0; i < dst.NumField(); i++ {
+		field := dst.Type().Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			overrideFromEnv(dst.Field(i), src.Field(i), environment)
+			continue
+		}
+
+		if _, ok := environment[envKey(field)]; ok {
+			dst.Field(i).Set(src.Field(i))
+		}
+	}
+}
+
+func secretKeys(t reflect.Type) []string {
+	var keys []string
+
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			keys = append(keys, secretKeys(field.Type)...)
+			continue
+		}
+
+		if isSecret(field) {
+			keys = append(keys, envKey(field))
+		}
+	}
+
+	return keys
+}
+
+func redactSecrets(v reflect.Value) {
+	for i := 0; i < v.NumField(); i++ {
+		field := v.Type().Field(i)
+
+		if field.Type.Kind() == reflect.Struct {
+			redactSecrets(v.Field(i))
+			continue
+		}
+
+		if isSecret(field) && v.Field(i).String() != "" {
+			v.Field(i).SetString(redactedValue)
+		}
+	}
+}
+
+func envKey(field reflect.StructField) string {
+	key, _, _ := strings.Cut(field.Tag.Get("env"), ",")
+	return key
+}
+
+func isSecret(field reflect.StructField) bool {
+	return field.Tag.Get("secret") == "true" && field.Type.Kind() == reflect.String
+}
@@ -0,0 +1,166 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// Минимальная длина JWT секрета в prod.
+const minProdJWTSecretLength = 32
+
+// Validate проверяет конфиг и возвращает все найденные ошибки сразу.
+func (c *Config) Validate() error {
+	errs := []error{
+		validatePort("http port", c.HTTP.Port),
+		validatePort("gRPC port", c.GRPC.Port),
+		validatePort("metrics port", c.Metrics.Port),
+		validatePort("database port", c.Database.Port),
+		c.validatePortConflicts(),
+		c.validateEnv(),
+		c.validateStorage(),
+		c.validateJWTSecret(),
+		c.validateAdminToken(),
+		validateLogLevel(c.Logging.Level),
+		c.validateLogging(),
+	}
+
+	if c.Tracing.SampleRatio < 0 || c.Tracing.SampleRatio > 1 {
+		errs = append(errs, fmt.Errorf("tracing sample ratio must be between 0 and 1, got %v", c.Tracing.SampleRatio))
+	}
+
+	return errors.Join(errs...)
+}
+
+func validatePort(name string, port int) error {
+	if port < 1 || port > 65535 {
+		return fmt.Errorf("%s must be between 1 and 65535, got %d", name, port)
+	}
+
+	return nil
+}
+
+func (c *Config) validatePortConflicts() error {
+	switch {
+	case c.HTTP.Port == c.GRPC.Port:
+		return fmt.Errorf("http and gRPC servers use the same port %d", c.HTTP.Port)
+	case c.HTTP.Port == c.Metrics.Port:
+		return fmt.Errorf("http and metrics servers use the same port %d", c.HTTP.Port)
+	case c.GRPC.Port == c.Metrics.Port:
+		return fmt.Errorf("gRPC and metrics servers use the same port %d", c.GRPC.Port)
+	}
+
+	return nil
+}
+
+func (c *Config) validateEnv() error {
+	switch c.HTTP.Env {
+	case "dev", "prod":
+		return nil
+	default:
+		return fmt.Errorf("unknown env %q, expected dev or prod", c.HTTP.Env)
+	}
+}
+
+func (c *Config) validateStorage() error {
+	switch c.Storage.Type {
+	case StoragePostgres:
+		return nil
+	case StorageMemory:
+		if c.HTTP.Env == "prod" {
+			return errors.New("memory storage must not be used in prod")
+		}
+
+		return nil
+	default:
+		return fmt.Errorf("unknown storage %q, expected %s or %s", c.Storage.Type, StoragePostgres, StorageMemory)
+	}
+}
+
+// validateJWTSecret требует наличия секрета, а в prod дополнительно отклоняет короткие и общеизвестные значения.
+func (c *Config) validateJWTSecret() error {
+	secret := c.Auth.JWTSecret
+
+	if secret == "" {
+		return errors.New("jwt secret is required")
+	}
+
+	if c.HTTP.Env != "prod" {
+		return nil
+	}
+
+	if len(secret) < minProdJWTSecretLength {
+		return fmt.Errorf("jwt secret must be at least %d characters long in prod", minProdJWTSecretLength)
+	}
+
+	if isWellKnownSecret(secret) {
+		return errors.New("jwt secret is a well-known value and must not be used in prod")
+	}
+
+	return nil
+}
+
+// validateAdminToken допускает пустой токен (ручки /admin тогда недоступны), а заданный в prod проверяет как секрет JWT.
+func (c *Config) validateAdminToken() error {
+	token := c.Metrics.AdminToken
+
+	if token == "" || c.HTTP.Env != "prod" {
+		return nil
+	}
+
+	if len(token) < minProdJWTSecretLength {
+		return fmt.Errorf("admin token must be at least %d characters long in prod", minProdJWTSecretLength)
+	}
+
+	if isWellKnownSecret(token) {
+		return errors.New("admin token is a well-known value and must not be used in prod")
+	}
+
+	return nil
+}
+
+// isWellKnownSecret проверяет, что секрет - пример из документации или заглушка.
+func isWellKnownSecret(secret string) bool {
+	normalized := strings.ToLower(secret)
+
+	if strings.Count(normalized, normalized[:1]) == len(normalized) {
+		return true
+	}
+
+	for _, marker := range []string{"secret", "changeme", "password", "example"} {
+		if strings.Contains(normalized, marker) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func (c *Config) validateLogging() error {
+	var errs []error
+
+	if c.Logging.Encoding != "json" && c.Logging.Encoding != "console" {
+		errs = append(errs, fmt.Errorf("unknown log encoding %q, expected json or console", c.Logging.Encoding))
+	}
+
+	if c.Logging.SamplingInitial < 0 || c.Logging.SamplingThereafter < 0 {
+		errs = append(errs, errors.New("log sampling settings must not be negative"))
+	}
+
+	for name, level := range c.Logging.PackageLevels {
+		if err := validateLogLevel(level); err != nil {
+			errs = append(errs, fmt.Errorf("package %s: %w", name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func validateLogLevel(level string) error {
+	switch level {
+	case "debug", "info", "warn", "error", "silent":
+		return nil
+	default:
+		return fmt.Errorf("unknown log level %q, expected debug, info, warn, error or silent", level)
+	}
+}
@@ -6,124 +6,340 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/maksemen2/pvz-service/internal/delivery/http/routes"
 	httpserver "github.com/maksemen2/pvz-service/internal/delivery/http/server"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
 	"github.com/maksemen2/pvz-service/internal/pkg/auth"
 	"net"
+	"time"
 
 	"github.com/maksemen2/pvz-service/config"
 	grpcserver "github.com/maksemen2/pvz-service/internal/delivery/grpc/server"
 	"github.com/maksemen2/pvz-service/internal/pkg/auth/jwt"
 	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/internal/pkg/health"
 	"github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	"github.com/maksemen2/pvz-service/internal/pkg/tracing"
+	instrumentedrepo "github.com/maksemen2/pvz-service/internal/repository/instrumented"
+	memoryrepo "github.com/maksemen2/pvz-service/internal/repository/memory"
 	postgresqlrepo "github.com/maksemen2/pvz-service/internal/repository/postgresql"
 	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/maksemen2/pvz-service/migrations"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
 	"go.uber.org/zap"
 )
 
+// Имена логгеров пакетов, для которых можно переопределить уровень логирования.
+const (
+	DatabaseLoggerName   = "database"
+	RepositoryLoggerName = "postgresqlrepo"
+	ServiceLoggerName    = "service"
+)
+
 type Application struct {
 	Config       *config.Config
 	Logger       *zap.Logger
-	Database     *database.PostgresDB
+	Tracing      *sdktrace.TracerProvider
+	Database     *database.PostgresDB // nil при хранении данных в памяти
 	Repositories *Repositories
 	Services     *Services
 	TokenManager auth.TokenManager
+	Health       *health.Checker
+	LogLevels    *logger.Levels
 }
 
 type Repositories struct {
-	Product   repositories.IProductRepo
-	PVZ       repositories.IPVZRepo
-	User      repositories.IUserRepo
-	Reception repositories.IReceptionRepo
+	Product     repositories.IProductRepo
+	PVZ         repositories.IPVZRepo
+	User        repositories.IUserRepo
+	Reception   repositories.IReceptionRepo
+	Stats       repositories.IStatsRepo
+	Manifest    repositories.IManifestRepo
+	Idempotency repositories.IIdempotencyRepo
+	PickupCode  repositories.IPickupCodeRepo
+	Return      repositories.IReturnRepo
 }
 
 type Services struct {
-	Auth      service.AuthService
-	Product   service.ProductService
-	PVZ       service.PVZService
-	Reception service.ReceptionService
-}
-
-type Servers struct {
-	HTTPServer *httpserver.Server
-	GRPCServer *grpcserver.Server
-	Metrics    *metrics.Server
+	Auth        service.AuthService
+	Product     service.ProductService
+	Issuance    service.IssuanceService
+	PVZ         service.PVZService
+	Reception   service.ReceptionService
+	Pickup      service.PickupService
+	Storage     service.StorageService
+	Return      service.ReturnService
+	Stats       service.StatsService
+	Idempotency service.IdempotencyService
 }
 
 func Initialize(cfg *config.Config) (*Application, error) {
-	log, err := logger.NewZapLogger(cfg.Logging)
+	log, logLevels, err := logger.NewZapLogger(cfg.Logging)
 	if err != nil {
 		return nil, fmt.Errorf("logger initialization failed: %w", err)
 	}
 
-	db, err := database.NewPostgresDB(cfg.Database, log)
+	storagePolicy, err := NewStoragePolicy(cfg.StoragePeriod)
+	if err != nil {
+		return nil, fmt.Errorf("storage period configuration is invalid: %w", err)
+	}
+
+	// Провайдер трассировки должен быть установлен до подключения к БД, чтобы запросы попадали в трассировки
+	tracerProvider, err := tracing.NewProvider(context.Background(), cfg.Tracing)
 	if err != nil {
-		return nil, fmt.Errorf("database connection failed: %w", err)
+		return nil, fmt.Errorf("tracing initialization failed: %w", err)
+	}
+
+	// При хранении данных в памяти подключение к БД не создается
+	var db *database.PostgresDB
+
+	if cfg.Storage.Type != config.StorageMemory {
+		db, err = database.NewPostgresDB(cfg.Database, log.Named(DatabaseLoggerName))
+		if err != nil {
+			tracerProvider.Shutdown(context.Background())
+			return nil, fmt.Errorf("database connection failed: %w", err)
+		}
+
+		if err := db.Migrate(context.Background(), migrations.FS); err != nil {
+			db.Close()
+			tracerProvider.Shutdown(context.Background())
+
+			return nil, fmt.Errorf("database migration failed: %w", err)
+		}
 	}
 
-	repos := InitializeRepositories(db, log)
+	repos := InitializeRepositories(cfg.Storage, db, log)
 	tokenManager := jwt.NewJWTManager(cfg.Auth)
 
-	services := InitializeServices(repos, log, tokenManager)
+	services := InitializeServices(repos, log, tokenManager, cfg, storagePolicy)
+	healthChecker := health.NewChecker(healthDatabase(db), seconds(cfg.Health.DBPingTimeoutSeconds))
 
 	return &Application{
 		Config:       cfg,
 		Logger:       log,
+		Tracing:      tracerProvider,
 		Database:     db,
 		Repositories: repos,
 		Services:     services,
 		TokenManager: tokenManager,
+		Health:       healthChecker,
+		LogLevels:    logLevels,
 	}, nil
 }
 
-func (a *Application) StartServers() (*Servers, error) {
-	router := a.BuildRouter()
-	httpServer := httpserver.New(a.Logger, router, a.Config.HTTP)
+// BuildLifecycle собирает компоненты приложения в порядке зависимостей:
+// подключение к БД и трассировка, сервер метрик, gRPC и HTTP серверы, фоновые задачи и готовность к трафику.
+// Останавливаются компоненты в обратном порядке: сначала сервис отмечается неготовым и ждет
+// ShutdownConfig.DrainDelaySeconds, чтобы балансировщик перестал направлять на него трафик
+// до остановки серверов, а подключение к БД закрывается последним.
+func (a *Application) BuildLifecycle() (*Lifecycle, error) {
+	timeouts := a.Config.Shutdown
+	lifecycle := NewLifecycle(a.Logger)
 
-	grpcListener, err := net.Listen("tcp", fmt.Sprintf(":%d", a.Config.GRPC.Port))
-	if err != nil {
-		return nil, fmt.Errorf("gRPC listener failed: %w", err)
+	// БД и провайдер трассировки создаются в Initialize, здесь они только закрываются.
+	// Трассировка останавливается до закрытия БД: к этому моменту запросов уже нет, а оставшиеся спаны нужно отправить.
+	if a.Database != nil {
+		lifecycle.Append(Component{
+			Name:        "database",
+			Stop:        StopFunc(a.Database.Close),
+			StopTimeout: seconds(timeouts.DatabaseTimeoutSeconds),
+		})
 	}
 
-	grpcServer := grpcserver.New(a.Logger, a.Services.PVZ)
-	metricsServer := metrics.NewServer(a.Logger, a.Config.Metrics)
+	lifecycle.Append(Component{
+		Name:        "tracing",
+		Stop:        a.Tracing.Shutdown,
+		StopTimeout: seconds(timeouts.TracingTimeoutSeconds),
+	})
 
-	go httpServer.Start()
-	go metricsServer.Start()
-	go grpcServer.Start(grpcListener)
+	metricsServer := metrics.NewServer(a.Logger, a.Config.Metrics, routes.NewAdmin(a.Logger, a.Config.HTTP, a.Config.Metrics.AdminToken, a.LogLevels))
+	lifecycle.Append(Component{
+		Name:        "metrics server",
+		Start:       metricsServer.Listen,
+		Run:         metricsServer.Serve,
+		Stop:        metricsServer.Stop,
+		StopTimeout: seconds(timeouts.MetricsTimeoutSeconds),
+	})
 
-	return &Servers{
-		HTTPServer: httpServer,
-		GRPCServer: grpcServer,
-		Metrics:    metricsServer,
-	}, nil
+	var grpcListener net.Listener
+
+	grpcServer := grpcserver.New(a.Logger, a.Health.GRPCHealthServer(), a.Services.PVZ, a.Services.Stats)
+	lifecycle.Append(Component{
+		Name: "gRPC server",
+		Start: func() (err error) {
+			grpcListener, err = net.Listen("tcp", fmt.Sprintf(":%d", a.Config.GRPC.Port))
+			if err != nil {
+				return fmt.Errorf("gRPC listener failed: %w", err)
+			}
+
+			return nil
+		},
+		Run:         func() error { return grpcServer.Start(grpcListener) },
+		Stop:        grpcServer.Stop,
+		StopTimeout: seconds(timeouts.GRPCTimeoutSeconds),
+	})
+
+	httpServer := httpserver.New(a.Logger, a.BuildRouter(), a.Config.HTTP)
+	lifecycle.Append(Component{
+		Name:        "HTTP server",
+		Start:       httpServer.Listen,
+		Run:         httpServer.Serve,
+		Stop:        httpServer.Stop,
+		StopTimeout: seconds(timeouts.HTTPTimeoutSeconds),
+	})
+
+	if a.Config.AutoClose.Enabled {
+		autoCloser, err := NewReceptionAutoCloser(a.Logger, a.Services.Reception, a.Config.AutoClose)
+		if err != nil {
+			return nil, fmt.Errorf("reception auto closer initialization failed: %w", err)
+		}
+
+		lifecycle.Append(Component{
+			Name:        "reception auto closer",
+			Run:         func() error { autoCloser.Start(); return nil },
+			Stop:        autoCloser.Stop,
+			StopTimeout: seconds(timeouts.WorkersTimeoutSeconds),
+		})
+	}
+
+	if a.Config.Metrics.BusinessIntervalSeconds > 0 {
+		businessMetrics := NewBusinessMetricsRefresher(a.Logger, a.Services.Stats, a.Services.Storage, seconds(a.Config.Metrics.BusinessIntervalSeconds))
+		lifecycle.Append(Component{
+			Name:        "business metrics refresher",
+			Run:         func() error { businessMetrics.Start(); return nil },
+			Stop:        businessMetrics.Stop,
+			StopTimeout: seconds(timeouts.WorkersTimeoutSeconds),
+		})
+	}
+
+	if a.Config.Idempotency.CleanupIntervalSeconds > 0 {
+		idempotencyCleaner := NewIdempotencyCleaner(a.Logger, a.Services.Idempotency, seconds(a.Config.Idempotency.CleanupIntervalSeconds))
+		lifecycle.Append(Component{
+			Name:        "idempotency keys cleaner",
+			Run:         func() error { idempotencyCleaner.Start(); return nil },
+			Stop:        idempotencyCleaner.Stop,
+			StopTimeout: seconds(timeouts.WorkersTimeoutSeconds),
+		})
+	}
+
+	lifecycle.Append(Component{
+		Name: "readiness",
+		Start: func() error {
+			a.Health.MarkReady()
+			return nil
+		},
+		Stop: func(ctx context.Context) error {
+			a.Health.MarkNotReady()
+			return drain(ctx, seconds(timeouts.DrainDelaySeconds))
+		},
+		StopTimeout: seconds(timeouts.DrainDelaySeconds) + DefaultStopTimeout,
+	})
+
+	return lifecycle, nil
 }
 
 func (a *Application) BuildRouter() *gin.Engine {
-	router := routes.New(a.Services.Auth, a.Services.Product, a.Services.PVZ, a.Services.Reception, a.Logger, a.TokenManager, a.Config.HTTP)
+	router := routes.New(a.Services.Auth, a.Services.Product, a.Services.Issuance, a.Services.Pickup, a.Services.Storage, a.Services.Return, a.Services.PVZ, a.Services.Reception, a.Services.Stats, a.Services.Idempotency, a.Logger, a.TokenManager, a.Config.HTTP, a.Config.Tracing, a.Health)
 	return router
 }
 
-func (s *Servers) Stop(ctx context.Context) {
-	s.HTTPServer.Stop(ctx)
-	s.Metrics.Stop(ctx)
-	s.GRPCServer.Stop()
+// InitializeRepositories создает репозитории выбранного в конфиге хранилища, обернутые сбором метрик.
+// Для хранилища в памяти db не используется и может быть nil.
+func InitializeRepositories(storage config.StorageConfig, db *database.PostgresDB, log *zap.Logger) *Repositories {
+	var repos *Repositories
+
+	if storage.Type == config.StorageMemory {
+		repos = memoryRepositories(memoryrepo.NewStore())
+	} else {
+		repos = postgresqlRepositories(db, log.Named(RepositoryLoggerName))
+	}
+
+	return &Repositories{
+		Product:     instrumentedrepo.NewProductRepository(repos.Product),
+		PVZ:         instrumentedrepo.NewPVZRepository(repos.PVZ),
+		User:        instrumentedrepo.NewUserRepository(repos.User),
+		Reception:   instrumentedrepo.NewReceptionRepository(repos.Reception),
+		Stats:       instrumentedrepo.NewStatsRepository(repos.Stats),
+		Manifest:    instrumentedrepo.NewManifestRepository(repos.Manifest),
+		Idempotency: instrumentedrepo.NewIdempotencyRepository(repos.Idempotency),
+		PickupCode:  instrumentedrepo.NewPickupCodeRepository(repos.PickupCode),
+		Return:      instrumentedrepo.NewReturnRepository(repos.Return),
+	}
+}
+
+func postgresqlRepositories(db *database.PostgresDB, log *zap.Logger) *Repositories {
+	return &Repositories{
+		Product:     postgresqlrepo.NewPostgresqlProductRepository(db, log),
+		PVZ:         postgresqlrepo.NewPostgresqlPVZRepository(db, log),
+		User:        postgresqlrepo.NewPostgresqlUserRepository(db, log),
+		Reception:   postgresqlrepo.NewPostgresqlReceptionRepository(db, log),
+		Stats:       postgresqlrepo.NewPostgresqlStatsRepository(db, log),
+		Manifest:    postgresqlrepo.NewPostgresqlManifestRepository(db, log),
+		Idempotency: postgresqlrepo.NewPostgresqlIdempotencyRepository(db, log),
+		PickupCode:  postgresqlrepo.NewPostgresqlPickupCodeRepository(db, log),
+		Return:      postgresqlrepo.NewPostgresqlReturnRepository(db, log),
+	}
 }
 
-func InitializeRepositories(db *database.PostgresDB, log *zap.Logger) *Repositories {
+// memoryRepositories создает репозитории, работающие с общим хранилищем в памяти.
+func memoryRepositories(store *memoryrepo.Store) *Repositories {
 	return &Repositories{
-		Product:   postgresqlrepo.NewPostgresqlProductRepository(db, log),
-		PVZ:       postgresqlrepo.NewPostgresqlPVZRepository(db, log),
-		User:      postgresqlrepo.NewPostgresqlUserRepository(db, log),
-		Reception: postgresqlrepo.NewPostgresqlReceptionRepository(db, log),
+		Product:     memoryrepo.NewMemoryProductRepository(store),
+		PVZ:         memoryrepo.NewMemoryPVZRepository(store),
+		User:        memoryrepo.NewMemoryUserRepository(store),
+		Reception:   memoryrepo.NewMemoryReceptionRepository(store),
+		Stats:       memoryrepo.NewMemoryStatsRepository(store),
+		Manifest:    memoryrepo.NewMemoryManifestRepository(store),
+		Idempotency: memoryrepo.NewMemoryIdempotencyRepository(store),
+		PickupCode:  memoryrepo.NewMemoryPickupCodeRepository(store),
+		Return:      memoryrepo.NewMemoryReturnRepository(store),
+	}
+}
+
+// healthDatabase возвращает базу данных для проверки готовности или nil, если данные хранятся в памяти.
+func healthDatabase(db *database.PostgresDB) health.Database {
+	if db == nil {
+		return nil
 	}
+
+	return db
 }
 
-func InitializeServices(repos *Repositories, log *zap.Logger, tokenManager auth.TokenManager) *Services {
+func InitializeServices(repos *Repositories, log *zap.Logger, tokenManager auth.TokenManager, cfg *config.Config, storagePolicy *models.StoragePolicy) *Services {
+	log = log.Named(ServiceLoggerName)
+
 	return &Services{
-		Auth:      service.NewAuthService(log, repos.User, tokenManager),
-		Product:   service.NewProductService(log, repos.Product),
-		PVZ:       service.NewPVZService(log, repos.PVZ),
-		Reception: service.NewReceptionService(log, repos.Reception),
+		Auth:        service.NewAuthService(log, repos.User, tokenManager),
+		Product:     service.NewProductService(log, repos.Product, cfg.Product),
+		Issuance:    service.NewIssuanceService(log, repos.Product, repos.PickupCode, cfg.Product, cfg.Pickup),
+		PVZ:         service.NewPVZService(log, repos.PVZ, repos.Return),
+		Reception:   service.NewReceptionService(log, repos.Reception, repos.Manifest, repos.PickupCode, cfg.Reception, cfg.Pickup),
+		Pickup:      service.NewPickupService(log, repos.PickupCode, repos.Product, cfg.Pickup),
+		Storage:     service.NewStorageService(log, repos.Product, storagePolicy),
+		Return:      service.NewReturnService(log, repos.Return),
+		Stats:       service.NewStatsService(log, repos.Stats),
+		Idempotency: service.NewIdempotencyService(log, repos.Idempotency, cfg.Idempotency),
+	}
+}
+
+func seconds(value int) time.Duration {
+	return time.Duration(value) * time.Second
+}
+
+// drain ждет delay после снятия готовности, пока балансировщик перестанет направлять запросы.
+// Прерывается раньше, если истекает ctx.
+func drain(ctx context.Context, delay time.Duration) error {
+	if delay <= 0 {
+		return nil
+	}
+
+	timer := time.NewTimer(delay)
+	defer timer.Stop()
+
+	select {
+	case <-timer.C:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
 	}
 }
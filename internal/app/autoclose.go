@@ -0,0 +1,124 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// ReceptionAutoCloser - фоновая задача, которая периодически
+// закрывает приемки, открытые дольше допустимого времени.
+// Одновременный запуск на нескольких репликах исключается блокировкой в БД.
+type ReceptionAutoCloser struct {
+	logger           *zap.Logger
+	receptionService service.ReceptionService
+	interval         time.Duration
+	policy           *models.StaleReceptionPolicy
+	ctx              context.Context
+	cancel           context.CancelFunc
+	done             chan struct{}
+}
+
+// NewReceptionAutoCloser принимает логгер, сервис приемок и конфиг автозакрытия.
+// Возвращает ошибку, если интервал или максимальный возраст приемки не положительные,
+// либо в переопределениях указан неизвестный город.
+func NewReceptionAutoCloser(logger *zap.Logger, receptionService service.ReceptionService, cfg config.ReceptionAutoCloseConfig) (*ReceptionAutoCloser, error) {
+	if cfg.IntervalSeconds <= 0 {
+		return nil, fmt.Errorf("invalid auto close interval: %d", cfg.IntervalSeconds)
+	}
+
+	if cfg.MaxOpenAgeSeconds <= 0 {
+		return nil, fmt.Errorf("invalid auto close max open age: %d", cfg.MaxOpenAgeSeconds)
+	}
+
+	policy := &models.StaleReceptionPolicy{
+		MaxOpenAge:     time.Duration(cfg.MaxOpenAgeSeconds) * time.Second,
+		CityMaxOpenAge: make(map[models.CityType]time.Duration, len(cfg.CityMaxOpenAgeSeconds)),
+	}
+
+	for city, seconds := range cfg.CityMaxOpenAgeSeconds {
+		cityType := models.CityType(city)
+		if !cityType.Valid() {
+			return nil, fmt.Errorf("invalid auto close city: %s", city)
+		}
+
+		if seconds <= 0 {
+			return nil, fmt.Errorf("invalid auto close max open age for %s: %d", city, seconds)
+		}
+
+		policy.CityMaxOpenAge[cityType] = time.Duration(seconds) * time.Second
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &ReceptionAutoCloser{
+		logger:           logger,
+		receptionService: receptionService,
+		interval:         time.Duration(cfg.IntervalSeconds) * time.Second,
+		policy:           policy,
+		ctx:              ctx,
+		cancel:           cancel,
+		done:             make(chan struct{}),
+	}, nil
+}
+
+// Start запускает цикл автозакрытия и блокируется до вызова Stop.
+func (a *ReceptionAutoCloser) Start() {
+	defer close(a.done)
+
+	a.logger.Info("Starting reception auto closer", zap.Duration("interval", a.interval))
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return
+		case <-ticker.C:
+			a.RunOnce(a.ctx)
+		}
+	}
+}
+
+// RunOnce выполняет один проход автозакрытия.
+// Ошибки логируются, так как задача будет повторена на следующем тике.
+func (a *ReceptionAutoCloser) RunOnce(ctx context.Context) {
+	closed, err := a.receptionService.CloseStaleReceptions(ctx, a.policy)
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrAutoCloseLocked) {
+			a.logger.Debug("Reception auto close is running on another instance")
+			return
+		}
+
+		a.logger.Error("Reception auto close failed", zap.Error(err))
+
+		return
+	}
+
+	if closed > 0 {
+		a.logger.Info("Stale receptions closed", zap.Int("count", closed))
+	}
+}
+
+// Stop останавливает цикл автозакрытия.
+// Принимает контекст для ограничения времени ожидания текущего прохода.
+func (a *ReceptionAutoCloser) Stop(ctx context.Context) error {
+	a.logger.Info("Stopping reception auto closer")
+	a.cancel()
+
+	select {
+	case <-a.done:
+		a.logger.Info("Reception auto closer stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("reception auto closer did not stop in time: %w", ctx.Err())
+	}
+}
@@ -0,0 +1,90 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// BusinessMetricsRefresher - фоновая задача, которая периодически пересчитывает
+// бизнес-метрики текущего состояния (открытые приемки, товары за день, количество ПВЗ,
+// товары с истекшим сроком хранения) из БД.
+// В отличие от счетчиков, эти значения не сбрасываются при перезапуске.
+// Каждая реплика считает метрики независимо, поэтому при агрегации по репликам нужно брать максимум, а не сумму.
+type BusinessMetricsRefresher struct {
+	logger         *zap.Logger
+	statsService   service.StatsService
+	storageService service.StorageService
+	interval       time.Duration
+	ctx            context.Context
+	cancel         context.CancelFunc
+	done           chan struct{}
+}
+
+// NewBusinessMetricsRefresher принимает логгер, сервисы статистики и сроков хранения и период пересчета.
+func NewBusinessMetricsRefresher(logger *zap.Logger, statsService service.StatsService, storageService service.StorageService, interval time.Duration) *BusinessMetricsRefresher {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &BusinessMetricsRefresher{
+		logger:         logger,
+		statsService:   statsService,
+		storageService: storageService,
+		interval:       interval,
+		ctx:            ctx,
+		cancel:         cancel,
+		done:           make(chan struct{}),
+	}
+}
+
+// Start сразу пересчитывает метрики, затем повторяет пересчет с заданным периодом.
+// Блокируется до вызова Stop.
+func (r *BusinessMetricsRefresher) Start() {
+	defer close(r.done)
+
+	r.logger.Info("Starting business metrics refresher", zap.Duration("interval", r.interval))
+
+	ticker := time.NewTicker(r.interval)
+	defer ticker.Stop()
+
+	r.RunOnce(r.ctx)
+
+	for {
+		select {
+		case <-r.ctx.Done():
+			return
+		case <-ticker.C:
+			r.RunOnce(r.ctx)
+		}
+	}
+}
+
+// RunOnce выполняет один пересчет метрик.
+// Ошибки логируются, так как пересчет будет повторен на следующем тике.
+// Ошибка пересчета одной группы метрик не мешает пересчитать остальные.
+func (r *BusinessMetricsRefresher) RunOnce(ctx context.Context) {
+	if err := r.statsService.RefreshBusinessMetrics(ctx); err != nil {
+		r.logger.Error("Business metrics refresh failed", zap.Error(err))
+	}
+
+	if err := r.storageService.RefreshExpiryMetrics(ctx); err != nil {
+		r.logger.Error("Storage expiry metrics refresh failed", zap.Error(err))
+	}
+}
+
+// Stop останавливает пересчет метрик.
+// Принимает контекст для ограничения времени ожидания текущего пересчета.
+func (r *BusinessMetricsRefresher) Stop(ctx context.Context) error {
+	r.logger.Info("Stopping business metrics refresher")
+	r.cancel()
+
+	select {
+	case <-r.done:
+		r.logger.Info("Business metrics refresher stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("business metrics refresher did not stop in time: %w", ctx.Err())
+	}
+}
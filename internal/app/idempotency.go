@@ -0,0 +1,85 @@
+package app
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+)
+
+// IdempotencyCleaner - фоновая задача, которая периодически удаляет истекшие ключи идемпотентности всех пользователей.
+// Удаление идемпотентно, поэтому задачу можно запускать на всех репликах без блокировки.
+type IdempotencyCleaner struct {
+	logger   *zap.Logger
+	service  service.IdempotencyService
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+// NewIdempotencyCleaner принимает логгер, сервис идемпотентности и период очистки.
+func NewIdempotencyCleaner(logger *zap.Logger, idempotencyService service.IdempotencyService, interval time.Duration) *IdempotencyCleaner {
+	ctx, cancel := context.WithCancel(context.Background())
+
+	return &IdempotencyCleaner{
+		logger:   logger,
+		service:  idempotencyService,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+// Start сразу выполняет очистку, затем повторяет её с заданным периодом.
+// Блокируется до вызова Stop.
+func (c *IdempotencyCleaner) Start() {
+	defer close(c.done)
+
+	c.logger.Info("Starting idempotency keys cleaner", zap.Duration("interval", c.interval))
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	c.RunOnce(c.ctx)
+
+	for {
+		select {
+		case <-c.ctx.Done():
+			return
+		case <-ticker.C:
+			c.RunOnce(c.ctx)
+		}
+	}
+}
+
+// RunOnce выполняет одну очистку. Ошибки логируются, так как очистка будет повторена на следующем тике.
+func (c *IdempotencyCleaner) RunOnce(ctx context.Context) {
+	deleted, err := c.service.CleanupExpired(ctx)
+	if err != nil {
+		c.logger.Error("Idempotency keys cleanup failed", zap.Error(err))
+		return
+	}
+
+	if deleted > 0 {
+		c.logger.Info("Expired idempotency keys deleted", zap.Int("count", deleted))
+	}
+}
+
+// Stop останавливает очистку.
+// Принимает контекст для ограничения времени ожидания текущей очистки.
+func (c *IdempotencyCleaner) Stop(ctx context.Context) error {
+	c.logger.Info("Stopping idempotency keys cleaner")
+	c.cancel()
+
+	select {
+	case <-c.done:
+		c.logger.Info("Idempotency keys cleaner stopped")
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("idempotency keys cleaner did not stop in time: %w", ctx.Err())
+	}
+}
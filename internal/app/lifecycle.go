@@ -0,0 +1,157 @@
+package app
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// DefaultStopTimeout - ограничение времени остановки компонента, если оно не задано.
+const DefaultStopTimeout = 5 * time.Second
+
+// Component - компонент приложения, которым управляет Lifecycle.
+// Все функции необязательны.
+type Component struct {
+	Name string
+	// Start подготавливает компонент к работе (например, занимает порт) и не должен блокироваться.
+	// Ошибка Start прерывает запуск приложения.
+	Start func() error
+	// Run выполняет основную работу компонента и блокируется до его остановки.
+	// Ошибка Run означает аварийное завершение компонента и передается в Lifecycle.Errors.
+	Run func() error
+	// Stop останавливает компонент. Контекст ограничен StopTimeout.
+	Stop func(ctx context.Context) error
+	// StopTimeout ограничивает время Stop и ожидания завершения Run.
+	// Если не задан, используется DefaultStopTimeout.
+	StopTimeout time.Duration
+}
+
+type runningComponent struct {
+	Component
+	done chan struct{} // закрывается после завершения Run
+}
+
+// Lifecycle запускает компоненты в порядке добавления и останавливает в обратном порядке,
+// поэтому компоненты, от которых зависят другие, нужно добавлять раньше.
+type Lifecycle struct {
+	logger     *zap.Logger
+	components []Component
+	started    []*runningComponent
+	errs       chan error
+}
+
+// NewLifecycle создает пустой Lifecycle.
+func NewLifecycle(logger *zap.Logger) *Lifecycle {
+	return &Lifecycle{
+		logger: logger,
+	}
+}
+
+// Append добавляет компонент в конец порядка запуска.
+func (l *Lifecycle) Append(components ...Component) {
+	l.components = append(l.components, components...)
+}
+
+// Start запускает компоненты по очереди.
+// Если компонент не запустился, уже запущенные компоненты останавливаются, а ошибка возвращается.
+func (l *Lifecycle) Start() error {
+	l.errs = make(chan error, len(l.components))
+
+	for _, component := range l.components {
+		if component.Start != nil {
+			if err := component.Start(); err != nil {
+				startErr := fmt.Errorf("%s: start failed: %w", component.Name, err)
+
+				return errors.Join(startErr, l.Stop(context.Background()))
+			}
+		}
+
+		running := &runningComponent{Component: component, done: make(chan struct{})}
+		l.started = append(l.started, running)
+
+		if component.Run == nil {
+			close(running.done)
+			continue
+		}
+
+		go l.run(running)
+	}
+
+	return nil
+}
+
+func (l *Lifecycle) run(component *runningComponent) {
+	defer close(component.done)
+
+	if err := component.Run(); err != nil {
+		l.errs <- fmt.Errorf("%s: %w", component.Name, err)
+	}
+}
+
+// Errors возвращает канал ошибок компонентов, аварийно завершившихся после запуска.
+func (l *Lifecycle) Errors() <-chan error {
+	return l.errs
+}
+
+// Stop останавливает запущенные компоненты в обратном порядке.
+// Каждому компоненту выделяется собственное время остановки, поэтому зависший компонент
+// не отнимает время у остальных. Ошибки остановки логируются и возвращаются вместе.
+func (l *Lifecycle) Stop(ctx context.Context) error {
+	var errs []error
+
+	for i := len(l.started) - 1; i >= 0; i-- {
+		if err := l.stop(ctx, l.started[i]); err != nil {
+			l.logger.Error("Component stop failed", zap.String("component", l.started[i].Name), zap.Error(err))
+			errs = append(errs, fmt.Errorf("%s: %w", l.started[i].Name, err))
+		}
+	}
+
+	l.started = nil
+
+	return errors.Join(errs...)
+}
+
+func (l *Lifecycle) stop(ctx context.Context, component *runningComponent) error {
+	timeout := component.StopTimeout
+	if timeout <= 0 {
+		timeout = DefaultStopTimeout
+	}
+
+	stopCtx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	if component.Stop != nil {
+		if err := component.Stop(stopCtx); err != nil {
+			return err
+		}
+	}
+
+	select {
+	case <-component.done:
+		return nil
+	case <-stopCtx.Done():
+		return fmt.Errorf("did not stop in time: %w", stopCtx.Err())
+	}
+}
+
+// StopFunc адаптирует функцию остановки без контекста (например, Close) к Component.Stop.
+// Если функция не завершилась до истечения контекста, возвращается ошибка контекста.
+func StopFunc(stop func() error) func(ctx context.Context) error {
+	return func(ctx context.Context) error {
+		result := make(chan error, 1)
+
+		go func() {
+			result <- stop()
+		}()
+
+		select {
+		case err := <-result:
+			return err
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+}
@@ -0,0 +1,152 @@
+//go:build unit
+// +build unit
+
+package app_test
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/app"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+type recorder struct {
+	mu     sync.Mutex
+	events []string
+}
+
+func (r *recorder) add(event string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events = append(r.events, event)
+}
+
+func (r *recorder) all() []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	return append([]string(nil), r.events...)
+}
+
+// component возвращает компонент, Run которого блокируется до вызова Stop.
+func (r *recorder) component(name string) app.Component {
+	stop := make(chan struct{})
+
+	return app.Component{
+		Name: name,
+		Start: func() error {
+			r.add("start " + name)
+			return nil
+		},
+		Run: func() error {
+			<-stop
+			return nil
+		},
+		Stop: func(context.Context) error {
+			r.add("stop " + name)
+			close(stop)
+
+			return nil
+		},
+	}
+}
+
+func TestLifecycle_Order(t *testing.T) {
+	rec := &recorder{}
+	lifecycle := app.NewLifecycle(zap.NewNop())
+	lifecycle.Append(rec.component("db"), rec.component("server"), rec.component("worker"))
+
+	require.NoError(t, lifecycle.Start())
+	require.NoError(t, lifecycle.Stop(context.Background()))
+
+	assert.Equal(t, []string{
+		"start db", "start server", "start worker",
+		"stop worker", "stop server", "stop db",
+	}, rec.all())
+}
+
+func TestLifecycle_StartError(t *testing.T) {
+	rec := &recorder{}
+	startErr := errors.New("address already in use")
+
+	lifecycle := app.NewLifecycle(zap.NewNop())
+	lifecycle.Append(
+		rec.component("db"),
+		app.Component{Name: "server", Start: func() error { return startErr }},
+		rec.component("worker"),
+	)
+
+	err := lifecycle.Start()
+	require.ErrorIs(t, err, startErr)
+	assert.Contains(t, err.Error(), "server")
+
+	assert.Equal(t, []string{"start db", "stop db"}, rec.all())
+}
+
+func TestLifecycle_StopTimeout(t *testing.T) {
+	rec := &recorder{}
+
+	lifecycle := app.NewLifecycle(zap.NewNop())
+	lifecycle.Append(
+		rec.component("db"),
+		app.Component{
+			Name: "stuck worker",
+			Run: func() error {
+				select {}
+			},
+			StopTimeout: 10 * time.Millisecond,
+		},
+		rec.component("server"),
+	)
+
+	require.NoError(t, lifecycle.Start())
+
+	err := lifecycle.Stop(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Contains(t, err.Error(), "stuck worker")
+
+	// Зависший компонент не мешает остановить остальные
+	assert.Equal(t, []string{"start db", "start server", "stop server", "stop db"}, rec.all())
+}
+
+func TestLifecycle_RunError(t *testing.T) {
+	runErr := errors.New("serve failed")
+
+	lifecycle := app.NewLifecycle(zap.NewNop())
+	lifecycle.Append(app.Component{
+		Name: "server",
+		Run:  func() error { return runErr },
+	})
+
+	require.NoError(t, lifecycle.Start())
+
+	select {
+	case err := <-lifecycle.Errors():
+		assert.ErrorIs(t, err, runErr)
+	case <-time.After(time.Second):
+		t.Fatal("run error was not reported")
+	}
+
+	assert.NoError(t, lifecycle.Stop(context.Background()))
+}
+
+func TestStopFunc(t *testing.T) {
+	closeErr := errors.New("close failed")
+
+	assert.ErrorIs(t, app.StopFunc(func() error { return closeErr })(context.Background()), closeErr)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	release := make(chan struct{})
+	defer close(release)
+
+	assert.ErrorIs(t, app.StopFunc(func() error { <-release; return nil })(ctx), context.DeadlineExceeded)
+}
@@ -0,0 +1,60 @@
+package app
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/maksemen2/pvz-service/config"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+// day - единица сроков хранения в конфиге.
+const day = 24 * time.Hour
+
+// NewStoragePolicy собирает сроки хранения товаров из конфига.
+// Возвращает ошибку, если срок хранения не положительный, время предупреждения отрицательное,
+// либо в переопределениях указан неизвестный тип товара или город.
+func NewStoragePolicy(cfg config.StoragePeriodConfig) (*models.StoragePolicy, error) {
+	if cfg.Days <= 0 {
+		return nil, fmt.Errorf("invalid storage period: %d", cfg.Days)
+	}
+
+	if cfg.WarningDays < 0 {
+		return nil, fmt.Errorf("invalid storage period warning: %d", cfg.WarningDays)
+	}
+
+	policy := &models.StoragePolicy{
+		Period:     time.Duration(cfg.Days) * day,
+		TypePeriod: make(map[models.ProductType]time.Duration, len(cfg.TypeDays)),
+		CityPeriod: make(map[models.CityType]time.Duration, len(cfg.CityDays)),
+		Warning:    time.Duration(cfg.WarningDays) * day,
+	}
+
+	for productType, days := range cfg.TypeDays {
+		typ := models.ProductType(productType)
+		if !typ.Valid() {
+			return nil, fmt.Errorf("invalid storage period product type: %s", productType)
+		}
+
+		if days <= 0 {
+			return nil, fmt.Errorf("invalid storage period for %s: %d", productType, days)
+		}
+
+		policy.TypePeriod[typ] = time.Duration(days) * day
+	}
+
+	for city, days := range cfg.CityDays {
+		cityType := models.CityType(city)
+		if !cityType.Valid() {
+			return nil, fmt.Errorf("invalid storage period city: %s", city)
+		}
+
+		if days <= 0 {
+			return nil, fmt.Errorf("invalid storage period for %s: %d", city, days)
+		}
+
+		policy.CityPeriod[cityType] = time.Duration(days) * day
+	}
+
+	return policy, nil
+}
@@ -0,0 +1,125 @@
+package commonerrors
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+)
+
+// domainProblem описывает, как доменная ошибка отдается клиенту.
+type domainProblem struct {
+	err     error
+	status  int
+	code    string
+	message string // Если пусто, клиенту отдается текст самой ошибки
+}
+
+// domainProblems возвращает таблицу соответствия доменных ошибок HTTP статусам и кодам.
+// Коды являются частью API и не должны меняться.
+func domainProblems() []domainProblem {
+	return []domainProblem{
+		{err: domainerrors.ErrUnexpected, status: http.StatusInternalServerError, code: CodeInternal, message: "internal server error"},
+		{err: domainerrors.ErrVersionMismatch, status: http.StatusPreconditionFailed, code: "version_mismatch"},
+
+		{err: domainerrors.ErrInvalidIdempotencyKey, status: http.StatusBadRequest, code: "invalid_idempotency_key"},
+		{err: domainerrors.ErrIdempotencyKeyReused, status: http.StatusConflict, code: "idempotency_key_reused"},
+		{err: domainerrors.ErrIdempotencyRequestInProgress, status: http.StatusConflict, code: "idempotency_request_in_progress"},
+
+		{err: domainerrors.ErrInvalidManifest, status: http.StatusBadRequest, code: "invalid_manifest"},
+
+		{err: domainerrors.ErrNoProductsInReception, status: http.StatusBadRequest, code: "no_products_in_reception"},
+		{err: domainerrors.ErrInvalidProductType, status: http.StatusBadRequest, code: "invalid_product_type"},
+		{err: domainerrors.ErrInvalidBatchSize, status: http.StatusBadRequest, code: "invalid_batch_size"},
+		{err: domainerrors.ErrInvalidBatchMode, status: http.StatusBadRequest, code: "invalid_batch_mode"},
+		{err: domainerrors.ErrDuplicateProductID, status: http.StatusBadRequest, code: "duplicate_product_id"},
+		{err: domainerrors.ErrProductIDConflict, status: http.StatusConflict, code: "product_id_conflict"},
+		{err: domainerrors.ErrInvalidProductTime, status: http.StatusBadRequest, code: "invalid_product_date_time"},
+
+		{err: domainerrors.ErrInvalidIssuanceSize, status: http.StatusBadRequest, code: "invalid_issuance_size"},
+		{err: domainerrors.ErrProductNotFound, status: http.StatusNotFound, code: "product_not_found"},
+		{err: domainerrors.ErrProductNotReceived, status: http.StatusConflict, code: "product_not_received"},
+		{err: domainerrors.ErrProductNotStored, status: http.StatusConflict, code: "product_not_stored"},
+		{err: domainerrors.ErrProductNotInOrder, status: http.StatusBadRequest, code: "product_not_in_order"},
+
+		{err: domainerrors.ErrInvalidPickupCode, status: http.StatusBadRequest, code: "invalid_pickup_code"},
+		{err: domainerrors.ErrPickupCodeExpired, status: http.StatusBadRequest, code: "pickup_code_expired"},
+		{err: domainerrors.ErrPickupCodeLocked, status: http.StatusConflict, code: "pickup_code_locked"},
+		{err: domainerrors.ErrPickupCodeThrottled, status: http.StatusTooManyRequests, code: "pickup_code_throttled"},
+		{err: domainerrors.ErrOrderNotFound, status: http.StatusNotFound, code: "order_not_found"},
+
+		{err: domainerrors.ErrNoExpiredProducts, status: http.StatusBadRequest, code: "no_expired_products"},
+
+		{err: domainerrors.ErrInvalidReturnReason, status: http.StatusBadRequest, code: "invalid_return_reason"},
+		{err: domainerrors.ErrInvalidReturnCondition, status: http.StatusBadRequest, code: "invalid_return_condition"},
+		{err: domainerrors.ErrInvalidBarcode, status: http.StatusBadRequest, code: "invalid_barcode"},
+		{err: domainerrors.ErrProductNotIssued, status: http.StatusConflict, code: "product_not_issued"},
+		{err: domainerrors.ErrNoPendingReturns, status: http.StatusBadRequest, code: "no_pending_returns"},
+
+		{err: domainerrors.ErrUserNotModerator, status: http.StatusForbidden, code: "user_not_moderator", message: "forbidden"},
+		{err: domainerrors.ErrInvalidCity, status: http.StatusBadRequest, code: "invalid_city"},
+		{err: domainerrors.ErrPVZAlreadyExists, status: http.StatusBadRequest, code: "pvz_already_exists"},
+		{err: domainerrors.ErrInvalidPage, status: http.StatusBadRequest, code: "invalid_page"},
+		{err: domainerrors.ErrInvalidLimit, status: http.StatusBadRequest, code: "invalid_limit"},
+		{err: domainerrors.ErrInvalidDateRange, status: http.StatusBadRequest, code: "invalid_date_range"},
+		{err: domainerrors.ErrInvalidStartDate, status: http.StatusBadRequest, code: "invalid_start_date"},
+		{err: domainerrors.ErrPVZNotFound, status: http.StatusBadRequest, code: "pvz_not_found"},
+
+		{err: domainerrors.ErrNoOpenReceptions, status: http.StatusBadRequest, code: "no_open_receptions"},
+		{err: domainerrors.ErrOpenReceptionExists, status: http.StatusBadRequest, code: "open_reception_exists"},
+		{err: domainerrors.ErrAutoCloseLocked, status: http.StatusConflict, code: "auto_close_locked"},
+		{err: domainerrors.ErrCancelReasonEmpty, status: http.StatusBadRequest, code: "cancel_reason_empty"},
+		{err: domainerrors.ErrNoClosedReceptions, status: http.StatusBadRequest, code: "no_closed_receptions"},
+		{err: domainerrors.ErrReceptionNotReopenable, status: http.StatusBadRequest, code: "reception_not_reopenable"},
+		{err: domainerrors.ErrReopenGracePeriodExpired, status: http.StatusBadRequest, code: "reopen_grace_period_expired"},
+
+		{err: domainerrors.ErrUserExists, status: http.StatusBadRequest, code: "user_exists"},
+		{err: domainerrors.ErrInvalidCredentials, status: http.StatusUnauthorized, code: "invalid_credentials", message: "invalid email or password"},
+		// Лучше не указывать, что пользователь не найден, поэтому код совпадает с ErrInvalidCredentials
+		{err: domainerrors.ErrUserNotFound, status: http.StatusUnauthorized, code: "invalid_credentials", message: "invalid email or password"},
+		{err: domainerrors.ErrInvalidRole, status: http.StatusBadRequest, code: "invalid_role", message: "invalid role provided"},
+		{err: domainerrors.ErrNotEnoughRights, status: http.StatusForbidden, code: "not_enough_rights", message: "forbidden"},
+		{err: domainerrors.ErrPasswordTooLong, status: http.StatusBadRequest, code: "password_too_long"},
+	}
+}
+
+// lookupDomainProblem ищет доменную ошибку в таблице, учитывая обёрнутые ошибки.
+func lookupDomainProblem(err error) (domainProblem, bool) {
+	for _, problem := range domainProblems() {
+		if errors.Is(err, problem.err) {
+			return problem, true
+		}
+	}
+
+	return domainProblem{}, false
+}
+
+// DomainCode возвращает стабильный код доменной ошибки или CodeInternal для неизвестных ошибок.
+func DomainCode(err error) string {
+	if problem, ok := lookupDomainProblem(err); ok {
+		return problem.code
+	}
+
+	return CodeInternal
+}
+
+// AbortWithDomainError прерывает обработку запроса и отправляет доменную ошибку в соответствии с таблицей.
+// Для неизвестных ошибок отправляет внутреннюю ошибку сервера и возвращает false,
+// чтобы вызывающий код мог залогировать такую ошибку.
+func AbortWithDomainError(c *gin.Context, err error) bool {
+	problem, ok := lookupDomainProblem(err)
+	if !ok {
+		Internal(c)
+		return false
+	}
+
+	message := problem.message
+	if message == "" {
+		message = err.Error()
+	}
+
+	Abort(c, problem.status, problem.code, message)
+
+	return true
+}
@@ -1,25 +1,71 @@
 // Пакет commonerrors содержит функции для генерации стандартных ошибок, возвращаемых в HTTP хендлерах.
-// Функции возвращают сгенерированную DTO модель httpdto.Error
+// Ошибки отправляются в формате RFC 7807 (application/problem+json) в виде DTO модели httpdto.Error
+// со стабильным машиночитаемым кодом, айди запроса и айди трассировки.
 package commonerrors
 
-import "github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+import (
+	"net/http"
 
-func Unauthorized() httpdto.Error {
-	return httpdto.Error{Message: "unauthorized"}
+	"github.com/gin-gonic/gin"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/requestid"
+	"github.com/maksemen2/pvz-service/internal/pkg/tracing"
+)
+
+// ContentType - тип содержимого ответов с ошибкой.
+const ContentType = "application/problem+json"
+
+// ProblemType - тип проблемы по RFC 7807. Отдельных страниц с описанием ошибок нет,
+// поэтому используется about:blank, а конкретная ошибка определяется полем code.
+const ProblemType = "about:blank"
+
+// Коды ошибок, не связанных с доменными ошибками.
+const (
+	CodeInvalidRequest = "invalid_request" // Некорректное тело, параметры или заголовки запроса
+	CodeUnauthorized   = "unauthorized"    // Отсутствует или недействителен токен
+	CodeForbidden      = "forbidden"       // Недостаточно прав
+	CodeInternal       = "internal_error"  // Внутренняя ошибка сервера
+)
+
+// Abort прерывает обработку запроса и отправляет ошибку с указанным статусом, кодом и сообщением.
+func Abort(c *gin.Context, status int, code, message string) {
+	problem := httpdto.Error{
+		Type:    ProblemType,
+		Title:   http.StatusText(status),
+		Status:  status,
+		Code:    code,
+		Message: message,
+	}
+
+	if c.Request != nil {
+		instance := c.Request.URL.Path
+		problem.Instance = &instance
+
+		if requestID := requestid.FromContext(c.Request.Context()); requestID != "" {
+			problem.RequestId = &requestID
+		}
+
+		if traceID := tracing.TraceID(c.Request.Context()); traceID != "" {
+			problem.TraceId = &traceID
+		}
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(status, problem)
 }
 
-func InvalidCredentials() httpdto.Error {
-	return httpdto.Error{Message: "invalid email or password"}
+func Unauthorized(c *gin.Context) {
+	Abort(c, http.StatusUnauthorized, CodeUnauthorized, "unauthorized")
 }
 
-func BadRequest(message string) httpdto.Error {
-	return httpdto.Error{Message: message}
+func BadRequest(c *gin.Context, message string) {
+	Abort(c, http.StatusBadRequest, CodeInvalidRequest, message)
 }
 
-func Internal() httpdto.Error {
-	return httpdto.Error{Message: "internal server error"}
+func Internal(c *gin.Context) {
+	Abort(c, http.StatusInternalServerError, CodeInternal, "internal server error")
 }
 
-func Forbidden() httpdto.Error {
-	return httpdto.Error{Message: "forbidden"}
+func Forbidden(c *gin.Context) {
+	Abort(c, http.StatusForbidden, CodeForbidden, "forbidden")
 }
@@ -0,0 +1,146 @@
+//go:build unit
+// +build unit
+
+package commonerrors_test
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func serve(t *testing.T, requestID string, handler gin.HandlerFunc) (*httptest.ResponseRecorder, httpdto.Error) {
+	t.Helper()
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+	router.Use(requestid.NewGinMiddleware())
+	router.GET("/pvz", handler)
+
+	req, _ := http.NewRequest("GET", "/pvz", nil)
+	if requestID != "" {
+		req.Header.Set("X-Request-ID", requestID)
+	}
+
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	var problem httpdto.Error
+	require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+
+	return resp, problem
+}
+
+func TestAbortWithDomainError(t *testing.T) {
+	tests := []struct {
+		name            string
+		err             error
+		expectedStatus  int
+		expectedCode    string
+		expectedMessage string
+	}{
+		{
+			name:            "Exposed domain error",
+			err:             domainerrors.ErrOpenReceptionExists,
+			expectedStatus:  http.StatusBadRequest,
+			expectedCode:    "open_reception_exists",
+			expectedMessage: domainerrors.ErrOpenReceptionExists.Error(),
+		},
+		{
+			name:            "Wrapped error with hidden message",
+			err:             fmt.Errorf("%w: %s", domainerrors.ErrNotEnoughRights, "moderator"),
+			expectedStatus:  http.StatusForbidden,
+			expectedCode:    "not_enough_rights",
+			expectedMessage: "forbidden",
+		},
+		{
+			name:            "User not found looks like invalid credentials",
+			err:             domainerrors.ErrUserNotFound,
+			expectedStatus:  http.StatusUnauthorized,
+			expectedCode:    "invalid_credentials",
+			expectedMessage: "invalid email or password",
+		},
+		{
+			name:            "Version mismatch",
+			err:             domainerrors.ErrVersionMismatch,
+			expectedStatus:  http.StatusPreconditionFailed,
+			expectedCode:    "version_mismatch",
+			expectedMessage: domainerrors.ErrVersionMismatch.Error(),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, problem := serve(t, "req-1", func(c *gin.Context) {
+				assert.True(t, commonerrors.AbortWithDomainError(c, tt.err))
+			})
+
+			assert.Equal(t, tt.expectedStatus, resp.Code)
+			assert.Equal(t, commonerrors.ContentType, resp.Header().Get("Content-Type"))
+			assert.Equal(t, commonerrors.ProblemType, problem.Type)
+			assert.Equal(t, http.StatusText(tt.expectedStatus), problem.Title)
+			assert.Equal(t, tt.expectedStatus, problem.Status)
+			assert.Equal(t, tt.expectedCode, problem.Code)
+			assert.Equal(t, tt.expectedMessage, problem.Message)
+			require.NotNil(t, problem.Instance)
+			assert.Equal(t, "/pvz", *problem.Instance)
+			require.NotNil(t, problem.RequestId)
+			assert.Equal(t, "req-1", *problem.RequestId)
+		})
+	}
+}
+
+func TestAbortWithDomainError_Unknown(t *testing.T) {
+	resp, problem := serve(t, "", func(c *gin.Context) {
+		assert.False(t, commonerrors.AbortWithDomainError(c, errors.New("boom")))
+	})
+
+	assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	assert.Equal(t, commonerrors.CodeInternal, problem.Code)
+	assert.Equal(t, "internal server error", problem.Message)
+	require.NotNil(t, problem.RequestId)
+	assert.Equal(t, resp.Header().Get(requestid.Header), *problem.RequestId)
+}
+
+func TestDomainCode_EveryDomainErrorHasCode(t *testing.T) {
+	domainErrors := []error{
+		domainerrors.ErrVersionMismatch,
+		domainerrors.ErrInvalidIdempotencyKey, domainerrors.ErrIdempotencyKeyReused, domainerrors.ErrIdempotencyRequestInProgress,
+		domainerrors.ErrInvalidManifest,
+		domainerrors.ErrNoProductsInReception, domainerrors.ErrInvalidProductType, domainerrors.ErrInvalidBatchSize,
+		domainerrors.ErrInvalidBatchMode, domainerrors.ErrDuplicateProductID, domainerrors.ErrProductIDConflict,
+		domainerrors.ErrUserNotModerator, domainerrors.ErrInvalidCity, domainerrors.ErrPVZAlreadyExists, domainerrors.ErrInvalidPage,
+		domainerrors.ErrInvalidLimit, domainerrors.ErrInvalidDateRange, domainerrors.ErrInvalidStartDate, domainerrors.ErrPVZNotFound,
+		domainerrors.ErrNoOpenReceptions, domainerrors.ErrOpenReceptionExists, domainerrors.ErrAutoCloseLocked, domainerrors.ErrCancelReasonEmpty,
+		domainerrors.ErrNoClosedReceptions, domainerrors.ErrReceptionNotReopenable, domainerrors.ErrReopenGracePeriodExpired,
+		domainerrors.ErrUserExists, domainerrors.ErrInvalidCredentials, domainerrors.ErrInvalidRole,
+		domainerrors.ErrNotEnoughRights, domainerrors.ErrPasswordTooLong,
+	}
+
+	seen := make(map[string]error, len(domainErrors))
+
+	for _, err := range domainErrors {
+		code := commonerrors.DomainCode(err)
+		assert.NotEqual(t, commonerrors.CodeInternal, code, err.Error())
+
+		if other, ok := seen[code]; ok {
+			t.Errorf("code %q is used by %q and %q", code, other, err)
+		}
+
+		seen[code] = err
+	}
+
+	assert.Equal(t, commonerrors.CodeInternal, commonerrors.DomainCode(domainerrors.ErrUnexpected))
+	assert.Equal(t, commonerrors.CodeInternal, commonerrors.DomainCode(errors.New("boom")))
+}
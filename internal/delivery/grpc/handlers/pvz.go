@@ -2,21 +2,27 @@ package grpchandlers
 
 import (
 	"context"
+	"errors"
 	"github.com/maksemen2/pvz-service/internal/delivery/grpc/pvz_v1"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/service"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"time"
 )
 
 // PVZServer - gRPC сервер для работы с пунктами выдачи заказов.
 type PVZServer struct {
 	pvz_v1.UnimplementedPVZServiceServer
-	pvzService service.PVZService
+	pvzService   service.PVZService
+	statsService service.StatsService
 }
 
-func NewPVZServer(pvzService service.PVZService) *PVZServer {
+func NewPVZServer(pvzService service.PVZService, statsService service.StatsService) *PVZServer {
 	return &PVZServer{
-		pvzService: pvzService,
+		pvzService:   pvzService,
+		statsService: statsService,
 	}
 }
 
@@ -31,3 +37,68 @@ func (h *PVZServer) GetPVZList(ctx context.Context, req *pvz_v1.GetPVZListReques
 		Pvzs: pvz_v1.ConvertToProtoPVZs(pvzs),
 	}, nil
 }
+
+// ListPVZs - метод для получения списка ПВЗ с приемками за период (аналог GET /pvz).
+// Как и GetStats, выполняется с правами модератора.
+func (h *PVZServer) ListPVZs(ctx context.Context, req *pvz_v1.ListPVZsRequest) (*pvz_v1.ListPVZsResponse, error) {
+	var startDate, endDate *time.Time
+
+	if req.GetStartDate() != nil {
+		date := req.GetStartDate().AsTime()
+		startDate = &date
+	}
+
+	if req.GetEndDate() != nil {
+		date := req.GetEndDate().AsTime()
+		endDate = &date
+	}
+
+	var page, limit *int
+
+	if req.GetPage() != 0 {
+		value := int(req.GetPage())
+		page = &value
+	}
+
+	if req.GetLimit() != 0 {
+		value := int(req.GetLimit())
+		limit = &value
+	}
+
+	pvzs, err := h.pvzService.ListPVZs(ctx, models.RoleModerator.String(), startDate, endDate, page, limit)
+	if err != nil {
+		switch {
+		case errors.Is(err, domainerrors.ErrInvalidPage),
+			errors.Is(err, domainerrors.ErrInvalidLimit),
+			errors.Is(err, domainerrors.ErrInvalidDateRange),
+			errors.Is(err, domainerrors.ErrInvalidStartDate):
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return &pvz_v1.ListPVZsResponse{
+		Pvzs: pvz_v1.ConvertToProtoPVZsWithReceptions(pvzs),
+	}, nil
+}
+
+// GetStats - метод для получения статистики по приемкам и товарам за период.
+// gRPC сервер является внутренним и не проводит авторизацию (как и GetPVZList),
+// поэтому запрос выполняется с правами модератора.
+func (h *PVZServer) GetStats(ctx context.Context, req *pvz_v1.GetStatsRequest) (*pvz_v1.GetStatsResponse, error) {
+	if req.GetStartDate() == nil || req.GetEndDate() == nil {
+		return nil, status.Error(codes.InvalidArgument, domainerrors.ErrInvalidDateRange.Error())
+	}
+
+	stats, err := h.statsService.GetStats(ctx, models.RoleModerator.String(), req.GetStartDate().AsTime(), req.GetEndDate().AsTime())
+	if err != nil {
+		if errors.Is(err, domainerrors.ErrInvalidDateRange) {
+			return nil, status.Error(codes.InvalidArgument, err.Error())
+		}
+
+		return nil, status.Error(codes.Internal, err.Error())
+	}
+
+	return pvz_v1.ConvertToProtoStats(stats), nil
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/stretchr/testify/assert"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
 func TestPVZServer_GetPVZList(t *testing.T) {
@@ -25,7 +26,7 @@ func TestPVZServer_GetPVZList(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockService := service_mocks.NewMockPVZService(ctrl)
-	handler := grpchandlers.NewPVZServer(mockService)
+	handler := grpchandlers.NewPVZServer(mockService, service_mocks.NewMockStatsService(ctrl))
 
 	ctx := context.Background()
 
@@ -62,3 +63,155 @@ func TestPVZServer_GetPVZList(t *testing.T) {
 		assert.Equal(t, codes.Internal, status.Code(err))
 	})
 }
+
+func TestPVZServer_ListPVZs(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service_mocks.NewMockPVZService(ctrl)
+	handler := grpchandlers.NewPVZServer(mockService, service_mocks.NewMockStatsService(ctrl))
+
+	ctx := context.Background()
+	startDate := time.Now().Add(-24 * time.Hour)
+
+	t.Run("Receptions with authors", func(t *testing.T) {
+		pvz := &models.PVZ{ID: uuid.New(), RegistrationDate: startDate, City: models.CityTypeKazan}
+		openedBy, closedBy := uuid.New(), uuid.New()
+		closedAt := time.Now()
+
+		reception := &models.Reception{
+			ID:         uuid.New(),
+			DateTime:   startDate,
+			PVZID:      pvz.ID,
+			Status:     models.ReceptionStatusClose,
+			OpenedBy:   openedBy,
+			ClosedAt:   &closedAt,
+			ClosedBy:   &closedBy,
+			AutoClosed: false,
+		}
+
+		page := 2
+
+		mockService.EXPECT().
+			ListPVZs(ctx, models.RoleModerator.String(), gomock.Any(), nil, &page, nil).
+			DoAndReturn(func(_ context.Context, _ string, gotStart, _ *time.Time, _, _ *int) ([]*models.PVZWithReceptions, error) {
+				assert.True(t, gotStart.Equal(startDate))
+
+				return []*models.PVZWithReceptions{
+					{PVZ: pvz, Receptions: []*models.ReceptionWithProducts{{Reception: reception}}},
+				}, nil
+			}).
+			Times(1)
+
+		resp, err := handler.ListPVZs(ctx, &pvz_v1.ListPVZsRequest{StartDate: timestamppb.New(startDate), Page: 2})
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Pvzs, 1)
+		assert.Equal(t, pvz.ID.String(), resp.Pvzs[0].Pvz.Id)
+		assert.Len(t, resp.Pvzs[0].Receptions, 1)
+
+		got := resp.Pvzs[0].Receptions[0]
+		assert.Equal(t, reception.ID.String(), got.Id)
+		assert.Equal(t, pvz_v1.ReceptionStatus_RECEPTION_STATUS_CLOSED, got.Status)
+		assert.Equal(t, openedBy.String(), got.OpenedBy)
+		assert.Equal(t, closedBy.String(), got.ClosedBy)
+		assert.True(t, got.ClosedAt.AsTime().Equal(closedAt))
+	})
+
+	t.Run("Invalid filter", func(t *testing.T) {
+		mockService.EXPECT().
+			ListPVZs(ctx, models.RoleModerator.String(), nil, nil, nil, nil).
+			Return(nil, domainerrors.ErrInvalidDateRange).
+			Times(1)
+
+		resp, err := handler.ListPVZs(ctx, &pvz_v1.ListPVZsRequest{})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		mockService.EXPECT().
+			ListPVZs(ctx, models.RoleModerator.String(), nil, nil, nil, nil).
+			Return(nil, domainerrors.ErrUnexpected).
+			Times(1)
+
+		resp, err := handler.ListPVZs(ctx, &pvz_v1.ListPVZsRequest{})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
+
+func TestPVZServer_GetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStatsService := service_mocks.NewMockStatsService(ctrl)
+	handler := grpchandlers.NewPVZServer(service_mocks.NewMockPVZService(ctrl), mockStatsService)
+
+	ctx := context.Background()
+	startDate := time.Now().Add(-24 * time.Hour)
+	endDate := time.Now()
+
+	req := &pvz_v1.GetStatsRequest{
+		StartDate: timestamppb.New(startDate),
+		EndDate:   timestamppb.New(endDate),
+	}
+
+	t.Run("Successful stats", func(t *testing.T) {
+		expectedStats := &models.Stats{
+			PVZs: []*models.PVZStats{
+				{PVZID: uuid.New(), City: models.CityTypeMoscow, ReceptionsCount: 3, AvgReceptionDuration: time.Minute},
+			},
+			ProductTypes: []*models.ProductTypeStats{
+				{City: models.CityTypeMoscow, Day: startDate, Type: models.ProductTypeShoes, Count: 5},
+			},
+		}
+
+		mockStatsService.EXPECT().
+			GetStats(ctx, models.RoleModerator.String(), gomock.Any(), gomock.Any()).
+			Return(expectedStats, nil).
+			Times(1)
+
+		resp, err := handler.GetStats(ctx, req)
+
+		assert.NoError(t, err)
+		assert.Len(t, resp.Pvzs, 1)
+		assert.Equal(t, int64(3), resp.Pvzs[0].ReceptionsCount)
+		assert.Equal(t, time.Minute, resp.Pvzs[0].AvgReceptionDuration.AsDuration())
+		assert.Len(t, resp.ProductTypes, 1)
+		assert.Equal(t, models.ProductTypeShoes.String(), resp.ProductTypes[0].Type)
+	})
+
+	t.Run("Missing dates", func(t *testing.T) {
+		resp, err := handler.GetStats(ctx, &pvz_v1.GetStatsRequest{})
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Invalid date range", func(t *testing.T) {
+		mockStatsService.EXPECT().
+			GetStats(ctx, models.RoleModerator.String(), gomock.Any(), gomock.Any()).
+			Return(nil, domainerrors.ErrInvalidDateRange).
+			Times(1)
+
+		resp, err := handler.GetStats(ctx, req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.InvalidArgument, status.Code(err))
+	})
+
+	t.Run("Service error", func(t *testing.T) {
+		mockStatsService.EXPECT().
+			GetStats(ctx, models.RoleModerator.String(), gomock.Any(), gomock.Any()).
+			Return(nil, domainerrors.ErrUnexpected).
+			Times(1)
+
+		resp, err := handler.GetStats(ctx, req)
+
+		assert.Nil(t, resp)
+		assert.Equal(t, codes.Internal, status.Code(err))
+	})
+}
@@ -1,7 +1,9 @@
 package pvz_v1
 
 import (
+	"github.com/google/uuid"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"google.golang.org/protobuf/types/known/durationpb"
 	"google.golang.org/protobuf/types/known/timestamppb"
 )
 
@@ -18,3 +20,98 @@ func ConvertToProtoPVZs(pvzs []*models.PVZ) []*PVZ {
 
 	return result
 }
+
+func ConvertToProtoPVZsWithReceptions(pvzs []*models.PVZWithReceptions) []*PVZWithReceptions {
+	result := make([]*PVZWithReceptions, 0, len(pvzs))
+
+	for _, p := range pvzs {
+		receptions := make([]*Reception, 0, len(p.Receptions))
+
+		for _, r := range p.Receptions {
+			receptions = append(receptions, ConvertToProtoReception(r.Reception))
+		}
+
+		result = append(result, &PVZWithReceptions{
+			Pvz:        ConvertToProtoPVZs([]*models.PVZ{p.PVZ})[0],
+			Receptions: receptions,
+		})
+	}
+
+	return result
+}
+
+func ConvertToProtoReceptionStatus(status models.ReceptionStatus) ReceptionStatus {
+	switch status {
+	case models.ReceptionStatusClose:
+		return ReceptionStatus_RECEPTION_STATUS_CLOSED
+	case models.ReceptionStatusCancelled:
+		return ReceptionStatus_RECEPTION_STATUS_CANCELLED
+	}
+
+	return ReceptionStatus_RECEPTION_STATUS_IN_PROGRESS
+}
+
+func ConvertToProtoReception(reception *models.Reception) *Reception {
+	result := &Reception{
+		Id:          reception.ID.String(),
+		DateTime:    timestamppb.New(reception.DateTime),
+		PvzId:       reception.PVZID.String(),
+		Status:      ConvertToProtoReceptionStatus(reception.Status),
+		AutoClosed:  reception.AutoClosed,
+		CloseReason: reception.CloseReason,
+	}
+
+	if reception.OpenedBy != uuid.Nil {
+		result.OpenedBy = reception.OpenedBy.String()
+	}
+
+	if reception.ClosedAt != nil {
+		result.ClosedAt = timestamppb.New(*reception.ClosedAt)
+	}
+
+	if reception.ClosedBy != nil {
+		result.ClosedBy = reception.ClosedBy.String()
+	}
+
+	if reception.ReopenedAt != nil {
+		result.ReopenedAt = timestamppb.New(*reception.ReopenedAt)
+	}
+
+	if reception.ReopenedBy != nil {
+		result.ReopenedBy = reception.ReopenedBy.String()
+	}
+
+	return result
+}
+
+func ConvertToProtoStats(stats *models.Stats) *GetStatsResponse {
+	pvzs := make([]*PVZStats, 0, len(stats.PVZs))
+
+	for _, s := range stats.PVZs {
+		pvzs = append(pvzs, &PVZStats{
+			PvzId:                   s.PVZID.String(),
+			City:                    s.City.String(),
+			ReceptionsCount:         int64(s.ReceptionsCount),
+			ClosedReceptionsCount:   int64(s.ClosedReceptionsCount),
+			ProductsCount:           int64(s.ProductsCount),
+			AvgReceptionDuration:    durationpb.New(s.AvgReceptionDuration),
+			AvgProductsPerReception: s.AvgProductsPerReception,
+		})
+	}
+
+	productTypes := make([]*ProductTypeStats, 0, len(stats.ProductTypes))
+
+	for _, s := range stats.ProductTypes {
+		productTypes = append(productTypes, &ProductTypeStats{
+			City:  s.City.String(),
+			Day:   timestamppb.New(s.Day),
+			Type:  s.Type.String(),
+			Count: int64(s.Count),
+		})
+	}
+
+	return &GetStatsResponse{
+		Pvzs:         pvzs,
+		ProductTypes: productTypes,
+	}
+}
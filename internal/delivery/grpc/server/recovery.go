@@ -0,0 +1,32 @@
+package grpcserver
+
+import (
+	"context"
+	"runtime/debug"
+
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// recoveryInterceptor перехватывает панику в обработчике, логирует ее
+// и возвращает клиенту codes.Internal вместо падения процесса.
+func recoveryInterceptor(log *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+		defer func() {
+			if r := recover(); r != nil {
+				logger.FromContext(ctx, log).Error("Panic in gRPC handler",
+					zap.String("path", info.FullMethod),
+					zap.Any("panic", r),
+					zap.ByteString("stack", debug.Stack()),
+				)
+
+				err = status.Error(codes.Internal, "internal server error")
+			}
+		}()
+
+		return handler(ctx, req)
+	}
+}
@@ -1,42 +1,88 @@
 package grpcserver
 
 import (
+	"context"
+	"fmt"
 	grpchandlers "github.com/maksemen2/pvz-service/internal/delivery/grpc/handlers"
 	"github.com/maksemen2/pvz-service/internal/delivery/grpc/pvz_v1"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	"github.com/maksemen2/pvz-service/internal/pkg/requestid"
 	"github.com/maksemen2/pvz-service/internal/service"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"go.uber.org/zap"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
 	"net"
 )
 
 // Server - структура для gRPC сервера.
 type Server struct {
 	server *grpc.Server
+	health *health.Server
 	logger *zap.Logger
 }
 
-func New(logger *zap.Logger, pvzService service.PVZService) *Server {
-	srv := grpc.NewServer()
+// New создает gRPC сервер с сервисом ПВЗ и сервисом grpc.health.v1, состояние которого
+// выставляется при запуске и остановке сервера.
+func New(logger *zap.Logger, healthServer *health.Server, pvzService service.PVZService, statsService service.StatsService) *Server {
+	srv := grpc.NewServer(
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		// Восстановление после паники стоит последним, чтобы логирование и метрики видели codes.Internal
+		grpc.ChainUnaryInterceptor(
+			requestid.UnaryServerInterceptor(),
+			l.UnaryServerInterceptor(logger),
+			metrics.UnaryServerInterceptor(),
+			recoveryInterceptor(logger),
+		),
+	)
 
-	pvz_v1.RegisterPVZServiceServer(srv, grpchandlers.NewPVZServer(pvzService))
+	pvz_v1.RegisterPVZServiceServer(srv, grpchandlers.NewPVZServer(pvzService, statsService))
+	healthpb.RegisterHealthServer(srv, healthServer)
 
 	return &Server{
 		server: srv,
+		health: healthServer,
 		logger: logger,
 	}
 }
 
-// Start - запускает gRPC сервер на указанном слушателе.
-// Принимает слушатель net.Listener.
-func (s *Server) Start(lis net.Listener) {
+// Start - запускает gRPC сервер на указанном слушателе и блокируется до остановки сервера.
+// Принимает слушатель net.Listener. После Stop возвращает nil.
+func (s *Server) Start(lis net.Listener) error {
 	s.logger.Info("Starting gRPC server", zap.String("addr", lis.Addr().String()))
 
+	s.health.SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	s.health.SetServingStatus(pvz_v1.PVZService_ServiceDesc.ServiceName, healthpb.HealthCheckResponse_SERVING)
+
 	if err := s.server.Serve(lis); err != nil {
-		s.logger.Error("Failed to serve", zap.Error(err))
+		s.health.Shutdown()
+		return fmt.Errorf("gRPC server error: %w", err)
 	}
+
+	return nil
 }
 
-// Stop - останавливает gRPC сервер.
-func (s *Server) Stop() {
-	s.server.GracefulStop()
+// Stop - переводит grpc.health.v1 в NOT_SERVING и останавливает gRPC сервер, дожидаясь завершения текущих вызовов.
+// Если контекст истек раньше, оставшиеся вызовы прерываются.
+func (s *Server) Stop(ctx context.Context) error {
+	s.logger.Info("Stopping gRPC server")
+	s.health.Shutdown()
+
+	stopped := make(chan struct{})
+
+	go func() {
+		s.server.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+		s.logger.Info("gRPC server stopped")
+		return nil
+	case <-ctx.Done():
+		s.server.Stop()
+		return fmt.Errorf("gRPC server shutdown: %w", ctx.Err())
+	}
 }
@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package grpcserver_test
+
+import (
+	"context"
+	"net"
+	"testing"
+
+	"github.com/maksemen2/pvz-service/internal/delivery/grpc/pvz_v1"
+	grpcserver "github.com/maksemen2/pvz-service/internal/delivery/grpc/server"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+)
+
+func startServer(t *testing.T, pvzService *service_mocks.MockPVZService, ctrl *gomock.Controller) (*grpc.ClientConn, *observer.ObservedLogs) {
+	t.Helper()
+
+	core, logs := observer.New(zapcore.InfoLevel)
+	server := grpcserver.New(zap.New(core), health.NewServer(), pvzService, service_mocks.NewMockStatsService(ctrl))
+
+	lis := bufconn.Listen(1024 * 1024)
+	go server.Start(lis)
+
+	t.Cleanup(func() { server.Stop(context.Background()) })
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) {
+			return lis.DialContext(ctx)
+		}),
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+	)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { conn.Close() })
+
+	return conn, logs
+}
+
+func TestServer_Interceptors(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service_mocks.NewMockPVZService(ctrl)
+	conn, logs := startServer(t, mockService, ctrl)
+	client := pvz_v1.NewPVZServiceClient(conn)
+
+	t.Run("Successful call", func(t *testing.T) {
+		okBefore := testutil.ToFloat64(metrics.GRPCRequestsTotal.WithLabelValues(pvz_v1.PVZService_GetPVZList_FullMethodName, codes.OK.String()))
+
+		mockService.EXPECT().
+			GetAllPVZs(gomock.Any()).
+			Return([]*models.PVZ{}, nil).
+			Times(1)
+
+		_, err := client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{})
+		require.NoError(t, err)
+
+		assert.Equal(t, okBefore+1, testutil.ToFloat64(metrics.GRPCRequestsTotal.WithLabelValues(pvz_v1.PVZService_GetPVZList_FullMethodName, codes.OK.String())))
+
+		entries := logs.FilterMessage("Request").All()
+		require.Len(t, entries, 1)
+
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "gRPC", fields["method"])
+		assert.Equal(t, pvz_v1.PVZService_GetPVZList_FullMethodName, fields["path"])
+		assert.Equal(t, codes.OK.String(), fields["status"])
+		assert.Contains(t, fields, "requestID")
+		assert.Contains(t, fields, "duration")
+
+		logs.TakeAll()
+	})
+
+	t.Run("Panic in handler", func(t *testing.T) {
+		internalBefore := testutil.ToFloat64(metrics.GRPCRequestsTotal.WithLabelValues(pvz_v1.PVZService_GetPVZList_FullMethodName, codes.Internal.String()))
+
+		mockService.EXPECT().
+			GetAllPVZs(gomock.Any()).
+			DoAndReturn(func(context.Context) ([]*models.PVZ, error) {
+				panic("boom")
+			}).
+			Times(1)
+
+		_, err := client.GetPVZList(context.Background(), &pvz_v1.GetPVZListRequest{})
+		assert.Equal(t, codes.Internal, status.Code(err))
+
+		assert.Equal(t, internalBefore+1, testutil.ToFloat64(metrics.GRPCRequestsTotal.WithLabelValues(pvz_v1.PVZService_GetPVZList_FullMethodName, codes.Internal.String())))
+		assert.Equal(t, 1, logs.FilterMessage("Panic in gRPC handler").Len())
+
+		entries := logs.FilterMessage("Request").All()
+		require.Len(t, entries, 1)
+		assert.Equal(t, codes.Internal.String(), entries[0].ContextMap()["status"])
+	})
+}
+
+func TestServer_Health(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	conn, _ := startServer(t, service_mocks.NewMockPVZService(ctrl), ctrl)
+	client := healthpb.NewHealthClient(conn)
+
+	for _, serviceName := range []string{"", pvz_v1.PVZService_ServiceDesc.ServiceName} {
+		resp, err := client.Check(context.Background(), &healthpb.HealthCheckRequest{Service: serviceName})
+		require.NoError(t, err)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+	}
+}
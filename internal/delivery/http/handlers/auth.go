@@ -1,7 +1,7 @@
 package httphandlers
 
 import (
-	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/service"
 	"go.uber.org/zap"
 	"net/http"
@@ -9,7 +9,6 @@ import (
 	"github.com/gin-gonic/gin"
 	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
 	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
-	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 )
 
 // AuthHandler - обработчик аутентификации пользователей.
@@ -25,24 +24,6 @@ func NewAuthHandler(logger *zap.Logger, authService service.AuthService) *AuthHa
 	}
 }
 
-func (h *AuthHandler) handleDomainError(c *gin.Context, err error) {
-	switch {
-	case errors.Is(err, domainerrors.ErrUnexpected):
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-	case errors.Is(err, domainerrors.ErrUserExists):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("user already exists"))
-	case errors.Is(err, domainerrors.ErrInvalidRole):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid role provided"))
-	case errors.Is(err, domainerrors.ErrInvalidCredentials), errors.Is(err, domainerrors.ErrUserNotFound):
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.InvalidCredentials()) // Лучше не указывать, что пользователь не найден
-	case errors.Is(err, domainerrors.ErrPasswordTooLong):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest(err.Error()))
-	default:
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-		h.logger.Error("unexpected error", zap.Error(err))
-	}
-}
-
 func (h *AuthHandler) validateRegisterRequestBody(req httpdto.PostRegisterJSONRequestBody) bool {
 	return req.Role != "" && req.Email != "" && req.Password != ""
 }
@@ -65,15 +46,15 @@ func (h *AuthHandler) HandleRegister(c *gin.Context) {
 	var req httpdto.PostRegisterJSONRequestBody
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debug("BindJSON error handling register", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling register", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
 
 	if !h.validateRegisterRequestBody(req) {
-		h.logger.Debug("invalid request body handling register")
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid request body handling register")
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
@@ -81,7 +62,7 @@ func (h *AuthHandler) HandleRegister(c *gin.Context) {
 	domainUser, err := h.authService.RegisterUser(c.Request.Context(), string(req.Email), req.Password, string(req.Role))
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
@@ -91,15 +72,15 @@ func (h *AuthHandler) HandleRegister(c *gin.Context) {
 func (h *AuthHandler) HandleLogin(c *gin.Context) {
 	var req httpdto.PostLoginJSONRequestBody
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debug("BindJSON error handling login", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling login", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
 
 	if !h.validateLoginRequestBody(req) {
-		h.logger.Debug("invalid request body handling login")
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid request body handling login")
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
@@ -107,7 +88,7 @@ func (h *AuthHandler) HandleLogin(c *gin.Context) {
 	token, err := h.authService.AuthenticateUser(c.Request.Context(), string(req.Email), req.Password)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
@@ -117,15 +98,15 @@ func (h *AuthHandler) HandleLogin(c *gin.Context) {
 func (h *AuthHandler) HandleDummyLogin(c *gin.Context) {
 	var req httpdto.PostDummyLoginJSONRequestBody
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debug("BindJSON error handling dummy login", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling dummy login", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
 
 	if !h.validateDummyLoginRequestBody(req) {
-		h.logger.Debug("invalid request body handling dummy login")
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid request body handling dummy login")
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
@@ -133,7 +114,7 @@ func (h *AuthHandler) HandleDummyLogin(c *gin.Context) {
 	token, err := h.authService.DummyLogin(c.Request.Context(), string(req.Role))
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
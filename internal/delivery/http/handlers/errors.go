@@ -0,0 +1,16 @@
+package httphandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// handleDomainError отправляет доменную ошибку клиенту по общей таблице ошибок commonerrors.
+// Неизвестные ошибки логируются и отправляются как внутренняя ошибка сервера.
+func handleDomainError(c *gin.Context, logger *zap.Logger, err error) {
+	if !commonerrors.AbortWithDomainError(c, err) {
+		l.FromContext(c.Request.Context(), logger).Error("unexpected error", zap.Error(err))
+	}
+}
@@ -0,0 +1,99 @@
+package httphandlers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+// В API используются ETag двух видов:
+//   - сильные ETag версии ресурса ("3") - возвращаются изменяемыми ресурсами и принимаются в If-Match;
+//   - слабые ETag хеша ответа (W/"...") - возвращаются списками и статистикой и подходят только для If-None-Match.
+
+// versionETag возвращает ETag для версии ресурса.
+func versionETag(version int) string {
+	return fmt.Sprintf("%q", strconv.Itoa(version))
+}
+
+// setVersionETag устанавливает заголовок ETag с версией ресурса.
+// Для неизвестной версии (models.AnyVersion) заголовок не устанавливается.
+func setVersionETag(c *gin.Context, version int) {
+	if version != models.AnyVersion {
+		c.Header("ETag", versionETag(version))
+	}
+}
+
+// ifMatchVersion возвращает ожидаемую версию ресурса из заголовка If-Match.
+// Если заголовка нет или он равен *, возвращает models.AnyVersion.
+// Если значение не является ETag версии ресурса (например, это слабый ETag хеша ответа),
+// запрос прерывается с кодом 400 и возвращается false.
+func ifMatchVersion(c *gin.Context) (int, bool) {
+	header := strings.TrimSpace(c.GetHeader("If-Match"))
+	if header == "" || header == "*" {
+		return models.AnyVersion, true
+	}
+
+	// If-Match использует строгое сравнение, поэтому слабые ETag (W/"...") не подходят
+	unquoted, err := strconv.Unquote(header)
+	if err == nil {
+		version, err := strconv.Atoi(unquoted)
+		if err == nil && version > 0 {
+			return version, true
+		}
+	}
+
+	commonerrors.BadRequest(c, `If-Match must be a version ETag (for example "3") or *`)
+
+	return models.AnyVersion, false
+}
+
+// noneMatch проверяет, что ETag не указан в заголовке If-None-Match.
+// Для If-None-Match используется слабое сравнение.
+func noneMatch(c *gin.Context, etag string) bool {
+	header := c.GetHeader("If-None-Match")
+	if header == "" {
+		return true
+	}
+
+	etag = strings.TrimPrefix(etag, "W/")
+
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == "*" || candidate == etag {
+			return false
+		}
+	}
+
+	return true
+}
+
+// respondJSONWithETag отправляет JSON ответ со слабым ETag, вычисленным по телу ответа.
+// ETag слабый, так как не является версией ресурса и не принимается в If-Match (см. ifMatchVersion).
+// Если ETag совпадает с заголовком If-None-Match, отправляет 304 без тела.
+func respondJSONWithETag(c *gin.Context, status int, obj any) {
+	body, err := json.Marshal(obj)
+	if err != nil {
+		commonerrors.Internal(c)
+		return
+	}
+
+	hash := sha256.Sum256(body)
+	etag := fmt.Sprintf("W/%q", hex.EncodeToString(hash[:16]))
+
+	c.Header("ETag", etag)
+
+	if !noneMatch(c, etag) {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Data(status, "application/json; charset=utf-8", body)
+}
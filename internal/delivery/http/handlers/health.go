@@ -0,0 +1,70 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/health"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// databaseUnavailable - причина недоступности базы данных в ответе /readyz.
+const databaseUnavailable = "unavailable"
+
+// HealthHandler - обработчик проверок живости и готовности сервиса.
+type HealthHandler struct {
+	logger  *zap.Logger
+	checker health.ReadinessChecker
+}
+
+func NewHealthHandler(logger *zap.Logger, checker health.ReadinessChecker) *HealthHandler {
+	return &HealthHandler{
+		logger:  logger,
+		checker: checker,
+	}
+}
+
+func (h *HealthHandler) RegisterRoutes(r gin.IRoutes) {
+	r.GET("/healthz", h.HandleHealthz)
+	r.GET("/readyz", h.HandleReadyz)
+}
+
+// HandleHealthz сообщает, что процесс жив. Зависимости не проверяются,
+// чтобы недоступность базы данных не приводила к перезапуску процесса.
+func (h *HealthHandler) HandleHealthz(c *gin.Context) {
+	c.JSON(http.StatusOK, httpdto.Health{Status: httpdto.Ok})
+}
+
+// HandleReadyz проверяет готовность сервиса принимать запросы.
+// Если сервис не готов, отвечает 503 с результатами проверок.
+// Ручка доступна без авторизации, поэтому причина недоступности базы только логируется,
+// а в ответ попадает фиксированная строка.
+func (h *HealthHandler) HandleReadyz(c *gin.Context) {
+	report := h.checker.Ready(c.Request.Context())
+
+	resp := httpdto.Readiness{
+		Status:           httpdto.Ready,
+		Database:         httpdto.DependencyStatus{Status: httpdto.Up},
+		MigrationVersion: report.MigrationVersion,
+		Grpc:             httpdto.ReadinessGrpc(report.GRPC.String()),
+	}
+
+	if !report.Database.Up() {
+		message := databaseUnavailable
+		resp.Database = httpdto.DependencyStatus{Status: httpdto.Down, Error: &message}
+
+		l.FromContext(c.Request.Context(), h.logger).Warn("database is not available", zap.Error(report.Database.Err))
+	}
+
+	if !report.Ready {
+		resp.Status = httpdto.NotReady
+
+		c.JSON(http.StatusServiceUnavailable, resp)
+
+		return
+	}
+
+	c.JSON(http.StatusOK, resp)
+}
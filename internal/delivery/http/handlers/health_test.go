@@ -0,0 +1,125 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type readinessCheckerFunc func(ctx context.Context) health.Report
+
+func (f readinessCheckerFunc) Ready(ctx context.Context) health.Report {
+	return f(ctx)
+}
+
+func TestHealthHandler_HandleHealthz(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	handler := httphandlers.NewHealthHandler(zap.NewNop(), readinessCheckerFunc(func(context.Context) health.Report {
+		t.Fatal("liveness must not check dependencies")
+		return health.Report{}
+	}))
+	handler.RegisterRoutes(router)
+
+	req, _ := http.NewRequest("GET", "/healthz", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"status":"ok"}`, resp.Body.String())
+}
+
+func TestHealthHandler_HandleReadyz(t *testing.T) {
+	version := int64(1)
+
+	tests := []struct {
+		name             string
+		report           health.Report
+		expectedCode     int
+		expectedStatus   httpdto.ReadinessStatus
+		expectedDatabase httpdto.DependencyStatusStatus
+	}{
+		{
+			name: "Ready",
+			report: health.Report{
+				Ready:            true,
+				MigrationVersion: &version,
+				GRPC:             healthpb.HealthCheckResponse_SERVING,
+			},
+			expectedCode:     http.StatusOK,
+			expectedStatus:   httpdto.Ready,
+			expectedDatabase: httpdto.Up,
+		},
+		{
+			name: "Database down",
+			report: health.Report{
+				Database: health.DependencyStatus{Err: errors.New("dial tcp db.internal:5432: connection refused")},
+				GRPC:     healthpb.HealthCheckResponse_SERVING,
+			},
+			expectedCode:     http.StatusServiceUnavailable,
+			expectedStatus:   httpdto.NotReady,
+			expectedDatabase: httpdto.Down,
+		},
+		{
+			name: "Stopping",
+			report: health.Report{
+				MigrationVersion: &version,
+				GRPC:             healthpb.HealthCheckResponse_NOT_SERVING,
+			},
+			expectedCode:     http.StatusServiceUnavailable,
+			expectedStatus:   httpdto.NotReady,
+			expectedDatabase: httpdto.Up,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			handler := httphandlers.NewHealthHandler(zap.NewNop(), readinessCheckerFunc(func(context.Context) health.Report {
+				return tt.report
+			}))
+			handler.RegisterRoutes(router)
+
+			req, _ := http.NewRequest("GET", "/readyz", nil)
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			var body httpdto.Readiness
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &body))
+
+			assert.Equal(t, tt.expectedStatus, body.Status)
+			assert.Equal(t, tt.expectedDatabase, body.Database.Status)
+			assert.Equal(t, httpdto.ReadinessGrpc(tt.report.GRPC.String()), body.Grpc)
+			assert.Equal(t, tt.report.MigrationVersion, body.MigrationVersion)
+
+			// Текст ошибки базы может содержать адрес и пользователя, поэтому наружу не отдается
+			if tt.report.Database.Err != nil {
+				require.NotNil(t, body.Database.Error)
+				assert.Equal(t, "unavailable", *body.Database.Error)
+				assert.NotContains(t, resp.Body.String(), "db.internal")
+			} else {
+				assert.Nil(t, body.Database.Error)
+			}
+		})
+	}
+}
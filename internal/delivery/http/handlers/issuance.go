@@ -0,0 +1,85 @@
+package httphandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type IssuanceHandler struct {
+	logger          *zap.Logger
+	issuanceService service.IssuanceService
+}
+
+func NewIssuanceHandler(logger *zap.Logger, issuanceService service.IssuanceService) *IssuanceHandler {
+	return &IssuanceHandler{
+		logger:          logger,
+		issuanceService: issuanceService,
+	}
+}
+
+func (h *IssuanceHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.POST("/pvz/:pvzId/issue", h.HandleIssueProducts)
+}
+
+func (h *IssuanceHandler) HandleIssueProducts(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	var req httpdto.PostPvzPvzIdIssueJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling issue products", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	if req.OrderId == uuid.Nil || req.Code == "" {
+		l.FromContext(c.Request.Context(), h.logger).Debug("orderId and code are required handling issue products")
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	issuance, err := h.issuanceService.IssueProducts(c.Request.Context(), userID, role, pvzUUID, req.OrderId, req.Code, req.ProductIds)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, httpdto.ModelToIssuanceResponse(issuance))
+}
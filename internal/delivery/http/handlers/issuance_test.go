@@ -0,0 +1,176 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestIssuanceHandler_HandleIssueProducts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockIssuanceService := service_mocks.NewMockIssuanceService(ctrl)
+	logger := zap.NewNop()
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	orderID := uuid.New()
+	productID := uuid.New()
+	issuedAt := time.Now().UTC()
+	body := `{"orderId": "` + orderID.String() + `", "code": "123456", "productIds": ["` + productID.String() + `"]}`
+
+	issuance := &models.Issuance{
+		ID:       uuid.New(),
+		PVZID:    pvzID,
+		IssuedBy: userID,
+		DateTime: issuedAt,
+		Products: []*models.Product{{
+			ID:          productID,
+			Type:        models.ProductTypeShoes,
+			ReceptionID: uuid.New(),
+			Status:      models.ProductStatusIssued,
+			IssuedAt:    &issuedAt,
+		}},
+	}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		body         string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:  "successful issue",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockIssuanceService.EXPECT().
+					IssueProducts(gomock.Any(), userID, string(models.RoleEmployee), pvzID, orderID, "123456", []uuid.UUID{productID}).
+					Return(issuance, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			body:         body,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:         "invalid body",
+			pvzID:        pvzID.String(),
+			body:         `{"productIds": ["not-uuid"]}`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:         "missing pickup code",
+			pvzID:        pvzID.String(),
+			body:         `{"orderId": "` + orderID.String() + `", "productIds": ["` + productID.String() + `"]}`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:  "invalid pickup code",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockIssuanceService.EXPECT().
+					IssueProducts(gomock.Any(), userID, string(models.RoleEmployee), pvzID, orderID, "123456", []uuid.UUID{productID}).
+					Return(nil, domainerrors.ErrInvalidPickupCode)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_pickup_code",
+		},
+		{
+			name:  "product not found",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockIssuanceService.EXPECT().
+					IssueProducts(gomock.Any(), userID, string(models.RoleEmployee), pvzID, orderID, "123456", []uuid.UUID{productID}).
+					Return(nil, domainerrors.ErrProductNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedErr:  "product_not_found",
+		},
+		{
+			name:  "product already issued",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockIssuanceService.EXPECT().
+					IssueProducts(gomock.Any(), userID, string(models.RoleEmployee), pvzID, orderID, "123456", []uuid.UUID{productID}).
+					Return(nil, domainerrors.ErrProductNotStored)
+			},
+			expectedCode: http.StatusConflict,
+			expectedErr:  "product_not_stored",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewIssuanceHandler(logger, mockIssuanceService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/pvz/:pvzId/issue", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleEmployee))
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleIssueProducts(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/issue", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.Issuance
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, issuance.ID, response.Id)
+			require.Len(t, response.Products, 1)
+			assert.Equal(t, productID, *response.Products[0].Id)
+			require.NotNil(t, response.Products[0].Status)
+			assert.Equal(t, httpdto.Issued, *response.Products[0].Status)
+		})
+	}
+}
@@ -0,0 +1,76 @@
+package httphandlers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"go.uber.org/zap"
+)
+
+// LogLevelHandler - обработчик изменения уровней логирования во время работы.
+// Регистрируется только на внутреннем порту сервера метрик (см. routes.NewAdmin), где доступ
+// проверяется по отдельному админскому токену из конфига.
+type LogLevelHandler struct {
+	logger *zap.Logger
+	levels *l.Levels
+}
+
+func NewLogLevelHandler(logger *zap.Logger, levels *l.Levels) *LogLevelHandler {
+	return &LogLevelHandler{
+		logger: logger,
+		levels: levels,
+	}
+}
+
+func (h *LogLevelHandler) RegisterRoutes(group *gin.RouterGroup) {
+	admin := group.Group("/admin")
+
+	admin.GET("/log-level", h.HandleGetLogLevel)
+	admin.PUT("/log-level", h.HandlePutLogLevel)
+}
+
+func (h *LogLevelHandler) HandleGetLogLevel(c *gin.Context) {
+	c.JSON(http.StatusOK, h.response())
+}
+
+func (h *LogLevelHandler) HandlePutLogLevel(c *gin.Context) {
+	var req httpdto.PutAdminLogLevelJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling put log level", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	var err error
+
+	if req.Package != nil {
+		err = h.levels.SetPackageLevel(*req.Package, req.Level)
+	} else {
+		err = h.levels.SetLevel(req.Level)
+	}
+
+	if err != nil {
+		commonerrors.BadRequest(c, err.Error())
+		return
+	}
+
+	// Пишется на уровне Warn, чтобы изменение было видно при любом уровне, кроме silent
+	l.FromContext(c.Request.Context(), h.logger).Warn("Log level changed",
+		zap.String("level", req.Level),
+		zap.Stringp("package", req.Package),
+	)
+
+	c.JSON(http.StatusOK, h.response())
+}
+
+func (h *LogLevelHandler) response() httpdto.LogLevels {
+	return httpdto.LogLevels{
+		Level:    h.levels.Level(),
+		Packages: h.levels.Packages(),
+	}
+}
@@ -0,0 +1,106 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maksemen2/pvz-service/config"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func newLogLevelRouter(t *testing.T) (*gin.Engine, *logger.Levels) {
+	t.Helper()
+
+	levels, err := logger.NewLevels(config.LoggingConfig{Level: "info"})
+	require.NoError(t, err)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	httphandlers.NewLogLevelHandler(zap.NewNop(), levels).RegisterRoutes(router.Group(""))
+
+	return router, levels
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	pkg := "postgresqlrepo"
+
+	tests := []struct {
+		name            string
+		body            httpdto.PutAdminLogLevelJSONRequestBody
+		expectedCode    int
+		expectedLevel   string
+		expectedPackage map[string]string
+	}{
+		{
+			name:            "Change global level",
+			body:            httpdto.PutAdminLogLevelJSONRequestBody{Level: "debug"},
+			expectedCode:    http.StatusOK,
+			expectedLevel:   "debug",
+			expectedPackage: map[string]string{},
+		},
+		{
+			name:            "Change package level",
+			body:            httpdto.PutAdminLogLevelJSONRequestBody{Level: "debug", Package: &pkg},
+			expectedCode:    http.StatusOK,
+			expectedLevel:   "info",
+			expectedPackage: map[string]string{pkg: "debug"},
+		},
+		{
+			name:            "Unknown level",
+			body:            httpdto.PutAdminLogLevelJSONRequestBody{Level: "verbose"},
+			expectedCode:    http.StatusBadRequest,
+			expectedLevel:   "info",
+			expectedPackage: map[string]string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			router, levels := newLogLevelRouter(t)
+
+			body, _ := json.Marshal(tt.body)
+			req, _ := http.NewRequest("PUT", "/admin/log-level", bytes.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.expectedLevel, levels.Level())
+			assert.Equal(t, tt.expectedPackage, levels.Packages())
+
+			if tt.expectedCode != http.StatusOK {
+				return
+			}
+
+			var result httpdto.LogLevels
+
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &result))
+			assert.Equal(t, tt.expectedLevel, result.Level)
+			assert.Equal(t, tt.expectedPackage, result.Packages)
+		})
+	}
+}
+
+func TestLogLevelHandler_Get(t *testing.T) {
+	router, _ := newLogLevelRouter(t)
+
+	req, _ := http.NewRequest("GET", "/admin/log-level", nil)
+	resp := httptest.NewRecorder()
+	router.ServeHTTP(resp, req)
+
+	assert.Equal(t, http.StatusOK, resp.Code)
+	assert.JSONEq(t, `{"level":"info","packages":{}}`, resp.Body.String())
+}
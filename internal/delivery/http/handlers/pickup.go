@@ -0,0 +1,119 @@
+package httphandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type PickupHandler struct {
+	logger        *zap.Logger
+	pickupService service.PickupService
+}
+
+func NewPickupHandler(logger *zap.Logger, pickupService service.PickupService) *PickupHandler {
+	return &PickupHandler{
+		logger:        logger,
+		pickupService: pickupService,
+	}
+}
+
+func (h *PickupHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.POST("/pvz/:pvzId/pickup/verify", h.HandleVerifyCode)
+	group.POST("/pvz/:pvzId/orders/:orderId/pickup_code", h.HandleReissueCode)
+}
+
+func (h *PickupHandler) HandleVerifyCode(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	var req httpdto.PostPvzPvzIdPickupVerifyJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling verify pickup code", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	if req.OrderId == uuid.Nil || req.Code == "" {
+		l.FromContext(c.Request.Context(), h.logger).Debug("orderId and code are required handling verify pickup code")
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	order, err := h.pickupService.VerifyCode(c.Request.Context(), role, pvzUUID, req.OrderId, req.Code)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpdto.ModelToPickupOrderResponse(order))
+}
+
+func (h *PickupHandler) HandleReissueCode(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	orderID := c.Param("orderId")
+
+	orderUUID, err := uuid.Parse(orderID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid orderID", zap.String("orderID", orderID))
+		commonerrors.BadRequest(c, "invalid orderID")
+
+		return
+	}
+
+	code, err := h.pickupService.ReissueCode(c.Request.Context(), role, pvzUUID, orderUUID)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, httpdto.ModelToPickupCodeResponse(code))
+}
@@ -0,0 +1,257 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestPickupHandler_HandleVerifyCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPickupService := service_mocks.NewMockPickupService(ctrl)
+	logger := zap.NewNop()
+
+	pvzID := uuid.New()
+	orderID := uuid.New()
+	productID := uuid.New()
+
+	order := &models.PickupOrder{
+		OrderID: orderID,
+		PVZID:   pvzID,
+		Products: []*models.Product{{
+			ID:          productID,
+			Type:        models.ProductTypeShoes,
+			ReceptionID: uuid.New(),
+			OrderID:     orderID,
+			Status:      models.ProductStatusStored,
+		}},
+	}
+
+	body := `{"orderId": "` + orderID.String() + `", "code": "123456"}`
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		body         string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:  "successful verification",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockPickupService.EXPECT().
+					VerifyCode(gomock.Any(), string(models.RoleEmployee), pvzID, orderID, "123456").
+					Return(order, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			body:         body,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:         "invalid body",
+			pvzID:        pvzID.String(),
+			body:         `{"code": "123456"}`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:  "invalid code",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockPickupService.EXPECT().
+					VerifyCode(gomock.Any(), string(models.RoleEmployee), pvzID, orderID, "123456").
+					Return(nil, domainerrors.ErrInvalidPickupCode)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_pickup_code",
+		},
+		{
+			name:  "code locked",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockPickupService.EXPECT().
+					VerifyCode(gomock.Any(), string(models.RoleEmployee), pvzID, orderID, "123456").
+					Return(nil, domainerrors.ErrPickupCodeLocked)
+			},
+			expectedCode: http.StatusConflict,
+			expectedErr:  "pickup_code_locked",
+		},
+		{
+			name:  "throttled",
+			pvzID: pvzID.String(),
+			body:  body,
+			mockSetup: func() {
+				mockPickupService.EXPECT().
+					VerifyCode(gomock.Any(), string(models.RoleEmployee), pvzID, orderID, "123456").
+					Return(nil, domainerrors.ErrPickupCodeThrottled)
+			},
+			expectedCode: http.StatusTooManyRequests,
+			expectedErr:  "pickup_code_throttled",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewPickupHandler(logger, mockPickupService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/pvz/:pvzId/pickup/verify", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleEmployee))
+				handler.HandleVerifyCode(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/pickup/verify", bytes.NewBufferString(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.PickupOrder
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, orderID, response.OrderId)
+			require.Len(t, response.Products, 1)
+			assert.Equal(t, productID, *response.Products[0].Id)
+			require.NotNil(t, response.Products[0].OrderId)
+			assert.Equal(t, orderID, *response.Products[0].OrderId)
+		})
+	}
+}
+
+func TestPickupHandler_HandleReissueCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPickupService := service_mocks.NewMockPickupService(ctrl)
+	logger := zap.NewNop()
+
+	pvzID := uuid.New()
+	orderID := uuid.New()
+
+	code := &models.PickupCode{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		OrderID:   orderID,
+		Code:      "012345",
+		CodeHash:  "hash",
+		ExpiresAt: time.Now().Add(time.Hour).UTC(),
+	}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		orderID      string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:    "successful reissue",
+			pvzID:   pvzID.String(),
+			orderID: orderID.String(),
+			mockSetup: func() {
+				mockPickupService.EXPECT().ReissueCode(gomock.Any(), string(models.RoleModerator), pvzID, orderID).Return(code, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid orderID format",
+			pvzID:        pvzID.String(),
+			orderID:      "invalid-uuid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:    "order not found",
+			pvzID:   pvzID.String(),
+			orderID: orderID.String(),
+			mockSetup: func() {
+				mockPickupService.EXPECT().ReissueCode(gomock.Any(), string(models.RoleModerator), pvzID, orderID).Return(nil, domainerrors.ErrOrderNotFound)
+			},
+			expectedCode: http.StatusNotFound,
+			expectedErr:  "order_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewPickupHandler(logger, mockPickupService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/pvz/:pvzId/orders/:orderId/pickup_code", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleModerator))
+				handler.HandleReissueCode(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/orders/"+tt.orderID+"/pickup_code", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.PickupCode
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, "012345", response.Code)
+			assert.Equal(t, orderID, response.OrderId)
+			assert.True(t, code.ExpiresAt.Equal(response.ExpiresAt))
+		})
+	}
+}
@@ -1,13 +1,12 @@
 package httphandlers
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
 	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
-	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/service"
 	"go.uber.org/zap"
 	"net/http"
@@ -25,23 +24,10 @@ func NewProductHandler(logger *zap.Logger, productService service.ProductService
 	}
 }
 
-func (h *ProductHandler) handleDomainError(c *gin.Context, err error) {
-	switch {
-	case errors.Is(err, domainerrors.ErrUnexpected):
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-	case errors.Is(err, domainerrors.ErrNotEnoughRights):
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
-	case errors.Is(err, domainerrors.ErrNoOpenReceptions), errors.Is(err, domainerrors.ErrInvalidProductType), errors.Is(err, domainerrors.ErrNoProductsInReception):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest(err.Error()))
-	default:
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-		h.logger.Error("unexpected error", zap.Error(err))
-	}
-}
-
 func (h *ProductHandler) RegisterRoutes(group *gin.RouterGroup) {
 	group.POST("/pvz/:pvzId/delete_last_product", h.HandleDeleteLastProduct)
 	group.POST("/products", h.HandleAddProduct)
+	group.POST("/products/batch", h.HandleAddProductsBatch)
 }
 
 func (h *ProductHandler) HandleDeleteLastProduct(c *gin.Context) {
@@ -49,8 +35,8 @@ func (h *ProductHandler) HandleDeleteLastProduct(c *gin.Context) {
 
 	if !ok {
 		// Мы считаем это ошибкой, потому что мидлварь вообще не должен был пропускать такой запрос
-		h.logger.Error("no role in context handling delete last product")
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
+		l.FromContext(c.Request.Context(), h.logger).Error("no role in context handling delete last product")
+		commonerrors.Forbidden(c)
 
 		return
 	}
@@ -60,16 +46,21 @@ func (h *ProductHandler) HandleDeleteLastProduct(c *gin.Context) {
 	pvzUUID, err := uuid.Parse(pvzID)
 
 	if err != nil {
-		h.logger.Debug("invalid pvzID", zap.String("pvzID", pvzID), zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid pvzID"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID), zap.Error(err))
+		commonerrors.BadRequest(c, "invalid pvzID")
 
 		return
 	}
 
-	err = h.productService.DeleteLastProduct(c.Request.Context(), role, pvzUUID)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	err = h.productService.DeleteLastProduct(c.Request.Context(), role, pvzUUID, expectedVersion)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
@@ -80,8 +71,8 @@ func (h *ProductHandler) HandleAddProduct(c *gin.Context) {
 	role, ok := auth.GetRoleFromContext(c)
 
 	if !ok {
-		h.logger.Error("no role in context handling add product")
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
+		l.FromContext(c.Request.Context(), h.logger).Error("no role in context handling add product")
+		commonerrors.Forbidden(c)
 
 		return
 	}
@@ -89,18 +80,74 @@ func (h *ProductHandler) HandleAddProduct(c *gin.Context) {
 	var req httpdto.PostProductsJSONRequestBody
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debug("BindJSON error handling add product", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling add product", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
 
-	domainProduct, err := h.productService.AddProduct(c.Request.Context(), role, string(req.Type), req.PvzId)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	var orderID uuid.UUID
+	if req.OrderId != nil {
+		orderID = *req.OrderId
+	}
+
+	domainProduct, err := h.productService.AddProduct(c.Request.Context(), role, string(req.Type), req.PvzId, orderID, expectedVersion)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
 	c.JSON(http.StatusCreated, httpdto.ModelToProductResponse(domainProduct))
 }
+
+func (h *ProductHandler) HandleAddProductsBatch(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("no role in context handling add products batch")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	var req httpdto.PostProductsBatchJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling add products batch", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	mode := httpdto.AllOrNothing
+	if req.Mode != nil {
+		mode = *req.Mode
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	result, err := h.productService.AddProductsBatch(c.Request.Context(), role, req.PvzId, string(mode), httpdto.BatchItemsToModel(req.Items), expectedVersion)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	response := httpdto.ModelToProductsBatchResponse(result, commonerrors.DomainCode)
+
+	if !result.Applied {
+		c.JSON(http.StatusUnprocessableEntity, response)
+		return
+	}
+
+	c.JSON(http.StatusCreated, response)
+}
@@ -44,7 +44,7 @@ func TestProductHandler_HandleDeleteLastProduct(t *testing.T) {
 			mockSetup: func(pvzID string) {
 				pvzUUID := uuid.MustParse(pvzID)
 				mockProductService.EXPECT().
-					DeleteLastProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), pvzUUID).
+					DeleteLastProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), pvzUUID, gomock.Any()).
 					Return(nil)
 			},
 			expectedCode: http.StatusOK,
@@ -62,7 +62,7 @@ func TestProductHandler_HandleDeleteLastProduct(t *testing.T) {
 			role:  models.RoleEmployee,
 			mockSetup: func(pvzID string) {
 				mockProductService.EXPECT().
-					DeleteLastProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), gomock.Any()).
+					DeleteLastProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), gomock.Any(), gomock.Any()).
 					Return(domainerrors.ErrNotEnoughRights)
 			},
 			expectedCode: http.StatusForbidden,
@@ -73,7 +73,7 @@ func TestProductHandler_HandleDeleteLastProduct(t *testing.T) {
 			role:  models.RoleEmployee,
 			mockSetup: func(pvzID string) {
 				mockProductService.EXPECT().
-					DeleteLastProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), gomock.Any()).
+					DeleteLastProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), gomock.Any(), gomock.Any()).
 					Return(domainerrors.ErrNoOpenReceptions)
 			},
 			expectedCode: http.StatusBadRequest,
@@ -134,7 +134,7 @@ func TestProductHandler_HandleAddProduct(t *testing.T) {
 			role: models.RoleEmployee,
 			mockSetup: func() {
 				mockProductService.EXPECT().
-					AddProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), models.ProductTypeClothes.String(), validPvzID).
+					AddProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), models.ProductTypeClothes.String(), validPvzID, uuid.Nil, gomock.Any()).
 					Return(validProduct, nil)
 			},
 			expectedCode: http.StatusCreated,
@@ -155,7 +155,7 @@ func TestProductHandler_HandleAddProduct(t *testing.T) {
 			role: models.RoleEmployee,
 			mockSetup: func() {
 				mockProductService.EXPECT().
-					AddProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), "invalid_type", validPvzID).
+					AddProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), "invalid_type", validPvzID, uuid.Nil, gomock.Any()).
 					Return(nil, domainerrors.ErrInvalidProductType)
 			},
 			expectedCode: http.StatusBadRequest,
@@ -169,7 +169,7 @@ func TestProductHandler_HandleAddProduct(t *testing.T) {
 			role: models.RoleEmployee,
 			mockSetup: func() {
 				mockProductService.EXPECT().
-					AddProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), "одежда", validPvzID).
+					AddProduct(gomock.Any(), gomock.Eq(string(models.RoleEmployee)), "одежда", validPvzID, uuid.Nil, gomock.Any()).
 					Return(nil, domainerrors.ErrNotEnoughRights)
 			},
 			expectedCode: http.StatusForbidden,
@@ -200,3 +200,106 @@ func TestProductHandler_HandleAddProduct(t *testing.T) {
 		})
 	}
 }
+
+func TestProductHandler_HandleAddProductsBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockProductService := service_mocks.NewMockProductService(ctrl)
+	logger := zap.NewNop()
+
+	pvzID := uuid.New()
+	itemID := uuid.New()
+	bestEffort := httpdto.BestEffort
+
+	validBody := httpdto.PostProductsBatchJSONRequestBody{
+		PvzId: pvzID,
+		Items: []httpdto.ProductsBatchItem{{Id: &itemID, Type: httpdto.ProductsBatchItemTypeОбувь}},
+	}
+
+	tests := []struct {
+		name         string
+		requestBody  interface{}
+		mockSetup    func()
+		expectedCode int
+	}{
+		{
+			name:        "Successful batch with default mode",
+			requestBody: validBody,
+			mockSetup: func() {
+				mockProductService.EXPECT().
+					AddProductsBatch(gomock.Any(), models.RoleEmployee.String(), pvzID, models.BatchModeAllOrNothing.String(), []*models.AddProduct{{ID: itemID, Type: models.ProductTypeShoes}}, gomock.Any()).
+					Return(&models.AddProductsBatchResult{ReceptionID: uuid.New(), Applied: true, Items: []*models.BatchItemResult{
+						{Index: 0, Status: models.BatchItemStatusCreated, Product: &models.Product{ID: itemID, Type: models.ProductTypeShoes}},
+					}}, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name: "Best effort mode",
+			requestBody: httpdto.PostProductsBatchJSONRequestBody{
+				PvzId: pvzID,
+				Mode:  &bestEffort,
+				Items: validBody.Items,
+			},
+			mockSetup: func() {
+				mockProductService.EXPECT().
+					AddProductsBatch(gomock.Any(), models.RoleEmployee.String(), pvzID, models.BatchModeBestEffort.String(), gomock.Any(), gomock.Any()).
+					Return(&models.AddProductsBatchResult{Applied: true}, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:        "Batch rejected",
+			requestBody: validBody,
+			mockSetup: func() {
+				mockProductService.EXPECT().
+					AddProductsBatch(gomock.Any(), models.RoleEmployee.String(), pvzID, models.BatchModeAllOrNothing.String(), gomock.Any(), gomock.Any()).
+					Return(&models.AddProductsBatchResult{Applied: false, Items: []*models.BatchItemResult{
+						{Index: 0, Status: models.BatchItemStatusFailed, Err: domainerrors.ErrInvalidProductType},
+					}}, nil)
+			},
+			expectedCode: http.StatusUnprocessableEntity,
+		},
+		{
+			name:         "Invalid request body",
+			requestBody:  "invalid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Batch too large",
+			requestBody: validBody,
+			mockSetup: func() {
+				mockProductService.EXPECT().
+					AddProductsBatch(gomock.Any(), models.RoleEmployee.String(), pvzID, models.BatchModeAllOrNothing.String(), gomock.Any(), gomock.Any()).
+					Return(nil, domainerrors.ErrInvalidBatchSize)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewProductHandler(logger, mockProductService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.POST("/products/batch", func(c *gin.Context) {
+				c.Set(auth.RoleKey, models.RoleEmployee.String())
+				handler.HandleAddProductsBatch(c)
+			})
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest("POST", "/products/batch", bytes.NewBuffer(body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
@@ -1,12 +1,11 @@
 package httphandlers
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
 	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
 	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
-	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/service"
 	"go.uber.org/zap"
 	"net/http"
@@ -24,24 +23,6 @@ func NewPVZHandler(logger *zap.Logger, pzvService service.PVZService) *PVZHandle
 	}
 }
 
-func (h *PVZHandler) handleDomainError(c *gin.Context, err error) {
-	switch {
-	case errors.Is(err, domainerrors.ErrUnexpected):
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-	case errors.Is(err, domainerrors.ErrInvalidCity):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid city provided"))
-	case errors.Is(err, domainerrors.ErrUserNotModerator):
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
-	case errors.Is(err, domainerrors.ErrPVZAlreadyExists):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("pvz already exists"))
-	case errors.Is(err, domainerrors.ErrInvalidLimit), errors.Is(err, domainerrors.ErrInvalidPage), errors.Is(err, domainerrors.ErrInvalidStartDate), errors.Is(err, domainerrors.ErrInvalidDateRange):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest(err.Error()))
-	default:
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-		h.logger.Error("unexpected error", zap.Error(err))
-	}
-}
-
 func (h *PVZHandler) RegisterRoutes(group *gin.RouterGroup) {
 	group.POST("/pvz", h.HandleCreatePVZ)
 	group.GET("/pvz", h.HandleListPVZ)
@@ -50,8 +31,8 @@ func (h *PVZHandler) RegisterRoutes(group *gin.RouterGroup) {
 func (h *PVZHandler) HandleCreatePVZ(c *gin.Context) {
 	userRole, ok := auth.GetRoleFromContext(c)
 	if !ok {
-		h.logger.Error("no role in context handling create pvz")
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.Unauthorized())
+		l.FromContext(c.Request.Context(), h.logger).Error("no role in context handling create pvz")
+		commonerrors.Unauthorized(c)
 
 		return
 	}
@@ -59,8 +40,8 @@ func (h *PVZHandler) HandleCreatePVZ(c *gin.Context) {
 	var req httpdto.PostPvzJSONRequestBody
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debug("BindJSON error handling create pvz", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling create pvz", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
@@ -68,18 +49,19 @@ func (h *PVZHandler) HandleCreatePVZ(c *gin.Context) {
 	domainPVZ, err := h.pzvService.CreatePVZ(c.Request.Context(), userRole, string(req.City), req.Id, req.RegistrationDate)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
+	setVersionETag(c, domainPVZ.Version)
 	c.JSON(http.StatusCreated, httpdto.ToPVZResponse(domainPVZ))
 }
 
 func (h *PVZHandler) HandleListPVZ(c *gin.Context) {
 	userRole, ok := auth.GetRoleFromContext(c)
 	if !ok {
-		h.logger.Error("no role in context handling list pvz")
-		c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.Unauthorized())
+		l.FromContext(c.Request.Context(), h.logger).Error("no role in context handling list pvz")
+		commonerrors.Unauthorized(c)
 
 		return
 	}
@@ -87,8 +69,8 @@ func (h *PVZHandler) HandleListPVZ(c *gin.Context) {
 	var query httpdto.GetPvzParams
 
 	if err := c.ShouldBindQuery(&query); err != nil {
-		h.logger.Debug("BindQuery error handling list pvz", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid query parameters"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindQuery error handling list pvz", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid query parameters")
 
 		return
 	}
@@ -96,7 +78,7 @@ func (h *PVZHandler) HandleListPVZ(c *gin.Context) {
 	pvzsWithReceptions, err := h.pzvService.ListPVZs(c.Request.Context(), userRole, query.StartDate, query.EndDate, query.Page, query.Limit)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
@@ -106,5 +88,5 @@ func (h *PVZHandler) HandleListPVZ(c *gin.Context) {
 		answer = append(answer, httpdto.ModelToPVZWithReceptionsResponse(pvz))
 	}
 
-	c.JSON(http.StatusOK, answer)
+	respondJSONWithETag(c, http.StatusOK, answer)
 }
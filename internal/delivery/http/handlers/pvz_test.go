@@ -9,6 +9,7 @@ import (
 	"go.uber.org/mock/gomock"
 	"net/http"
 	"net/http/httptest"
+	"strings"
 	"testing"
 	"time"
 
@@ -200,3 +201,55 @@ func TestPVZHandler_HandleListPVZ(t *testing.T) {
 		})
 	}
 }
+
+func TestPVZHandler_HandleListPVZ_ConditionalGet(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPVZService := service_mocks.NewMockPVZService(ctrl)
+	handler := httphandlers.NewPVZHandler(zap.NewNop(), mockPVZService)
+	pvzs := []*models.PVZWithReceptions{
+		{PVZ: &models.PVZ{ID: uuid.New(), City: "Москва", Version: 2}},
+	}
+
+	mockPVZService.EXPECT().
+		ListPVZs(gomock.Any(), models.RoleModerator.String(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+		Return(pvzs, nil).
+		Times(3)
+
+	gin.SetMode(gin.TestMode)
+	router := gin.New()
+
+	router.GET("/pvz", func(c *gin.Context) {
+		c.Set(auth.RoleKey, models.RoleModerator.String())
+		handler.HandleListPVZ(c)
+	})
+
+	get := func(ifNoneMatch string) *httptest.ResponseRecorder {
+		req, _ := http.NewRequest("GET", "/pvz", nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+
+		resp := httptest.NewRecorder()
+		router.ServeHTTP(resp, req)
+
+		return resp
+	}
+
+	first := get("")
+	assert.Equal(t, http.StatusOK, first.Code)
+
+	// ETag хеша ответа слабый, чтобы его нельзя было спутать с версией ресурса в If-Match
+	etag := first.Header().Get("ETag")
+	assert.True(t, strings.HasPrefix(etag, `W/"`))
+
+	notModified := get(etag)
+	assert.Equal(t, http.StatusNotModified, notModified.Code)
+	assert.Empty(t, notModified.Body.Bytes())
+	assert.Equal(t, etag, notModified.Header().Get("ETag"))
+
+	changed := get(`"stale"`)
+	assert.Equal(t, http.StatusOK, changed.Code)
+	assert.Equal(t, first.Body.String(), changed.Body.String())
+}
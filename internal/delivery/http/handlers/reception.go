@@ -1,13 +1,12 @@
 package httphandlers
 
 import (
-	"errors"
 	"github.com/gin-gonic/gin"
 	"github.com/google/uuid"
 	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
 	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
-	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/service"
 	"go.uber.org/zap"
 	"net/http"
@@ -25,22 +24,11 @@ func NewReceptionHandler(logger *zap.Logger, receptionService service.ReceptionS
 	}
 }
 
-func (h *ReceptionHandler) handleDomainError(c *gin.Context, err error) {
-	switch {
-	case errors.Is(err, domainerrors.ErrUnexpected):
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-	case errors.Is(err, domainerrors.ErrNoOpenReceptions), errors.Is(err, domainerrors.ErrOpenReceptionExists), errors.Is(err, domainerrors.ErrPVZNotFound):
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest(err.Error()))
-	case errors.Is(err, domainerrors.ErrNotEnoughRights):
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
-	default:
-		c.AbortWithStatusJSON(http.StatusInternalServerError, commonerrors.Internal())
-		h.logger.Error("unexpected error", zap.Error(err))
-	}
-}
-
 func (h *ReceptionHandler) RegisterRoutes(group *gin.RouterGroup) {
 	group.POST("/pvz/:pvzId/close_last_reception", h.HandleCloseLastReception)
+	group.POST("/pvz/:pvzId/cancel_last_reception", h.HandleCancelLastReception)
+	group.POST("/pvz/:pvzId/reopen_last_reception", h.HandleReopenLastReception)
+	group.POST("/pvz/:pvzId/manifest", h.HandleAttachManifest)
 	group.POST("/receptions", h.HandleCreateReception)
 }
 
@@ -48,8 +36,17 @@ func (h *ReceptionHandler) HandleCloseLastReception(c *gin.Context) {
 	role, ok := auth.GetRoleFromContext(c)
 
 	if !ok {
-		h.logger.Error("Failed to get role from context")
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
 
 		return
 	}
@@ -59,19 +56,25 @@ func (h *ReceptionHandler) HandleCloseLastReception(c *gin.Context) {
 	pvzUUID, err := uuid.Parse(pvzID)
 
 	if err != nil {
-		h.logger.Debug("invalid pvzID", zap.String("pvzID", pvzID))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid pvzID"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
 
 		return
 	}
 
-	domainReception, err := h.receptionService.CloseLastReception(c.Request.Context(), role, pvzUUID)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	domainReception, err := h.receptionService.CloseLastReception(c.Request.Context(), userID, role, pvzUUID, expectedVersion)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
+	setVersionETag(c, domainReception.Version)
 	c.JSON(http.StatusOK, httpdto.ModelToReceptionResponse(domainReception))
 }
 
@@ -79,8 +82,17 @@ func (h *ReceptionHandler) HandleCreateReception(c *gin.Context) {
 	role, ok := auth.GetRoleFromContext(c)
 
 	if !ok {
-		h.logger.Error("Failed to get role from context")
-		c.AbortWithStatusJSON(http.StatusForbidden, commonerrors.Forbidden())
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
 
 		return
 	}
@@ -88,18 +100,179 @@ func (h *ReceptionHandler) HandleCreateReception(c *gin.Context) {
 	var req httpdto.PostReceptionsJSONRequestBody
 
 	if err := c.ShouldBindJSON(&req); err != nil {
-		h.logger.Debug("BindJSON error handling create reception", zap.Error(err))
-		c.AbortWithStatusJSON(http.StatusBadRequest, commonerrors.BadRequest("invalid request body"))
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling create reception", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
 
 		return
 	}
 
-	domainReception, err := h.receptionService.CreateReceptionIfNoOpen(c.Request.Context(), role, req.PvzId)
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	domainReception, err := h.receptionService.CreateReceptionIfNoOpen(c.Request.Context(), userID, role, req.PvzId, expectedVersion)
 
 	if err != nil {
-		h.handleDomainError(c, err)
+		handleDomainError(c, h.logger, err)
 		return
 	}
 
+	setVersionETag(c, domainReception.Version)
 	c.JSON(http.StatusCreated, httpdto.ModelToReceptionResponse(domainReception))
 }
+
+func (h *ReceptionHandler) HandleCancelLastReception(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	var req httpdto.PostPvzPvzIdCancelLastReceptionJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling cancel reception", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	domainReception, err := h.receptionService.CancelLastReception(c.Request.Context(), userID, role, pvzUUID, req.Reason, expectedVersion)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	setVersionETag(c, domainReception.Version)
+	c.JSON(http.StatusOK, httpdto.ModelToReceptionResponse(domainReception))
+}
+
+func (h *ReceptionHandler) HandleReopenLastReception(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	domainReception, err := h.receptionService.ReopenLastReception(c.Request.Context(), userID, role, pvzUUID, expectedVersion)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	setVersionETag(c, domainReception.Version)
+	c.JSON(http.StatusOK, httpdto.ModelToReceptionResponse(domainReception))
+}
+
+func (h *ReceptionHandler) HandleAttachManifest(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	var req httpdto.PostPvzPvzIdManifestJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling attach manifest", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	expectedVersion, ok := ifMatchVersion(c)
+	if !ok {
+		return
+	}
+
+	manifest, err := h.receptionService.AttachManifest(c.Request.Context(), userID, role, pvzUUID, httpdto.ManifestItemsToModel(req.Items), expectedVersion)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, httpdto.ModelToManifestResponse(manifest))
+}
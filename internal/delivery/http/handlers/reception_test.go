@@ -29,6 +29,7 @@ func TestReceptionHandler_HandleCloseLastReception(t *testing.T) {
 
 	mockReceptionService := service_mocks.NewMockReceptionService(ctrl)
 	logger := zap.NewNop()
+	userID := uuid.New()
 
 	tests := []struct {
 		name         string
@@ -44,7 +45,7 @@ func TestReceptionHandler_HandleCloseLastReception(t *testing.T) {
 			mockSetup: func(pvzID string) {
 				pvzUUID := uuid.MustParse(pvzID)
 				mockReceptionService.EXPECT().
-					CloseLastReception(gomock.Any(), models.RoleModerator.String(), pvzUUID).
+					CloseLastReception(gomock.Any(), userID, models.RoleModerator.String(), pvzUUID, gomock.Any()).
 					Return(&models.Reception{ID: uuid.New()}, nil)
 			},
 			expectedCode: http.StatusOK,
@@ -63,7 +64,7 @@ func TestReceptionHandler_HandleCloseLastReception(t *testing.T) {
 			mockSetup: func(pvzID string) {
 				pvzUUID := uuid.MustParse(pvzID)
 				mockReceptionService.EXPECT().
-					CloseLastReception(gomock.Any(), models.RoleModerator.String(), pvzUUID).
+					CloseLastReception(gomock.Any(), userID, models.RoleModerator.String(), pvzUUID, gomock.Any()).
 					Return(nil, domainerrors.ErrNoOpenReceptions)
 			},
 			expectedCode: http.StatusBadRequest,
@@ -75,7 +76,7 @@ func TestReceptionHandler_HandleCloseLastReception(t *testing.T) {
 			mockSetup: func(pvzID string) {
 				pvzUUID := uuid.MustParse(pvzID)
 				mockReceptionService.EXPECT().
-					CloseLastReception(gomock.Any(), models.RoleEmployee.String(), pvzUUID).
+					CloseLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzUUID, gomock.Any()).
 					Return(nil, domainerrors.ErrNotEnoughRights)
 			},
 			expectedCode: http.StatusForbidden,
@@ -93,6 +94,7 @@ func TestReceptionHandler_HandleCloseLastReception(t *testing.T) {
 
 			router.POST("/pvz/:pvzId/close_last_reception", func(c *gin.Context) {
 				c.Set(auth.RoleKey, tt.role.String())
+				c.Set(auth.UserIDKey, userID)
 				handler.HandleCloseLastReception(c)
 			})
 
@@ -112,6 +114,7 @@ func TestReceptionHandler_HandleCreateReception(t *testing.T) {
 
 	mockReceptionService := service_mocks.NewMockReceptionService(ctrl)
 	logger := zap.NewNop()
+	userID := uuid.New()
 
 	validPvzID := uuid.New()
 	validReception := &models.Reception{
@@ -134,7 +137,7 @@ func TestReceptionHandler_HandleCreateReception(t *testing.T) {
 			role: models.RoleEmployee,
 			mockSetup: func() {
 				mockReceptionService.EXPECT().
-					CreateReceptionIfNoOpen(gomock.Any(), models.RoleEmployee.String(), validPvzID).
+					CreateReceptionIfNoOpen(gomock.Any(), userID, models.RoleEmployee.String(), validPvzID, gomock.Any()).
 					Return(validReception, nil)
 			},
 			expectedCode: http.StatusCreated,
@@ -154,7 +157,7 @@ func TestReceptionHandler_HandleCreateReception(t *testing.T) {
 			role: models.RoleEmployee,
 			mockSetup: func() {
 				mockReceptionService.EXPECT().
-					CreateReceptionIfNoOpen(gomock.Any(), models.RoleEmployee.String(), validPvzID).
+					CreateReceptionIfNoOpen(gomock.Any(), userID, models.RoleEmployee.String(), validPvzID, gomock.Any()).
 					Return(nil, domainerrors.ErrOpenReceptionExists)
 			},
 			expectedCode: http.StatusBadRequest,
@@ -167,7 +170,7 @@ func TestReceptionHandler_HandleCreateReception(t *testing.T) {
 			role: models.RoleEmployee,
 			mockSetup: func() {
 				mockReceptionService.EXPECT().
-					CreateReceptionIfNoOpen(gomock.Any(), models.RoleEmployee.String(), validPvzID).
+					CreateReceptionIfNoOpen(gomock.Any(), userID, models.RoleEmployee.String(), validPvzID, gomock.Any()).
 					Return(nil, domainerrors.ErrNotEnoughRights)
 			},
 			expectedCode: http.StatusForbidden,
@@ -185,6 +188,7 @@ func TestReceptionHandler_HandleCreateReception(t *testing.T) {
 
 			router.POST("/receptions", func(c *gin.Context) {
 				c.Set(auth.RoleKey, tt.role.String())
+				c.Set(auth.UserIDKey, userID)
 				handler.HandleCreateReception(c)
 			})
 
@@ -198,3 +202,358 @@ func TestReceptionHandler_HandleCreateReception(t *testing.T) {
 		})
 	}
 }
+
+func TestReceptionHandler_HandleCancelLastReception(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReceptionService := service_mocks.NewMockReceptionService(ctrl)
+	logger := zap.NewNop()
+	userID := uuid.New()
+	pvzID := uuid.New()
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		requestBody  interface{}
+		mockSetup    func()
+		expectedCode int
+	}{
+		{
+			name:        "Successful cancel",
+			pvzID:       pvzID.String(),
+			requestBody: httpdto.PostPvzPvzIdCancelLastReceptionJSONRequestBody{Reason: "wrong pvz"},
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					CancelLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, "wrong pvz", gomock.Any()).
+					Return(&models.Reception{ID: uuid.New(), Status: models.ReceptionStatusCancelled}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid pvz id format",
+			pvzID:        "invalid-uuid",
+			requestBody:  httpdto.PostPvzPvzIdCancelLastReceptionJSONRequestBody{Reason: "wrong pvz"},
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid request body",
+			pvzID:        pvzID.String(),
+			requestBody:  "invalid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Empty reason",
+			pvzID:       pvzID.String(),
+			requestBody: httpdto.PostPvzPvzIdCancelLastReceptionJSONRequestBody{Reason: ""},
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					CancelLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, "", gomock.Any()).
+					Return(nil, domainerrors.ErrCancelReasonEmpty)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "No open receptions",
+			pvzID:       pvzID.String(),
+			requestBody: httpdto.PostPvzPvzIdCancelLastReceptionJSONRequestBody{Reason: "wrong pvz"},
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					CancelLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, "wrong pvz", gomock.Any()).
+					Return(nil, domainerrors.ErrNoOpenReceptions)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReceptionHandler(logger, mockReceptionService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.POST("/pvz/:pvzId/cancel_last_reception", func(c *gin.Context) {
+				c.Set(auth.RoleKey, models.RoleEmployee.String())
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleCancelLastReception(c)
+			})
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/cancel_last_reception", bytes.NewBuffer(body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
+
+func TestReceptionHandler_HandleReopenLastReception(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReceptionService := service_mocks.NewMockReceptionService(ctrl)
+	logger := zap.NewNop()
+	userID := uuid.New()
+	pvzID := uuid.New()
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		mockSetup    func()
+		expectedCode int
+	}{
+		{
+			name:  "Successful reopen",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					ReopenLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, gomock.Any()).
+					Return(&models.Reception{ID: uuid.New(), Status: models.ReceptionStatusInProgress}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "Invalid pvz id format",
+			pvzID:        "invalid-uuid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Grace period expired",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					ReopenLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, gomock.Any()).
+					Return(nil, domainerrors.ErrReopenGracePeriodExpired)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:  "Closed by another user",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					ReopenLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, gomock.Any()).
+					Return(nil, domainerrors.ErrNotEnoughRights)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReceptionHandler(logger, mockReceptionService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.POST("/pvz/:pvzId/reopen_last_reception", func(c *gin.Context) {
+				c.Set(auth.RoleKey, models.RoleEmployee.String())
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleReopenLastReception(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/reopen_last_reception", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
+
+func TestReceptionHandler_HandleAttachManifest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReceptionService := service_mocks.NewMockReceptionService(ctrl)
+	logger := zap.NewNop()
+	userID := uuid.New()
+	pvzID := uuid.New()
+
+	validBody := httpdto.PostPvzPvzIdManifestJSONRequestBody{
+		Items: []httpdto.ManifestItem{{Type: httpdto.ManifestItemTypeОбувь, Count: 2}},
+	}
+	expectedItems := []models.ManifestItem{{Type: models.ProductTypeShoes, Count: 2}}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		requestBody  interface{}
+		mockSetup    func()
+		expectedCode int
+	}{
+		{
+			name:        "Successful attach",
+			pvzID:       pvzID.String(),
+			requestBody: validBody,
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					AttachManifest(gomock.Any(), userID, models.RoleModerator.String(), pvzID, expectedItems, gomock.Any()).
+					Return(&models.Manifest{ID: uuid.New(), PVZID: pvzID, Items: expectedItems}, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "Invalid pvz id format",
+			pvzID:        "invalid-uuid",
+			requestBody:  validBody,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Invalid request body",
+			pvzID:        pvzID.String(),
+			requestBody:  "invalid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid manifest",
+			pvzID:       pvzID.String(),
+			requestBody: validBody,
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					AttachManifest(gomock.Any(), userID, models.RoleModerator.String(), pvzID, expectedItems, gomock.Any()).
+					Return(nil, domainerrors.ErrInvalidManifest)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Not enough rights",
+			pvzID:       pvzID.String(),
+			requestBody: validBody,
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					AttachManifest(gomock.Any(), userID, models.RoleModerator.String(), pvzID, expectedItems, gomock.Any()).
+					Return(nil, domainerrors.ErrNotEnoughRights)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReceptionHandler(logger, mockReceptionService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.POST("/pvz/:pvzId/manifest", func(c *gin.Context) {
+				c.Set(auth.RoleKey, models.RoleModerator.String())
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleAttachManifest(c)
+			})
+
+			body, _ := json.Marshal(tt.requestBody)
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/manifest", bytes.NewBuffer(body))
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
+
+func TestReceptionHandler_HandleCloseLastReception_IfMatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReceptionService := service_mocks.NewMockReceptionService(ctrl)
+	logger := zap.NewNop()
+	userID := uuid.New()
+	pvzID := uuid.New()
+
+	tests := []struct {
+		name         string
+		ifMatch      string
+		mockSetup    func()
+		expectedCode int
+		expectedETag string
+	}{
+		{
+			name:    "Matching version",
+			ifMatch: `"3"`,
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					CloseLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, 3).
+					Return(&models.Reception{ID: uuid.New(), Version: 4}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedETag: `"4"`,
+		},
+		{
+			name:    "Any version",
+			ifMatch: "*",
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					CloseLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion).
+					Return(&models.Reception{ID: uuid.New(), Version: 2}, nil)
+			},
+			expectedCode: http.StatusOK,
+			expectedETag: `"2"`,
+		},
+		{
+			name:    "Version mismatch",
+			ifMatch: `"3"`,
+			mockSetup: func() {
+				mockReceptionService.EXPECT().
+					CloseLastReception(gomock.Any(), userID, models.RoleEmployee.String(), pvzID, 3).
+					Return(nil, domainerrors.ErrVersionMismatch)
+			},
+			expectedCode: http.StatusPreconditionFailed,
+		},
+		{
+			name:         "Weak etag",
+			ifMatch:      `W/"3"`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:         "Not a version",
+			ifMatch:      `"abc"`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReceptionHandler(logger, mockReceptionService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.POST("/pvz/:pvzId/close_last_reception", func(c *gin.Context) {
+				c.Set(auth.RoleKey, models.RoleEmployee.String())
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleCloseLastReception(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+pvzID.String()+"/close_last_reception", nil)
+			req.Header.Set("If-Match", tt.ifMatch)
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.expectedETag, resp.Header().Get("ETag"))
+		})
+	}
+}
@@ -0,0 +1,161 @@
+package httphandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type ReturnHandler struct {
+	logger        *zap.Logger
+	returnService service.ReturnService
+}
+
+func NewReturnHandler(logger *zap.Logger, returnService service.ReturnService) *ReturnHandler {
+	return &ReturnHandler{
+		logger:        logger,
+		returnService: returnService,
+	}
+}
+
+func (h *ReturnHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.POST("/pvz/:pvzId/returns", h.HandleRegisterReturn)
+	group.GET("/pvz/:pvzId/returns", h.HandleListReturns)
+	group.POST("/pvz/:pvzId/returns/dispatch", h.HandleDispatchReturns)
+}
+
+func (h *ReturnHandler) HandleRegisterReturn(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	var req httpdto.PostPvzPvzIdReturnsJSONRequestBody
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindJSON error handling register return", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid request body")
+
+		return
+	}
+
+	var barcode, productType string
+
+	if req.Barcode != nil {
+		barcode = *req.Barcode
+	}
+
+	if req.Type != nil {
+		productType = string(*req.Type)
+	}
+
+	ret, err := h.returnService.RegisterReturn(c.Request.Context(), userID, role, pvzUUID, req.ProductId, barcode, productType, string(req.Reason), string(req.Condition))
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, httpdto.ModelToCustomerReturnResponse(ret))
+}
+
+func (h *ReturnHandler) HandleListReturns(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	var query httpdto.GetPvzPvzIdReturnsParams
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindQuery error handling list returns", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid query parameters")
+
+		return
+	}
+
+	report, err := h.returnService.ListReturns(c.Request.Context(), role, pvzUUID, query.StartDate, query.EndDate)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpdto.ModelToReturnsReportResponse(report))
+}
+
+func (h *ReturnHandler) HandleDispatchReturns(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	shipment, err := h.returnService.DispatchReturns(c.Request.Context(), role, pvzUUID)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpdto.ModelToShipmentResponse(shipment))
+}
@@ -0,0 +1,380 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestReturnHandler_HandleRegisterReturn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReturnService := service_mocks.NewMockReturnService(ctrl)
+	logger := zap.NewNop()
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	productID := uuid.New()
+	shipmentID := uuid.New()
+
+	ret := &models.CustomerReturn{
+		ID:         uuid.New(),
+		PVZID:      pvzID,
+		ProductID:  &productID,
+		Type:       models.ProductTypeClothes,
+		Reason:     models.ReturnReasonWrongItem,
+		Condition:  models.ReturnConditionNew,
+		ShipmentID: shipmentID,
+		CreatedBy:  userID,
+		DateTime:   time.Now().UTC(),
+	}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		requestBody  string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:        "successful registration",
+			pvzID:       pvzID.String(),
+			requestBody: `{"productId":"` + productID.String() + `","reason":"wrong_item","condition":"new"}`,
+			mockSetup: func() {
+				mockReturnService.EXPECT().
+					RegisterReturn(gomock.Any(), userID, string(models.RoleEmployee), pvzID, &productID, "", "", "wrong_item", "new").
+					Return(ret, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			requestBody:  `{"reason":"wrong_item","condition":"new"}`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:         "invalid request body",
+			pvzID:        pvzID.String(),
+			requestBody:  `{"reason":`,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:        "invalid reason",
+			pvzID:       pvzID.String(),
+			requestBody: `{"barcode":"4600000000017","type":"обувь","reason":"broken","condition":"new"}`,
+			mockSetup: func() {
+				mockReturnService.EXPECT().
+					RegisterReturn(gomock.Any(), userID, string(models.RoleEmployee), pvzID, nil, "4600000000017", "обувь", "broken", "new").
+					Return(nil, domainerrors.ErrInvalidReturnReason)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_return_reason",
+		},
+		{
+			name:        "product not issued",
+			pvzID:       pvzID.String(),
+			requestBody: `{"productId":"` + productID.String() + `","reason":"wrong_item","condition":"new"}`,
+			mockSetup: func() {
+				mockReturnService.EXPECT().
+					RegisterReturn(gomock.Any(), userID, string(models.RoleEmployee), pvzID, &productID, "", "", "wrong_item", "new").
+					Return(nil, domainerrors.ErrProductNotIssued)
+			},
+			expectedCode: http.StatusConflict,
+			expectedErr:  "product_not_issued",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReturnHandler(logger, mockReturnService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/pvz/:pvzId/returns", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleEmployee))
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleRegisterReturn(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/returns", bytes.NewBufferString(tt.requestBody))
+			req.Header.Set("Content-Type", "application/json")
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.CustomerReturn
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, ret.ID, response.Id)
+			assert.Equal(t, shipmentID, response.ShipmentId)
+			assert.Equal(t, httpdto.WrongItem, response.Reason)
+			require.NotNil(t, response.ProductId)
+			assert.Equal(t, productID, *response.ProductId)
+		})
+	}
+}
+
+func TestReturnHandler_HandleListReturns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReturnService := service_mocks.NewMockReturnService(ctrl)
+	logger := zap.NewNop()
+
+	pvzID := uuid.New()
+
+	report := models.NewReturnsReport(pvzID, []*models.CustomerReturn{{
+		ID:         uuid.New(),
+		PVZID:      pvzID,
+		Barcode:    "4600000000017",
+		Type:       models.ProductTypeShoes,
+		Reason:     models.ReturnReasonDoesNotFit,
+		Condition:  models.ReturnConditionUsed,
+		ShipmentID: uuid.New(),
+		CreatedBy:  uuid.New(),
+		DateTime:   time.Now().UTC(),
+	}})
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		query        string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:  "successful listing",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReturnService.EXPECT().ListReturns(gomock.Any(), string(models.RoleModerator), pvzID, nil, nil).Return(report, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:         "invalid query",
+			pvzID:        pvzID.String(),
+			query:        "?startDate=yesterday",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:  "pvz not found",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReturnService.EXPECT().ListReturns(gomock.Any(), string(models.RoleModerator), pvzID, nil, nil).Return(nil, domainerrors.ErrPVZNotFound)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "pvz_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReturnHandler(logger, mockReturnService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/pvz/:pvzId/returns", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleModerator))
+				handler.HandleListReturns(c)
+			})
+
+			req, _ := http.NewRequest("GET", "/pvz/"+tt.pvzID+"/returns"+tt.query, nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.ReturnsReport
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, 1, response.Total)
+			require.Len(t, response.Returns, 1)
+			require.NotNil(t, response.Returns[0].Barcode)
+			assert.Equal(t, "4600000000017", *response.Returns[0].Barcode)
+			assert.Nil(t, response.Returns[0].ProductId)
+
+			// В отчет попадают все причины, в том числе без возвратов
+			require.Len(t, response.ByReason, len(models.AllReturnReasons()))
+
+			for _, count := range response.ByReason {
+				if count.Reason == httpdto.DoesNotFit {
+					assert.Equal(t, 1, count.Count)
+				} else {
+					assert.Zero(t, count.Count)
+				}
+			}
+		})
+	}
+}
+
+func TestReturnHandler_HandleDispatchReturns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockReturnService := service_mocks.NewMockReturnService(ctrl)
+	logger := zap.NewNop()
+
+	pvzID := uuid.New()
+	shipmentID := uuid.New()
+	dispatchedAt := time.Now().UTC()
+
+	shipment := &models.Shipment{
+		ID:           shipmentID,
+		PVZID:        pvzID,
+		Kind:         models.ShipmentKindCustomerReturns,
+		CreatedBy:    uuid.New(),
+		DateTime:     dispatchedAt.Add(-time.Hour),
+		DispatchedAt: &dispatchedAt,
+		Returns: []*models.CustomerReturn{{
+			ID:         uuid.New(),
+			PVZID:      pvzID,
+			Barcode:    "4600000000017",
+			Type:       models.ProductTypeShoes,
+			Reason:     models.ReturnReasonDefective,
+			Condition:  models.ReturnConditionDamaged,
+			ShipmentID: shipmentID,
+			CreatedBy:  uuid.New(),
+			DateTime:   dispatchedAt.Add(-time.Hour),
+		}},
+	}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:  "successful dispatch",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReturnService.EXPECT().DispatchReturns(gomock.Any(), string(models.RoleEmployee), pvzID).Return(shipment, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:  "no pending returns",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReturnService.EXPECT().DispatchReturns(gomock.Any(), string(models.RoleEmployee), pvzID).Return(nil, domainerrors.ErrNoPendingReturns)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "no_pending_returns",
+		},
+		{
+			name:  "not enough rights",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockReturnService.EXPECT().DispatchReturns(gomock.Any(), string(models.RoleEmployee), pvzID).Return(nil, domainerrors.ErrNotEnoughRights)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedErr:  "not_enough_rights",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewReturnHandler(logger, mockReturnService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/pvz/:pvzId/returns/dispatch", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleEmployee))
+				handler.HandleDispatchReturns(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/returns/dispatch", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.Shipment
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, shipmentID, response.Id)
+			assert.Equal(t, httpdto.CustomerReturns, response.Kind)
+			require.NotNil(t, response.DispatchedAt)
+			assert.True(t, dispatchedAt.Equal(*response.DispatchedAt))
+			require.NotNil(t, response.Returns)
+			require.Len(t, *response.Returns, 1)
+			assert.Equal(t, shipmentID, (*response.Returns)[0].ShipmentId)
+		})
+	}
+}
@@ -0,0 +1,56 @@
+package httphandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type StatsHandler struct {
+	logger       *zap.Logger
+	statsService service.StatsService
+}
+
+func NewStatsHandler(logger *zap.Logger, statsService service.StatsService) *StatsHandler {
+	return &StatsHandler{
+		logger:       logger,
+		statsService: statsService,
+	}
+}
+
+func (h *StatsHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/stats", h.HandleGetStats)
+}
+
+func (h *StatsHandler) HandleGetStats(c *gin.Context) {
+	userRole, ok := auth.GetRoleFromContext(c)
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("no role in context handling get stats")
+		commonerrors.Unauthorized(c)
+
+		return
+	}
+
+	var query httpdto.GetStatsParams
+
+	if err := c.ShouldBindQuery(&query); err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("BindQuery error handling get stats", zap.Error(err))
+		commonerrors.BadRequest(c, "invalid query parameters")
+
+		return
+	}
+
+	stats, err := h.statsService.GetStats(c.Request.Context(), userRole, query.StartDate, query.EndDate)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	respondJSONWithETag(c, http.StatusOK, httpdto.ModelToStatsResponse(stats))
+}
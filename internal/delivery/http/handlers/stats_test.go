@@ -0,0 +1,124 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"go.uber.org/mock/gomock"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+)
+
+func TestStatsHandler_HandleGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStatsService := service_mocks.NewMockStatsService(ctrl)
+	logger := zap.NewNop()
+
+	now := time.Now()
+
+	validQuery := map[string]string{
+		"startDate": now.Add(-24 * time.Hour).Format(time.RFC3339),
+		"endDate":   now.Format(time.RFC3339),
+	}
+
+	tests := []struct {
+		name         string
+		queryParams  map[string]string
+		role         models.RoleType
+		mockSetup    func()
+		expectedCode int
+	}{
+		{
+			name:        "Successful stats",
+			queryParams: validQuery,
+			role:        models.RoleModerator,
+			mockSetup: func() {
+				mockStatsService.EXPECT().
+					GetStats(gomock.Any(), models.RoleModerator.String(), gomock.Any(), gomock.Any()).
+					Return(&models.Stats{
+						PVZs: []*models.PVZStats{{PVZID: uuid.New(), City: models.CityTypeMoscow, ReceptionsCount: 1}},
+						ProductTypes: []*models.ProductTypeStats{
+							{City: models.CityTypeMoscow, Day: now, Type: models.ProductTypeElectronics, Count: 1},
+						},
+					}, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name: "Invalid date",
+			queryParams: map[string]string{
+				"startDate": "invalid_date",
+			},
+			role:         models.RoleModerator,
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Invalid date range",
+			queryParams: validQuery,
+			role:        models.RoleModerator,
+			mockSetup: func() {
+				mockStatsService.EXPECT().
+					GetStats(gomock.Any(), models.RoleModerator.String(), gomock.Any(), gomock.Any()).
+					Return(nil, domainerrors.ErrInvalidDateRange)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:        "Not enough rights",
+			queryParams: validQuery,
+			role:        models.RoleEmployee,
+			mockSetup: func() {
+				mockStatsService.EXPECT().
+					GetStats(gomock.Any(), models.RoleEmployee.String(), gomock.Any(), gomock.Any()).
+					Return(nil, domainerrors.ErrNotEnoughRights)
+			},
+			expectedCode: http.StatusForbidden,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewStatsHandler(logger, mockStatsService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+
+			router.GET("/stats", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(tt.role))
+				handler.HandleGetStats(c)
+			})
+
+			req, _ := http.NewRequest("GET", "/stats", nil)
+
+			q := req.URL.Query()
+			for k, v := range tt.queryParams {
+				q.Add(k, v)
+			}
+
+			req.URL.RawQuery = q.Encode()
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+		})
+	}
+}
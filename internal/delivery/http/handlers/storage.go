@@ -0,0 +1,101 @@
+package httphandlers
+
+import (
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+	"net/http"
+)
+
+type StorageHandler struct {
+	logger         *zap.Logger
+	storageService service.StorageService
+}
+
+func NewStorageHandler(logger *zap.Logger, storageService service.StorageService) *StorageHandler {
+	return &StorageHandler{
+		logger:         logger,
+		storageService: storageService,
+	}
+}
+
+func (h *StorageHandler) RegisterRoutes(group *gin.RouterGroup) {
+	group.GET("/pvz/:pvzId/storage/expiring", h.HandleListExpiring)
+	group.POST("/pvz/:pvzId/storage/return_to_sender", h.HandleReturnToSender)
+}
+
+func (h *StorageHandler) HandleListExpiring(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	expiring, err := h.storageService.ListExpiring(c.Request.Context(), role, pvzUUID)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, httpdto.ModelToExpiringProductsResponse(expiring))
+}
+
+func (h *StorageHandler) HandleReturnToSender(c *gin.Context) {
+	role, ok := auth.GetRoleFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get role from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	userID, ok := auth.GetUserIDFromContext(c)
+
+	if !ok {
+		l.FromContext(c.Request.Context(), h.logger).Error("Failed to get user id from context")
+		commonerrors.Forbidden(c)
+
+		return
+	}
+
+	pvzID := c.Param("pvzId")
+
+	pvzUUID, err := uuid.Parse(pvzID)
+
+	if err != nil {
+		l.FromContext(c.Request.Context(), h.logger).Debug("invalid pvzID", zap.String("pvzID", pvzID))
+		commonerrors.BadRequest(c, "invalid pvzID")
+
+		return
+	}
+
+	shipment, err := h.storageService.ReturnToSender(c.Request.Context(), userID, role, pvzUUID)
+
+	if err != nil {
+		handleDomainError(c, h.logger, err)
+		return
+	}
+
+	c.JSON(http.StatusCreated, httpdto.ModelToShipmentResponse(shipment))
+}
@@ -0,0 +1,229 @@
+//go:build unit
+// +build unit
+
+package httphandlers_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/httpdto"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestStorageHandler_HandleListExpiring(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorageService := service_mocks.NewMockStorageService(ctrl)
+	logger := zap.NewNop()
+
+	pvzID := uuid.New()
+	productID := uuid.New()
+	expiresAt := time.Now().Add(-time.Hour).UTC()
+
+	expiring := []*models.ExpiringProduct{{
+		Product: &models.Product{
+			ID:          productID,
+			Type:        models.ProductTypeClothes,
+			ReceptionID: uuid.New(),
+			Status:      models.ProductStatusStored,
+		},
+		ExpiresAt: expiresAt,
+		Expired:   true,
+	}}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:  "successful listing",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockStorageService.EXPECT().ListExpiring(gomock.Any(), string(models.RoleModerator), pvzID).Return(expiring, nil)
+			},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:  "pvz not found",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockStorageService.EXPECT().ListExpiring(gomock.Any(), string(models.RoleModerator), pvzID).Return(nil, domainerrors.ErrPVZNotFound)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "pvz_not_found",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewStorageHandler(logger, mockStorageService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.GET("/pvz/:pvzId/storage/expiring", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleModerator))
+				handler.HandleListExpiring(c)
+			})
+
+			req, _ := http.NewRequest("GET", "/pvz/"+tt.pvzID+"/storage/expiring", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response []httpdto.ExpiringProduct
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			require.Len(t, response, 1)
+			assert.Equal(t, productID, *response[0].Product.Id)
+			assert.True(t, expiresAt.Equal(response[0].ExpiresAt))
+			assert.True(t, response[0].Expired)
+		})
+	}
+}
+
+func TestStorageHandler_HandleReturnToSender(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockStorageService := service_mocks.NewMockStorageService(ctrl)
+	logger := zap.NewNop()
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	shipmentID := uuid.New()
+	productID := uuid.New()
+	returnedAt := time.Now().UTC()
+
+	shipment := &models.Shipment{
+		ID:        shipmentID,
+		PVZID:     pvzID,
+		Kind:      models.ShipmentKindReturnToSender,
+		CreatedBy: userID,
+		DateTime:  returnedAt,
+		Products: []*models.Product{{
+			ID:          productID,
+			Type:        models.ProductTypeShoes,
+			ReceptionID: uuid.New(),
+			Status:      models.ProductStatusReturnedToSender,
+			ShipmentID:  &shipmentID,
+			ReturnedAt:  &returnedAt,
+		}},
+	}
+
+	tests := []struct {
+		name         string
+		pvzID        string
+		mockSetup    func()
+		expectedCode int
+		expectedErr  string
+	}{
+		{
+			name:  "successful return",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockStorageService.EXPECT().ReturnToSender(gomock.Any(), userID, string(models.RoleEmployee), pvzID).Return(shipment, nil)
+			},
+			expectedCode: http.StatusCreated,
+		},
+		{
+			name:         "invalid pvzID format",
+			pvzID:        "invalid-uuid",
+			mockSetup:    func() {},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "invalid_request",
+		},
+		{
+			name:  "no expired products",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockStorageService.EXPECT().ReturnToSender(gomock.Any(), userID, string(models.RoleEmployee), pvzID).Return(nil, domainerrors.ErrNoExpiredProducts)
+			},
+			expectedCode: http.StatusBadRequest,
+			expectedErr:  "no_expired_products",
+		},
+		{
+			name:  "not enough rights",
+			pvzID: pvzID.String(),
+			mockSetup: func() {
+				mockStorageService.EXPECT().ReturnToSender(gomock.Any(), userID, string(models.RoleEmployee), pvzID).Return(nil, domainerrors.ErrNotEnoughRights)
+			},
+			expectedCode: http.StatusForbidden,
+			expectedErr:  "not_enough_rights",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handler := httphandlers.NewStorageHandler(logger, mockStorageService)
+
+			gin.SetMode(gin.TestMode)
+			router := gin.New()
+			router.POST("/pvz/:pvzId/storage/return_to_sender", func(c *gin.Context) {
+				c.Set(auth.RoleKey, string(models.RoleEmployee))
+				c.Set(auth.UserIDKey, userID)
+				handler.HandleReturnToSender(c)
+			})
+
+			req, _ := http.NewRequest("POST", "/pvz/"+tt.pvzID+"/storage/return_to_sender", nil)
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+
+			if tt.expectedErr != "" {
+				var problem httpdto.Error
+				require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &problem))
+				assert.Equal(t, tt.expectedErr, problem.Code)
+
+				return
+			}
+
+			var response httpdto.Shipment
+			require.NoError(t, json.Unmarshal(resp.Body.Bytes(), &response))
+			assert.Equal(t, shipmentID, response.Id)
+			assert.Equal(t, httpdto.ReturnToSender, response.Kind)
+			require.Len(t, response.Products, 1)
+			require.NotNil(t, response.Products[0].ShipmentId)
+			assert.Equal(t, shipmentID, *response.Products[0].ShipmentId)
+		})
+	}
+}
@@ -7,4 +7,5 @@ type ReceptionWithProductsResponse struct {
 type PVZWithReceptionsResponse struct {
 	PVZ        *PVZ                             `json:"pvz"`
 	Receptions []*ReceptionWithProductsResponse `json:"receptions"`
+	Returns    []*CustomerReturn                `json:"returns"`
 }
@@ -1,34 +1,343 @@
 package httpdto
 
 import (
+	"github.com/google/uuid"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/oapi-codegen/runtime/types"
 )
 
 func ToPVZResponse(pvz *models.PVZ) *PVZ {
-	return &PVZ{
+	response := &PVZ{
 		City:             PVZCity(pvz.City),
 		Id:               &pvz.ID,
 		RegistrationDate: &pvz.RegistrationDate,
 	}
+
+	if pvz.Version != models.AnyVersion {
+		response.Version = &pvz.Version
+	}
+
+	return response
 }
 
 func ModelToReceptionResponse(reception *models.Reception) *Reception {
-	return &Reception{
-		DateTime: reception.DateTime,
-		Id:       &reception.ID,
-		PvzId:    reception.PVZID,
-		Status:   ReceptionStatus(reception.Status),
+	response := &Reception{
+		DateTime:   reception.DateTime,
+		Id:         &reception.ID,
+		PvzId:      reception.PVZID,
+		Status:     ReceptionStatus(reception.Status),
+		ClosedAt:   reception.ClosedAt,
+		ClosedBy:   reception.ClosedBy,
+		ReopenedAt: reception.ReopenedAt,
+		ReopenedBy: reception.ReopenedBy,
+	}
+
+	// У приемок, созданных до появления opened_by, автор неизвестен
+	if reception.OpenedBy != uuid.Nil {
+		response.OpenedBy = &reception.OpenedBy
+	}
+
+	if reception.AutoClosed {
+		response.AutoClosed = &reception.AutoClosed
+	}
+
+	if reception.CloseReason != "" {
+		response.CloseReason = &reception.CloseReason
+	}
+
+	if reception.Version != models.AnyVersion {
+		response.Version = &reception.Version
+	}
+
+	if reception.Discrepancy != nil {
+		response.DiscrepancyReport = ModelToDiscrepancyReportResponse(reception.Discrepancy)
+	}
+
+	if reception.PickupCodes != nil {
+		codes := make([]PickupCode, 0, len(reception.PickupCodes))
+
+		for _, code := range reception.PickupCodes {
+			codes = append(codes, *ModelToPickupCodeResponse(code))
+		}
+
+		response.PickupCodes = &codes
+	}
+
+	return response
+}
+
+func ModelToDiscrepancyReportResponse(report *models.DiscrepancyReport) *DiscrepancyReport {
+	items := make([]DiscrepancyItem, 0, len(report.Items))
+
+	for _, item := range report.Items {
+		items = append(items, DiscrepancyItem{
+			Type:     DiscrepancyItemType(item.Type),
+			Kind:     DiscrepancyItemKind(item.Kind),
+			Expected: item.Expected,
+			Actual:   item.Actual,
+		})
+	}
+
+	return &DiscrepancyReport{
+		CreatedAt: report.CreatedAt,
+		Items:     items,
+	}
+}
+
+func ManifestItemsToModel(items []ManifestItem) []models.ManifestItem {
+	result := make([]models.ManifestItem, 0, len(items))
+
+	for _, item := range items {
+		result = append(result, models.ManifestItem{
+			Type:  models.ProductType(item.Type),
+			Count: item.Count,
+		})
+	}
+
+	return result
+}
+
+func ModelToManifestResponse(manifest *models.Manifest) *Manifest {
+	items := make([]ManifestItem, 0, len(manifest.Items))
+
+	for _, item := range manifest.Items {
+		items = append(items, ManifestItem{
+			Type:  ManifestItemType(item.Type),
+			Count: item.Count,
+		})
+	}
+
+	return &Manifest{
+		Id:          manifest.ID,
+		PvzId:       manifest.PVZID,
+		ReceptionId: manifest.ReceptionID,
+		CreatedBy:   &manifest.CreatedBy,
+		CreatedAt:   manifest.CreatedAt,
+		Items:       items,
 	}
 }
 
 func ModelToProductResponse(product *models.Product) *Product {
-	return &Product{
+	response := &Product{
 		DateTime:    &product.DateTime,
 		Id:          &product.ID,
 		ReceptionId: product.ReceptionID,
 		Type:        ProductType(product.Type),
+		IssuanceId:  product.IssuanceID,
+		IssuedAt:    product.IssuedAt,
+		ShipmentId:  product.ShipmentID,
+		ReturnedAt:  product.ReturnedAt,
+	}
+
+	if product.OrderID != uuid.Nil {
+		response.OrderId = &product.OrderID
+	}
+
+	if product.Status != "" {
+		status := ProductStatus(product.Status)
+		response.Status = &status
+	}
+
+	return response
+}
+
+func ModelToIssuanceResponse(issuance *models.Issuance) *Issuance {
+	products := make([]Product, 0, len(issuance.Products))
+
+	for _, product := range issuance.Products {
+		products = append(products, *ModelToProductResponse(product))
+	}
+
+	return &Issuance{
+		Id:       issuance.ID,
+		PvzId:    issuance.PVZID,
+		IssuedBy: issuance.IssuedBy,
+		DateTime: issuance.DateTime,
+		Products: products,
+	}
+}
+
+func ModelToShipmentResponse(shipment *models.Shipment) *Shipment {
+	products := make([]Product, 0, len(shipment.Products))
+
+	for _, product := range shipment.Products {
+		products = append(products, *ModelToProductResponse(product))
+	}
+
+	response := &Shipment{
+		Id:           shipment.ID,
+		PvzId:        shipment.PVZID,
+		Kind:         ShipmentKind(shipment.Kind),
+		CreatedBy:    shipment.CreatedBy,
+		DateTime:     shipment.DateTime,
+		DispatchedAt: shipment.DispatchedAt,
+		Products:     products,
+	}
+
+	if shipment.Kind == models.ShipmentKindCustomerReturns {
+		returns := ModelToCustomerReturnsResponse(shipment.Returns)
+		response.Returns = &returns
+	}
+
+	return response
+}
+
+func ModelToCustomerReturnResponse(ret *models.CustomerReturn) *CustomerReturn {
+	response := &CustomerReturn{
+		Id:         ret.ID,
+		PvzId:      ret.PVZID,
+		ProductId:  ret.ProductID,
+		Type:       CustomerReturnType(ret.Type),
+		Reason:     ReturnReason(ret.Reason),
+		Condition:  ReturnCondition(ret.Condition),
+		ShipmentId: ret.ShipmentID,
+		CreatedBy:  ret.CreatedBy,
+		DateTime:   ret.DateTime,
+	}
+
+	if ret.Barcode != "" {
+		response.Barcode = &ret.Barcode
+	}
+
+	return response
+}
+
+func ModelToCustomerReturnsResponse(returns []*models.CustomerReturn) []CustomerReturn {
+	response := make([]CustomerReturn, 0, len(returns))
+
+	for _, ret := range returns {
+		response = append(response, *ModelToCustomerReturnResponse(ret))
+	}
+
+	return response
+}
+
+// ModelToReturnsReportResponse преобразует отчет по возвратам в DTO.
+// Причины и состояния перечисляются в фиксированном порядке (см. models.AllReturnReasons и models.AllReturnConditions).
+func ModelToReturnsReportResponse(report *models.ReturnsReport) *ReturnsReport {
+	response := &ReturnsReport{
+		PvzId:       report.PVZID,
+		Total:       len(report.Returns),
+		ByReason:    make([]ReturnReasonCount, 0, len(report.ByReason)),
+		ByCondition: make([]ReturnConditionCount, 0, len(report.ByCondition)),
+		Returns:     ModelToCustomerReturnsResponse(report.Returns),
+	}
+
+	for _, reason := range models.AllReturnReasons() {
+		response.ByReason = append(response.ByReason, ReturnReasonCount{
+			Reason: ReturnReason(reason),
+			Count:  report.ByReason[reason],
+		})
+	}
+
+	for _, condition := range models.AllReturnConditions() {
+		response.ByCondition = append(response.ByCondition, ReturnConditionCount{
+			Condition: ReturnCondition(condition),
+			Count:     report.ByCondition[condition],
+		})
+	}
+
+	return response
+}
+
+func ModelToExpiringProductsResponse(expiring []*models.ExpiringProduct) []ExpiringProduct {
+	response := make([]ExpiringProduct, 0, len(expiring))
+
+	for _, item := range expiring {
+		response = append(response, ExpiringProduct{
+			Product:   *ModelToProductResponse(item.Product),
+			ExpiresAt: item.ExpiresAt,
+			Expired:   item.Expired,
+		})
+	}
+
+	return response
+}
+
+func ModelToPickupCodeResponse(code *models.PickupCode) *PickupCode {
+	return &PickupCode{
+		OrderId:   code.OrderID,
+		PvzId:     code.PVZID,
+		Code:      code.Code,
+		ExpiresAt: code.ExpiresAt,
+	}
+}
+
+func ModelToPickupOrderResponse(order *models.PickupOrder) *PickupOrder {
+	products := make([]Product, 0, len(order.Products))
+
+	for _, product := range order.Products {
+		products = append(products, *ModelToProductResponse(product))
+	}
+
+	return &PickupOrder{
+		OrderId:  order.OrderID,
+		PvzId:    order.PVZID,
+		Products: products,
+	}
+}
+
+func BatchItemsToModel(items []ProductsBatchItem) []*models.AddProduct {
+	result := make([]*models.AddProduct, 0, len(items))
+
+	for _, item := range items {
+		product := &models.AddProduct{
+			Type: models.ProductType(item.Type),
+		}
+
+		if item.Id != nil {
+			product.ID = *item.Id
+		}
+
+		if item.DateTime != nil {
+			product.DateTime = *item.DateTime
+		}
+
+		if item.OrderId != nil {
+			product.OrderID = *item.OrderId
+		}
+
+		result = append(result, product)
 	}
+
+	return result
+}
+
+// ModelToProductsBatchResponse преобразует результат пакетного добавления товаров в DTO.
+// errorCode возвращает стабильный код ошибки товара (см. commonerrors.DomainCode).
+func ModelToProductsBatchResponse(result *models.AddProductsBatchResult, errorCode func(error) string) *ProductsBatchResult {
+	items := make([]ProductsBatchItemResult, 0, len(result.Items))
+
+	for _, item := range result.Items {
+		response := ProductsBatchItemResult{
+			Index:  item.Index,
+			Status: ProductsBatchItemResultStatus(item.Status),
+		}
+
+		if item.Product != nil {
+			response.Product = ModelToProductResponse(item.Product)
+		}
+
+		if item.Err != nil {
+			message := item.Err.Error()
+			code := errorCode(item.Err)
+			response.Error = &message
+			response.ErrorCode = &code
+		}
+
+		items = append(items, response)
+	}
+
+	response := &ProductsBatchResult{
+		Applied: result.Applied,
+		Items:   items,
+	}
+
+	if result.ReceptionID != uuid.Nil {
+		response.ReceptionId = &result.ReceptionID
+	}
+
+	return response
 }
 
 func ModelToReceptionWithProductsResponse(reception *models.ReceptionWithProducts) *ReceptionWithProductsResponse {
@@ -39,13 +348,8 @@ func ModelToReceptionWithProductsResponse(reception *models.ReceptionWithProduct
 	}
 
 	return &ReceptionWithProductsResponse{
-		Reception: &Reception{
-			DateTime: reception.Reception.DateTime,
-			Id:       &reception.Reception.ID,
-			PvzId:    reception.Reception.PVZID,
-			Status:   ReceptionStatus(reception.Reception.Status),
-		},
-		Products: products,
+		Reception: ModelToReceptionResponse(reception.Reception),
+		Products:  products,
 	}
 }
 
@@ -56,13 +360,16 @@ func ModelToPVZWithReceptionsResponse(pvz *models.PVZWithReceptions) *PVZWithRec
 		receptions = append(receptions, ModelToReceptionWithProductsResponse(reception))
 	}
 
+	returns := make([]*CustomerReturn, 0, len(pvz.Returns))
+
+	for _, ret := range pvz.Returns {
+		returns = append(returns, ModelToCustomerReturnResponse(ret))
+	}
+
 	return &PVZWithReceptionsResponse{
-		PVZ: &PVZ{
-			City:             PVZCity(pvz.PVZ.City),
-			Id:               &pvz.PVZ.ID,
-			RegistrationDate: &pvz.PVZ.RegistrationDate,
-		},
+		PVZ:        ToPVZResponse(pvz.PVZ),
 		Receptions: receptions,
+		Returns:    returns,
 	}
 }
 
@@ -73,3 +380,35 @@ func ModelToUserResponse(user *models.User) *User {
 		Role:  UserRole(user.Role),
 	}
 }
+
+func ModelToStatsResponse(stats *models.Stats) *Stats {
+	pvzs := make([]PVZStats, 0, len(stats.PVZs))
+
+	for _, pvzStats := range stats.PVZs {
+		pvzs = append(pvzs, PVZStats{
+			PvzId:                       pvzStats.PVZID,
+			City:                        PVZStatsCity(pvzStats.City),
+			ReceptionsCount:             pvzStats.ReceptionsCount,
+			ClosedReceptionsCount:       pvzStats.ClosedReceptionsCount,
+			ProductsCount:               pvzStats.ProductsCount,
+			AvgReceptionDurationSeconds: pvzStats.AvgReceptionDuration.Seconds(),
+			AvgProductsPerReception:     pvzStats.AvgProductsPerReception,
+		})
+	}
+
+	productTypes := make([]ProductTypeStats, 0, len(stats.ProductTypes))
+
+	for _, productTypeStats := range stats.ProductTypes {
+		productTypes = append(productTypes, ProductTypeStats{
+			City:  ProductTypeStatsCity(productTypeStats.City),
+			Day:   types.Date{Time: productTypeStats.Day},
+			Type:  ProductTypeStatsType(productTypeStats.Type),
+			Count: productTypeStats.Count,
+		})
+	}
+
+	return &Stats{
+		Pvzs:         pvzs,
+		ProductTypes: productTypes,
+	}
+}
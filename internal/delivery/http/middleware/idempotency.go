@@ -0,0 +1,131 @@
+// Пакет httpmiddleware содержит мидлвари HTTP сервера, которым нужны сервисы приложения.
+package httpmiddleware
+
+import (
+	"bytes"
+	"context"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"io"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"go.uber.org/zap"
+)
+
+const (
+	IdempotencyKeyHeader     = "Idempotency-Key"     // Заголовок с ключом идемпотентности
+	IdempotentReplayedHeader = "Idempotent-Replayed" // Заголовок, которым помечается повторно отданный ответ
+)
+
+// replayedHeaders - заголовки ответа, которые сохраняются и отдаются при повторе вместе с телом.
+// Служебные заголовки (айди запроса и трассировки) не повторяются: они относятся к конкретному запросу.
+var replayedHeaders = []string{"ETag", "Location", "Retry-After"}
+
+// responseRecorder копирует тело ответа, чтобы его можно было сохранить.
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// NewIdempotencyMiddleware возвращает мидлварь для GIN, обрабатывающую заголовок Idempotency-Key в POST запросах.
+// Должен устанавливаться после мидлвари авторизации, так как ключи хранятся отдельно для каждого пользователя.
+// Публичные /dummyLogin, /register и /login намеренно не обрабатываются: сохранять для повтора ответы с токенами нельзя,
+// а повторная регистрация отклоняется сама.
+// Первый ответ на запрос с ключом сохраняется вместе с заголовками из replayedHeaders и отдается при повторе того же запроса.
+// Ответы с кодом 5xx не сохраняются, чтобы запрос можно было повторить.
+// Может принимать логгер и сервис идемпотентности.
+func NewIdempotencyMiddleware(logger *zap.Logger, idempotencyService service.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader(IdempotencyKeyHeader)
+		if c.Request.Method != http.MethodPost || key == "" {
+			c.Next()
+			return
+		}
+
+		userID, ok := auth.GetUserIDFromContext(c)
+		if !ok {
+			c.Next()
+			return
+		}
+
+		log := l.FromContext(c.Request.Context(), logger)
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			log.Debug("failed to read request body", zap.Error(err))
+			commonerrors.BadRequest(c, "invalid request body")
+
+			return
+		}
+
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		record, err := idempotencyService.Begin(c.Request.Context(), userID, key, c.Request.Method, c.Request.URL.Path, body)
+		if err != nil {
+			handleIdempotencyError(c, log, err)
+			return
+		}
+
+		if record != nil {
+			log.Debug("replaying idempotent response", zap.String("key", key))
+			c.Header(IdempotentReplayedHeader, "true")
+
+			for name, value := range record.Headers {
+				c.Header(name, value)
+			}
+
+			c.Data(record.StatusCode, record.ContentType, record.ResponseBody)
+			c.Abort()
+
+			return
+		}
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+
+		c.Next()
+
+		// Ответ сохраняется, даже если клиент уже отключился - именно в этом случае он повторит запрос
+		ctx := context.WithoutCancel(c.Request.Context())
+		status := recorder.Status()
+
+		if status >= http.StatusInternalServerError {
+			if err := idempotencyService.Release(ctx, userID, key); err != nil {
+				log.Error("failed to release idempotency key", zap.String("key", key), zap.Error(err))
+			}
+
+			return
+		}
+
+		headers := make(map[string]string)
+
+		for _, name := range replayedHeaders {
+			if value := recorder.Header().Get(name); value != "" {
+				headers[name] = value
+			}
+		}
+
+		if err := idempotencyService.Complete(ctx, userID, key, status, recorder.Header().Get("Content-Type"), headers, recorder.body.Bytes()); err != nil {
+			log.Error("failed to save idempotent response", zap.String("key", key), zap.Error(err))
+		}
+	}
+}
+
+func handleIdempotencyError(c *gin.Context, logger *zap.Logger, err error) {
+	if !commonerrors.AbortWithDomainError(c, err) {
+		logger.Error("unexpected error", zap.Error(err))
+	}
+}
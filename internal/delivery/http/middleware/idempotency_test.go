@@ -0,0 +1,203 @@
+//go:build unit
+// +build unit
+
+package httpmiddleware_test
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	httpmiddleware "github.com/maksemen2/pvz-service/internal/delivery/http/middleware"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	service_mocks "github.com/maksemen2/pvz-service/internal/service/mocks"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestIdempotencyMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockService := service_mocks.NewMockIdempotencyService(ctrl)
+	logger := zap.NewNop()
+
+	userID := uuid.New()
+	body := `{"type":"обувь"}`
+	handlerStatus := http.StatusCreated
+
+	router := gin.New()
+	router.Use(func(c *gin.Context) {
+		c.Set(auth.UserIDKey, userID)
+	}, httpmiddleware.NewIdempotencyMiddleware(logger, mockService))
+
+	handlerCalls := 0
+	handler := func(c *gin.Context) {
+		handlerCalls++
+
+		// Хендлер должен получить исходное тело запроса
+		received, _ := io.ReadAll(c.Request.Body)
+		assert.Equal(t, body, string(received))
+
+		c.Header("ETag", `"1"`)
+		c.Header("Location", "/products/1")
+		c.JSON(handlerStatus, gin.H{"id": "1"})
+	}
+
+	router.POST("/products", handler)
+	router.GET("/products", handler)
+
+	tests := []struct {
+		name            string
+		method          string
+		key             string
+		mockSetup       func()
+		expectedCode    int
+		expectedBody    string
+		expectedReplay  bool
+		expectedHandled bool
+	}{
+		{
+			name:   "First request stored",
+			method: http.MethodPost,
+			key:    "key",
+			mockSetup: func() {
+				mockService.EXPECT().
+					Begin(gomock.Any(), userID, "key", http.MethodPost, "/products", []byte(body)).
+					Return(nil, nil)
+				mockService.EXPECT().
+					Complete(gomock.Any(), userID, "key", http.StatusCreated, "application/json; charset=utf-8", map[string]string{"ETag": `"1"`, "Location": "/products/1"}, []byte(`{"id":"1"}`)).
+					Return(nil)
+			},
+			expectedCode:    http.StatusCreated,
+			expectedBody:    `{"id":"1"}`,
+			expectedHandled: true,
+		},
+		{
+			name:   "Retry replayed",
+			method: http.MethodPost,
+			key:    "key",
+			mockSetup: func() {
+				mockService.EXPECT().
+					Begin(gomock.Any(), userID, "key", http.MethodPost, "/products", []byte(body)).
+					Return(&models.IdempotencyRecord{
+						StatusCode:   http.StatusCreated,
+						ContentType:  "application/json",
+						Headers:      map[string]string{"ETag": `"1"`, "Location": "/products/1"},
+						ResponseBody: []byte(`{"id":"1"}`),
+					}, nil)
+			},
+			expectedCode:   http.StatusCreated,
+			expectedBody:   `{"id":"1"}`,
+			expectedReplay: true,
+		},
+		{
+			name:   "Key reused with different body",
+			method: http.MethodPost,
+			key:    "key",
+			mockSetup: func() {
+				mockService.EXPECT().
+					Begin(gomock.Any(), userID, "key", http.MethodPost, "/products", gomock.Any()).
+					Return(nil, domainerrors.ErrIdempotencyKeyReused)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:   "Request in progress",
+			method: http.MethodPost,
+			key:    "key",
+			mockSetup: func() {
+				mockService.EXPECT().
+					Begin(gomock.Any(), userID, "key", http.MethodPost, "/products", gomock.Any()).
+					Return(nil, domainerrors.ErrIdempotencyRequestInProgress)
+			},
+			expectedCode: http.StatusConflict,
+		},
+		{
+			name:   "Invalid key",
+			method: http.MethodPost,
+			key:    "key",
+			mockSetup: func() {
+				mockService.EXPECT().
+					Begin(gomock.Any(), userID, "key", http.MethodPost, "/products", gomock.Any()).
+					Return(nil, domainerrors.ErrInvalidIdempotencyKey)
+			},
+			expectedCode: http.StatusBadRequest,
+		},
+		{
+			name:            "Request without key",
+			method:          http.MethodPost,
+			mockSetup:       func() {},
+			expectedCode:    http.StatusCreated,
+			expectedHandled: true,
+		},
+		{
+			name:            "GET request ignored",
+			method:          http.MethodGet,
+			key:             "key",
+			mockSetup:       func() {},
+			expectedCode:    http.StatusCreated,
+			expectedHandled: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tt.mockSetup()
+
+			handlerCalls = 0
+
+			req, _ := http.NewRequest(tt.method, "/products", bytes.NewBufferString(body))
+			if tt.key != "" {
+				req.Header.Set(httpmiddleware.IdempotencyKeyHeader, tt.key)
+			}
+
+			resp := httptest.NewRecorder()
+
+			router.ServeHTTP(resp, req)
+
+			assert.Equal(t, tt.expectedCode, resp.Code)
+			assert.Equal(t, tt.expectedHandled, handlerCalls == 1)
+
+			if tt.expectedBody != "" {
+				assert.Equal(t, tt.expectedBody, resp.Body.String())
+			}
+
+			if tt.expectedReplay {
+				assert.Equal(t, "true", resp.Header().Get(httpmiddleware.IdempotentReplayedHeader))
+				// Заголовки повторяются вместе с телом
+				assert.Equal(t, `"1"`, resp.Header().Get("ETag"))
+				assert.Equal(t, "/products/1", resp.Header().Get("Location"))
+			}
+		})
+	}
+
+	t.Run("Server error releases key", func(t *testing.T) {
+		handlerStatus = http.StatusInternalServerError
+
+		mockService.EXPECT().
+			Begin(gomock.Any(), userID, "key", http.MethodPost, "/products", gomock.Any()).
+			Return(nil, nil)
+		mockService.EXPECT().
+			Release(gomock.Any(), userID, "key").
+			Return(nil)
+
+		req, _ := http.NewRequest(http.MethodPost, "/products", bytes.NewBufferString(body))
+		req.Header.Set(httpmiddleware.IdempotencyKeyHeader, "key")
+
+		resp := httptest.NewRecorder()
+
+		router.ServeHTTP(resp, req)
+
+		assert.Equal(t, http.StatusInternalServerError, resp.Code)
+	})
+}
@@ -4,24 +4,36 @@ import (
 	"github.com/gin-gonic/gin"
 	"github.com/maksemen2/pvz-service/config"
 	httphandlers "github.com/maksemen2/pvz-service/internal/delivery/http/handlers"
+	httpmiddleware "github.com/maksemen2/pvz-service/internal/delivery/http/middleware"
 	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	"github.com/maksemen2/pvz-service/internal/pkg/health"
 	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	"github.com/maksemen2/pvz-service/internal/pkg/requestid"
+	"github.com/maksemen2/pvz-service/internal/pkg/tracing"
 	"github.com/maksemen2/pvz-service/internal/service"
+	"go.opentelemetry.io/contrib/instrumentation/github.com/gin-gonic/gin/otelgin"
 	"go.uber.org/zap"
 )
 
 // New настраивает роутинг приложения и устанавливает мидлвари.
 // Возвращает инстанс gin.Engine
-func New(authService service.AuthService, productService service.ProductService, pvzService service.PVZService, receptionService service.ReceptionService, logger *zap.Logger, tokenManager auth.TokenManager, config config.HTTPConfig) *gin.Engine {
+func New(authService service.AuthService, productService service.ProductService, issuanceService service.IssuanceService, pickupService service.PickupService, storageService service.StorageService, returnService service.ReturnService, pvzService service.PVZService, receptionService service.ReceptionService, statsService service.StatsService, idempotencyService service.IdempotencyService, logger *zap.Logger, tokenManager auth.TokenManager, config config.HTTPConfig, tracingConfig config.TracingConfig, healthChecker health.ReadinessChecker) *gin.Engine {
 	router := gin.New()
 
 	if config.Env == "prod" {
 		gin.SetMode(gin.ReleaseMode)
 	}
 
-	router.Use(gin.Recovery(), metrics.NewGinMiddleware(), l.NewMiddleware(logger))
+	// Проверки здоровья регистрируются до мидлварей, чтобы частые запросы оркестратора
+	// не попадали в логи, метрики и трассировки
+	healthHandler := httphandlers.NewHealthHandler(logger, healthChecker)
 
+	healthHandler.RegisterRoutes(router)
+
+	router.Use(requestid.NewGinMiddleware(), otelgin.Middleware(tracing.ServiceName(tracingConfig)), gin.Recovery(), metrics.NewGinMiddleware(), l.NewMiddleware(logger))
+
+	// Публичные ручки не поддерживают Idempotency-Key (см. httpmiddleware.NewIdempotencyMiddleware)
 	public := router.Group("")
 
 	authHandler := httphandlers.NewAuthHandler(logger, authService)
@@ -30,12 +42,28 @@ func New(authService service.AuthService, productService service.ProductService,
 
 	protected := router.Group("")
 
-	protected.Use(auth.NewGinMiddleware(logger, tokenManager))
+	protected.Use(auth.NewGinMiddleware(logger, tokenManager), httpmiddleware.NewIdempotencyMiddleware(logger, idempotencyService))
 
 	productHandler := httphandlers.NewProductHandler(logger, productService)
 
 	productHandler.RegisterRoutes(protected)
 
+	issuanceHandler := httphandlers.NewIssuanceHandler(logger, issuanceService)
+
+	issuanceHandler.RegisterRoutes(protected)
+
+	pickupHandler := httphandlers.NewPickupHandler(logger, pickupService)
+
+	pickupHandler.RegisterRoutes(protected)
+
+	storageHandler := httphandlers.NewStorageHandler(logger, storageService)
+
+	storageHandler.RegisterRoutes(protected)
+
+	returnHandler := httphandlers.NewReturnHandler(logger, returnService)
+
+	returnHandler.RegisterRoutes(protected)
+
 	pvzHandler := httphandlers.NewPVZHandler(logger, pvzService)
 
 	pvzHandler.RegisterRoutes(protected)
@@ -44,5 +72,29 @@ func New(authService service.AuthService, productService service.ProductService,
 
 	receptionHandler.RegisterRoutes(protected)
 
+	statsHandler := httphandlers.NewStatsHandler(logger, statsService)
+
+	statsHandler.RegisterRoutes(protected)
+
+	return router
+}
+
+// NewAdmin настраивает роутинг служебных ручек (/admin), которые обслуживаются сервером метрик
+// на внутреннем порту, а не публичным API. Доступ к ним только по Bearer токену adminToken.
+// Возвращает инстанс gin.Engine
+func NewAdmin(logger *zap.Logger, config config.HTTPConfig, adminToken string, logLevels *l.Levels) *gin.Engine {
+	router := gin.New()
+
+	if config.Env == "prod" {
+		gin.SetMode(gin.ReleaseMode)
+	}
+
+	router.Use(requestid.NewGinMiddleware(), gin.Recovery(), l.NewMiddleware(logger))
+	router.Use(auth.NewStaticTokenMiddleware(logger, adminToken))
+
+	logLevelHandler := httphandlers.NewLogLevelHandler(logger, logLevels)
+
+	logLevelHandler.RegisterRoutes(router.Group(""))
+
 	return router
 }
@@ -0,0 +1,56 @@
+//go:build unit
+// +build unit
+
+package routes_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/maksemen2/pvz-service/config"
+	"github.com/maksemen2/pvz-service/internal/delivery/http/routes"
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestNewAdmin_RequiresToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	levels, err := logger.NewLevels(config.LoggingConfig{Level: "info"})
+	require.NoError(t, err)
+
+	router := routes.NewAdmin(zap.NewNop(), config.HTTPConfig{Env: "dev"}, "admin-token", levels)
+
+	putLogLevel := func(header string) int {
+		w := httptest.NewRecorder()
+		req, _ := http.NewRequest(http.MethodPut, "/admin/log-level", strings.NewReader(`{"level":"debug"}`))
+		req.Header.Set("Content-Type", "application/json")
+
+		if header != "" {
+			req.Header.Set("Authorization", header)
+		}
+
+		router.ServeHTTP(w, req)
+
+		return w.Code
+	}
+
+	t.Run("Unauthenticated", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, putLogLevel(""))
+		assert.Equal(t, "info", levels.Level(), "level must not change without a token")
+	})
+
+	t.Run("Wrong token", func(t *testing.T) {
+		assert.Equal(t, http.StatusUnauthorized, putLogLevel("Bearer wrong-token"))
+	})
+
+	t.Run("Admin token", func(t *testing.T) {
+		assert.Equal(t, http.StatusOK, putLogLevel("Bearer admin-token"))
+		assert.Equal(t, "debug", levels.Level())
+	})
+}
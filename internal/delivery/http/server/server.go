@@ -3,15 +3,18 @@ package httpserver
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/gin-gonic/gin"
 	"github.com/maksemen2/pvz-service/config"
 	"go.uber.org/zap"
+	"net"
 	"net/http"
 )
 
 // Server - структура HTTP-сервера для обработки API запросов.
 type Server struct {
 	httpServer *http.Server
+	listener   net.Listener
 	logger     *zap.Logger
 }
 
@@ -27,23 +30,42 @@ func New(logger *zap.Logger, router *gin.Engine, cfg config.HTTPConfig) *Server
 	}
 }
 
-// Start запускает HTTP сервер
-func (s *Server) Start() {
-	s.logger.Info("Starting HTTP server", zap.String("addr", s.httpServer.Addr))
+// Listen занимает адрес HTTP сервера.
+// Ошибка (например, занятый порт) возвращается сразу, до начала обслуживания запросов.
+func (s *Server) Listen() error {
+	lis, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("HTTP listener failed: %w", err)
+	}
+
+	s.listener = lis
+
+	return nil
+}
 
-	if err := s.httpServer.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		s.logger.Fatal("HTTP server error", zap.Error(err))
+// Serve обслуживает запросы на адресе, занятом Listen, и блокируется до остановки сервера.
+// После Stop возвращает nil.
+func (s *Server) Serve() error {
+	s.logger.Info("Starting HTTP server", zap.String("addr", s.listener.Addr().String()))
+
+	if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("HTTP server error: %w", err)
 	}
+
+	return nil
 }
 
-// Stop останавливает HTTP сервер
-// GracefulShutdown должен быть обеспечен снаружи
-func (s *Server) Stop(ctx context.Context) {
+// Stop останавливает HTTP сервер, дожидаясь завершения текущих запросов.
+// Если контекст истек раньше, оставшиеся соединения закрываются принудительно.
+func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping HTTP server")
 
 	if err := s.httpServer.Shutdown(ctx); err != nil {
-		s.logger.Error("Error stopping HTTP server", zap.Error(err))
+		s.httpServer.Close()
+		return fmt.Errorf("HTTP server shutdown: %w", err)
 	}
 
 	s.logger.Info("HTTP server stopped")
+
+	return nil
 }
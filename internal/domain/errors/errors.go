@@ -3,5 +3,6 @@ package domainerrors
 import "errors"
 
 var (
-	ErrUnexpected = errors.New("unexpected error") // Непредвиденная ошибка сервиса
+	ErrUnexpected      = errors.New("unexpected error")          // Непредвиденная ошибка сервиса
+	ErrVersionMismatch = errors.New("resource version mismatch") // Ресурс был изменен после получения клиентом указанной версии
 )
@@ -0,0 +1,9 @@
+package domainerrors
+
+import "errors"
+
+var (
+	ErrInvalidIdempotencyKey        = errors.New("invalid idempotency key")                                   // Пустой или слишком длинный ключ идемпотентности
+	ErrIdempotencyKeyReused         = errors.New("idempotency key was already used with a different request") // Ключ повторно использован с другим запросом
+	ErrIdempotencyRequestInProgress = errors.New("request with this idempotency key is still in progress")    // Запрос с этим ключом еще обрабатывается
+)
@@ -0,0 +1,11 @@
+package domainerrors
+
+import "errors"
+
+var (
+	ErrInvalidIssuanceSize = errors.New("invalid issuance size")                   // Не переданы товары для выдачи или превышено максимальное количество товаров в выдаче
+	ErrProductNotFound     = errors.New("product not found in this pvz")           // Товар не найден среди принятых в этом пункте выдачи
+	ErrProductNotReceived  = errors.New("product reception is not closed")         // Приемка товара еще не закрыта или отменена
+	ErrProductNotStored    = errors.New("product is no longer stored in this pvz") // Товар уже выдан или возвращен отправителю
+	ErrProductNotInOrder   = errors.New("product does not belong to the order")    // Товар не относится к заказу, код получения которого предъявлен
+)
@@ -0,0 +1,7 @@
+package domainerrors
+
+import "errors"
+
+var (
+	ErrInvalidManifest = errors.New("invalid manifest: items must be non-empty, with valid unique product types and positive counts") // Некорректный манифест приемки
+)
@@ -0,0 +1,11 @@
+package domainerrors
+
+import "errors"
+
+var (
+	ErrInvalidPickupCode   = errors.New("invalid pickup code")                                    // Код получения не подходит или у заказа нет действующего кода
+	ErrPickupCodeExpired   = errors.New("pickup code has expired")                                // Истек срок действия кода получения
+	ErrPickupCodeLocked    = errors.New("pickup code is locked after too many attempts")          // Превышено количество попыток ввода кода получения
+	ErrPickupCodeThrottled = errors.New("too many invalid pickup codes in this pvz, retry later") // Слишком много неудачных попыток ввода кодов в пункте выдачи
+	ErrOrderNotFound       = errors.New("no stored products of this order in this pvz")           // В пункте выдачи нет хранящихся товаров заказа
+)
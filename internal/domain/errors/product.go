@@ -3,6 +3,11 @@ package domainerrors
 import "errors"
 
 var (
-	ErrNoProductsInReception = errors.New("no products in this reception") // Нет продуктов в этой приемке
-	ErrInvalidProductType    = errors.New("invalid product type")          // Недопустимый тип продукта
+	ErrNoProductsInReception = errors.New("no products in this reception")                   // Нет продуктов в этой приемке
+	ErrInvalidProductType    = errors.New("invalid product type")                            // Недопустимый тип продукта
+	ErrInvalidBatchSize      = errors.New("invalid batch size")                              // Пустой пакет товаров или превышен максимальный размер пакета
+	ErrInvalidBatchMode      = errors.New("invalid batch mode")                              // Недопустимый режим пакетного добавления
+	ErrDuplicateProductID    = errors.New("product id is repeated in batch")                 // Айди товара повторяется внутри пакета
+	ErrProductIDConflict     = errors.New("product id is already used in another reception") // Товар с таким айди уже добавлен в другую приемку
+	ErrInvalidProductTime    = errors.New("product date time is outside of the reception")   // Время сканирования товара в будущем или раньше открытия приемки
 )
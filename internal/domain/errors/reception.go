@@ -3,6 +3,11 @@ package domainerrors
 import "errors"
 
 var (
-	ErrNoOpenReceptions    = errors.New("no open receptions in this pvz")             // Нет открытых приемок в этом пункте выдачи
-	ErrOpenReceptionExists = errors.New("open reception already exists for this pvz") // Уже существует открытая приемка для этого пункта выдачи
+	ErrNoOpenReceptions         = errors.New("no open receptions in this pvz")                        // Нет открытых приемок в этом пункте выдачи
+	ErrOpenReceptionExists      = errors.New("open reception already exists for this pvz")            // Уже существует открытая приемка для этого пункта выдачи
+	ErrAutoCloseLocked          = errors.New("stale receptions are being closed by another instance") // Автозакрытие приемок уже выполняется другой репликой
+	ErrCancelReasonEmpty        = errors.New("cancel reason is required")                             // Не указана причина отмены приемки
+	ErrNoClosedReceptions       = errors.New("no closed receptions in this pvz")                      // Нет закрытых приемок в этом пункте выдачи
+	ErrReceptionNotReopenable   = errors.New("last reception of this pvz can not be reopened")        // Последняя приемка отменена или после неё уже есть новая
+	ErrReopenGracePeriodExpired = errors.New("reopen grace period has expired")                       // Истекло время, в течение которого сотрудник может переоткрыть приемку
 )
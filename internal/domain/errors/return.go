@@ -0,0 +1,11 @@
+package domainerrors
+
+import "errors"
+
+var (
+	ErrInvalidReturnReason    = errors.New("invalid return reason")                   // Недопустимая причина возврата
+	ErrInvalidReturnCondition = errors.New("invalid return condition")                // Недопустимое состояние возвращенного товара
+	ErrInvalidBarcode         = errors.New("invalid barcode")                         // Для неизвестного товара не указан штрихкод или он слишком длинный
+	ErrProductNotIssued       = errors.New("product was not issued to a customer")    // Товар еще хранится, возвращен отправителю или уже возвращен клиентом
+	ErrNoPendingReturns       = errors.New("no pending customer returns in this pvz") // В ПВЗ нет собираемой отправки возвратов
+)
@@ -0,0 +1,7 @@
+package domainerrors
+
+import "errors"
+
+var (
+	ErrNoExpiredProducts = errors.New("no products with expired storage period in this pvz") // В ПВЗ нет товаров, которые нужно вернуть отправителю
+)
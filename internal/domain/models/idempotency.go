@@ -0,0 +1,37 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// IdempotencyKeyMaxLength - максимальная длина ключа идемпотентности.
+const IdempotencyKeyMaxLength = 255
+
+// IdempotencyRecord - сохраненный результат запроса с заголовком Idempotency-Key.
+// Ключ уникален в пределах пользователя. Fingerprint - хеш метода, пути и тела запроса,
+// по нему повтор отличается от повторного использования ключа с другим запросом.
+// Пока запрос обрабатывается, StatusCode равен 0.
+// Headers - заголовки ответа, которые отдаются при повторе вместе с телом (например, ETag и Location).
+type IdempotencyRecord struct {
+	UserID       uuid.UUID
+	Key          string
+	Fingerprint  string
+	StatusCode   int
+	ContentType  string
+	Headers      map[string]string
+	ResponseBody []byte
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+}
+
+// Completed возвращает true, если ответ на запрос уже сохранен.
+func (r *IdempotencyRecord) Completed() bool {
+	return r.StatusCode != 0
+}
+
+// ValidIdempotencyKey проверяет, что ключ не пустой и не длиннее IdempotencyKeyMaxLength.
+func ValidIdempotencyKey(key string) bool {
+	return key != "" && len(key) <= IdempotencyKeyMaxLength
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Issuance - выдача товаров клиенту. Одна выдача может включать несколько товаров одного ПВЗ.
+type Issuance struct {
+	ID       uuid.UUID
+	PVZID    uuid.UUID
+	IssuedBy uuid.UUID
+	DateTime time.Time
+	Products []*Product
+}
@@ -0,0 +1,117 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"sort"
+	"time"
+)
+
+// Manifest - ожидаемый состав приемки (ASN), по которому при закрытии
+// приемки строится отчет о расхождениях.
+// Если ReceptionID равен nil, манифест загружен заранее и будет привязан
+// к следующей приемке, открытой в ПВЗ.
+type Manifest struct {
+	ID          uuid.UUID
+	PVZID       uuid.UUID
+	ReceptionID *uuid.UUID
+	CreatedBy   uuid.UUID
+	CreatedAt   time.Time
+	Items       []ManifestItem
+}
+
+// ManifestItem - ожидаемое количество товаров одного типа.
+type ManifestItem struct {
+	Type  ProductType
+	Count int
+}
+
+// Valid проверяет, что манифест не пустой, типы товаров корректны и не повторяются,
+// а ожидаемые количества положительные.
+func (m *Manifest) Valid() bool {
+	if len(m.Items) == 0 {
+		return false
+	}
+
+	seen := make(map[ProductType]struct{}, len(m.Items))
+
+	for _, item := range m.Items {
+		if !item.Type.Valid() || item.Count <= 0 {
+			return false
+		}
+
+		if _, ok := seen[item.Type]; ok {
+			return false
+		}
+
+		seen[item.Type] = struct{}{}
+	}
+
+	return true
+}
+
+// DiscrepancyKind - вид расхождения между манифестом и фактически принятыми товарами.
+type DiscrepancyKind string
+
+const (
+	DiscrepancyKindMissing       DiscrepancyKind = "missing"        // Товаров принято меньше, чем ожидалось
+	DiscrepancyKindUnexpected    DiscrepancyKind = "unexpected"     // Принят тип товара, которого нет в манифесте
+	DiscrepancyKindOverDelivered DiscrepancyKind = "over_delivered" // Товаров принято больше, чем ожидалось
+)
+
+func (d DiscrepancyKind) String() string {
+	return string(d)
+}
+
+// DiscrepancyItem - расхождение по одному типу товара.
+type DiscrepancyItem struct {
+	Type     ProductType
+	Kind     DiscrepancyKind
+	Expected int
+	Actual   int
+}
+
+// DiscrepancyReport - отчет о расхождениях, построенный при закрытии приемки с манифестом.
+// Пустой список Items означает, что приемка полностью совпала с манифестом.
+type DiscrepancyReport struct {
+	ReceptionID uuid.UUID
+	CreatedAt   time.Time
+	Items       []DiscrepancyItem
+}
+
+// NewDiscrepancyReport сравнивает манифест с количеством фактически принятых товаров каждого типа
+// и возвращает отчет. Расхождения отсортированы по типу товара.
+func NewDiscrepancyReport(receptionID uuid.UUID, manifest *Manifest, received map[ProductType]int, createdAt time.Time) *DiscrepancyReport {
+	expected := make(map[ProductType]int, len(manifest.Items))
+	for _, item := range manifest.Items {
+		expected[item.Type] += item.Count
+	}
+
+	items := make([]DiscrepancyItem, 0)
+
+	for productType, expectedCount := range expected {
+		actual := received[productType]
+
+		switch {
+		case actual < expectedCount:
+			items = append(items, DiscrepancyItem{Type: productType, Kind: DiscrepancyKindMissing, Expected: expectedCount, Actual: actual})
+		case actual > expectedCount:
+			items = append(items, DiscrepancyItem{Type: productType, Kind: DiscrepancyKindOverDelivered, Expected: expectedCount, Actual: actual})
+		}
+	}
+
+	for productType, actual := range received {
+		if _, ok := expected[productType]; !ok && actual > 0 {
+			items = append(items, DiscrepancyItem{Type: productType, Kind: DiscrepancyKindUnexpected, Actual: actual})
+		}
+	}
+
+	sort.Slice(items, func(i, j int) bool {
+		return items[i].Type < items[j].Type
+	})
+
+	return &DiscrepancyReport{
+		ReceptionID: receptionID,
+		CreatedAt:   createdAt,
+		Items:       items,
+	}
+}
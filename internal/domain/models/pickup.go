@@ -0,0 +1,33 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// PickupCode - одноразовый код, по которому клиент получает товары заказа в ПВЗ.
+// Код создается при закрытии приемки с товарами заказа и хранится только в виде хэша.
+// У заказа в ПВЗ может быть только один неиспользованный код.
+type PickupCode struct {
+	ID        uuid.UUID
+	PVZID     uuid.UUID
+	OrderID   uuid.UUID
+	Code      string // Код в открытом виде, заполняется только при создании и не сохраняется
+	CodeHash  string
+	Attempts  int // Количество неудачных попыток ввода кода
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    *time.Time
+}
+
+// Expired проверяет, истек ли срок действия кода к моменту now.
+func (c *PickupCode) Expired(now time.Time) bool {
+	return !now.Before(c.ExpiresAt)
+}
+
+// PickupOrder - заказ, код получения которого подтвержден, с товарами для выдачи клиенту.
+type PickupOrder struct {
+	OrderID  uuid.UUID
+	PVZID    uuid.UUID
+	Products []*Product
+}
@@ -6,11 +6,20 @@ import (
 )
 
 // Product - структура, представляющая товар в системе.
+// Товары одного заказа (OrderID) выдаются клиенту по одному коду получения (см. PickupCode).
+// IssuanceID и IssuedAt заполнены только у выданных клиенту товаров,
+// ShipmentID и ReturnedAt - только у возвращенных отправителю.
 type Product struct {
 	ID          uuid.UUID
 	DateTime    time.Time
 	Type        ProductType
 	ReceptionID uuid.UUID
+	OrderID     uuid.UUID
+	Status      ProductStatus
+	IssuanceID  *uuid.UUID
+	IssuedAt    *time.Time
+	ShipmentID  *uuid.UUID
+	ReturnedAt  *time.Time
 }
 
 // AddProduct - структура, инкапсулирующая данные для добавления товара в приемку с указанием только айди пвз.
@@ -19,6 +28,7 @@ type AddProduct struct {
 	DateTime time.Time
 	Type     ProductType
 	PVZID    uuid.UUID
+	OrderID  uuid.UUID
 }
 
 type ProductType string
@@ -38,6 +48,85 @@ func (p ProductType) Valid() bool {
 	return false
 }
 
+// AllProductTypes возвращает все допустимые типы товаров.
+func AllProductTypes() []ProductType {
+	return []ProductType{ProductTypeElectronics, ProductTypeClothes, ProductTypeShoes}
+}
+
 func (p ProductType) String() string {
 	return string(p)
 }
+
+// ProductStatus - состояние товара в ПВЗ.
+type ProductStatus string
+
+const (
+	ProductStatusStored             ProductStatus = "stored"               // Товар принят и хранится в ПВЗ
+	ProductStatusIssued             ProductStatus = "issued"               // Товар выдан клиенту
+	ProductStatusReturnedToSender   ProductStatus = "returned_to_sender"   // Товар возвращен отправителю
+	ProductStatusReturnedByCustomer ProductStatus = "returned_by_customer" // Выданный товар вернул клиент (см. CustomerReturn)
+)
+
+func (s ProductStatus) Valid() bool {
+	switch s {
+	case ProductStatusStored, ProductStatusIssued, ProductStatusReturnedToSender, ProductStatusReturnedByCustomer:
+		return true
+	}
+
+	return false
+}
+
+func (s ProductStatus) String() string {
+	return string(s)
+}
+
+// BatchMode - режим пакетного добавления товаров.
+type BatchMode string
+
+const (
+	BatchModeAllOrNothing BatchMode = "all_or_nothing" // При ошибке хотя бы в одном товаре не добавляется ни один
+	BatchModeBestEffort   BatchMode = "best_effort"    // Добавляются все корректные товары, ошибочные пропускаются
+)
+
+func (m BatchMode) Valid() bool {
+	switch m {
+	case BatchModeAllOrNothing, BatchModeBestEffort:
+		return true
+	}
+
+	return false
+}
+
+func (m BatchMode) String() string {
+	return string(m)
+}
+
+// BatchItemStatus - результат обработки одного товара из пакета.
+type BatchItemStatus string
+
+const (
+	BatchItemStatusCreated   BatchItemStatus = "created"   // Товар добавлен
+	BatchItemStatusDuplicate BatchItemStatus = "duplicate" // Товар с таким айди уже был добавлен в эту приемку ранее (повтор запроса)
+	BatchItemStatusFailed    BatchItemStatus = "failed"    // Товар не добавлен, причина в Err
+	BatchItemStatusSkipped   BatchItemStatus = "skipped"   // Товар корректен, но не добавлен, потому что пакет отклонен целиком
+)
+
+func (s BatchItemStatus) String() string {
+	return string(s)
+}
+
+// BatchItemResult - результат обработки товара с индексом Index из пакета.
+type BatchItemResult struct {
+	Index   int
+	Status  BatchItemStatus
+	Product *Product // Добавленный или ранее добавленный товар, nil для Failed и Skipped
+	Err     error
+}
+
+// AddProductsBatchResult - результат пакетного добавления товаров.
+// Applied равен false, если пакет в режиме BatchModeAllOrNothing был отклонен целиком.
+type AddProductsBatchResult struct {
+	ReceptionID uuid.UUID
+	Applied     bool
+	Items       []*BatchItemResult
+}
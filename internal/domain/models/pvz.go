@@ -9,6 +9,7 @@ type PVZ struct {
 	ID               uuid.UUID
 	RegistrationDate time.Time
 	City             CityType
+	Version          int // Увеличивается при открытии, закрытии, отмене и переоткрытии приемки и загрузке манифеста (см. AnyVersion)
 }
 
 type CityType string
@@ -28,6 +29,11 @@ func (m CityType) Valid() bool {
 	return false
 }
 
+// AllCityTypes возвращает все допустимые города.
+func AllCityTypes() []CityType {
+	return []CityType{CityTypeMoscow, CityTypeSPB, CityTypeKazan}
+}
+
 func (m CityType) String() string {
 	return string(m)
 }
@@ -35,4 +41,5 @@ func (m CityType) String() string {
 type PVZWithReceptions struct {
 	PVZ        *PVZ
 	Receptions []*ReceptionWithProducts
+	Returns    []*CustomerReturn
 }
@@ -9,7 +9,21 @@ type Reception struct {
 	ID       uuid.UUID
 	DateTime time.Time
 	PVZID    uuid.UUID
-	Status   ReceptionStatus // in_progress, close
+	Status   ReceptionStatus // in_progress, close, cancelled
+	OpenedBy uuid.UUID       // Айди пользователя, открывшего приемку
+	ClosedAt *time.Time      // Время закрытия или отмены приемки, nil для открытых приемок
+	ClosedBy *uuid.UUID      // Айди пользователя, закрывшего или отменившего приемку, nil для открытых и автоматически закрытых приемок
+
+	AutoClosed  bool   // Приемка была закрыта автоматически (см. app.ReceptionAutoCloser)
+	CloseReason string // Причина автоматического закрытия или отмены приемки
+
+	ReopenedAt *time.Time // Время последнего повторного открытия приемки, nil если приемка не переоткрывалась
+	ReopenedBy *uuid.UUID // Айди пользователя, последним переоткрывшего приемку
+
+	Version int // Увеличивается при каждом изменении приемки и её товаров (см. AnyVersion)
+
+	Discrepancy *DiscrepancyReport // Отчет о расхождениях с манифестом, заполняется только в результате закрытия приемки с манифестом
+	PickupCodes []*PickupCode      // Коды получения заказов приемки, заполняются только в результате закрытия приемки сотрудником
 }
 
 type ReceptionStatus string
@@ -17,11 +31,12 @@ type ReceptionStatus string
 const (
 	ReceptionStatusInProgress ReceptionStatus = "in_progress"
 	ReceptionStatusClose      ReceptionStatus = "close"
+	ReceptionStatusCancelled  ReceptionStatus = "cancelled" // Приемка открыта по ошибке и не учитывается в статистике
 )
 
 func (r ReceptionStatus) Valid() bool {
 	switch r {
-	case ReceptionStatusInProgress, ReceptionStatusClose:
+	case ReceptionStatusInProgress, ReceptionStatusClose, ReceptionStatusCancelled:
 		return true
 	}
 
@@ -32,6 +47,22 @@ func (r ReceptionStatus) String() string {
 	return string(r)
 }
 
+// StaleReceptionPolicy - правила автоматического закрытия забытых приемок.
+// MaxOpenAge применяется ко всем городам, для которых нет значения в CityMaxOpenAge.
+type StaleReceptionPolicy struct {
+	MaxOpenAge     time.Duration
+	CityMaxOpenAge map[CityType]time.Duration
+}
+
+// MaxOpenAgeFor возвращает максимальное время, которое приемка может быть открыта в указанном городе.
+func (p *StaleReceptionPolicy) MaxOpenAgeFor(city CityType) time.Duration {
+	if maxOpenAge, ok := p.CityMaxOpenAge[city]; ok {
+		return maxOpenAge
+	}
+
+	return p.MaxOpenAge
+}
+
 type ReceptionWithProducts struct {
 	Reception *Reception
 	Products  []*Product
@@ -0,0 +1,114 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// CustomerReturn - товар, который клиент вернул в ПВЗ.
+// Если ProductID не nil, возвращен выданный в этом ПВЗ товар, иначе товар неизвестен системе
+// и определяется только штрихкодом (Barcode) и типом.
+// Возврат сразу попадает в ожидающую отправку возвратов ПВЗ (ShipmentID, см. ShipmentKindCustomerReturns).
+type CustomerReturn struct {
+	ID         uuid.UUID
+	PVZID      uuid.UUID
+	ProductID  *uuid.UUID
+	Barcode    string
+	Type       ProductType
+	Reason     ReturnReason
+	Condition  ReturnCondition
+	ShipmentID uuid.UUID
+	CreatedBy  uuid.UUID
+	DateTime   time.Time
+}
+
+// ReturnReason - причина возврата товара клиентом.
+type ReturnReason string
+
+const (
+	ReturnReasonDefective      ReturnReason = "defective"        // Брак
+	ReturnReasonWrongItem      ReturnReason = "wrong_item"       // Привезли не тот товар
+	ReturnReasonNotAsDescribed ReturnReason = "not_as_described" // Товар не соответствует описанию
+	ReturnReasonDoesNotFit     ReturnReason = "does_not_fit"     // Не подошел размер
+	ReturnReasonChangedMind    ReturnReason = "changed_mind"     // Клиент передумал
+)
+
+func (r ReturnReason) Valid() bool {
+	switch r {
+	case ReturnReasonDefective, ReturnReasonWrongItem, ReturnReasonNotAsDescribed, ReturnReasonDoesNotFit, ReturnReasonChangedMind:
+		return true
+	}
+
+	return false
+}
+
+// AllReturnReasons возвращает все допустимые причины возврата.
+func AllReturnReasons() []ReturnReason {
+	return []ReturnReason{ReturnReasonDefective, ReturnReasonWrongItem, ReturnReasonNotAsDescribed, ReturnReasonDoesNotFit, ReturnReasonChangedMind}
+}
+
+func (r ReturnReason) String() string {
+	return string(r)
+}
+
+// ReturnCondition - состояние возвращенного товара.
+type ReturnCondition string
+
+const (
+	ReturnConditionNew     ReturnCondition = "new"     // Товар не использовался, упаковка целая
+	ReturnConditionOpened  ReturnCondition = "opened"  // Упаковка вскрыта
+	ReturnConditionUsed    ReturnCondition = "used"    // Товар был в использовании
+	ReturnConditionDamaged ReturnCondition = "damaged" // Товар поврежден
+)
+
+func (c ReturnCondition) Valid() bool {
+	switch c {
+	case ReturnConditionNew, ReturnConditionOpened, ReturnConditionUsed, ReturnConditionDamaged:
+		return true
+	}
+
+	return false
+}
+
+// AllReturnConditions возвращает все допустимые состояния возвращенного товара.
+func AllReturnConditions() []ReturnCondition {
+	return []ReturnCondition{ReturnConditionNew, ReturnConditionOpened, ReturnConditionUsed, ReturnConditionDamaged}
+}
+
+func (c ReturnCondition) String() string {
+	return string(c)
+}
+
+// ReturnsReport - возвраты ПВЗ за период и их количество по причинам и состояниям.
+type ReturnsReport struct {
+	PVZID       uuid.UUID
+	Returns     []*CustomerReturn
+	ByReason    map[ReturnReason]int
+	ByCondition map[ReturnCondition]int
+}
+
+// NewReturnsReport строит отчет по возвратам ПВЗ.
+// Причины и состояния без возвратов попадают в отчет с нулевым количеством.
+func NewReturnsReport(pvzID uuid.UUID, returns []*CustomerReturn) *ReturnsReport {
+	report := &ReturnsReport{
+		PVZID:       pvzID,
+		Returns:     returns,
+		ByReason:    make(map[ReturnReason]int, len(AllReturnReasons())),
+		ByCondition: make(map[ReturnCondition]int, len(AllReturnConditions())),
+	}
+
+	for _, reason := range AllReturnReasons() {
+		report.ByReason[reason] = 0
+	}
+
+	for _, condition := range AllReturnConditions() {
+		report.ByCondition[condition] = 0
+	}
+
+	for _, ret := range returns {
+		report.ByReason[ret.Reason]++
+		report.ByCondition[ret.Condition]++
+	}
+
+	return report
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"github.com/google/uuid"
+	"time"
+)
+
+// Shipment - исходящая отправка товаров из ПВЗ.
+// Отправка возвратов клиентов (ShipmentKindCustomerReturns) собирается постепенно и содержит Returns,
+// пока DispatchedAt равен nil, в нее попадают новые возвраты ПВЗ.
+// Остальные отправки содержат Products и уходят из ПВЗ сразу после создания.
+type Shipment struct {
+	ID           uuid.UUID
+	PVZID        uuid.UUID
+	Kind         ShipmentKind
+	CreatedBy    uuid.UUID
+	DateTime     time.Time
+	DispatchedAt *time.Time
+	Products     []*Product
+	Returns      []*CustomerReturn
+}
+
+// ShipmentKind - назначение исходящей отправки.
+type ShipmentKind string
+
+const (
+	ShipmentKindReturnToSender  ShipmentKind = "return_to_sender" // Возврат отправителю товаров с истекшим сроком хранения
+	ShipmentKindCustomerReturns ShipmentKind = "customer_returns" // Товары, которые вернули клиенты
+)
+
+func (k ShipmentKind) Valid() bool {
+	switch k {
+	case ShipmentKindReturnToSender, ShipmentKindCustomerReturns:
+		return true
+	}
+
+	return false
+}
+
+func (k ShipmentKind) String() string {
+	return string(k)
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+)
+
+// StatsFilter - структура для инкапсуляции диапазона дат, за который считается статистика.
+// Обе даты обязательны.
+type StatsFilter struct {
+	StartDate time.Time
+	EndDate   time.Time
+}
+
+// Valid проводит валидацию StatsFilter. Возвращает доменные ошибки.
+func (f *StatsFilter) Valid() error {
+	if f.StartDate.IsZero() || f.EndDate.IsZero() {
+		return domainerrors.ErrInvalidDateRange
+	}
+
+	if f.StartDate.After(f.EndDate) {
+		return domainerrors.ErrInvalidDateRange
+	}
+
+	return nil
+}
+
+// PVZStats - агрегированная статистика приемок по одному ПВЗ.
+type PVZStats struct {
+	PVZID                   uuid.UUID
+	City                    CityType
+	ReceptionsCount         int
+	ClosedReceptionsCount   int
+	ProductsCount           int
+	AvgReceptionDuration    time.Duration // Среднее время от открытия до закрытия, считается только по закрытым вручную приемкам
+	AvgProductsPerReception float64
+}
+
+// ProductTypeStats - количество принятых товаров определенного типа в городе за день.
+type ProductTypeStats struct {
+	City  CityType
+	Day   time.Time
+	Type  ProductType
+	Count int
+}
+
+// Stats - статистика приемок и товаров за период.
+type Stats struct {
+	PVZs         []*PVZStats
+	ProductTypes []*ProductTypeStats
+}
+
+// BusinessSnapshot - текущее состояние приемок и ПВЗ, из которого считаются бизнес-метрики.
+// Города и типы товаров, для которых нет данных, в картах отсутствуют.
+type BusinessSnapshot struct {
+	OpenReceptions        map[CityType]int                 // Количество открытых приемок по городам
+	OldestOpenReceptionAt *time.Time                       // Время открытия самой старой открытой приемки, nil если открытых приемок нет
+	ProductsReceived      map[CityType]map[ProductType]int // Количество товаров, принятых с начала дня, по городам и типам
+	PVZCount              int                              // Общее количество ПВЗ
+}
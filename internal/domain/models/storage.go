@@ -0,0 +1,64 @@
+package models
+
+import "time"
+
+// StoragePolicy - сроки хранения товаров в ПВЗ, отсчитываемые от времени приемки товара.
+// Срок для типа товара из TypePeriod важнее срока для города из CityPeriod,
+// Period применяется, если для товара нет ни того, ни другого.
+type StoragePolicy struct {
+	Period     time.Duration
+	TypePeriod map[ProductType]time.Duration
+	CityPeriod map[CityType]time.Duration
+	Warning    time.Duration // За сколько до истечения срока товар попадает в список истекающих
+}
+
+// PeriodFor возвращает срок хранения товара указанного типа в ПВЗ указанного города.
+func (p *StoragePolicy) PeriodFor(city CityType, productType ProductType) time.Duration {
+	if period, ok := p.TypePeriod[productType]; ok {
+		return period
+	}
+
+	if period, ok := p.CityPeriod[city]; ok {
+		return period
+	}
+
+	return p.Period
+}
+
+// ExpiresAt возвращает момент истечения срока хранения товара в ПВЗ указанного города.
+func (p *StoragePolicy) ExpiresAt(city CityType, product *Product) time.Time {
+	return product.DateTime.Add(p.PeriodFor(city, product.Type))
+}
+
+// Deadlines возвращает для всех городов и типов товаров границы времени приемки,
+// товары с которыми истекают не позже момента at.
+func (p *StoragePolicy) Deadlines(at time.Time) []StorageDeadline {
+	deadlines := make([]StorageDeadline, 0, len(AllCityTypes())*len(AllProductTypes()))
+
+	for _, city := range AllCityTypes() {
+		for _, productType := range AllProductTypes() {
+			deadlines = append(deadlines, StorageDeadline{
+				City:       city,
+				Type:       productType,
+				ReceivedBy: at.Add(-p.PeriodFor(city, productType)),
+			})
+		}
+	}
+
+	return deadlines
+}
+
+// StorageDeadline - граница времени приемки для товаров типа Type в ПВЗ города City.
+// Товары, принятые не позже ReceivedBy, истекают к моменту, для которого граница рассчитана.
+type StorageDeadline struct {
+	City       CityType
+	Type       ProductType
+	ReceivedBy time.Time
+}
+
+// ExpiringProduct - хранящийся в ПВЗ товар, срок хранения которого истек или скоро истечет.
+type ExpiringProduct struct {
+	Product   *Product
+	ExpiresAt time.Time
+	Expired   bool
+}
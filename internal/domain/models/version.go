@@ -0,0 +1,7 @@
+package models
+
+// AnyVersion - ожидаемая версия ресурса, при которой версия не проверяется.
+// Версии ПВЗ и приемок начинаются с 1, поэтому 0 не совпадает ни с одной из них.
+// Версия используется для оптимистичной блокировки: изменение выполняется,
+// только если текущая версия ресурса совпадает с ожидаемой.
+const AnyVersion = 0
@@ -0,0 +1,17 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+// IIdempotencyRepo - интерфейс для репозитория сохраненных ответов на идемпотентные запросы.
+type IIdempotencyRepo interface {
+	Reserve(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, bool, error)                // Резервирует ключ или возвращает уже существующую запись.
+	Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, headers map[string]string, body []byte) error // Сохраняет ответ на запрос вместе с заголовками для повтора.
+	Release(ctx context.Context, userID uuid.UUID, key string) error                                                                              // Удаляет незавершенную запись, чтобы запрос можно было повторить.
+	DeleteExpired(ctx context.Context, now time.Time) (int, error)                                                                                // Удаляет истекшие записи всех пользователей и возвращает их количество.
+}
@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+// IManifestRepo - интерфейс для репозитория манифестов приемок и отчетов о расхождениях.
+type IManifestRepo interface {
+	Save(ctx context.Context, manifest *models.Manifest, expectedPVZVersion int) error            // Сохраняет манифест для открытой приемки ПВЗ или как ожидающий следующей приемки, заменяя предыдущий.
+	GetByReception(ctx context.Context, receptionID uuid.UUID) (*models.Manifest, error)          // Возвращает манифест, привязанный к приемке.
+	CountReceived(ctx context.Context, receptionID uuid.UUID) (map[models.ProductType]int, error) // Возвращает количество принятых товаров каждого типа в приемке.
+	SaveDiscrepancyReport(ctx context.Context, report *models.DiscrepancyReport) error            // Сохраняет отчет о расхождениях, заменяя ранее построенный для той же приемки.
+}
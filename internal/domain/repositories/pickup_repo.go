@@ -0,0 +1,20 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+// IPickupCodeRepo - интерфейс для репозитория кодов получения заказов.
+type IPickupCodeRepo interface {
+	OrdersWithoutCode(ctx context.Context, receptionID uuid.UUID) ([]uuid.UUID, error)            // Возвращает заказы с хранящимися товарами приемки, у которых в ПВЗ нет неиспользованного кода.
+	Save(ctx context.Context, code *models.PickupCode) error                                      // Сохраняет код, заменяя неиспользованный код того же заказа в ПВЗ.
+	GetActive(ctx context.Context, pvzID, orderID uuid.UUID) (*models.PickupCode, error)          // Возвращает неиспользованный код заказа в ПВЗ.
+	ReserveAttempt(ctx context.Context, codeID uuid.UUID, maxAttempts int) error                  // Увеличивает счетчик попыток неиспользованного кода, если попыток меньше maxAttempts.
+	ReleaseAttempt(ctx context.Context, codeID uuid.UUID) error                                   // Возвращает попытку, занятую ReserveAttempt.
+	RegisterFailure(ctx context.Context, pvzID uuid.UUID, failedAt, prunedBefore time.Time) error // Сохраняет неудачную попытку ввода кода в ПВЗ и удаляет попытки ПВЗ раньше prunedBefore.
+	CountFailures(ctx context.Context, pvzID uuid.UUID, since time.Time) (int, error)             // Возвращает количество неудачных попыток ввода кодов в ПВЗ начиная с since.
+}
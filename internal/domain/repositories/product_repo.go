@@ -8,6 +8,12 @@ import (
 
 // IProductRepo - интерфейс для репозитория товаров.
 type IProductRepo interface {
-	Create(ctx context.Context, product *models.AddProduct) (*models.Product, error) // Создает запись о товаре из доменной модели и возвращает ошибку.
-	DeleteLast(ctx context.Context, pvzID uuid.UUID) error                           // Удаляет последнюю запись о товаре из последней открытой приёмки указанного PVZ и возвращает ошибку.
+	Create(ctx context.Context, product *models.AddProduct, expectedReceptionVersion int) (*models.Product, error)                                                                // Создает запись о товаре в открытой приёмке, если её версия совпадает с ожидаемой.
+	CreateBatch(ctx context.Context, pvzID uuid.UUID, products []*models.AddProduct, mode models.BatchMode, expectedReceptionVersion int) (*models.AddProductsBatchResult, error) // Добавляет пакет товаров в открытую приёмку указанного PVZ в одной транзакции.
+	DeleteLast(ctx context.Context, pvzID uuid.UUID, expectedReceptionVersion int) (*models.Product, models.CityType, error)                                                      // Удаляет последнюю запись о товаре из последней открытой приёмки указанного PVZ, если её версия совпадает с ожидаемой, и возвращает её вместе с городом PVZ.
+	Issue(ctx context.Context, issuance *models.Issuance, pickupCodeID uuid.UUID, productIDs []uuid.UUID) (*models.Issuance, models.CityType, error)                              // Выдает клиенту хранящиеся в PVZ товары заказа из закрытых приёмок, расходуя код получения заказа после выдачи всех его товаров, и возвращает выдачу с товарами вместе с городом PVZ.
+	ListStoredByOrder(ctx context.Context, pvzID, orderID uuid.UUID) ([]*models.Product, error)                                                                                   // Возвращает хранящиеся в PVZ товары заказа из закрытых приёмок.
+	ListExpiring(ctx context.Context, pvzID uuid.UUID, deadlines []models.StorageDeadline) ([]*models.Product, models.CityType, error)                                            // Возвращает хранящиеся в PVZ товары из закрытых приёмок, принятые не позже границ deadlines, вместе с городом PVZ.
+	ReturnToSender(ctx context.Context, shipment *models.Shipment, deadlines []models.StorageDeadline) (*models.Shipment, models.CityType, error)                                 // Переносит хранящиеся в PVZ товары, принятые не позже границ deadlines, в отправку отправителю и возвращает её вместе с городом PVZ.
+	CountExpired(ctx context.Context, deadlines []models.StorageDeadline) (map[models.CityType]map[models.ProductType]int, error)                                                 // Возвращает количество хранящихся товаров из закрытых приёмок, принятых не позже границ deadlines, по городам и типам.
 }
@@ -4,10 +4,16 @@ import (
 	"context"
 	"github.com/google/uuid"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"time"
 )
 
 // IReceptionRepo - интерфейс для репозитория приемок.
 type IReceptionRepo interface {
-	CreateIfNoOpen(ctx context.Context, reception *models.Reception) error     // Создает запись о приемке из доменной модели и возвращает ошибку.
-	CloseLast(ctx context.Context, pvzID uuid.UUID) (*models.Reception, error) // Закрывает последнюю открытую приемку для указанного PVZ и возвращает ошибку.
+	CreateIfNoOpen(ctx context.Context, reception *models.Reception, expectedPVZVersion int) error                                                      // Создает запись о приемке из доменной модели и возвращает ошибку.
+	CloseLast(ctx context.Context, pvzID, closedBy uuid.UUID, closedAt time.Time, expectedVersion int) (*models.Reception, models.CityType, error)      // Закрывает последнюю открытую приемку для указанного PVZ, сохраняя время и автора закрытия и отчет о расхождениях с манифестом, и возвращает её вместе с городом PVZ.
+	CancelLast(ctx context.Context, pvzID, cancelledBy uuid.UUID, cancelledAt time.Time, reason string, expectedVersion int) (*models.Reception, error) // Отменяет открытую приемку для указанного PVZ, сохраняя время, автора и причину отмены.
+	GetLast(ctx context.Context, pvzID uuid.UUID) (*models.Reception, error)                                                                            // Возвращает последнюю по времени открытия приемку для указанного PVZ.
+	Reopen(ctx context.Context, receptionID, reopenedBy uuid.UUID, reopenedAt time.Time, expectedVersion int) (*models.Reception, error)                // Повторно открывает закрытую приемку, если она последняя в PVZ и нет открытых приемок.
+	CloseStale(ctx context.Context, city models.CityType, openedBefore, closedAt time.Time, reason string) ([]*models.Reception, error)                 // Автоматически закрывает приемки в городе, открытые раньше openedBefore, сохраняя отчеты о расхождениях, и возвращает их.
+	LockAutoClose(ctx context.Context) (func(), error)                                                                                                  // Захватывает блокировку автоматического закрытия на весь проход по городам и возвращает функцию её снятия.
 }
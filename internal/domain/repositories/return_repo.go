@@ -0,0 +1,16 @@
+package repositories
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"time"
+)
+
+// IReturnRepo - интерфейс для репозитория возвратов товаров клиентами.
+type IReturnRepo interface {
+	Register(ctx context.Context, ret *models.CustomerReturn) (*models.CustomerReturn, models.CityType, error)           // Сохраняет возврат в собираемой отправке возвратов ПВЗ, создавая её при необходимости, и возвращает его вместе с городом ПВЗ.
+	ListByPVZ(ctx context.Context, pvzID uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error)     // Возвращает возвраты ПВЗ за период.
+	ListByPVZs(ctx context.Context, pvzIDs []uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) // Возвращает возвраты нескольких ПВЗ за период.
+	Dispatch(ctx context.Context, pvzID uuid.UUID, dispatchedAt time.Time) (*models.Shipment, error)                     // Отправляет собираемую отправку возвратов ПВЗ и возвращает её вместе с возвратами.
+}
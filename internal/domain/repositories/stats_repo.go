@@ -0,0 +1,15 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+// IStatsRepo - интерфейс для репозитория статистики по приемкам и товарам.
+type IStatsRepo interface {
+	GetPVZStats(ctx context.Context, filter *models.StatsFilter) ([]*models.PVZStats, error)                 // Возвращает агрегированную статистику приемок по каждому ПВЗ за период.
+	GetProductTypeStats(ctx context.Context, filter *models.StatsFilter) ([]*models.ProductTypeStats, error) // Возвращает количество товаров по типам в разрезе городов и дней за период.
+	GetBusinessSnapshot(ctx context.Context, dayStart time.Time) (*models.BusinessSnapshot, error)           // Возвращает открытые приемки, товары, принятые начиная с dayStart, и количество ПВЗ.
+}
@@ -1,11 +1,12 @@
 package auth
 
 import (
-	"net/http"
+	"crypto/subtle"
 	"strings"
 
 	"github.com/gin-gonic/gin"
 	commonerrors "github.com/maksemen2/pvz-service/internal/common/errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"go.uber.org/zap"
 )
 
@@ -21,14 +22,14 @@ func NewGinMiddleware(logger *zap.Logger, tokenManager TokenManager) gin.Handler
 		tokenStr := c.GetHeader("Authorization")
 		if tokenStr == "" {
 			logger.Debug("unauthorized access")
-			c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.Unauthorized())
+			commonerrors.Unauthorized(c)
 
 			return
 		}
 
 		if !strings.HasPrefix(tokenStr, authHeaderPrefix) {
 			logger.Debug("invalid token", zap.String("token", tokenStr))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.Unauthorized())
+			commonerrors.Unauthorized(c)
 
 			return
 		}
@@ -38,17 +39,45 @@ func NewGinMiddleware(logger *zap.Logger, tokenManager TokenManager) gin.Handler
 		claims, err := tokenManager.Parse(token)
 		if err != nil {
 			logger.Debug("invalid token", zap.String("token", token), zap.Error(err))
-			c.AbortWithStatusJSON(http.StatusUnauthorized, commonerrors.Unauthorized())
+			commonerrors.Unauthorized(c)
 
 			return
 		}
 
+		userID, role := claims.GetUserID(), claims.GetRole()
+
 		// Прокидываем айди и роль в контекст
-		c.Set(UserIDKey, claims.GetUserID())
-		c.Set(RoleKey, claims.GetRole())
+		c.Set(UserIDKey, userID)
+		c.Set(RoleKey, role)
+
+		// И в поля логгера контекста запроса, чтобы они попадали в логи сервисов и репозиториев
+		c.Request = c.Request.WithContext(l.NewContext(c.Request.Context(),
+			zap.String(UserIDKey, userID.String()),
+			zap.String(RoleKey, role),
+		))
 
 		logger.Debug("access granted")
 
 		c.Next()
 	}
 }
+
+// NewStaticTokenMiddleware возвращает мидлварь для GIN, пропускающую только запросы с заданным Bearer токеном.
+// Токен сравнивается за постоянное время. Пустой токен запрещает доступ всем запросам.
+func NewStaticTokenMiddleware(logger *zap.Logger, token string) gin.HandlerFunc {
+	expected := []byte(token)
+
+	return func(c *gin.Context) {
+		tokenStr := c.GetHeader("Authorization")
+
+		got, ok := strings.CutPrefix(tokenStr, authHeaderPrefix)
+		if !ok || token == "" || subtle.ConstantTimeCompare([]byte(got), expected) != 1 {
+			logger.Debug("unauthorized access")
+			commonerrors.Unauthorized(c)
+
+			return
+		}
+
+		c.Next()
+	}
+}
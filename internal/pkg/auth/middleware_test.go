@@ -117,3 +117,45 @@ func TestAuthMiddleware(t *testing.T) {
 		assert.Equal(t, http.StatusUnauthorized, w.Code)
 	})
 }
+
+func TestStaticTokenMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	newRouter := func(token string) *gin.Engine {
+		router := gin.New()
+		router.Use(auth.NewStaticTokenMiddleware(zap.NewNop(), token))
+		router.GET("/test", func(c *gin.Context) {
+			c.Status(http.StatusOK)
+		})
+
+		return router
+	}
+
+	tests := []struct {
+		name           string
+		token          string
+		header         string
+		expectedStatus int
+	}{
+		{name: "No Authorization header", token: "admin-token", expectedStatus: http.StatusUnauthorized},
+		{name: "Wrong token", token: "admin-token", header: "Bearer other-token", expectedStatus: http.StatusUnauthorized},
+		{name: "Token without Bearer prefix", token: "admin-token", header: "admin-token", expectedStatus: http.StatusUnauthorized},
+		{name: "Token is not configured", token: "", header: "Bearer ", expectedStatus: http.StatusUnauthorized},
+		{name: "Valid token", token: "admin-token", header: "Bearer admin-token", expectedStatus: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			req, _ := http.NewRequest("GET", "/test", nil)
+
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			newRouter(tt.token).ServeHTTP(w, req)
+
+			assert.Equal(t, tt.expectedStatus, w.Code)
+		})
+	}
+}
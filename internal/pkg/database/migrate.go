@@ -0,0 +1,129 @@
+package database
+
+import (
+	"context"
+	"fmt"
+	"io/fs"
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap"
+)
+
+// migrationsLockKey - ключ advisory-блокировки, которая сериализует применение миграций несколькими экземплярами сервиса.
+const migrationsLockKey = "schema_migrations"
+
+const createMigrationsTable = `
+CREATE TABLE IF NOT EXISTS schema_migrations (
+    version BIGINT PRIMARY KEY,
+    applied_at TIMESTAMP NOT NULL DEFAULT now()
+)`
+
+// migration - файл миграции схемы.
+type migration struct {
+	version int64
+	name    string
+	query   string
+}
+
+// loadMigrations читает миграции из files в порядке версий.
+// Файлы называются NNNN_описание.sql, версии не должны повторяться.
+func loadMigrations(files fs.FS) ([]migration, error) {
+	names, err := fs.Glob(files, "*.sql")
+	if err != nil {
+		return nil, err
+	}
+
+	migrations := make([]migration, 0, len(names))
+	versions := make(map[int64]string, len(names))
+
+	for _, name := range names {
+		prefix, _, ok := strings.Cut(name, "_")
+
+		version, err := strconv.ParseInt(prefix, 10, 64)
+		if !ok || err != nil || version <= 0 {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+
+		if other, exists := versions[version]; exists {
+			return nil, fmt.Errorf("migrations %q and %q have the same version", other, name)
+		}
+
+		versions[version] = name
+
+		query, err := fs.ReadFile(files, name)
+		if err != nil {
+			return nil, err
+		}
+
+		migrations = append(migrations, migration{version: version, name: name, query: string(query)})
+	}
+
+	sort.Slice(migrations, func(i, j int) bool {
+		return migrations[i].version < migrations[j].version
+	})
+
+	return migrations, nil
+}
+
+// Migrate применяет миграции из files, версии которых еще нет в таблице schema_migrations.
+// Каждая миграция применяется в своей транзакции вместе с записью её версии,
+// поэтому после ошибки база остается на последней успешно примененной версии.
+// Экземпляры сервиса, запущенные одновременно, применяют миграции по очереди.
+func (d *PostgresDB) Migrate(ctx context.Context, files fs.FS) error {
+	migrations, err := loadMigrations(files)
+	if err != nil {
+		return fmt.Errorf("failed to load migrations: %w", err)
+	}
+
+	for _, m := range migrations {
+		applied, err := d.applyMigration(ctx, m)
+		if err != nil {
+			return fmt.Errorf("failed to apply migration %s: %w", m.name, err)
+		}
+
+		if applied {
+			d.logger.Info("migration applied", zap.Int64("version", m.version), zap.String("name", m.name))
+		}
+	}
+
+	return nil
+}
+
+// applyMigration применяет миграцию, если она еще не применена. Возвращает true, если миграция применена сейчас.
+func (d *PostgresDB) applyMigration(ctx context.Context, m migration) (bool, error) {
+	tx, err := d.BeginTxx(ctx, nil)
+	if err != nil {
+		return false, err
+	}
+	defer TxRollback(tx, d.logger)
+
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock(hashtext($1))`, migrationsLockKey); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, createMigrationsTable); err != nil {
+		return false, err
+	}
+
+	var applied bool
+
+	if err := tx.GetContext(ctx, &applied, `SELECT EXISTS (SELECT 1 FROM schema_migrations WHERE version = $1)`, m.version); err != nil {
+		return false, err
+	}
+
+	if applied {
+		return false, nil
+	}
+
+	if _, err := tx.ExecContext(ctx, m.query); err != nil {
+		return false, err
+	}
+
+	if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, m.version); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
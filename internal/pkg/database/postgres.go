@@ -1,11 +1,18 @@
 package database
 
 import (
+	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"github.com/jmoiron/sqlx"
 	"github.com/lib/pq"
 	"github.com/maksemen2/pvz-service/config"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/uptrace/opentelemetry-go-extra/otelsql"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
 	"go.uber.org/zap"
 )
 
@@ -15,33 +22,59 @@ const (
 	PGUniqueViolationCode = "23505" // Уникальное ограничение нарушено
 )
 
+// Значения метки result метрики metrics.DBTxRollbacks.
+const (
+	rollbackResultOK    = "ok"
+	rollbackResultError = "error"
+)
+
 // PostgresDB - структура для работы с PostgreSQL.
 type PostgresDB struct {
 	*sqlx.DB
-	logger *zap.Logger
+	logger         *zap.Logger
+	statsCollector prometheus.Collector
 }
 
 // NewPostgresDB - создает новое подключение к PostgreSQL.
 func NewPostgresDB(config config.DatabaseConfig, logger *zap.Logger) (*PostgresDB, error) {
-	db, err := sqlx.Connect("postgres", config.DSN())
+	// Драйвер оборачивается otelsql, чтобы каждый запрос записывался спаном в текущую трассировку
+	sqlDB, err := otelsql.Open("postgres", config.DSN(),
+		otelsql.WithDBSystem(semconv.DBSystemPostgreSQL.Value.AsString()),
+		otelsql.WithDBName(config.DBName),
+	)
 	if err != nil {
 		logger.Error("failed to connect to postgres", zap.Error(err))
 		return nil, err
 	}
 
+	db := sqlx.NewDb(sqlDB, "postgres")
+
 	logger.Info("connected to postgres")
 
 	if err := db.Ping(); err != nil {
 		logger.Error("failed to ping postgres", zap.Error(err))
+		db.Close()
+
 		return nil, err
 	}
 
 	db.SetMaxOpenConns(config.MaxOpenConnections)
 	db.SetMaxIdleConns(config.MaxIdleConnections)
 
+	// Статистика пула соединений (открытые, занятые, простаивающие соединения и ожидания)
+	// собирается при каждом запросе метрик
+	statsCollector := collectors.NewDBStatsCollector(db.DB, config.DBName)
+	if err := metrics.Registry.Register(statsCollector); err != nil {
+		// Коллектор уже зарегистрирован другим открытым подключением к той же базе
+		logger.Warn("failed to register database stats collector", zap.Error(err))
+
+		statsCollector = nil
+	}
+
 	return &PostgresDB{
-		DB:     db,
-		logger: logger,
+		DB:             db,
+		logger:         logger,
+		statsCollector: statsCollector,
 	}, nil
 }
 
@@ -49,8 +82,12 @@ func NewPostgresDB(config config.DatabaseConfig, logger *zap.Logger) (*PostgresD
 func (d *PostgresDB) Close() error {
 	d.logger.Info("closing postgres connection")
 
+	if d.statsCollector != nil {
+		metrics.Registry.Unregister(d.statsCollector)
+	}
+
 	if err := d.DB.Close(); err != nil {
-		d.logger.Error("failed to close postgres connection", zap.Error(err))
+		return fmt.Errorf("failed to close postgres connection: %w", err)
 	}
 
 	d.logger.Info("postgres connection closed")
@@ -58,6 +95,17 @@ func (d *PostgresDB) Close() error {
 	return nil
 }
 
+// MigrationVersion возвращает версию схемы базы данных - версию последней примененной миграции (см. Migrate).
+func (d *PostgresDB) MigrationVersion(ctx context.Context) (int64, error) {
+	var version int64
+
+	if err := d.GetContext(ctx, &version, `SELECT MAX(version) FROM schema_migrations`); err != nil {
+		return 0, err
+	}
+
+	return version, nil
+}
+
 // IsPGError - проверяет, является ли ошибка
 // ошибкой PostgreSQL с указанным кодом.
 func IsPGError(err error, code string) bool {
@@ -70,9 +118,16 @@ func IsPGError(err error, code string) bool {
 
 // TxRollback - хелпер для отката транзакции.
 // Предполагается, что эта функция должна использоваться в defer
-// Если транзакция уже завершена, то ошибка будет игнорироваться
+// Если транзакция уже завершена, то ошибка будет игнорироваться.
+// Каждый откат учитывается в метрике metrics.DBTxRollbacks.
 func TxRollback(tx *sqlx.Tx, logger *zap.Logger) {
-	if err := tx.Rollback(); err != nil && !errors.Is(err, sql.ErrTxDone) {
+	err := tx.Rollback()
+
+	switch {
+	case err == nil:
+		metrics.DBTxRollbacks.WithLabelValues(rollbackResultOK).Inc()
+	case !errors.Is(err, sql.ErrTxDone):
+		metrics.DBTxRollbacks.WithLabelValues(rollbackResultError).Inc()
 		logger.Error("failed to rollback transaction", zap.Error(err))
 	}
 }
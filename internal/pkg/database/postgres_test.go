@@ -4,13 +4,18 @@
 package database_test
 
 import (
+	"context"
 	"fmt"
 	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
 	"github.com/maksemen2/pvz-service/internal/pkg/testhelpers"
+	"github.com/maksemen2/pvz-service/migrations"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 	"go.uber.org/zap"
 	"testing"
+	"testing/fstest"
 )
 
 func TestOpenClose(t *testing.T) {
@@ -57,3 +62,128 @@ func TestIsPGError(t *testing.T) {
 	nonPgErr := fmt.Errorf("ordinary error")
 	assert.False(t, database.IsPGError(nonPgErr, database.PGUniqueViolationCode))
 }
+
+func TestTxRollback_Metrics(t *testing.T) {
+	cfg, cleanup := testhelpers.SetupPostgresContainer(t)
+	defer cleanup()
+
+	logger := zap.NewNop()
+	db, err := database.NewPostgresDB(cfg, logger)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	rolledBack := testutil.ToFloat64(metrics.DBTxRollbacks.WithLabelValues("ok"))
+
+	tx, err := db.Beginx()
+	require.NoError(t, err)
+
+	database.TxRollback(tx, logger)
+	assert.Equal(t, rolledBack+1, testutil.ToFloat64(metrics.DBTxRollbacks.WithLabelValues("ok")))
+
+	// Откат уже завершенной транзакции не учитывается
+	tx, err = db.Beginx()
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+
+	database.TxRollback(tx, logger)
+	assert.Equal(t, rolledBack+1, testutil.ToFloat64(metrics.DBTxRollbacks.WithLabelValues("ok")))
+
+	count, err := testutil.GatherAndCount(metrics.Registry, "go_sql_open_connections")
+	require.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+func TestMigrationVersion(t *testing.T) {
+	cfg, cleanup := testhelpers.SetupPostgresContainer(t)
+	defer cleanup()
+
+	db, err := database.NewPostgresDB(cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	// Таблицы версий еще нет
+	_, err = db.MigrationVersion(context.Background())
+	assert.Error(t, err)
+
+	cleanDB, err := testhelpers.CreateTestDB(db)
+	require.NoError(t, err)
+
+	defer cleanDB()
+
+	version, err := db.MigrationVersion(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, int64(6), version)
+
+	// Повторный запуск не применяет миграции заново
+	require.NoError(t, db.Migrate(context.Background(), migrations.FS))
+
+	var applied int
+
+	require.NoError(t, db.Get(&applied, `SELECT COUNT(*) FROM schema_migrations`))
+	assert.Equal(t, 6, applied)
+}
+
+func TestMigrate_UpgradesExistingDatabase(t *testing.T) {
+	cfg, cleanup := testhelpers.SetupPostgresContainer(t)
+	defer cleanup()
+
+	db, err := database.NewPostgresDB(cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	// База, созданная до появления версий схемы
+	_, err = db.Exec(`
+CREATE TABLE pvzs (id UUID PRIMARY KEY, registration_date TIMESTAMP NOT NULL, city VARCHAR(50) NOT NULL);
+CREATE TABLE receptions (id UUID PRIMARY KEY, pvz_id UUID NOT NULL REFERENCES pvzs(id), date_time TIMESTAMP NOT NULL, status VARCHAR(20) NOT NULL);
+CREATE TABLE products (id UUID PRIMARY KEY, date_time TIMESTAMP NOT NULL, type VARCHAR(50) NOT NULL, reception_id UUID NOT NULL REFERENCES receptions(id));
+INSERT INTO pvzs VALUES ('11111111-1111-1111-1111-111111111111', now(), 'Москва');
+INSERT INTO receptions VALUES ('22222222-2222-2222-2222-222222222222', '11111111-1111-1111-1111-111111111111', now(), 'close');
+INSERT INTO products VALUES ('33333333-3333-3333-3333-333333333333', now(), 'обувь', '22222222-2222-2222-2222-222222222222');
+`)
+	require.NoError(t, err)
+
+	cleanDB, err := testhelpers.CreateTestDB(db)
+	require.NoError(t, err)
+
+	defer cleanDB()
+
+	var product struct {
+		Status  string `db:"status"`
+		OrderID string `db:"order_id"`
+	}
+
+	// Существующие строки получают значения новых колонок
+	require.NoError(t, db.Get(&product, `SELECT status, order_id FROM products`))
+	assert.Equal(t, "stored", product.Status)
+	assert.Equal(t, "33333333-3333-3333-3333-333333333333", product.OrderID)
+
+	var version int
+
+	require.NoError(t, db.Get(&version, `SELECT version FROM pvzs`))
+	assert.Equal(t, 1, version)
+}
+
+func TestMigrate_InvalidFileName(t *testing.T) {
+	cfg, cleanup := testhelpers.SetupPostgresContainer(t)
+	defer cleanup()
+
+	db, err := database.NewPostgresDB(cfg, zap.NewNop())
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	err = db.Migrate(context.Background(), fstest.MapFS{
+		"0001_base.sql": {Data: []byte(`SELECT 1`)},
+		"init.sql":      {Data: []byte(`SELECT 1`)},
+	})
+	assert.Error(t, err)
+
+	err = db.Migrate(context.Background(), fstest.MapFS{
+		"0001_base.sql":  {Data: []byte(`SELECT 1`)},
+		"0001_other.sql": {Data: []byte(`SELECT 1`)},
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,120 @@
+// Пакет health реализует проверки живости и готовности сервиса.
+// Готовность учитывает доступность базы данных, состояние gRPC сервера и флаг готовности,
+// который снимается в начале остановки, чтобы балансировщик успел снять трафик с реплики.
+package health
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+const defaultPingTimeout = 2 * time.Second
+
+// Database - база данных, доступность которой проверяется при проверке готовности.
+type Database interface {
+	PingContext(ctx context.Context) error
+	MigrationVersion(ctx context.Context) (int64, error)
+}
+
+// DependencyStatus - результат проверки зависимости. Err равна nil, если зависимость доступна.
+type DependencyStatus struct {
+	Err error
+}
+
+// Up сообщает, доступна ли зависимость.
+func (s DependencyStatus) Up() bool {
+	return s.Err == nil
+}
+
+// Report - результат проверки готовности.
+type Report struct {
+	Ready            bool
+	Database         DependencyStatus
+	MigrationVersion *int64 // nil, если версию схемы не удалось определить
+	GRPC             healthpb.HealthCheckResponse_ServingStatus
+}
+
+// Checker проверяет готовность сервиса.
+// Он же владеет сервером grpc.health.v1, который регистрируется на gRPC сервере (см. GRPCHealthServer).
+type Checker struct {
+	db          Database
+	grpcHealth  *health.Server
+	pingTimeout time.Duration
+	ready       atomic.Bool
+}
+
+// NewChecker принимает базу данных и таймаут её проверки. Если таймаут не положительный, используется 2 секунды.
+// Если db равна nil (данные хранятся в памяти процесса), база данных всегда считается доступной.
+// Созданный Checker не готов, пока не будет вызван MarkReady, а gRPC сервер считается не обслуживающим
+// запросы, пока не будет запущен.
+func NewChecker(db Database, pingTimeout time.Duration) *Checker {
+	if pingTimeout <= 0 {
+		pingTimeout = defaultPingTimeout
+	}
+
+	grpcHealth := health.NewServer()
+	grpcHealth.SetServingStatus("", healthpb.HealthCheckResponse_NOT_SERVING)
+
+	return &Checker{
+		db:          db,
+		grpcHealth:  grpcHealth,
+		pingTimeout: pingTimeout,
+	}
+}
+
+// GRPCHealthServer возвращает сервер grpc.health.v1. gRPC сервер выставляет в нем свое состояние.
+func (c *Checker) GRPCHealthServer() *health.Server {
+	return c.grpcHealth
+}
+
+// MarkReady отмечает сервис готовым принимать запросы после запуска всех компонентов.
+func (c *Checker) MarkReady() {
+	c.ready.Store(true)
+}
+
+// MarkNotReady отмечает сервис неготовым и переводит grpc.health.v1 в NOT_SERVING.
+// Вызывается в начале остановки, дальнейшие изменения состояния gRPC сервера игнорируются.
+func (c *Checker) MarkNotReady() {
+	c.ready.Store(false)
+	c.grpcHealth.Shutdown()
+}
+
+// Ready проверяет доступность базы данных с таймаутом и состояние gRPC сервера.
+// Версия схемы - максимальная версия из таблицы schema_migrations, которую заполняет database.Migrate при старте.
+// Миграции применяются до MarkReady, поэтому версия только сообщается и на готовность не влияет:
+// если её не удалось прочитать или база не используется (хранилище в памяти), поле остается пустым.
+func (c *Checker) Ready(ctx context.Context) Report {
+	ctx, cancel := context.WithTimeout(ctx, c.pingTimeout)
+	defer cancel()
+
+	report := Report{
+		GRPC: healthpb.HealthCheckResponse_UNKNOWN,
+	}
+
+	if c.db != nil {
+		report.Database.Err = c.db.PingContext(ctx)
+	}
+
+	if c.db != nil && report.Database.Up() {
+		if version, err := c.db.MigrationVersion(ctx); err == nil {
+			report.MigrationVersion = &version
+		}
+	}
+
+	if resp, err := c.grpcHealth.Check(ctx, &healthpb.HealthCheckRequest{}); err == nil {
+		report.GRPC = resp.GetStatus()
+	}
+
+	report.Ready = c.ready.Load() && report.Database.Up() && report.GRPC == healthpb.HealthCheckResponse_SERVING
+
+	return report
+}
+
+// ReadinessChecker - интерфейс проверки готовности, реализуемый Checker.
+type ReadinessChecker interface {
+	Ready(ctx context.Context) Report
+}
@@ -0,0 +1,109 @@
+//go:build unit
+// +build unit
+
+package health_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/pkg/health"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+)
+
+type fakeDatabase struct {
+	pingErr    error
+	version    int64
+	versionErr error
+	delay      time.Duration
+}
+
+func (d *fakeDatabase) PingContext(ctx context.Context) error {
+	if d.delay > 0 {
+		select {
+		case <-time.After(d.delay):
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+
+	return d.pingErr
+}
+
+func (d *fakeDatabase) MigrationVersion(context.Context) (int64, error) {
+	return d.version, d.versionErr
+}
+
+func servingChecker(db health.Database) *health.Checker {
+	checker := health.NewChecker(db, 50*time.Millisecond)
+	checker.GRPCHealthServer().SetServingStatus("", healthpb.HealthCheckResponse_SERVING)
+	checker.MarkReady()
+
+	return checker
+}
+
+func TestChecker_Ready(t *testing.T) {
+	t.Run("Ready", func(t *testing.T) {
+		report := servingChecker(&fakeDatabase{version: 3}).Ready(context.Background())
+
+		assert.True(t, report.Ready)
+		assert.True(t, report.Database.Up())
+		require.NotNil(t, report.MigrationVersion)
+		assert.Equal(t, int64(3), *report.MigrationVersion)
+		assert.Equal(t, healthpb.HealthCheckResponse_SERVING, report.GRPC)
+	})
+
+	t.Run("Not ready until marked", func(t *testing.T) {
+		checker := health.NewChecker(&fakeDatabase{}, time.Second)
+
+		report := checker.Ready(context.Background())
+
+		assert.False(t, report.Ready)
+		assert.True(t, report.Database.Up())
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, report.GRPC)
+	})
+
+	t.Run("Database down", func(t *testing.T) {
+		report := servingChecker(&fakeDatabase{pingErr: errors.New("connection refused")}).Ready(context.Background())
+
+		assert.False(t, report.Ready)
+		assert.False(t, report.Database.Up())
+		assert.Nil(t, report.MigrationVersion)
+	})
+
+	t.Run("Database ping timeout", func(t *testing.T) {
+		report := servingChecker(&fakeDatabase{delay: time.Second}).Ready(context.Background())
+
+		assert.False(t, report.Ready)
+		assert.ErrorIs(t, report.Database.Err, context.DeadlineExceeded)
+	})
+
+	t.Run("Unknown migration version does not affect readiness", func(t *testing.T) {
+		report := servingChecker(&fakeDatabase{versionErr: errors.New("relation does not exist")}).Ready(context.Background())
+
+		assert.True(t, report.Ready)
+		assert.Nil(t, report.MigrationVersion)
+	})
+
+	t.Run("Without database", func(t *testing.T) {
+		report := servingChecker(nil).Ready(context.Background())
+
+		assert.True(t, report.Ready)
+		assert.True(t, report.Database.Up())
+		assert.Nil(t, report.MigrationVersion)
+	})
+
+	t.Run("Not ready after MarkNotReady", func(t *testing.T) {
+		checker := servingChecker(&fakeDatabase{})
+		checker.MarkNotReady()
+
+		report := checker.Ready(context.Background())
+
+		assert.False(t, report.Ready)
+		assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, report.GRPC)
+	})
+}
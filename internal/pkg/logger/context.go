@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel/trace"
+	"go.uber.org/zap"
+)
+
+// Поля лога с айди трассировки и спана OpenTelemetry.
+const (
+	TraceIDField = "traceID"
+	SpanIDField  = "spanID"
+)
+
+type fieldsKey struct{}
+
+// NewContext возвращает копию контекста с дополнительными полями для логирования
+// (например, айди запроса, айди пользователя и роль).
+// Поля добавляются к уже сохраненным в контексте.
+func NewContext(ctx context.Context, fields ...zap.Field) context.Context {
+	existing := fieldsFromContext(ctx)
+
+	merged := make([]zap.Field, 0, len(existing)+len(fields))
+	merged = append(merged, existing...)
+	merged = append(merged, fields...)
+
+	return context.WithValue(ctx, fieldsKey{}, merged)
+}
+
+// FromContext возвращает логгер с полями, сохраненными в контексте с помощью NewContext,
+// а также с айди трассировки и спана, если в контексте есть спан OpenTelemetry.
+// Если полей нет, возвращает переданный логгер.
+func FromContext(ctx context.Context, logger *zap.Logger) *zap.Logger {
+	fields := fieldsFromContext(ctx)
+
+	if ctx != nil {
+		if spanContext := trace.SpanContextFromContext(ctx); spanContext.IsValid() {
+			// Ограничиваем емкость, чтобы не изменить слайс, сохраненный в контексте
+			fields = append(fields[:len(fields):len(fields)],
+				zap.String(TraceIDField, spanContext.TraceID().String()),
+				zap.String(SpanIDField, spanContext.SpanID().String()),
+			)
+		}
+	}
+
+	if len(fields) == 0 {
+		return logger
+	}
+
+	return logger.With(fields...)
+}
+
+func fieldsFromContext(ctx context.Context) []zap.Field {
+	if ctx == nil {
+		return nil
+	}
+
+	fields, _ := ctx.Value(fieldsKey{}).([]zap.Field)
+
+	return fields
+}
@@ -0,0 +1,31 @@
+package logger
+
+import (
+	"context"
+	"time"
+
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor - интерсептор логирования для gRPC сервера.
+// Логирует вызов в том же формате, что и NewMiddleware: вместо HTTP метода и пути
+// используется полное имя gRPC метода, вместо HTTP статуса - код gRPC.
+// Должен стоять после интерсептора, добавляющего айди запроса.
+func UnaryServerInterceptor(logger *zap.Logger) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		FromContext(ctx, logger).Info("Request",
+			zap.String("method", "gRPC"),
+			zap.String("path", info.FullMethod),
+			zap.String("status", status.Code(err).String()),
+			zap.Duration("duration", time.Since(start)),
+		)
+
+		return resp, err
+	}
+}
@@ -0,0 +1,201 @@
+package logger
+
+import (
+	"fmt"
+	"strings"
+	"sync"
+
+	"github.com/maksemen2/pvz-service/config"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// Уровень "silent": выше любого уровня zap, поэтому в лог не попадает ничего.
+const silentLevel = zapcore.FatalLevel + 1
+
+// Levels хранит уровни логирования, которые можно менять во время работы:
+// общий уровень и переопределения для отдельных пакетов.
+// Пакет определяется по имени логгера (zap.Logger.Named), переопределение
+// для "postgresqlrepo" действует и на вложенные логгеры "postgresqlrepo.*".
+type Levels struct {
+	global   zap.AtomicLevel
+	mu       sync.RWMutex
+	packages map[string]zapcore.Level
+}
+
+// NewLevels создает уровни логирования из конфигурации.
+func NewLevels(cfg config.LoggingConfig) (*Levels, error) {
+	levels := &Levels{
+		global:   zap.NewAtomicLevel(),
+		packages: make(map[string]zapcore.Level),
+	}
+
+	if err := levels.Apply(cfg); err != nil {
+		return nil, err
+	}
+
+	return levels, nil
+}
+
+// Apply выставляет общий уровень и переопределения пакетов из конфигурации.
+// Переопределения, выставленные во время работы, сбрасываются.
+func (l *Levels) Apply(cfg config.LoggingConfig) error {
+	global, err := parseLevel(cfg.Level)
+	if err != nil {
+		return err
+	}
+
+	packages := make(map[string]zapcore.Level, len(cfg.PackageLevels))
+
+	for name, level := range cfg.PackageLevels {
+		packages[name], err = parseLevel(level)
+		if err != nil {
+			return fmt.Errorf("package %s: %w", name, err)
+		}
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.global.SetLevel(global)
+	l.packages = packages
+
+	return nil
+}
+
+// Level возвращает общий уровень логирования.
+func (l *Levels) Level() string {
+	return levelName(l.global.Level())
+}
+
+// SetLevel меняет общий уровень логирования.
+func (l *Levels) SetLevel(level string) error {
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.global.SetLevel(parsed)
+
+	return nil
+}
+
+// Packages возвращает переопределения уровней по именам логгеров пакетов.
+func (l *Levels) Packages() map[string]string {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	packages := make(map[string]string, len(l.packages))
+	for name, level := range l.packages {
+		packages[name] = levelName(level)
+	}
+
+	return packages
+}
+
+// SetPackageLevel переопределяет уровень логирования для логгера пакета.
+func (l *Levels) SetPackageLevel(name, level string) error {
+	if name == "" {
+		return fmt.Errorf("empty package name")
+	}
+
+	parsed, err := parseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.packages[name] = parsed
+
+	return nil
+}
+
+// enabled проверяет, пишется ли сообщение уровня level логгера с именем name.
+func (l *Levels) enabled(name string, level zapcore.Level) bool {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for name != "" {
+		if packageLevel, ok := l.packages[name]; ok {
+			return packageLevel.Enabled(level)
+		}
+
+		// Переходим к родительскому логгеру: "postgresqlrepo.pvz" -> "postgresqlrepo"
+		dot := strings.LastIndexByte(name, '.')
+		if dot < 0 {
+			break
+		}
+
+		name = name[:dot]
+	}
+
+	return l.global.Enabled(level)
+}
+
+// anyEnabled проверяет, может ли сообщение уровня level попасть в лог хотя бы одного логгера.
+func (l *Levels) anyEnabled(level zapcore.Level) bool {
+	if l.global.Enabled(level) {
+		return true
+	}
+
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, packageLevel := range l.packages {
+		if packageLevel.Enabled(level) {
+			return true
+		}
+	}
+
+	return false
+}
+
+func parseLevel(level string) (zapcore.Level, error) {
+	switch level {
+	case "debug":
+		return zap.DebugLevel, nil
+	case "info":
+		return zap.InfoLevel, nil
+	case "warn":
+		return zap.WarnLevel, nil
+	case "error":
+		return zap.ErrorLevel, nil
+	case "silent":
+		return silentLevel, nil
+	default:
+		return 0, fmt.Errorf("unknown log level %q", level)
+	}
+}
+
+func levelName(level zapcore.Level) string {
+	if level == silentLevel {
+		return "silent"
+	}
+
+	return level.String()
+}
+
+// levelCore пропускает во вложенное ядро только сообщения, разрешенные Levels для имени логгера.
+type levelCore struct {
+	zapcore.Core
+	levels *Levels
+}
+
+func (c *levelCore) Enabled(level zapcore.Level) bool {
+	return c.levels.anyEnabled(level)
+}
+
+func (c *levelCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelCore{Core: c.Core.With(fields), levels: c.levels}
+}
+
+// Check передает проверку вложенному ядру, чтобы сохранить сэмплирование.
+func (c *levelCore) Check(entry zapcore.Entry, checked *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.levels.enabled(entry.LoggerName, entry.Level) {
+		return checked
+	}
+
+	return c.Core.Check(entry, checked)
+}
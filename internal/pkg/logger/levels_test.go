@@ -0,0 +1,101 @@
+//go:build unit
+// +build unit
+
+package logger_test
+
+import (
+	"testing"
+
+	"github.com/maksemen2/pvz-service/config"
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func newObservedLogger(t *testing.T, cfg config.LoggingConfig) (*zap.Logger, *logger.Levels, *observer.ObservedLogs) {
+	t.Helper()
+
+	levels, err := logger.NewLevels(cfg)
+	require.NoError(t, err)
+
+	core, logs := observer.New(zapcore.DebugLevel)
+
+	return zap.New(logger.NewCore(core, levels)), levels, logs
+}
+
+func TestLevels_Runtime(t *testing.T) {
+	log, levels, logs := newObservedLogger(t, config.LoggingConfig{Level: "info"})
+
+	log.Debug("hidden")
+	assert.Equal(t, 0, logs.Len())
+
+	require.NoError(t, levels.SetLevel("debug"))
+	log.Debug("visible")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "debug", levels.Level())
+
+	require.NoError(t, levels.SetLevel("silent"))
+	log.Error("hidden")
+	assert.Equal(t, 1, logs.Len())
+	assert.Equal(t, "silent", levels.Level())
+
+	assert.Error(t, levels.SetLevel("verbose"))
+}
+
+func TestLevels_PackageOverride(t *testing.T) {
+	log, levels, logs := newObservedLogger(t, config.LoggingConfig{
+		Level:         "info",
+		PackageLevels: map[string]string{"postgresqlrepo": "debug", "service": "error"},
+	})
+
+	log.Debug("root debug")
+	log.Named("postgresqlrepo").Debug("repo debug")
+	log.Named("postgresqlrepo").Named("pvz").Debug("nested repo debug")
+	log.Named("service").Warn("service warn")
+	log.Named("service").Error("service error")
+
+	messages := make([]string, 0, logs.Len())
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+
+	assert.Equal(t, []string{"repo debug", "nested repo debug", "service error"}, messages)
+
+	require.NoError(t, levels.SetPackageLevel("database", "warn"))
+	assert.Equal(t, map[string]string{"postgresqlrepo": "debug", "service": "error", "database": "warn"}, levels.Packages())
+
+	// Apply сбрасывает изменения, сделанные во время работы
+	require.NoError(t, levels.Apply(config.LoggingConfig{Level: "warn"}))
+	assert.Equal(t, "warn", levels.Level())
+	assert.Empty(t, levels.Packages())
+}
+
+func TestNewZapLogger(t *testing.T) {
+	tests := []struct {
+		name        string
+		cfg         config.LoggingConfig
+		expectError bool
+	}{
+		{name: "JSON", cfg: config.LoggingConfig{Level: "info", Encoding: "json", SamplingInitial: 100, SamplingThereafter: 100}},
+		{name: "Console without sampling", cfg: config.LoggingConfig{Level: "debug", Encoding: "console"}},
+		{name: "Unknown level", cfg: config.LoggingConfig{Level: "verbose"}, expectError: true},
+		{name: "Unknown package level", cfg: config.LoggingConfig{Level: "info", PackageLevels: map[string]string{"service": "loud"}}, expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			log, levels, err := logger.NewZapLogger(tt.cfg)
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NotNil(t, log)
+			assert.Equal(t, tt.cfg.Level, levels.Level())
+		})
+	}
+}
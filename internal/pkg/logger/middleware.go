@@ -7,14 +7,16 @@ import (
 	"go.uber.org/zap"
 )
 
-// NewMiddleware - мидлварь для логирования для Gin
+// NewMiddleware - мидлварь для логирования для Gin.
+// Логирует запрос с полями из контекста запроса (см. FromContext),
+// поэтому должен стоять после мидлваря, добавляющего айди запроса.
 func NewMiddleware(logger *zap.Logger) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		start := time.Now()
 
 		c.Next()
 
-		logger.Info("Request",
+		FromContext(c.Request.Context(), logger).Info("Request",
 			zap.String("method", c.Request.Method),
 			zap.String("path", c.Request.URL.Path),
 			zap.Int("status", c.Writer.Status()),
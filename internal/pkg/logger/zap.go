@@ -3,26 +3,48 @@ package logger
 import (
 	"github.com/maksemen2/pvz-service/config"
 	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
 )
 
 // NewZapLogger создает новый экземпляр логгера с использованием конфигурации.
 // Поле cfg.Level должно быть одним из: "debug", "info", "warn", "error" или "silent".
-// При уровне "silent" возвращается пустой логгер (zap.NewNop).
-func NewZapLogger(cfg config.LoggingConfig) (*zap.Logger, error) {
+// Возвращает также уровни логирования, которые можно менять во время работы.
+func NewZapLogger(cfg config.LoggingConfig) (*zap.Logger, *Levels, error) {
+	levels, err := NewLevels(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+
 	zapConfig := zap.NewProductionConfig()
 
-	switch cfg.Level {
-	case "debug":
-		zapConfig.Level.SetLevel(zap.DebugLevel)
-	case "info":
-		zapConfig.Level.SetLevel(zap.InfoLevel)
-	case "warn":
-		zapConfig.Level.SetLevel(zap.WarnLevel)
-	case "error":
-		zapConfig.Level.SetLevel(zap.ErrorLevel)
-	case "silent":
-		return zap.NewNop(), nil
+	if cfg.Encoding == "console" {
+		zapConfig.Encoding = "console"
+		zapConfig.EncoderConfig = zap.NewDevelopmentEncoderConfig()
 	}
 
-	return zapConfig.Build()
+	zapConfig.Sampling = nil
+	if cfg.SamplingInitial > 0 {
+		zapConfig.Sampling = &zap.SamplingConfig{
+			Initial:    cfg.SamplingInitial,
+			Thereafter: cfg.SamplingThereafter,
+		}
+	}
+
+	// Вложенное ядро пропускает все уровни, фильтрацией занимается levelCore
+	zapConfig.Level = zap.NewAtomicLevelAt(zap.DebugLevel)
+
+	logger, err := zapConfig.Build(zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+		return NewCore(core, levels)
+	}))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return logger, levels, nil
+}
+
+// NewCore оборачивает ядро фильтрацией по уровням levels.
+// Нужен для логгеров, созданных не через NewZapLogger (например, в тестах).
+func NewCore(core zapcore.Core, levels *Levels) zapcore.Core {
+	return &levelCore{Core: core, levels: levels}
 }
@@ -0,0 +1,32 @@
+package metrics
+
+import (
+	"context"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/status"
+)
+
+// UnaryServerInterceptor возвращает интерсептор для gRPC сервера.
+// Он собирает метрики по запросам, кодам ответа и времени ответа для каждого метода.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		start := time.Now()
+
+		resp, err := handler(ctx, req)
+
+		duration := time.Since(start).Seconds()
+
+		GRPCRequestsTotal.WithLabelValues(
+			info.FullMethod,
+			status.Code(err).String(),
+		).Inc()
+
+		GRPCResponseTime.WithLabelValues(
+			info.FullMethod,
+		).Observe(duration)
+
+		return resp, err
+	}
+}
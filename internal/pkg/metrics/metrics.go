@@ -20,6 +20,33 @@ var (
 		Buckets: []float64{0.1, 0.5, 1, 2, 5},
 	}, []string{"method", "path"})
 
+	GRPCRequestsTotal = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "grpc_requests_total",
+		Help: "Total number of gRPC requests",
+	}, []string{"method", "code"})
+
+	GRPCResponseTime = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "grpc_response_time_seconds",
+		Help:    "Duration of gRPC requests",
+		Buckets: []float64{0.1, 0.5, 1, 2, 5},
+	}, []string{"method"})
+
+	DBQueryDuration = promauto.With(Registry).NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "db_query_duration_seconds",
+		Help:    "Duration of repository operations",
+		Buckets: []float64{0.001, 0.005, 0.01, 0.05, 0.1, 0.5, 1},
+	}, []string{"operation"})
+
+	DBQueryErrors = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "db_query_errors_total",
+		Help: "Total number of repository operations failed with unexpected database error",
+	}, []string{"operation"})
+
+	DBTxRollbacks = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "db_tx_rollbacks_total",
+		Help: "Total number of rolled back transactions",
+	}, []string{"result"})
+
 	PVZCreated = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
 		Name: "business_pvz_created_total",
 		Help: "Total number of created PVZs",
@@ -30,8 +57,78 @@ var (
 		Help: "Total number of created receptions",
 	})
 
+	ReceptionsCancelled = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Name: "business_receptions_cancelled_total",
+		Help: "Total number of cancelled receptions",
+	})
+
+	ReceptionsReopened = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
+		Name: "business_receptions_reopened_total",
+		Help: "Total number of reopened receptions",
+	})
+
 	ProductsAdded = promauto.With(Registry).NewCounter(prometheus.CounterOpts{
 		Name: "business_products_added_total",
 		Help: "Total number of added products",
 	})
+
+	ReceptionsClosed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_receptions_closed_total",
+		Help: "Total number of closed receptions, including automatically closed",
+	}, []string{"city"})
+
+	ProductsDeleted = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_products_deleted_total",
+		Help: "Total number of deleted products",
+	}, []string{"city", "type"})
+
+	ProductsIssued = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_products_issued_total",
+		Help: "Total number of products issued to customers",
+	}, []string{"city", "type"})
+
+	ProductsReturnedToSender = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_products_returned_to_sender_total",
+		Help: "Total number of products returned to sender after storage period expiry",
+	}, []string{"city", "type"})
+
+	CustomerReturns = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_customer_returns_total",
+		Help: "Total number of products returned by customers by reason",
+	}, []string{"city", "reason"})
+
+	PickupCodeVerifications = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_pickup_code_verifications_total",
+		Help: "Total number of pickup code verifications by result",
+	}, []string{"result"})
+
+	OpenReceptions = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "business_open_receptions",
+		Help: "Number of currently open receptions",
+	}, []string{"city"})
+
+	OldestOpenReceptionAge = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Name: "business_oldest_open_reception_age_seconds",
+		Help: "Age of the oldest open reception, 0 if there are no open receptions",
+	})
+
+	ProductsReceivedToday = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "business_products_received_today",
+		Help: "Number of products received since the start of the current UTC day",
+	}, []string{"city", "type"})
+
+	ProductsStorageExpired = promauto.With(Registry).NewGaugeVec(prometheus.GaugeOpts{
+		Name: "business_products_storage_expired",
+		Help: "Number of stored products whose storage period has expired",
+	}, []string{"city", "type"})
+
+	PVZTotal = promauto.With(Registry).NewGauge(prometheus.GaugeOpts{
+		Name: "business_pvz_total",
+		Help: "Total number of PVZs",
+	})
+
+	ReceptionsAutoClosed = promauto.With(Registry).NewCounterVec(prometheus.CounterOpts{
+		Name: "business_receptions_auto_closed_total",
+		Help: "Total number of stale receptions closed automatically",
+	}, []string{"city"})
 )
@@ -2,24 +2,35 @@ package metrics
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"github.com/maksemen2/pvz-service/config"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"go.uber.org/zap"
+	"net"
 	"net/http"
 )
 
 // Server - структура, представляющая сервер метрик.
 type Server struct {
 	httpServer *http.Server
+	listener   net.Listener
 	logger     *zap.Logger
 }
 
-// NewServer принимает логгер и конфиг метрик.
+// NewServer принимает логгер, конфиг метрик и обработчик служебных ручек.
 // Создает ServeMux и регистрирует обработчик для метрик.
+// Если admin не nil, он обслуживает запросы к /admin/: порт метрик внутренний и не должен быть доступен
+// извне, поэтому служебные ручки недоступны клиентам публичного API.
 // Возвращает указатель на Server.
-func NewServer(logger *zap.Logger, cfg config.MetricsConfig) *Server {
+func NewServer(logger *zap.Logger, cfg config.MetricsConfig, admin http.Handler) *Server {
 	mux := http.NewServeMux()
 	mux.Handle(cfg.Path, promhttp.HandlerFor(Registry, promhttp.HandlerOpts{}))
+
+	if admin != nil {
+		mux.Handle("/admin/", admin)
+	}
+
 	srv := &http.Server{
 		Addr:    cfg.GetAddr(),
 		Handler: mux,
@@ -31,24 +42,42 @@ func NewServer(logger *zap.Logger, cfg config.MetricsConfig) *Server {
 	}
 }
 
-// Start запускает сервер метрик.
-// Если происходит ошибка - она логируется и программа завершается.
-func (s *Server) Start() {
-	s.logger.Info("Starting metrics server", zap.String("addr", s.httpServer.Addr))
+// Listen занимает адрес сервера метрик.
+// Ошибка возвращается сразу, до начала обслуживания запросов.
+func (s *Server) Listen() error {
+	lis, err := net.Listen("tcp", s.httpServer.Addr)
+	if err != nil {
+		return fmt.Errorf("metrics listener failed: %w", err)
+	}
+
+	s.listener = lis
+
+	return nil
+}
+
+// Serve обслуживает запросы на адресе, занятом Listen, и блокируется до остановки сервера.
+// После Stop возвращает nil.
+func (s *Server) Serve() error {
+	s.logger.Info("Starting metrics server", zap.String("addr", s.listener.Addr().String()))
 
-	if err := s.httpServer.ListenAndServe(); err != nil {
-		s.logger.Fatal("Metrics server error", zap.Error(err))
+	if err := s.httpServer.Serve(s.listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return fmt.Errorf("metrics server error: %w", err)
 	}
+
+	return nil
 }
 
 // Stop остановливает сервер метрик.
 // Принимает контекст для корректного завершения работы.
-func (s *Server) Stop(ctx context.Context) {
+func (s *Server) Stop(ctx context.Context) error {
 	s.logger.Info("Stopping metrics server")
 
 	if err := s.httpServer.Shutdown(ctx); err != nil {
-		s.logger.Error("Error stopping metrics server", zap.Error(err))
+		s.httpServer.Close()
+		return fmt.Errorf("metrics server shutdown: %w", err)
 	}
 
 	s.logger.Info("Metrics server stopped")
+
+	return nil
 }
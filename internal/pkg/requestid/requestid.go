@@ -0,0 +1,102 @@
+// Пакет requestid содержит мидлвари для HTTP и gRPC, которые принимают или генерируют айди запроса,
+// сохраняют его в context.Context и возвращают клиенту.
+package requestid
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"go.uber.org/zap"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+const (
+	Header      = "X-Request-ID" // Заголовок HTTP запроса и ответа с айди запроса
+	MetadataKey = "x-request-id" // Ключ метаданных gRPC с айди запроса
+	LogField    = "requestID"    // Поле лога с айди запроса
+	MaxLength   = 128            // Максимальная длина айди запроса, принимаемого от клиента
+)
+
+type requestIDKey struct{}
+
+// NewContext возвращает копию контекста с айди запроса.
+// Айди запроса также добавляется в поля логгера (см. logger.FromContext).
+func NewContext(ctx context.Context, requestID string) context.Context {
+	ctx = context.WithValue(ctx, requestIDKey{}, requestID)
+
+	return logger.NewContext(ctx, zap.String(LogField, requestID))
+}
+
+// FromContext возвращает айди запроса из контекста или пустую строку, если его нет.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+
+	return requestID
+}
+
+// Resolve возвращает айди запроса, переданный клиентом, если он допустим,
+// или генерирует новый в противном случае.
+func Resolve(candidate string) string {
+	if valid(candidate) {
+		return candidate
+	}
+
+	return uuid.NewString()
+}
+
+// valid проверяет, что айди запроса не пустой, не слишком длинный
+// и состоит только из видимых ASCII символов, чтобы его можно было безопасно логировать и возвращать в заголовках.
+func valid(requestID string) bool {
+	if requestID == "" || len(requestID) > MaxLength {
+		return false
+	}
+
+	for i := 0; i < len(requestID); i++ {
+		if requestID[i] < '!' || requestID[i] > '~' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// NewGinMiddleware возвращает мидлварь для GIN, которая принимает айди запроса из заголовка X-Request-ID
+// или генерирует новый, сохраняет его в контекст запроса и возвращает в заголовке ответа.
+func NewGinMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		requestID := Resolve(c.GetHeader(Header))
+
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), requestID))
+		c.Header(Header, requestID)
+
+		c.Next()
+	}
+}
+
+// UnaryServerInterceptor возвращает gRPC интерсептор, который принимает айди запроса из метаданных x-request-id
+// или генерирует новый, сохраняет его в контекст и возвращает клиенту в заголовках ответа.
+func UnaryServerInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req any, _ *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+		var candidate string
+
+		if md, ok := metadata.FromIncomingContext(ctx); ok {
+			if values := md.Get(MetadataKey); len(values) > 0 {
+				candidate = values[0]
+			}
+		}
+
+		requestID := Resolve(candidate)
+
+		// Ошибка возможна только если заголовки уже отправлены, что для unary вызова до обработчика невозможно
+		_ = grpc.SetHeader(ctx, metadata.Pairs(MetadataKey, requestID))
+
+		return handler(NewContext(ctx, requestID), req)
+	}
+}
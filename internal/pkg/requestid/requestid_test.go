@@ -0,0 +1,111 @@
+//go:build unit
+// +build unit
+
+package requestid_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/requestid"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/metadata"
+)
+
+func TestGinMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		header     string
+		expectKeep bool
+	}{
+		{name: "Accepts client id", header: "client-request-1", expectKeep: true},
+		{name: "Generates missing id", header: ""},
+		{name: "Replaces id with spaces", header: "bad id"},
+		{name: "Replaces too long id", header: strings.Repeat("a", requestid.MaxLength+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var fromContext string
+
+			router := gin.New()
+			router.Use(requestid.NewGinMiddleware())
+			router.GET("/", func(c *gin.Context) {
+				fromContext = requestid.FromContext(c.Request.Context())
+				c.Status(http.StatusOK)
+			})
+
+			req, _ := http.NewRequest("GET", "/", nil)
+			if tt.header != "" {
+				req.Header.Set(requestid.Header, tt.header)
+			}
+
+			resp := httptest.NewRecorder()
+			router.ServeHTTP(resp, req)
+
+			returned := resp.Header().Get(requestid.Header)
+			assert.Equal(t, fromContext, returned)
+
+			if tt.expectKeep {
+				assert.Equal(t, tt.header, returned)
+			} else {
+				_, err := uuid.Parse(returned)
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestNewContext_AddsLogField(t *testing.T) {
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	ctx = logger.NewContext(ctx, zap.String("userID", "user-1"))
+
+	logger.FromContext(ctx, zap.New(core)).Info("message")
+
+	require.Equal(t, 1, logs.Len())
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, "req-1", fields[requestid.LogField])
+	assert.Equal(t, "user-1", fields["userID"])
+}
+
+func TestUnaryServerInterceptor(t *testing.T) {
+	interceptor := requestid.UnaryServerInterceptor()
+
+	handler := func(ctx context.Context, _ any) (any, error) {
+		return requestid.FromContext(ctx), nil
+	}
+
+	t.Run("Accepts id from metadata", func(t *testing.T) {
+		ctx := metadata.NewIncomingContext(context.Background(), metadata.Pairs(requestid.MetadataKey, "grpc-request-1"))
+
+		got, err := interceptor(ctx, nil, &grpc.UnaryServerInfo{}, handler)
+
+		require.NoError(t, err)
+		assert.Equal(t, "grpc-request-1", got)
+	})
+
+	t.Run("Generates missing id", func(t *testing.T) {
+		got, err := interceptor(context.Background(), nil, &grpc.UnaryServerInfo{}, handler)
+
+		require.NoError(t, err)
+
+		_, err = uuid.Parse(got.(string))
+		assert.NoError(t, err)
+	})
+}
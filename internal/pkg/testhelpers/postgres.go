@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/migrations"
 	"testing"
 	"time"
 
@@ -66,43 +67,27 @@ func SetupPostgresContainer(t *testing.T) (config.DatabaseConfig, func()) {
 	}
 }
 
-// CreateTestDB создает тестовую базу данных с необходимыми таблицами.
+// CreateTestDB создает таблицы тестовой базы данных, применяя миграции схемы.
 // Возвращает функцию для очистки базы данных после тестов.
 func CreateTestDB(db *database.PostgresDB) (func(), error) {
-	_, err := db.Exec(`
-CREATE TABLE IF NOT EXISTS pvzs (
-	id UUID PRIMARY KEY,
-	registration_date TIMESTAMP NOT NULL,
-	city VARCHAR(50) NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS receptions (
-	id UUID PRIMARY KEY,
-	pvz_id UUID NOT NULL REFERENCES pvzs(id),
-	date_time TIMESTAMP NOT NULL,
-	status VARCHAR(20) NOT NULL
-);
-
-CREATE TABLE IF NOT EXISTS products (
-	id UUID PRIMARY KEY,
-	date_time TIMESTAMP NOT NULL,
-	type VARCHAR(50) NOT NULL,
-	reception_id UUID NOT NULL REFERENCES receptions(id)
-);
-
-CREATE TABLE IF NOT EXISTS users (
-	id UUID PRIMARY KEY,
-	email VARCHAR(255) UNIQUE NOT NULL,
-	password_hash VARCHAR(255) NOT NULL,
-	role VARCHAR(50) NOT NULL
-);
-`)
+	err := db.Migrate(context.Background(), migrations.FS)
 
 	cleanup := func() {
+		_, _ = db.Exec("DROP TABLE IF EXISTS reception_discrepancy_items")
+		_, _ = db.Exec("DROP TABLE IF EXISTS reception_discrepancy_reports")
+		_, _ = db.Exec("DROP TABLE IF EXISTS reception_manifest_items")
+		_, _ = db.Exec("DROP TABLE IF EXISTS reception_manifests")
+		_, _ = db.Exec("DROP TABLE IF EXISTS pickup_code_failures")
+		_, _ = db.Exec("DROP TABLE IF EXISTS pickup_codes")
+		_, _ = db.Exec("DROP TABLE IF EXISTS customer_returns")
 		_, _ = db.Exec("DROP TABLE IF EXISTS products")
+		_, _ = db.Exec("DROP TABLE IF EXISTS issuances")
+		_, _ = db.Exec("DROP TABLE IF EXISTS shipments")
 		_, _ = db.Exec("DROP TABLE IF EXISTS receptions")
 		_, _ = db.Exec("DROP TABLE IF EXISTS pvzs")
 		_, _ = db.Exec("DROP TABLE IF EXISTS users")
+		_, _ = db.Exec("DROP TABLE IF EXISTS idempotency_keys")
+		_, _ = db.Exec("DROP TABLE IF EXISTS schema_migrations")
 	}
 
 	return cleanup, err
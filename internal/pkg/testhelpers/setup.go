@@ -13,11 +13,20 @@ func SetupTestEnvironment(t *testing.T) (*config.Config, func()) {
 	httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
 
 	return &config.Config{
-		Database: dbCfg,
-		HTTP:     config.HTTPConfig{Port: 8081, Host: "localhost", Env: "prod"},
-		Auth:     config.AuthConfig{JWTSecret: "test-secret", TokenExpirationSeconds: 3600},
-		Metrics:  config.MetricsConfig{Port: 9001, Path: "/metrics"},
-		Logging:  config.LoggingConfig{Level: "silent"},
-		GRPC:     config.GRPCConfig{Port: 3001},
+		Storage:       config.StorageConfig{Type: config.StoragePostgres},
+		Database:      dbCfg,
+		HTTP:          config.HTTPConfig{Port: 8081, Host: "localhost", Env: "prod"},
+		Auth:          config.AuthConfig{JWTSecret: "test-secret", TokenExpirationSeconds: 3600},
+		Metrics:       config.MetricsConfig{Port: 9001, Path: "/metrics"},
+		Logging:       config.LoggingConfig{Level: "silent", Encoding: "json"},
+		GRPC:          config.GRPCConfig{Port: 3001},
+		Reception:     config.ReceptionConfig{ReopenGracePeriodSeconds: 600},
+		Product:       config.ProductConfig{BatchMaxItems: 100, IssueMaxItems: 50},
+		Pickup:        config.PickupConfig{CodeTTLHours: 168, MaxAttempts: 5, PVZMaxFailures: 20, PVZFailureWindowSeconds: 600},
+		StoragePeriod: config.StoragePeriodConfig{Days: 14, WarningDays: 2},
+		Idempotency:   config.IdempotencyConfig{TTLSeconds: 86400, LockTimeoutSeconds: 60},
+		Tracing:       config.TracingConfig{Exporter: "none", SampleRatio: 1},
+		Health:        config.HealthConfig{DBPingTimeoutSeconds: 2},
+		Shutdown:      config.ShutdownConfig{HTTPTimeoutSeconds: 5, GRPCTimeoutSeconds: 5, MetricsTimeoutSeconds: 5, WorkersTimeoutSeconds: 5, TracingTimeoutSeconds: 5, DatabaseTimeoutSeconds: 5},
 	}, cleanup
 }
@@ -0,0 +1,92 @@
+// Пакет tracing настраивает трассировку OpenTelemetry: экспортер, сэмплирование и глобальный TracerProvider.
+package tracing
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/maksemen2/pvz-service/config"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортеры спанов.
+const (
+	ExporterOTLP   = "otlp"
+	ExporterStdout = "stdout"
+	ExporterNone   = "none"
+)
+
+const defaultServiceName = "pvz-service"
+
+// NewProvider создает TracerProvider по конфигурации и устанавливает его глобальным,
+// вместе с пропагатором W3C Trace Context. Провайдер нужно остановить с помощью Shutdown,
+// чтобы отправить оставшиеся спаны.
+func NewProvider(ctx context.Context, cfg config.TracingConfig) (*sdktrace.TracerProvider, error) {
+	serviceName := ServiceName(cfg)
+
+	options := []sdktrace.TracerProviderOption{
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+		sdktrace.WithResource(resource.NewWithAttributes(semconv.SchemaURL, semconv.ServiceName(serviceName))),
+	}
+
+	exporter, err := newExporter(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	if exporter != nil {
+		options = append(options, sdktrace.WithBatcher(exporter))
+	}
+
+	provider := sdktrace.NewTracerProvider(options...)
+
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+
+	return provider, nil
+}
+
+// ServiceName возвращает имя сервиса из конфигурации или имя по умолчанию.
+func ServiceName(cfg config.TracingConfig) string {
+	if cfg.ServiceName == "" {
+		return defaultServiceName
+	}
+
+	return cfg.ServiceName
+}
+
+// newExporter создает экспортер спанов. Для ExporterNone возвращает nil.
+func newExporter(ctx context.Context, cfg config.TracingConfig) (sdktrace.SpanExporter, error) {
+	switch cfg.Exporter {
+	case ExporterOTLP:
+		options := []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(cfg.OTLPEndpoint)}
+		if cfg.OTLPInsecure {
+			options = append(options, otlptracegrpc.WithInsecure())
+		}
+
+		return otlptracegrpc.New(ctx, options...)
+	case ExporterStdout:
+		return stdouttrace.New()
+	case ExporterNone, "":
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+}
+
+// TraceID возвращает айди трассировки из контекста или пустую строку, если спана в контексте нет.
+func TraceID(ctx context.Context) string {
+	spanContext := trace.SpanContextFromContext(ctx)
+	if !spanContext.HasTraceID() {
+		return ""
+	}
+
+	return spanContext.TraceID().String()
+}
@@ -0,0 +1,78 @@
+//go:build unit
+// +build unit
+
+package tracing_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/maksemen2/pvz-service/config"
+	"github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/tracing"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+func TestNewProvider(t *testing.T) {
+	tests := []struct {
+		name        string
+		exporter    string
+		expectError bool
+	}{
+		{name: "No exporter", exporter: tracing.ExporterNone},
+		{name: "Empty exporter", exporter: ""},
+		{name: "Stdout exporter", exporter: tracing.ExporterStdout},
+		{name: "Unknown exporter", exporter: "jaeger", expectError: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			provider, err := tracing.NewProvider(context.Background(), config.TracingConfig{Exporter: tt.exporter, SampleRatio: 1})
+			if tt.expectError {
+				assert.Error(t, err)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.NoError(t, provider.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestTraceID(t *testing.T) {
+	provider, err := tracing.NewProvider(context.Background(), config.TracingConfig{Exporter: tracing.ExporterNone, SampleRatio: 1})
+	require.NoError(t, err)
+
+	defer provider.Shutdown(context.Background())
+
+	assert.Empty(t, tracing.TraceID(context.Background()))
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	defer span.End()
+
+	assert.Equal(t, span.SpanContext().TraceID().String(), tracing.TraceID(ctx))
+}
+
+func TestLoggerFromContext_TraceFields(t *testing.T) {
+	provider, err := tracing.NewProvider(context.Background(), config.TracingConfig{Exporter: tracing.ExporterNone, SampleRatio: 1})
+	require.NoError(t, err)
+
+	defer provider.Shutdown(context.Background())
+
+	core, logs := observer.New(zapcore.InfoLevel)
+
+	ctx, span := provider.Tracer("test").Start(context.Background(), "operation")
+	defer span.End()
+
+	logger.FromContext(ctx, zap.New(core)).Info("message")
+
+	require.Equal(t, 1, logs.Len())
+
+	fields := logs.All()[0].ContextMap()
+	assert.Equal(t, span.SpanContext().TraceID().String(), fields[logger.TraceIDField])
+	assert.Equal(t, span.SpanContext().SpanID().String(), fields[logger.SpanIDField])
+}
@@ -6,4 +6,5 @@ var (
 	ErrUnexpected      = errors.New("unexpected database error") // Непредвиденная ошибка
 	ErrUniqueViolation = errors.New("unique field violation")    // Нарушение уникальности
 	ErrNoRows          = errors.New("no rows found")             // Строки не найдены
+	ErrLockNotAcquired = errors.New("lock not acquired")         // Блокировка удерживается другим процессом
 )
@@ -0,0 +1,52 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// idempotencyRepository оборачивает repositories.IIdempotencyRepo метриками.
+type idempotencyRepository struct {
+	next repositories.IIdempotencyRepo
+}
+
+// NewIdempotencyRepository создает обертку над репозиторием идемпотентных запросов, собирающую метрики.
+func NewIdempotencyRepository(next repositories.IIdempotencyRepo) repositories.IIdempotencyRepo {
+	return &idempotencyRepository{next: next}
+}
+
+func (r *idempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, bool, error) {
+	start := time.Now()
+	existing, reserved, err := r.next.Reserve(ctx, record, staleBefore)
+	observe("idempotency.Reserve", start, err)
+
+	return existing, reserved, err
+}
+
+func (r *idempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, headers map[string]string, body []byte) error {
+	start := time.Now()
+	err := r.next.Complete(ctx, userID, key, statusCode, contentType, headers, body)
+	observe("idempotency.Complete", start, err)
+
+	return err
+}
+
+func (r *idempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	start := time.Now()
+	err := r.next.Release(ctx, userID, key)
+	observe("idempotency.Release", start, err)
+
+	return err
+}
+
+func (r *idempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	start := time.Now()
+	deleted, err := r.next.DeleteExpired(ctx, now)
+	observe("idempotency.DeleteExpired", start, err)
+
+	return deleted, err
+}
@@ -0,0 +1,22 @@
+// Пакет instrumentedrepo содержит обертки над репозиториями, которые записывают
+// время выполнения и ошибки каждого метода в metrics.Registry.
+// Операции называются по схеме "<репозиторий>.<метод>", например pvz.List или product.Create.
+package instrumentedrepo
+
+import (
+	"errors"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// observe записывает время выполнения операции и, если она завершилась непредвиденной ошибкой, увеличивает счетчик ошибок.
+// Ожидаемые исходы (строки не найдены, нарушение уникальности, несовпадение версии и т.п.) ошибками базы данных не считаются.
+func observe(operation string, start time.Time, err error) {
+	metrics.DBQueryDuration.WithLabelValues(operation).Observe(time.Since(start).Seconds())
+
+	if errors.Is(err, databaseerrors.ErrUnexpected) {
+		metrics.DBQueryErrors.WithLabelValues(operation).Inc()
+	}
+}
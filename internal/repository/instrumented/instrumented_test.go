@@ -0,0 +1,76 @@
+//go:build unit
+// +build unit
+
+package instrumentedrepo_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	instrumentedrepo "github.com/maksemen2/pvz-service/internal/repository/instrumented"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+)
+
+func TestPVZRepository_List(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIPVZRepo(ctrl)
+	repo := instrumentedrepo.NewPVZRepository(mockRepo)
+
+	ctx := context.Background()
+	filter := &models.PVZFilter{Page: 1, PageSize: 10}
+
+	t.Run("Success", func(t *testing.T) {
+		errorsBefore := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("pvz.List"))
+
+		expected := []*models.PVZWithReceptions{{PVZ: &models.PVZ{ID: uuid.New()}}}
+		mockRepo.EXPECT().List(ctx, filter).Return(expected, nil).Times(1)
+
+		result, err := repo.List(ctx, filter)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expected, result)
+		assert.Equal(t, errorsBefore, testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("pvz.List")))
+		assert.Positive(t, testutil.CollectAndCount(metrics.DBQueryDuration))
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		errorsBefore := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("pvz.List"))
+
+		mockRepo.EXPECT().List(ctx, filter).Return(nil, databaseerrors.ErrUnexpected).Times(1)
+
+		_, err := repo.List(ctx, filter)
+
+		assert.ErrorIs(t, err, databaseerrors.ErrUnexpected)
+		assert.Equal(t, errorsBefore+1, testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("pvz.List")))
+	})
+}
+
+func TestProductRepository_DeleteLast_ExpectedErrorNotCounted(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	repo := instrumentedrepo.NewProductRepository(mockRepo)
+
+	ctx := context.Background()
+	pvzID := uuid.New()
+
+	errorsBefore := testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("product.DeleteLast"))
+
+	mockRepo.EXPECT().DeleteLast(ctx, pvzID, 1).Return(nil, models.CityType(""), domainerrors.ErrNoProductsInReception).Times(1)
+
+	_, _, err := repo.DeleteLast(ctx, pvzID, 1)
+
+	assert.ErrorIs(t, err, domainerrors.ErrNoProductsInReception)
+	assert.Equal(t, errorsBefore, testutil.ToFloat64(metrics.DBQueryErrors.WithLabelValues("product.DeleteLast")))
+}
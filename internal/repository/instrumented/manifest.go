@@ -0,0 +1,52 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// manifestRepository оборачивает repositories.IManifestRepo метриками.
+type manifestRepository struct {
+	next repositories.IManifestRepo
+}
+
+// NewManifestRepository создает обертку над репозиторием манифестов, собирающую метрики.
+func NewManifestRepository(next repositories.IManifestRepo) repositories.IManifestRepo {
+	return &manifestRepository{next: next}
+}
+
+func (r *manifestRepository) Save(ctx context.Context, manifest *models.Manifest, expectedPVZVersion int) error {
+	start := time.Now()
+	err := r.next.Save(ctx, manifest, expectedPVZVersion)
+	observe("manifest.Save", start, err)
+
+	return err
+}
+
+func (r *manifestRepository) GetByReception(ctx context.Context, receptionID uuid.UUID) (*models.Manifest, error) {
+	start := time.Now()
+	manifest, err := r.next.GetByReception(ctx, receptionID)
+	observe("manifest.GetByReception", start, err)
+
+	return manifest, err
+}
+
+func (r *manifestRepository) CountReceived(ctx context.Context, receptionID uuid.UUID) (map[models.ProductType]int, error) {
+	start := time.Now()
+	counts, err := r.next.CountReceived(ctx, receptionID)
+	observe("manifest.CountReceived", start, err)
+
+	return counts, err
+}
+
+func (r *manifestRepository) SaveDiscrepancyReport(ctx context.Context, report *models.DiscrepancyReport) error {
+	start := time.Now()
+	err := r.next.SaveDiscrepancyReport(ctx, report)
+	observe("manifest.SaveDiscrepancyReport", start, err)
+
+	return err
+}
@@ -0,0 +1,76 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// pickupCodeRepository оборачивает repositories.IPickupCodeRepo метриками.
+type pickupCodeRepository struct {
+	next repositories.IPickupCodeRepo
+}
+
+// NewPickupCodeRepository создает обертку над репозиторием кодов получения, собирающую метрики.
+func NewPickupCodeRepository(next repositories.IPickupCodeRepo) repositories.IPickupCodeRepo {
+	return &pickupCodeRepository{next: next}
+}
+
+func (r *pickupCodeRepository) OrdersWithoutCode(ctx context.Context, receptionID uuid.UUID) ([]uuid.UUID, error) {
+	start := time.Now()
+	orderIDs, err := r.next.OrdersWithoutCode(ctx, receptionID)
+	observe("pickup.OrdersWithoutCode", start, err)
+
+	return orderIDs, err
+}
+
+func (r *pickupCodeRepository) Save(ctx context.Context, code *models.PickupCode) error {
+	start := time.Now()
+	err := r.next.Save(ctx, code)
+	observe("pickup.Save", start, err)
+
+	return err
+}
+
+func (r *pickupCodeRepository) GetActive(ctx context.Context, pvzID, orderID uuid.UUID) (*models.PickupCode, error) {
+	start := time.Now()
+	code, err := r.next.GetActive(ctx, pvzID, orderID)
+	observe("pickup.GetActive", start, err)
+
+	return code, err
+}
+
+func (r *pickupCodeRepository) ReserveAttempt(ctx context.Context, codeID uuid.UUID, maxAttempts int) error {
+	start := time.Now()
+	err := r.next.ReserveAttempt(ctx, codeID, maxAttempts)
+	observe("pickup.ReserveAttempt", start, err)
+
+	return err
+}
+
+func (r *pickupCodeRepository) ReleaseAttempt(ctx context.Context, codeID uuid.UUID) error {
+	start := time.Now()
+	err := r.next.ReleaseAttempt(ctx, codeID)
+	observe("pickup.ReleaseAttempt", start, err)
+
+	return err
+}
+
+func (r *pickupCodeRepository) RegisterFailure(ctx context.Context, pvzID uuid.UUID, failedAt, prunedBefore time.Time) error {
+	start := time.Now()
+	err := r.next.RegisterFailure(ctx, pvzID, failedAt, prunedBefore)
+	observe("pickup.RegisterFailure", start, err)
+
+	return err
+}
+
+func (r *pickupCodeRepository) CountFailures(ctx context.Context, pvzID uuid.UUID, since time.Time) (int, error) {
+	start := time.Now()
+	count, err := r.next.CountFailures(ctx, pvzID, since)
+	observe("pickup.CountFailures", start, err)
+
+	return count, err
+}
@@ -0,0 +1,84 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// productRepository оборачивает repositories.IProductRepo метриками.
+type productRepository struct {
+	next repositories.IProductRepo
+}
+
+// NewProductRepository создает обертку над репозиторием товаров, собирающую метрики.
+func NewProductRepository(next repositories.IProductRepo) repositories.IProductRepo {
+	return &productRepository{next: next}
+}
+
+func (r *productRepository) Create(ctx context.Context, product *models.AddProduct, expectedReceptionVersion int) (*models.Product, error) {
+	start := time.Now()
+	created, err := r.next.Create(ctx, product, expectedReceptionVersion)
+	observe("product.Create", start, err)
+
+	return created, err
+}
+
+func (r *productRepository) CreateBatch(ctx context.Context, pvzID uuid.UUID, products []*models.AddProduct, mode models.BatchMode, expectedReceptionVersion int) (*models.AddProductsBatchResult, error) {
+	start := time.Now()
+	result, err := r.next.CreateBatch(ctx, pvzID, products, mode, expectedReceptionVersion)
+	observe("product.CreateBatch", start, err)
+
+	return result, err
+}
+
+func (r *productRepository) DeleteLast(ctx context.Context, pvzID uuid.UUID, expectedReceptionVersion int) (*models.Product, models.CityType, error) {
+	start := time.Now()
+	product, city, err := r.next.DeleteLast(ctx, pvzID, expectedReceptionVersion)
+	observe("product.DeleteLast", start, err)
+
+	return product, city, err
+}
+
+func (r *productRepository) Issue(ctx context.Context, issuance *models.Issuance, pickupCodeID uuid.UUID, productIDs []uuid.UUID) (*models.Issuance, models.CityType, error) {
+	start := time.Now()
+	result, city, err := r.next.Issue(ctx, issuance, pickupCodeID, productIDs)
+	observe("product.Issue", start, err)
+
+	return result, city, err
+}
+
+func (r *productRepository) ListStoredByOrder(ctx context.Context, pvzID, orderID uuid.UUID) ([]*models.Product, error) {
+	start := time.Now()
+	products, err := r.next.ListStoredByOrder(ctx, pvzID, orderID)
+	observe("product.ListStoredByOrder", start, err)
+
+	return products, err
+}
+
+func (r *productRepository) ListExpiring(ctx context.Context, pvzID uuid.UUID, deadlines []models.StorageDeadline) ([]*models.Product, models.CityType, error) {
+	start := time.Now()
+	products, city, err := r.next.ListExpiring(ctx, pvzID, deadlines)
+	observe("product.ListExpiring", start, err)
+
+	return products, city, err
+}
+
+func (r *productRepository) ReturnToSender(ctx context.Context, shipment *models.Shipment, deadlines []models.StorageDeadline) (*models.Shipment, models.CityType, error) {
+	start := time.Now()
+	result, city, err := r.next.ReturnToSender(ctx, shipment, deadlines)
+	observe("product.ReturnToSender", start, err)
+
+	return result, city, err
+}
+
+func (r *productRepository) CountExpired(ctx context.Context, deadlines []models.StorageDeadline) (map[models.CityType]map[models.ProductType]int, error) {
+	start := time.Now()
+	counts, err := r.next.CountExpired(ctx, deadlines)
+	observe("product.CountExpired", start, err)
+
+	return counts, err
+}
@@ -0,0 +1,43 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// pvzRepository оборачивает repositories.IPVZRepo метриками.
+type pvzRepository struct {
+	next repositories.IPVZRepo
+}
+
+// NewPVZRepository создает обертку над репозиторием ПВЗ, собирающую метрики.
+func NewPVZRepository(next repositories.IPVZRepo) repositories.IPVZRepo {
+	return &pvzRepository{next: next}
+}
+
+func (r *pvzRepository) Create(ctx context.Context, pvz *models.PVZ) error {
+	start := time.Now()
+	err := r.next.Create(ctx, pvz)
+	observe("pvz.Create", start, err)
+
+	return err
+}
+
+func (r *pvzRepository) List(ctx context.Context, filter *models.PVZFilter) ([]*models.PVZWithReceptions, error) {
+	start := time.Now()
+	pvzs, err := r.next.List(ctx, filter)
+	observe("pvz.List", start, err)
+
+	return pvzs, err
+}
+
+func (r *pvzRepository) GetAll(ctx context.Context) ([]*models.PVZ, error) {
+	start := time.Now()
+	pvzs, err := r.next.GetAll(ctx)
+	observe("pvz.GetAll", start, err)
+
+	return pvzs, err
+}
@@ -0,0 +1,76 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// receptionRepository оборачивает repositories.IReceptionRepo метриками.
+type receptionRepository struct {
+	next repositories.IReceptionRepo
+}
+
+// NewReceptionRepository создает обертку над репозиторием приемок, собирающую метрики.
+func NewReceptionRepository(next repositories.IReceptionRepo) repositories.IReceptionRepo {
+	return &receptionRepository{next: next}
+}
+
+func (r *receptionRepository) CreateIfNoOpen(ctx context.Context, reception *models.Reception, expectedPVZVersion int) error {
+	start := time.Now()
+	err := r.next.CreateIfNoOpen(ctx, reception, expectedPVZVersion)
+	observe("reception.CreateIfNoOpen", start, err)
+
+	return err
+}
+
+func (r *receptionRepository) CloseLast(ctx context.Context, pvzID, closedBy uuid.UUID, closedAt time.Time, expectedVersion int) (*models.Reception, models.CityType, error) {
+	start := time.Now()
+	reception, city, err := r.next.CloseLast(ctx, pvzID, closedBy, closedAt, expectedVersion)
+	observe("reception.CloseLast", start, err)
+
+	return reception, city, err
+}
+
+func (r *receptionRepository) CancelLast(ctx context.Context, pvzID, cancelledBy uuid.UUID, cancelledAt time.Time, reason string, expectedVersion int) (*models.Reception, error) {
+	start := time.Now()
+	reception, err := r.next.CancelLast(ctx, pvzID, cancelledBy, cancelledAt, reason, expectedVersion)
+	observe("reception.CancelLast", start, err)
+
+	return reception, err
+}
+
+func (r *receptionRepository) GetLast(ctx context.Context, pvzID uuid.UUID) (*models.Reception, error) {
+	start := time.Now()
+	reception, err := r.next.GetLast(ctx, pvzID)
+	observe("reception.GetLast", start, err)
+
+	return reception, err
+}
+
+func (r *receptionRepository) Reopen(ctx context.Context, receptionID, reopenedBy uuid.UUID, reopenedAt time.Time, expectedVersion int) (*models.Reception, error) {
+	start := time.Now()
+	reception, err := r.next.Reopen(ctx, receptionID, reopenedBy, reopenedAt, expectedVersion)
+	observe("reception.Reopen", start, err)
+
+	return reception, err
+}
+
+func (r *receptionRepository) CloseStale(ctx context.Context, city models.CityType, openedBefore, closedAt time.Time, reason string) ([]*models.Reception, error) {
+	start := time.Now()
+	receptions, err := r.next.CloseStale(ctx, city, openedBefore, closedAt, reason)
+	observe("reception.CloseStale", start, err)
+
+	return receptions, err
+}
+
+func (r *receptionRepository) LockAutoClose(ctx context.Context) (func(), error) {
+	start := time.Now()
+	unlock, err := r.next.LockAutoClose(ctx)
+	observe("reception.LockAutoClose", start, err)
+
+	return unlock, err
+}
@@ -0,0 +1,52 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// returnRepository оборачивает repositories.IReturnRepo метриками.
+type returnRepository struct {
+	next repositories.IReturnRepo
+}
+
+// NewReturnRepository создает обертку над репозиторием возвратов, собирающую метрики.
+func NewReturnRepository(next repositories.IReturnRepo) repositories.IReturnRepo {
+	return &returnRepository{next: next}
+}
+
+func (r *returnRepository) Register(ctx context.Context, ret *models.CustomerReturn) (*models.CustomerReturn, models.CityType, error) {
+	start := time.Now()
+	registered, city, err := r.next.Register(ctx, ret)
+	observe("return.Register", start, err)
+
+	return registered, city, err
+}
+
+func (r *returnRepository) ListByPVZ(ctx context.Context, pvzID uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) {
+	start := time.Now()
+	returns, err := r.next.ListByPVZ(ctx, pvzID, startDate, endDate)
+	observe("return.ListByPVZ", start, err)
+
+	return returns, err
+}
+
+func (r *returnRepository) ListByPVZs(ctx context.Context, pvzIDs []uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) {
+	start := time.Now()
+	returns, err := r.next.ListByPVZs(ctx, pvzIDs, startDate, endDate)
+	observe("return.ListByPVZs", start, err)
+
+	return returns, err
+}
+
+func (r *returnRepository) Dispatch(ctx context.Context, pvzID uuid.UUID, dispatchedAt time.Time) (*models.Shipment, error) {
+	start := time.Now()
+	shipment, err := r.next.Dispatch(ctx, pvzID, dispatchedAt)
+	observe("return.Dispatch", start, err)
+
+	return shipment, err
+}
@@ -0,0 +1,43 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// statsRepository оборачивает repositories.IStatsRepo метриками.
+type statsRepository struct {
+	next repositories.IStatsRepo
+}
+
+// NewStatsRepository создает обертку над репозиторием статистики, собирающую метрики.
+func NewStatsRepository(next repositories.IStatsRepo) repositories.IStatsRepo {
+	return &statsRepository{next: next}
+}
+
+func (r *statsRepository) GetPVZStats(ctx context.Context, filter *models.StatsFilter) ([]*models.PVZStats, error) {
+	start := time.Now()
+	stats, err := r.next.GetPVZStats(ctx, filter)
+	observe("stats.GetPVZStats", start, err)
+
+	return stats, err
+}
+
+func (r *statsRepository) GetProductTypeStats(ctx context.Context, filter *models.StatsFilter) ([]*models.ProductTypeStats, error) {
+	start := time.Now()
+	stats, err := r.next.GetProductTypeStats(ctx, filter)
+	observe("stats.GetProductTypeStats", start, err)
+
+	return stats, err
+}
+
+func (r *statsRepository) GetBusinessSnapshot(ctx context.Context, dayStart time.Time) (*models.BusinessSnapshot, error) {
+	start := time.Now()
+	snapshot, err := r.next.GetBusinessSnapshot(ctx, dayStart)
+	observe("stats.GetBusinessSnapshot", start, err)
+
+	return snapshot, err
+}
@@ -0,0 +1,35 @@
+package instrumentedrepo
+
+import (
+	"context"
+	"time"
+
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// userRepository оборачивает repositories.IUserRepo метриками.
+type userRepository struct {
+	next repositories.IUserRepo
+}
+
+// NewUserRepository создает обертку над репозиторием пользователей, собирающую метрики.
+func NewUserRepository(next repositories.IUserRepo) repositories.IUserRepo {
+	return &userRepository{next: next}
+}
+
+func (r *userRepository) Create(ctx context.Context, user *models.User) error {
+	start := time.Now()
+	err := r.next.Create(ctx, user)
+	observe("user.Create", start, err)
+
+	return err
+}
+
+func (r *userRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	start := time.Now()
+	user, err := r.next.GetByEmail(ctx, email)
+	observe("user.GetByEmail", start, err)
+
+	return user, err
+}
@@ -0,0 +1,27 @@
+//go:build unit
+// +build unit
+
+package memoryrepo_test
+
+import (
+	"testing"
+
+	memoryrepo "github.com/maksemen2/pvz-service/internal/repository/memory"
+	"github.com/maksemen2/pvz-service/internal/repository/repotest"
+)
+
+func TestContract(t *testing.T) {
+	repotest.Run(t, func(*testing.T) repotest.Repositories {
+		store := memoryrepo.NewStore()
+
+		return repotest.Repositories{
+			PVZ:        memoryrepo.NewMemoryPVZRepository(store),
+			Reception:  memoryrepo.NewMemoryReceptionRepository(store),
+			Product:    memoryrepo.NewMemoryProductRepository(store),
+			User:       memoryrepo.NewMemoryUserRepository(store),
+			PickupCode: memoryrepo.NewMemoryPickupCodeRepository(store),
+			Return:     memoryrepo.NewMemoryReturnRepository(store),
+			Manifest:   memoryrepo.NewMemoryManifestRepository(store),
+		}
+	})
+}
@@ -0,0 +1,116 @@
+package memoryrepo
+
+import (
+	"context"
+	"maps"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryIdempotencyRepository реализует интерфейс repositories.IIdempotencyRepo
+type memoryIdempotencyRepository struct {
+	store *Store
+}
+
+// NewMemoryIdempotencyRepository создает новый экземпляр memoryIdempotencyRepository.
+func NewMemoryIdempotencyRepository(store *Store) repositories.IIdempotencyRepo {
+	return &memoryIdempotencyRepository{store: store}
+}
+
+// Reserve резервирует ключ идемпотентности пользователя, сохраняя незавершенную запись.
+// Истекшая запись, а также незавершенная запись, созданная раньше staleBefore, заменяется новой.
+// Остальные истекшие записи удаляются фоновой задачей (см. DeleteExpired).
+// Возвращает true, если ключ зарезервирован, иначе - уже существующую запись и false.
+func (r *memoryIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, bool, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, false, err
+	}
+	defer r.store.unlock()
+
+	key := idempotencyKey{userID: record.UserID, key: record.Key}
+
+	// Истекшую запись и зависшую незавершенную можно заменить
+	existing, exists := r.store.idempotency[key]
+	if exists && existing.ExpiresAt.After(record.CreatedAt) && (existing.Completed() || !existing.CreatedAt.Before(staleBefore)) {
+		return copyIdempotencyRecord(existing), false, nil
+	}
+
+	r.store.idempotency[key] = &models.IdempotencyRecord{
+		UserID:      record.UserID,
+		Key:         record.Key,
+		Fingerprint: record.Fingerprint,
+		CreatedAt:   record.CreatedAt,
+		ExpiresAt:   record.ExpiresAt,
+	}
+
+	return nil, true, nil
+}
+
+// Complete сохраняет ответ на запрос с ключом идемпотентности.
+// Если записи нет, возвращает databaseerrors.ErrNoRows.
+func (r *memoryIdempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, headers map[string]string, body []byte) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	record, ok := r.store.idempotency[idempotencyKey{userID: userID, key: key}]
+	if !ok {
+		return databaseerrors.ErrNoRows
+	}
+
+	record.StatusCode = statusCode
+	record.ContentType = contentType
+	record.Headers = maps.Clone(headers)
+	record.ResponseBody = append([]byte(nil), body...)
+
+	return nil
+}
+
+// Release удаляет незавершенную запись с ключом идемпотентности.
+// Завершенные записи не удаляются.
+func (r *memoryIdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	recordKey := idempotencyKey{userID: userID, key: key}
+
+	if record, ok := r.store.idempotency[recordKey]; ok && !record.Completed() {
+		delete(r.store.idempotency, recordKey)
+	}
+
+	return nil
+}
+
+// DeleteExpired удаляет истекшие к моменту now записи всех пользователей и возвращает их количество.
+func (r *memoryIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer r.store.unlock()
+
+	deleted := 0
+
+	for key, record := range r.store.idempotency {
+		if !record.ExpiresAt.After(now) {
+			delete(r.store.idempotency, key)
+			deleted++
+		}
+	}
+
+	return deleted, nil
+}
+
+func copyIdempotencyRecord(record *models.IdempotencyRecord) *models.IdempotencyRecord {
+	c := *record
+	c.Headers = maps.Clone(record.Headers)
+	c.ResponseBody = append([]byte(nil), record.ResponseBody...)
+
+	return &c
+}
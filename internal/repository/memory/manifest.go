@@ -0,0 +1,170 @@
+package memoryrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryManifestRepository - структура репозитория для работы с манифестами приемок в памяти.
+// Реализует интерфейс repositories.IManifestRepo
+type memoryManifestRepository struct {
+	store *Store
+}
+
+// NewMemoryManifestRepository - конструктор для создания нового экземпляра memoryManifestRepository.
+func NewMemoryManifestRepository(store *Store) repositories.IManifestRepo {
+	return &memoryManifestRepository{store: store}
+}
+
+// Save сохраняет манифест для открытой приемки ПВЗ (manifest.ReceptionID заполняется)
+// или как ожидающий следующей приемки, заменяя ранее сохраненный манифест той же приемки или ожидающий манифест ПВЗ.
+// После сохранения версия ПВЗ увеличивается.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Если expectedPVZVersion не равна models.AnyVersion и не совпадает с версией ПВЗ, возвращает domainerrors.ErrVersionMismatch.
+func (r *memoryManifestRepository) Save(ctx context.Context, manifest *models.Manifest, expectedPVZVersion int) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	pvz, err := r.store.checkPVZVersion(manifest.PVZID, expectedPVZVersion)
+	if err != nil {
+		return err
+	}
+
+	manifest.ReceptionID = nil
+
+	if open := r.store.openReception(manifest.PVZID); open != nil {
+		receptionID := open.ID
+		manifest.ReceptionID = &receptionID
+	}
+
+	for id, existing := range r.store.manifests {
+		if existing.PVZID == manifest.PVZID && sameReception(existing.ReceptionID, manifest.ReceptionID) {
+			delete(r.store.manifests, id)
+		}
+	}
+
+	if _, exists := r.store.manifests[manifest.ID]; exists {
+		return databaseerrors.ErrUnexpected
+	}
+
+	r.store.manifests[manifest.ID] = copyManifest(manifest)
+	pvz.Version++
+
+	return nil
+}
+
+// GetByReception возвращает манифест, привязанный к приемке. Позиции отсортированы по типу товара.
+// Если у приемки нет манифеста, возвращает databaseerrors.ErrNoRows.
+func (r *memoryManifestRepository) GetByReception(ctx context.Context, receptionID uuid.UUID) (*models.Manifest, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	found := r.store.receptionManifest(receptionID)
+	if found == nil {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	return found, nil
+}
+
+// CountReceived возвращает количество товаров каждого типа, принятых в приемке.
+// Типы, товаров которых в приемке нет, в результат не попадают.
+func (r *memoryManifestRepository) CountReceived(ctx context.Context, receptionID uuid.UUID) (map[models.ProductType]int, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	return r.store.countReceived(receptionID), nil
+}
+
+// SaveDiscrepancyReport сохраняет отчет о расхождениях, заменяя ранее построенный для той же приемки.
+func (r *memoryManifestRepository) SaveDiscrepancyReport(ctx context.Context, report *models.DiscrepancyReport) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	r.store.saveDiscrepancyReport(report)
+
+	return nil
+}
+
+// receptionManifest возвращает копию манифеста приемки с позициями, отсортированными по типу, или nil.
+// Вызывающий должен удерживать блокировку хранилища.
+func (s *Store) receptionManifest(receptionID uuid.UUID) *models.Manifest {
+	for _, manifest := range s.manifests {
+		if manifest.ReceptionID != nil && *manifest.ReceptionID == receptionID {
+			found := copyManifest(manifest)
+			sort.Slice(found.Items, func(i, j int) bool {
+				return found.Items[i].Type < found.Items[j].Type
+			})
+
+			return found
+		}
+	}
+
+	return nil
+}
+
+// countReceived подсчитывает принятые товары приемки по типам. Вызывающий должен удерживать блокировку хранилища.
+func (s *Store) countReceived(receptionID uuid.UUID) map[models.ProductType]int {
+	result := make(map[models.ProductType]int)
+	for _, product := range s.receptionProducts(receptionID) {
+		result[product.Type]++
+	}
+
+	return result
+}
+
+// saveDiscrepancyReport сохраняет копию отчета о расхождениях. Вызывающий должен удерживать блокировку хранилища.
+func (s *Store) saveDiscrepancyReport(report *models.DiscrepancyReport) {
+	stored := *report
+	stored.Items = append([]models.DiscrepancyItem(nil), report.Items...)
+	s.reports[report.ReceptionID] = &stored
+}
+
+// buildDiscrepancyReport строит и сохраняет отчет о расхождениях под той же блокировкой, что и закрытие приемки.
+// Если у приемки нет манифеста, возвращает nil.
+func (s *Store) buildDiscrepancyReport(receptionID uuid.UUID, createdAt time.Time) *models.DiscrepancyReport {
+	manifest := s.receptionManifest(receptionID)
+	if manifest == nil {
+		return nil
+	}
+
+	report := models.NewDiscrepancyReport(receptionID, manifest, s.countReceived(receptionID), createdAt)
+	s.saveDiscrepancyReport(report)
+
+	return report
+}
+
+// sameReception сравнивает айди приемок, считая равными два nil (IS NOT DISTINCT FROM в PostgreSQL).
+func sameReception(a, b *uuid.UUID) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+
+	return *a == *b
+}
+
+func copyManifest(manifest *models.Manifest) *models.Manifest {
+	c := *manifest
+	c.Items = append([]models.ManifestItem(nil), manifest.Items...)
+
+	if manifest.ReceptionID != nil {
+		receptionID := *manifest.ReceptionID
+		c.ReceptionID = &receptionID
+	}
+
+	return &c
+}
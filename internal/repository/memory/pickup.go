@@ -0,0 +1,204 @@
+package memoryrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryPickupCodeRepository - структура репозитория для работы с кодами получения заказов в памяти.
+// Реализует интерфейс repositories.IPickupCodeRepo
+type memoryPickupCodeRepository struct {
+	store *Store
+}
+
+// NewMemoryPickupCodeRepository - конструктор для создания нового экземпляра memoryPickupCodeRepository.
+func NewMemoryPickupCodeRepository(store *Store) repositories.IPickupCodeRepo {
+	return &memoryPickupCodeRepository{store: store}
+}
+
+// activeCode возвращает неиспользованный код заказа в ПВЗ или nil. Вызывается под блокировкой хранилища.
+func (r *memoryPickupCodeRepository) activeCode(pvzID, orderID uuid.UUID) *models.PickupCode {
+	for _, code := range r.store.pickupCodes {
+		if code.PVZID == pvzID && code.OrderID == orderID && code.UsedAt == nil {
+			return code
+		}
+	}
+
+	return nil
+}
+
+// OrdersWithoutCode возвращает заказы, хранящиеся товары которых есть в приемке,
+// но у которых в ПВЗ приемки нет неиспользованного кода получения. Заказы отсортированы по айди.
+func (r *memoryPickupCodeRepository) OrdersWithoutCode(ctx context.Context, receptionID uuid.UUID) ([]uuid.UUID, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	orderIDs := make([]uuid.UUID, 0)
+
+	reception, ok := r.store.receptions[receptionID]
+	if !ok {
+		return orderIDs, nil
+	}
+
+	seen := make(map[uuid.UUID]struct{})
+
+	for _, product := range r.store.receptionProducts(receptionID) {
+		if product.Status != models.ProductStatusStored {
+			continue
+		}
+
+		if _, ok := seen[product.OrderID]; ok {
+			continue
+		}
+
+		seen[product.OrderID] = struct{}{}
+
+		if r.activeCode(reception.PVZID, product.OrderID) == nil {
+			orderIDs = append(orderIDs, product.OrderID)
+		}
+	}
+
+	sort.Slice(orderIDs, func(i, j int) bool {
+		return compareIDs(orderIDs[i], orderIDs[j]) < 0
+	})
+
+	return orderIDs, nil
+}
+
+// Save сохраняет код получения, заменяя неиспользованный код того же заказа в ПВЗ.
+func (r *memoryPickupCodeRepository) Save(ctx context.Context, code *models.PickupCode) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	// Как и внешний ключ в PostgreSQL, код привязан к существующему ПВЗ
+	if _, ok := r.store.pvzs[code.PVZID]; !ok {
+		return databaseerrors.ErrUnexpected
+	}
+
+	if previous := r.activeCode(code.PVZID, code.OrderID); previous != nil {
+		delete(r.store.pickupCodes, previous.ID)
+	}
+
+	if _, exists := r.store.pickupCodes[code.ID]; exists {
+		return databaseerrors.ErrUnexpected
+	}
+
+	r.store.pickupCodes[code.ID] = copyPickupCode(code)
+
+	return nil
+}
+
+// GetActive возвращает неиспользованный код получения заказа в ПВЗ.
+// Если такого кода нет, возвращает databaseerrors.ErrNoRows.
+func (r *memoryPickupCodeRepository) GetActive(ctx context.Context, pvzID, orderID uuid.UUID) (*models.PickupCode, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	code := r.activeCode(pvzID, orderID)
+	if code == nil {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	return copyPickupCode(code), nil
+}
+
+// ReserveAttempt увеличивает счетчик попыток неиспользованного кода, если попыток меньше maxAttempts.
+// Иначе возвращает databaseerrors.ErrNoRows.
+func (r *memoryPickupCodeRepository) ReserveAttempt(ctx context.Context, codeID uuid.UUID, maxAttempts int) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	code, ok := r.store.pickupCodes[codeID]
+	if !ok || code.UsedAt != nil || code.Attempts >= maxAttempts {
+		return databaseerrors.ErrNoRows
+	}
+
+	code.Attempts++
+
+	return nil
+}
+
+// ReleaseAttempt возвращает попытку, занятую ReserveAttempt.
+func (r *memoryPickupCodeRepository) ReleaseAttempt(ctx context.Context, codeID uuid.UUID) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	if code, ok := r.store.pickupCodes[codeID]; ok && code.Attempts > 0 {
+		code.Attempts--
+	}
+
+	return nil
+}
+
+// RegisterFailure сохраняет неудачную попытку ввода кода в ПВЗ в момент failedAt
+// и удаляет попытки ПВЗ раньше prunedBefore.
+func (r *memoryPickupCodeRepository) RegisterFailure(ctx context.Context, pvzID uuid.UUID, failedAt, prunedBefore time.Time) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	if _, ok := r.store.pvzs[pvzID]; !ok {
+		return databaseerrors.ErrUnexpected
+	}
+
+	failures := r.store.pickupFailures[:0]
+
+	for _, failure := range r.store.pickupFailures {
+		if failure.pvzID == pvzID && failure.failedAt.Before(prunedBefore) {
+			continue
+		}
+
+		failures = append(failures, failure)
+	}
+
+	r.store.pickupFailures = append(failures, pickupFailure{pvzID: pvzID, failedAt: failedAt})
+
+	return nil
+}
+
+// CountFailures возвращает количество неудачных попыток ввода кодов в ПВЗ начиная с since включительно.
+func (r *memoryPickupCodeRepository) CountFailures(ctx context.Context, pvzID uuid.UUID, since time.Time) (int, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return 0, err
+	}
+	defer r.store.unlock()
+
+	count := 0
+
+	for _, failure := range r.store.pickupFailures {
+		if failure.pvzID == pvzID && !failure.failedAt.Before(since) {
+			count++
+		}
+	}
+
+	return count, nil
+}
+
+func copyPickupCode(code *models.PickupCode) *models.PickupCode {
+	c := *code
+	c.Code = ""
+
+	if code.UsedAt != nil {
+		usedAt := *code.UsedAt
+		c.UsedAt = &usedAt
+	}
+
+	return &c
+}
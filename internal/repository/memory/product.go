@@ -0,0 +1,455 @@
+package memoryrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryProductRepository - структура репозитория для работы с товарами в памяти.
+// Реализует интерфейс repositories.IProductRepo
+type memoryProductRepository struct {
+	store *Store
+}
+
+// NewMemoryProductRepository - конструктор для создания нового экземпляра memoryProductRepository.
+func NewMemoryProductRepository(store *Store) repositories.IProductRepo {
+	return &memoryProductRepository{store: store}
+}
+
+// openReception - хелпер для получения последней открытой приёмки в ПВЗ с проверкой её версии.
+// Возвращает domainerrors.ErrNoOpenReceptions, если открытых приёмок нет, и domainerrors.ErrVersionMismatch,
+// если expectedVersion не равна models.AnyVersion и не совпадает с версией приёмки.
+func (r *memoryProductRepository) openReception(pvzID uuid.UUID, expectedVersion int) (*models.Reception, error) {
+	reception := r.store.openReception(pvzID)
+	if reception == nil {
+		return nil, domainerrors.ErrNoOpenReceptions
+	}
+
+	if expectedVersion != models.AnyVersion && reception.Version != expectedVersion {
+		return nil, domainerrors.ErrVersionMismatch
+	}
+
+	return reception, nil
+}
+
+// Create - добавляет товар в последнюю открытую приёмку ПВЗ и увеличивает версию приёмки.
+// Возвращает созданный товар или ошибку, если открытой приёмки нет или её версия не совпала.
+func (r *memoryProductRepository) Create(ctx context.Context, product *models.AddProduct, expectedReceptionVersion int) (*models.Product, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	reception, err := r.openReception(product.PVZID, expectedReceptionVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	// Повтор айди в PostgreSQL нарушает первичный ключ, что считается непредвиденной ошибкой
+	if _, exists := r.store.products[product.ID]; exists {
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	created := &models.Product{
+		ID:          product.ID,
+		DateTime:    product.DateTime,
+		Type:        product.Type,
+		ReceptionID: reception.ID,
+		OrderID:     product.OrderID,
+		Status:      models.ProductStatusStored,
+	}
+
+	r.store.products[created.ID] = created
+	reception.Version++
+
+	return copyProduct(created), nil
+}
+
+// CreateBatch - добавляет пакет товаров в открытую приёмку указанного ПВЗ атомарно.
+// Товары, уже добавленные в эту приёмку (в том числе раньше в этом же пакете), получают статус
+// models.BatchItemStatusDuplicate, а товары с айди из другой приёмки - ошибку domainerrors.ErrProductIDConflict.
+// В режиме models.BatchModeAllOrNothing при ошибке не добавляется ни один товар и результат возвращается с Applied = false.
+// Версия приёмки увеличивается, если был добавлен хотя бы один товар.
+func (r *memoryProductRepository) CreateBatch(ctx context.Context, pvzID uuid.UUID, products []*models.AddProduct, mode models.BatchMode, expectedReceptionVersion int) (*models.AddProductsBatchResult, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	reception, err := r.openReception(pvzID, expectedReceptionVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &models.AddProductsBatchResult{
+		ReceptionID: reception.ID,
+		Applied:     true,
+		Items:       make([]*models.BatchItemResult, 0, len(products)),
+	}
+
+	// Товары сохраняются только после обработки всего пакета, чтобы отклоненный пакет не оставил следов
+	created := make(map[uuid.UUID]*models.Product, len(products))
+
+	for i, product := range products {
+		item := &models.BatchItemResult{Index: i}
+
+		// Товар не может быть отсканирован раньше открытия приёмки
+		if product.DateTime.Before(reception.DateTime) {
+			item.Status = models.BatchItemStatusFailed
+			item.Err = fmt.Errorf("%w: %s", domainerrors.ErrInvalidProductTime, product.DateTime.Format(time.RFC3339))
+			result.Items = append(result.Items, item)
+
+			if mode == models.BatchModeAllOrNothing {
+				result.Applied = false
+			}
+
+			continue
+		}
+
+		existing, exists := created[product.ID]
+		if !exists {
+			existing, exists = r.store.products[product.ID]
+		}
+
+		switch {
+		case !exists:
+			existing = &models.Product{
+				ID:          product.ID,
+				DateTime:    product.DateTime,
+				Type:        product.Type,
+				ReceptionID: reception.ID,
+				OrderID:     product.OrderID,
+				Status:      models.ProductStatusStored,
+			}
+			created[product.ID] = existing
+
+			item.Status = models.BatchItemStatusCreated
+			item.Product = copyProduct(existing)
+		case existing.ReceptionID == reception.ID:
+			item.Status = models.BatchItemStatusDuplicate
+			item.Product = copyProduct(existing)
+		default:
+			item.Status = models.BatchItemStatusFailed
+			item.Err = domainerrors.ErrProductIDConflict
+		}
+
+		result.Items = append(result.Items, item)
+
+		if item.Status == models.BatchItemStatusFailed && mode == models.BatchModeAllOrNothing {
+			result.Applied = false
+		}
+	}
+
+	if !result.Applied {
+		for _, item := range result.Items {
+			if item.Status != models.BatchItemStatusFailed {
+				item.Status = models.BatchItemStatusSkipped
+				item.Product = nil
+			}
+		}
+
+		return result, nil
+	}
+
+	for id, product := range created {
+		r.store.products[id] = product
+	}
+
+	if len(created) > 0 {
+		reception.Version++
+	}
+
+	return result, nil
+}
+
+// DeleteLast - удаляет последний товар из открытой приёмки в указанном ПВЗ (LIFO) и увеличивает версию приёмки.
+// Если открытой приёмки нет, возвращает domainerrors.ErrNoOpenReceptions, если в ней нет товаров -
+// domainerrors.ErrNoProductsInReception, если последний товар уже не хранится в ПВЗ - domainerrors.ErrProductNotStored.
+// Возвращает удаленный товар и город ПВЗ.
+func (r *memoryProductRepository) DeleteLast(ctx context.Context, pvzID uuid.UUID, expectedReceptionVersion int) (*models.Product, models.CityType, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, "", err
+	}
+	defer r.store.unlock()
+
+	reception, err := r.openReception(pvzID, expectedReceptionVersion)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var last *models.Product
+
+	for _, product := range r.store.receptionProducts(reception.ID) {
+		if last == nil || product.DateTime.After(last.DateTime) {
+			last = product
+		}
+	}
+
+	if last == nil {
+		return nil, "", domainerrors.ErrNoProductsInReception
+	}
+
+	if last.Status != models.ProductStatusStored {
+		return nil, "", domainerrors.ErrProductNotStored
+	}
+
+	delete(r.store.products, last.ID)
+	reception.Version++
+
+	return copyProduct(last), r.store.pvzs[pvzID].City, nil
+}
+
+// Issue - выдает клиенту товары ПВЗ атомарно и расходует код получения pickupCodeID.
+// Выдать можно только хранящиеся в ПВЗ товары заказа кода из закрытых приёмок,
+// при ошибке хотя бы в одном товаре не выдается ни один, а код расходуется только после выдачи
+// всех хранящихся товаров заказа (см. postgresqlProductRepository.Issue).
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Возвращает выдачу с товарами в порядке productIDs и город ПВЗ.
+func (r *memoryProductRepository) Issue(ctx context.Context, issuance *models.Issuance, pickupCodeID uuid.UUID, productIDs []uuid.UUID) (*models.Issuance, models.CityType, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, "", err
+	}
+	defer r.store.unlock()
+
+	pvz, ok := r.store.pvzs[issuance.PVZID]
+	if !ok {
+		return nil, "", databaseerrors.ErrNoRows
+	}
+
+	code, ok := r.store.pickupCodes[pickupCodeID]
+	if !ok || code.PVZID != pvz.ID || code.UsedAt != nil || code.Expired(issuance.DateTime) {
+		return nil, "", domainerrors.ErrInvalidPickupCode
+	}
+
+	products := make([]*models.Product, 0, len(productIDs))
+
+	for _, id := range productIDs {
+		product, ok := r.store.products[id]
+		if !ok || r.store.receptions[product.ReceptionID].PVZID != pvz.ID {
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotFound, id)
+		}
+
+		if product.OrderID != code.OrderID {
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotInOrder, id)
+		}
+
+		if r.store.receptions[product.ReceptionID].Status != models.ReceptionStatusClose {
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotReceived, id)
+		}
+
+		if product.Status != models.ProductStatusStored {
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotStored, id)
+		}
+
+		products = append(products, product)
+	}
+
+	stored := *issuance
+	stored.Products = nil
+	r.store.issuances[stored.ID] = &stored
+
+	result := stored
+	result.Products = make([]*models.Product, 0, len(products))
+
+	for _, product := range products {
+		issuanceID, issuedAt := issuance.ID, issuance.DateTime
+
+		product.Status = models.ProductStatusIssued
+		product.IssuanceID = &issuanceID
+		product.IssuedAt = &issuedAt
+
+		result.Products = append(result.Products, copyProduct(product))
+	}
+
+	if !r.store.hasStoredOrderProducts(pvz.ID, code.OrderID) {
+		usedAt := issuance.DateTime
+		code.UsedAt = &usedAt
+	}
+
+	return &result, pvz.City, nil
+}
+
+// hasStoredOrderProducts проверяет, остались ли в ПВЗ хранящиеся товары заказа.
+// Вызывается под блокировкой хранилища.
+func (s *Store) hasStoredOrderProducts(pvzID, orderID uuid.UUID) bool {
+	for _, product := range s.products {
+		if product.OrderID == orderID && product.Status == models.ProductStatusStored &&
+			s.receptions[product.ReceptionID].PVZID == pvzID {
+			return true
+		}
+	}
+
+	return false
+}
+
+// ListStoredByOrder - возвращает хранящиеся в ПВЗ товары заказа из закрытых приёмок
+// в порядке приёмки (по времени, затем по айди). Если таких товаров нет, возвращает пустой список.
+func (r *memoryProductRepository) ListStoredByOrder(ctx context.Context, pvzID, orderID uuid.UUID) ([]*models.Product, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	products := make([]*models.Product, 0)
+
+	for _, product := range r.store.products {
+		reception := r.store.receptions[product.ReceptionID]
+
+		if reception.PVZID != pvzID || reception.Status != models.ReceptionStatusClose ||
+			product.OrderID != orderID || product.Status != models.ProductStatusStored {
+			continue
+		}
+
+		products = append(products, copyProduct(product))
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].DateTime.Equal(products[j].DateTime) {
+			return products[i].DateTime.Before(products[j].DateTime)
+		}
+
+		return compareIDs(products[i].ID, products[j].ID) < 0
+	})
+
+	return products, nil
+}
+
+// storageDeadlineKey - город ПВЗ и тип товара, для которых задана граница срока хранения.
+type storageDeadlineKey struct {
+	city        models.CityType
+	productType models.ProductType
+}
+
+// expiredProducts возвращает хранящиеся товары из закрытых приёмок, принятые не позже границы
+// для города ПВЗ и типа товара из deadlines, в порядке приёмки (по времени, затем по айди).
+// Если pvzID не равен uuid.Nil, возвращаются только товары этого ПВЗ. Вызывается под блокировкой хранилища.
+func (r *memoryProductRepository) expiredProducts(pvzID uuid.UUID, deadlines []models.StorageDeadline) []*models.Product {
+	receivedBy := make(map[storageDeadlineKey]time.Time, len(deadlines))
+	for _, deadline := range deadlines {
+		receivedBy[storageDeadlineKey{city: deadline.City, productType: deadline.Type}] = deadline.ReceivedBy
+	}
+
+	products := make([]*models.Product, 0)
+
+	for _, product := range r.store.products {
+		reception := r.store.receptions[product.ReceptionID]
+
+		if pvzID != uuid.Nil && reception.PVZID != pvzID {
+			continue
+		}
+
+		if reception.Status != models.ReceptionStatusClose || product.Status != models.ProductStatusStored {
+			continue
+		}
+
+		deadline, ok := receivedBy[storageDeadlineKey{city: r.store.pvzs[reception.PVZID].City, productType: product.Type}]
+		if !ok || product.DateTime.After(deadline) {
+			continue
+		}
+
+		products = append(products, product)
+	}
+
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].DateTime.Equal(products[j].DateTime) {
+			return products[i].DateTime.Before(products[j].DateTime)
+		}
+
+		return compareIDs(products[i].ID, products[j].ID) < 0
+	})
+
+	return products
+}
+
+// ListExpiring - возвращает хранящиеся в ПВЗ товары из закрытых приёмок, принятые не позже границы
+// для города ПВЗ и типа товара из deadlines, в порядке приёмки (по времени, затем по айди).
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows. Возвращает товары и город ПВЗ.
+func (r *memoryProductRepository) ListExpiring(ctx context.Context, pvzID uuid.UUID, deadlines []models.StorageDeadline) ([]*models.Product, models.CityType, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, "", err
+	}
+	defer r.store.unlock()
+
+	pvz, ok := r.store.pvzs[pvzID]
+	if !ok {
+		return nil, "", databaseerrors.ErrNoRows
+	}
+
+	expired := r.expiredProducts(pvzID, deadlines)
+
+	products := make([]*models.Product, 0, len(expired))
+	for _, product := range expired {
+		products = append(products, copyProduct(product))
+	}
+
+	return products, pvz.City, nil
+}
+
+// ReturnToSender - переносит хранящиеся в ПВЗ товары из закрытых приёмок, принятые не позже границы
+// для города ПВЗ и типа товара из deadlines, в новую отправку отправителю атомарно.
+// Если таких товаров нет, возвращает domainerrors.ErrNoExpiredProducts и не создает отправку.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Возвращает отправку с товарами в порядке приёмки и город ПВЗ.
+func (r *memoryProductRepository) ReturnToSender(ctx context.Context, shipment *models.Shipment, deadlines []models.StorageDeadline) (*models.Shipment, models.CityType, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, "", err
+	}
+	defer r.store.unlock()
+
+	pvz, ok := r.store.pvzs[shipment.PVZID]
+	if !ok {
+		return nil, "", databaseerrors.ErrNoRows
+	}
+
+	products := r.expiredProducts(pvz.ID, deadlines)
+	if len(products) == 0 {
+		return nil, "", domainerrors.ErrNoExpiredProducts
+	}
+
+	r.store.shipments[shipment.ID] = copyShipment(shipment)
+
+	result := copyShipment(shipment)
+	result.Products = make([]*models.Product, 0, len(products))
+
+	for _, product := range products {
+		shipmentID, returnedAt := shipment.ID, shipment.DateTime
+
+		product.Status = models.ProductStatusReturnedToSender
+		product.ShipmentID = &shipmentID
+		product.ReturnedAt = &returnedAt
+
+		result.Products = append(result.Products, copyProduct(product))
+	}
+
+	return result, pvz.City, nil
+}
+
+// CountExpired - возвращает количество хранящихся товаров из закрытых приёмок, принятых не позже границы
+// для города ПВЗ и типа товара из deadlines, по городам и типам товаров. Города и типы без таких товаров не попадают в результат.
+func (r *memoryProductRepository) CountExpired(ctx context.Context, deadlines []models.StorageDeadline) (map[models.CityType]map[models.ProductType]int, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	counts := make(map[models.CityType]map[models.ProductType]int)
+
+	for _, product := range r.expiredProducts(uuid.Nil, deadlines) {
+		city := r.store.pvzs[r.store.receptions[product.ReceptionID].PVZID].City
+		if counts[city] == nil {
+			counts[city] = make(map[models.ProductType]int)
+		}
+
+		counts[city][product.Type]++
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,162 @@
+package memoryrepo
+
+import (
+	"context"
+	"sort"
+
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryPVZRepository реализует интерфейс
+// repositories.IPVZRepo для работы с ПВЗ в памяти.
+type memoryPVZRepository struct {
+	store *Store
+}
+
+// NewMemoryPVZRepository создает новый экземпляр memoryPVZRepository.
+func NewMemoryPVZRepository(store *Store) repositories.IPVZRepo {
+	return &memoryPVZRepository{store: store}
+}
+
+// Create сохраняет ПВЗ и заполняет pvz.Version начальной версией.
+// Если ПВЗ с указанным айди уже существует, возвращает databaseerrors.ErrUniqueViolation.
+func (r *memoryPVZRepository) Create(ctx context.Context, pvz *models.PVZ) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	if _, exists := r.store.pvzs[pvz.ID]; exists {
+		return databaseerrors.ErrUniqueViolation
+	}
+
+	pvz.Version = 1
+	r.store.pvzs[pvz.ID] = copyPVZ(pvz)
+
+	return nil
+}
+
+// List выводит список ПВЗ, приемок в них и товаров в приёмках так же, как репозиторий PostgreSQL:
+// ПВЗ отсортированы по дате регистрации (новые первыми) и разбиты на страницы до фильтрации по дате,
+// поэтому при фильтре по дате страница может содержать меньше PageSize ПВЗ.
+// Приемки отсортированы от новых к старым, товары - от старых к новым.
+func (r *memoryPVZRepository) List(ctx context.Context, filter *models.PVZFilter) ([]*models.PVZWithReceptions, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	pvzs := r.store.sortedPVZs()
+
+	offset := (filter.Page - 1) * filter.PageSize
+	if offset > len(pvzs) {
+		offset = len(pvzs)
+	}
+
+	end := offset + filter.PageSize
+	if end > len(pvzs) {
+		end = len(pvzs)
+	}
+
+	dateFiltered := filter.StartDate != nil || filter.EndDate != nil
+	result := make([]*models.PVZWithReceptions, 0, end-offset)
+
+	for _, pvz := range pvzs[offset:end] {
+		receptions := r.listReceptions(pvz, filter)
+
+		// Как и INNER JOIN в PostgreSQL, фильтр по дате исключает ПВЗ без подходящих приемок
+		if dateFiltered && len(receptions) == 0 {
+			continue
+		}
+
+		result = append(result, &models.PVZWithReceptions{
+			PVZ:        copyPVZ(pvz),
+			Receptions: receptions,
+		})
+	}
+
+	return result, nil
+}
+
+// listReceptions возвращает приемки ПВЗ, попадающие в диапазон дат фильтра, вместе с товарами.
+func (r *memoryPVZRepository) listReceptions(pvz *models.PVZ, filter *models.PVZFilter) []*models.ReceptionWithProducts {
+	receptions := make([]*models.ReceptionWithProducts, 0)
+
+	for _, reception := range r.store.receptions {
+		if reception.PVZID != pvz.ID {
+			continue
+		}
+
+		if filter.StartDate != nil && reception.DateTime.Before(*filter.StartDate) {
+			continue
+		}
+
+		if filter.EndDate != nil && reception.DateTime.After(*filter.EndDate) {
+			continue
+		}
+
+		products := make([]*models.Product, 0)
+		for _, product := range r.store.receptionProducts(reception.ID) {
+			products = append(products, copyProduct(product))
+		}
+
+		sortProducts(products)
+
+		receptions = append(receptions, &models.ReceptionWithProducts{
+			Reception: copyReception(reception),
+			Products:  products,
+		})
+	}
+
+	sort.Slice(receptions, func(i, j int) bool {
+		return receptions[i].Reception.DateTime.After(receptions[j].Reception.DateTime)
+	})
+
+	return receptions
+}
+
+// GetAll возвращает все существующие ПВЗ.
+func (r *memoryPVZRepository) GetAll(ctx context.Context) ([]*models.PVZ, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	pvzs := make([]*models.PVZ, 0, len(r.store.pvzs))
+	for _, pvz := range r.store.sortedPVZs() {
+		pvzs = append(pvzs, copyPVZ(pvz))
+	}
+
+	return pvzs, nil
+}
+
+// sortedPVZs возвращает ПВЗ, отсортированные по дате регистрации (новые первыми) и айди.
+func (s *Store) sortedPVZs() []*models.PVZ {
+	pvzs := make([]*models.PVZ, 0, len(s.pvzs))
+	for _, pvz := range s.pvzs {
+		pvzs = append(pvzs, pvz)
+	}
+
+	sort.Slice(pvzs, func(i, j int) bool {
+		if !pvzs[i].RegistrationDate.Equal(pvzs[j].RegistrationDate) {
+			return pvzs[i].RegistrationDate.After(pvzs[j].RegistrationDate)
+		}
+
+		return compareIDs(pvzs[i].ID, pvzs[j].ID) < 0
+	})
+
+	return pvzs
+}
+
+// sortProducts сортирует товары по времени приемки и айди.
+func sortProducts(products []*models.Product) {
+	sort.Slice(products, func(i, j int) bool {
+		if !products[i].DateTime.Equal(products[j].DateTime) {
+			return products[i].DateTime.Before(products[j].DateTime)
+		}
+
+		return compareIDs(products[i].ID, products[j].ID) < 0
+	})
+}
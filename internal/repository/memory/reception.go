@@ -0,0 +1,269 @@
+package memoryrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryReceptionRepository реализует интерфейс repositories.IReceptionRepo
+type memoryReceptionRepository struct {
+	store *Store
+}
+
+// NewMemoryReceptionRepository создает новый экземпляр memoryReceptionRepository.
+func NewMemoryReceptionRepository(store *Store) repositories.IReceptionRepo {
+	return &memoryReceptionRepository{store: store}
+}
+
+// CreateIfNoOpen создает новую приемку, если в ПВЗ нет открытых приемок.
+// Привязывает к новой приемке ожидающий манифест ПВЗ и увеличивает версию ПВЗ.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Если expectedPVZVersion не равна models.AnyVersion и не совпадает с версией ПВЗ, возвращает domainerrors.ErrVersionMismatch.
+// Если открытая приёмка уже существует, возвращает domainerrors.ErrOpenReceptionExists.
+// Заполняет reception.Version начальной версией приемки.
+func (r *memoryReceptionRepository) CreateIfNoOpen(ctx context.Context, reception *models.Reception, expectedPVZVersion int) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	pvz, err := r.store.checkPVZVersion(reception.PVZID, expectedPVZVersion)
+	if err != nil {
+		return err
+	}
+
+	if r.store.openReception(reception.PVZID) != nil {
+		return domainerrors.ErrOpenReceptionExists
+	}
+
+	// Повтор айди в PostgreSQL нарушает первичный ключ, что считается непредвиденной ошибкой
+	if _, exists := r.store.receptions[reception.ID]; exists {
+		return databaseerrors.ErrUnexpected
+	}
+
+	r.store.receptions[reception.ID] = &models.Reception{
+		ID:       reception.ID,
+		DateTime: reception.DateTime,
+		PVZID:    reception.PVZID,
+		Status:   reception.Status,
+		OpenedBy: reception.OpenedBy,
+		Version:  1,
+	}
+	reception.Version = 1
+
+	for _, manifest := range r.store.manifests {
+		if manifest.PVZID == reception.PVZID && manifest.ReceptionID == nil {
+			receptionID := reception.ID
+			manifest.ReceptionID = &receptionID
+		}
+	}
+
+	pvz.Version++
+
+	return nil
+}
+
+// CloseLast закрывает последнюю открытую приемку в ПВЗ и сохраняет время её закрытия
+// и айди закрывшего её пользователя. Возвращает закрытую приемку и город ПВЗ.
+// Если к приемке привязан манифест, под той же блокировкой строит и сохраняет отчет о расхождениях.
+// Если открытой приемки нет, возвращает domainerrors.ErrNoOpenReceptions.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией приемки, возвращает domainerrors.ErrVersionMismatch.
+func (r *memoryReceptionRepository) CloseLast(ctx context.Context, pvzID, closedBy uuid.UUID, closedAt time.Time, expectedVersion int) (*models.Reception, models.CityType, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, "", err
+	}
+	defer r.store.unlock()
+
+	reception, err := r.openReceptionForUpdate(pvzID, expectedVersion)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reception.Status = models.ReceptionStatusClose
+	reception.ClosedAt = &closedAt
+	reception.ClosedBy = &closedBy
+	reception.Version++
+	r.store.pvzs[pvzID].Version++
+
+	closed := copyReception(reception)
+	closed.Discrepancy = r.store.buildDiscrepancyReport(reception.ID, closedAt)
+
+	return closed, r.store.pvzs[pvzID].City, nil
+}
+
+// CancelLast отменяет открытую приемку в ПВЗ и сохраняет время отмены,
+// айди отменившего её пользователя и причину.
+// Если открытой приемки нет, возвращает domainerrors.ErrNoOpenReceptions.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией приемки, возвращает domainerrors.ErrVersionMismatch.
+func (r *memoryReceptionRepository) CancelLast(ctx context.Context, pvzID, cancelledBy uuid.UUID, cancelledAt time.Time, reason string, expectedVersion int) (*models.Reception, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	reception, err := r.openReceptionForUpdate(pvzID, expectedVersion)
+	if err != nil {
+		return nil, err
+	}
+
+	reception.Status = models.ReceptionStatusCancelled
+	reception.ClosedAt = &cancelledAt
+	reception.ClosedBy = &cancelledBy
+	reception.CloseReason = reason
+	reception.Version++
+	r.store.pvzs[pvzID].Version++
+
+	return copyReception(reception), nil
+}
+
+// GetLast возвращает последнюю по времени открытия приемку в ПВЗ независимо от её статуса.
+// Если в ПВЗ нет приемок, возвращает databaseerrors.ErrNoRows.
+func (r *memoryReceptionRepository) GetLast(ctx context.Context, pvzID uuid.UUID) (*models.Reception, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	var last *models.Reception
+
+	for _, reception := range r.store.receptions {
+		if reception.PVZID == pvzID && (last == nil || reception.DateTime.After(last.DateTime)) {
+			last = reception
+		}
+	}
+
+	if last == nil {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	return copyReception(last), nil
+}
+
+// Reopen повторно открывает закрытую приемку и сохраняет время и айди переоткрывшего её пользователя.
+// Данные о закрытии сбрасываются. Приемка должна быть закрыта, быть последней в ПВЗ,
+// и в ПВЗ не должно быть открытых приемок, иначе возвращается domainerrors.ErrReceptionNotReopenable.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией приемки, возвращает domainerrors.ErrVersionMismatch.
+func (r *memoryReceptionRepository) Reopen(ctx context.Context, receptionID, reopenedBy uuid.UUID, reopenedAt time.Time, expectedVersion int) (*models.Reception, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	reception, ok := r.store.receptions[receptionID]
+	if !ok {
+		return nil, domainerrors.ErrReceptionNotReopenable
+	}
+
+	// Как и в PostgreSQL, при невыполненных условиях несовпадение версии сообщается в первую очередь
+	if expectedVersion != models.AnyVersion && reception.Version != expectedVersion {
+		return nil, domainerrors.ErrVersionMismatch
+	}
+
+	if reception.Status != models.ReceptionStatusClose || !r.isLastAndNoOpen(reception) {
+		return nil, domainerrors.ErrReceptionNotReopenable
+	}
+
+	reception.Status = models.ReceptionStatusInProgress
+	reception.ClosedAt = nil
+	reception.ClosedBy = nil
+	reception.AutoClosed = false
+	reception.CloseReason = ""
+	reception.ReopenedAt = &reopenedAt
+	reception.ReopenedBy = &reopenedBy
+	reception.Version++
+	r.store.pvzs[reception.PVZID].Version++
+
+	return copyReception(reception), nil
+}
+
+// CloseStale автоматически закрывает все приемки в ПВЗ указанного города, открытые раньше openedBefore.
+// Закрытые приемки помечаются как auto_closed с указанной причиной, closed_by остается пустым.
+// Для приемок с манифестом под той же блокировкой строятся отчеты о расхождениях.
+// Вызывается под блокировкой LockAutoClose.
+// Возвращает список закрытых приемок в порядке открытия.
+func (r *memoryReceptionRepository) CloseStale(ctx context.Context, city models.CityType, openedBefore, closedAt time.Time, reason string) ([]*models.Reception, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	result := make([]*models.Reception, 0)
+
+	for _, reception := range r.store.receptions {
+		if reception.Status != models.ReceptionStatusInProgress || !reception.DateTime.Before(openedBefore) {
+			continue
+		}
+
+		pvz, ok := r.store.pvzs[reception.PVZID]
+		if !ok || pvz.City != city {
+			continue
+		}
+
+		reception.Status = models.ReceptionStatusClose
+		reception.ClosedAt = &closedAt
+		reception.AutoClosed = true
+		reception.CloseReason = reason
+		reception.Version++
+		pvz.Version++
+
+		closed := copyReception(reception)
+		closed.Discrepancy = r.store.buildDiscrepancyReport(reception.ID, closedAt)
+
+		result = append(result, closed)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].DateTime.Before(result[j].DateTime)
+	})
+
+	return result, nil
+}
+
+// openReceptionForUpdate возвращает открытую приемку ПВЗ для изменения, проверяя её версию.
+// Если открытой приемки нет, возвращает domainerrors.ErrNoOpenReceptions,
+// если версия не совпадает - domainerrors.ErrVersionMismatch.
+func (r *memoryReceptionRepository) openReceptionForUpdate(pvzID uuid.UUID, expectedVersion int) (*models.Reception, error) {
+	reception := r.store.openReception(pvzID)
+	if reception == nil {
+		return nil, domainerrors.ErrNoOpenReceptions
+	}
+
+	if expectedVersion != models.AnyVersion && reception.Version != expectedVersion {
+		return nil, domainerrors.ErrVersionMismatch
+	}
+
+	return reception, nil
+}
+
+// isLastAndNoOpen проверяет, что после приемки в ПВЗ не открывались другие и среди них нет открытых.
+func (r *memoryReceptionRepository) isLastAndNoOpen(reception *models.Reception) bool {
+	for _, other := range r.store.receptions {
+		if other.PVZID != reception.PVZID || other.ID == reception.ID {
+			continue
+		}
+
+		if other.DateTime.After(reception.DateTime) || other.Status == models.ReceptionStatusInProgress {
+			return false
+		}
+	}
+
+	return true
+}
+
+// LockAutoClose захватывает блокировку автоматического закрытия приемок и возвращает функцию её снятия.
+// Хранилище в памяти не разделяется между репликами, поэтому блокировка защищает только от
+// одновременных проходов в одном процессе. Если блокировка занята, возвращает databaseerrors.ErrLockNotAcquired.
+func (r *memoryReceptionRepository) LockAutoClose(ctx context.Context) (func(), error) {
+	if !r.store.autoCloseMu.TryLock() {
+		return nil, databaseerrors.ErrLockNotAcquired
+	}
+
+	return r.store.autoCloseMu.Unlock, nil
+}
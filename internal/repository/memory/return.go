@@ -0,0 +1,189 @@
+package memoryrepo
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryReturnRepository - структура репозитория для работы с возвратами товаров клиентами в памяти.
+// Реализует интерфейс repositories.IReturnRepo
+type memoryReturnRepository struct {
+	store *Store
+}
+
+// NewMemoryReturnRepository - конструктор для создания нового экземпляра memoryReturnRepository.
+func NewMemoryReturnRepository(store *Store) repositories.IReturnRepo {
+	return &memoryReturnRepository{store: store}
+}
+
+// pendingShipment возвращает собираемую отправку возвратов ПВЗ или nil. Вызывается под блокировкой хранилища.
+func (r *memoryReturnRepository) pendingShipment(pvzID uuid.UUID) *models.Shipment {
+	for _, shipment := range r.store.shipments {
+		if shipment.PVZID == pvzID && shipment.Kind == models.ShipmentKindCustomerReturns && shipment.DispatchedAt == nil {
+			return shipment
+		}
+	}
+
+	return nil
+}
+
+// sortedReturns возвращает копии возвратов, для которых match вернул true, в порядке регистрации.
+// Вызывается под блокировкой хранилища.
+func (r *memoryReturnRepository) sortedReturns(match func(ret *models.CustomerReturn) bool) []*models.CustomerReturn {
+	returns := make([]*models.CustomerReturn, 0)
+
+	for _, ret := range r.store.returns {
+		if match(ret) {
+			returns = append(returns, copyCustomerReturn(ret))
+		}
+	}
+
+	sort.Slice(returns, func(i, j int) bool {
+		if !returns[i].DateTime.Equal(returns[j].DateTime) {
+			return returns[i].DateTime.Before(returns[j].DateTime)
+		}
+
+		return compareIDs(returns[i].ID, returns[j].ID) < 0
+	})
+
+	return returns
+}
+
+// Register атомарно сохраняет возврат.
+// Если ret.ProductID не nil, товар должен быть выдан клиенту в этом ПВЗ: иначе возвращается
+// domainerrors.ErrProductNotFound или domainerrors.ErrProductNotIssued. Тип возврата берется из товара,
+// а сам товар получает статус models.ProductStatusReturnedByCustomer.
+// Возврат добавляется в собираемую отправку возвратов ПВЗ, если её нет - она создается.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows. Возвращает сохраненный возврат и город ПВЗ.
+func (r *memoryReturnRepository) Register(ctx context.Context, ret *models.CustomerReturn) (*models.CustomerReturn, models.CityType, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, "", err
+	}
+	defer r.store.unlock()
+
+	pvz, ok := r.store.pvzs[ret.PVZID]
+	if !ok {
+		return nil, "", databaseerrors.ErrNoRows
+	}
+
+	if _, exists := r.store.returns[ret.ID]; exists {
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	result := copyCustomerReturn(ret)
+
+	if ret.ProductID != nil {
+		product, ok := r.store.products[*ret.ProductID]
+		if !ok || r.store.receptions[product.ReceptionID].PVZID != ret.PVZID {
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotFound, *ret.ProductID)
+		}
+
+		if product.Status != models.ProductStatusIssued {
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotIssued, *ret.ProductID)
+		}
+
+		product.Status = models.ProductStatusReturnedByCustomer
+		result.Type = product.Type
+	}
+
+	shipment := r.pendingShipment(ret.PVZID)
+	if shipment == nil {
+		shipment = &models.Shipment{
+			ID:        uuid.New(),
+			PVZID:     ret.PVZID,
+			Kind:      models.ShipmentKindCustomerReturns,
+			CreatedBy: ret.CreatedBy,
+			DateTime:  ret.DateTime,
+		}
+		r.store.shipments[shipment.ID] = shipment
+	}
+
+	result.ShipmentID = shipment.ID
+	r.store.returns[result.ID] = copyCustomerReturn(result)
+
+	return result, pvz.City, nil
+}
+
+// ListByPVZ возвращает возвраты ПВЗ, зарегистрированные в период от startDate до endDate включительно,
+// в порядке регистрации. Границы периода необязательны.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+func (r *memoryReturnRepository) ListByPVZ(ctx context.Context, pvzID uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	if _, ok := r.store.pvzs[pvzID]; !ok {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	return r.periodReturns([]uuid.UUID{pvzID}, startDate, endDate), nil
+}
+
+// ListByPVZs возвращает возвраты нескольких ПВЗ, зарегистрированные в период от startDate до endDate включительно,
+// в порядке регистрации. Границы периода необязательны.
+func (r *memoryReturnRepository) ListByPVZs(ctx context.Context, pvzIDs []uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	return r.periodReturns(pvzIDs, startDate, endDate), nil
+}
+
+// periodReturns возвращает копии возвратов ПВЗ за период в порядке регистрации. Вызывается под блокировкой хранилища.
+func (r *memoryReturnRepository) periodReturns(pvzIDs []uuid.UUID, startDate, endDate *time.Time) []*models.CustomerReturn {
+	pvzs := make(map[uuid.UUID]struct{}, len(pvzIDs))
+	for _, id := range pvzIDs {
+		pvzs[id] = struct{}{}
+	}
+
+	return r.sortedReturns(func(ret *models.CustomerReturn) bool {
+		if _, ok := pvzs[ret.PVZID]; !ok {
+			return false
+		}
+
+		if startDate != nil && ret.DateTime.Before(*startDate) {
+			return false
+		}
+
+		return endDate == nil || !ret.DateTime.After(*endDate)
+	})
+}
+
+// Dispatch атомарно отмечает собираемую отправку возвратов ПВЗ отправленной в момент dispatchedAt.
+// Следующий возврат ПВЗ попадет уже в новую отправку.
+// Если собираемой отправки нет, возвращает domainerrors.ErrNoPendingReturns.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows. Возвращает отправку с возвратами в порядке регистрации.
+func (r *memoryReturnRepository) Dispatch(ctx context.Context, pvzID uuid.UUID, dispatchedAt time.Time) (*models.Shipment, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	if _, ok := r.store.pvzs[pvzID]; !ok {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	shipment := r.pendingShipment(pvzID)
+	if shipment == nil {
+		return nil, domainerrors.ErrNoPendingReturns
+	}
+
+	shipment.DispatchedAt = &dispatchedAt
+
+	result := copyShipment(shipment)
+	result.Returns = r.sortedReturns(func(ret *models.CustomerReturn) bool {
+		return ret.ShipmentID == shipment.ID
+	})
+
+	return result, nil
+}
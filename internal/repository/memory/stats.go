@@ -0,0 +1,201 @@
+package memoryrepo
+
+import (
+	"context"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+)
+
+// memoryStatsRepository реализует интерфейс repositories.IStatsRepo.
+// Статистика считается перебором данных хранилища с теми же правилами, что и агрегатные запросы PostgreSQL.
+type memoryStatsRepository struct {
+	store *Store
+}
+
+// NewMemoryStatsRepository создает новый экземпляр memoryStatsRepository.
+func NewMemoryStatsRepository(store *Store) repositories.IStatsRepo {
+	return &memoryStatsRepository{store: store}
+}
+
+// GetPVZStats возвращает статистику по приемкам, открытым в указанном диапазоне, для каждого ПВЗ.
+// Средняя длительность считается только по закрытым вручную приемкам (см. postgresqlStatsRepository.GetPVZStats),
+// среднее количество товаров - по всем приемкам.
+// Отмененные приемки не учитываются. ПВЗ без приемок в диапазоне в результат не попадают.
+// Результат отсортирован по количеству приемок (больше первыми) и айди ПВЗ.
+func (r *memoryStatsRepository) GetPVZStats(ctx context.Context, filter *models.StatsFilter) ([]*models.PVZStats, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	stats := make(map[uuid.UUID]*models.PVZStats)
+	durations := make(map[uuid.UUID]time.Duration)
+	closedByHand := make(map[uuid.UUID]int)
+
+	for _, reception := range r.store.receptions {
+		if reception.Status == models.ReceptionStatusCancelled || !inRange(reception.DateTime, filter.StartDate, filter.EndDate) {
+			continue
+		}
+
+		pvzStats, ok := stats[reception.PVZID]
+		if !ok {
+			pvzStats = &models.PVZStats{
+				PVZID: reception.PVZID,
+				City:  r.store.pvzs[reception.PVZID].City,
+			}
+			stats[reception.PVZID] = pvzStats
+		}
+
+		pvzStats.ReceptionsCount++
+		pvzStats.ProductsCount += len(r.store.receptionProducts(reception.ID))
+
+		if reception.ClosedAt != nil {
+			pvzStats.ClosedReceptionsCount++
+		}
+
+		if reception.ClosedAt != nil && !reception.AutoClosed {
+			closedByHand[reception.PVZID]++
+			durations[reception.PVZID] += reception.ClosedAt.Sub(reception.DateTime)
+		}
+	}
+
+	result := make([]*models.PVZStats, 0, len(stats))
+
+	for pvzID, pvzStats := range stats {
+		if closedByHand[pvzID] > 0 {
+			pvzStats.AvgReceptionDuration = durations[pvzID] / time.Duration(closedByHand[pvzID])
+		}
+
+		pvzStats.AvgProductsPerReception = float64(pvzStats.ProductsCount) / float64(pvzStats.ReceptionsCount)
+		result = append(result, pvzStats)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].ReceptionsCount != result[j].ReceptionsCount {
+			return result[i].ReceptionsCount > result[j].ReceptionsCount
+		}
+
+		return compareIDs(result[i].PVZID, result[j].PVZID) < 0
+	})
+
+	return result, nil
+}
+
+// productTypeStatsKey - город, день и тип товара, по которым группируется статистика товаров.
+type productTypeStatsKey struct {
+	city        models.CityType
+	day         time.Time
+	productType models.ProductType
+}
+
+// GetProductTypeStats возвращает количество товаров каждого типа, принятых в указанном диапазоне,
+// сгруппированное по городу ПВЗ и дню приемки товара (в UTC). Товары из отмененных приемок не учитываются.
+// Результат отсортирован по дню, городу и типу товара.
+func (r *memoryStatsRepository) GetProductTypeStats(ctx context.Context, filter *models.StatsFilter) ([]*models.ProductTypeStats, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	counts := make(map[productTypeStatsKey]int)
+
+	for _, product := range r.store.products {
+		if !inRange(product.DateTime, filter.StartDate, filter.EndDate) {
+			continue
+		}
+
+		reception := r.store.receptions[product.ReceptionID]
+		if reception.Status == models.ReceptionStatusCancelled {
+			continue
+		}
+
+		counts[productTypeStatsKey{
+			city:        r.store.pvzs[reception.PVZID].City,
+			day:         truncateDay(product.DateTime),
+			productType: product.Type,
+		}]++
+	}
+
+	result := make([]*models.ProductTypeStats, 0, len(counts))
+	for key, count := range counts {
+		result = append(result, &models.ProductTypeStats{
+			City:  key.city,
+			Day:   key.day,
+			Type:  key.productType,
+			Count: count,
+		})
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		switch {
+		case !result[i].Day.Equal(result[j].Day):
+			return result[i].Day.Before(result[j].Day)
+		case result[i].City != result[j].City:
+			return result[i].City < result[j].City
+		default:
+			return result[i].Type < result[j].Type
+		}
+	})
+
+	return result, nil
+}
+
+// GetBusinessSnapshot возвращает количество открытых приемок по городам, время открытия самой старой из них,
+// количество товаров, принятых начиная с dayStart, по городам и типам, и общее количество ПВЗ.
+// Товары из отмененных приемок не учитываются.
+func (r *memoryStatsRepository) GetBusinessSnapshot(ctx context.Context, dayStart time.Time) (*models.BusinessSnapshot, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	snapshot := &models.BusinessSnapshot{
+		OpenReceptions:   make(map[models.CityType]int),
+		ProductsReceived: make(map[models.CityType]map[models.ProductType]int),
+		PVZCount:         len(r.store.pvzs),
+	}
+
+	for _, reception := range r.store.receptions {
+		if reception.Status != models.ReceptionStatusInProgress {
+			continue
+		}
+
+		snapshot.OpenReceptions[r.store.pvzs[reception.PVZID].City]++
+
+		if snapshot.OldestOpenReceptionAt == nil || reception.DateTime.Before(*snapshot.OldestOpenReceptionAt) {
+			oldestAt := reception.DateTime
+			snapshot.OldestOpenReceptionAt = &oldestAt
+		}
+	}
+
+	for _, product := range r.store.products {
+		reception := r.store.receptions[product.ReceptionID]
+		if product.DateTime.Before(dayStart) || reception.Status == models.ReceptionStatusCancelled {
+			continue
+		}
+
+		city := r.store.pvzs[reception.PVZID].City
+		if snapshot.ProductsReceived[city] == nil {
+			snapshot.ProductsReceived[city] = make(map[models.ProductType]int)
+		}
+
+		snapshot.ProductsReceived[city][product.Type]++
+	}
+
+	return snapshot, nil
+}
+
+// inRange проверяет, что t попадает в диапазон [start, end] включительно.
+func inRange(t, start, end time.Time) bool {
+	return !t.Before(start) && !t.After(end)
+}
+
+// truncateDay отбрасывает время, оставляя начало дня в UTC, как date_trunc('day', ...) в PostgreSQL.
+func truncateDay(t time.Time) time.Time {
+	year, month, day := t.UTC().Date()
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,198 @@
+// Пакет memoryrepo содержит реализации репозиториев, хранящие данные в памяти процесса.
+// Они повторяют поведение репозиториев PostgreSQL (ошибки, порядок и пагинация результатов,
+// версии ресурсов) и используются для локального запуска без базы данных (STORAGE=memory) и в тестах.
+// Данные теряются при остановке процесса и не разделяются между репликами.
+package memoryrepo
+
+import (
+	"bytes"
+	"context"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// Store - общее хранилище репозиториев в памяти, аналог базы данных.
+// Репозитории одного хранилища видят данные друг друга, как таблицы одной базы.
+// Каждая операция выполняется целиком под блокировкой хранилища, поэтому она атомарна, как транзакция.
+type Store struct {
+	mu          sync.Mutex
+	pvzs        map[uuid.UUID]*models.PVZ
+	receptions  map[uuid.UUID]*models.Reception
+	products    map[uuid.UUID]*models.Product
+	issuances   map[uuid.UUID]*models.Issuance // Без товаров, товары ссылаются на выдачу через IssuanceID
+	shipments   map[uuid.UUID]*models.Shipment // Без товаров и возвратов, они ссылаются на отправку через ShipmentID
+	returns     map[uuid.UUID]*models.CustomerReturn
+	users       map[uuid.UUID]*models.User
+	manifests   map[uuid.UUID]*models.Manifest
+	reports     map[uuid.UUID]*models.DiscrepancyReport // По айди приемки
+	idempotency map[idempotencyKey]*models.IdempotencyRecord
+	pickupCodes map[uuid.UUID]*models.PickupCode
+
+	pickupFailures []pickupFailure
+
+	autoCloseMu sync.Mutex // Блокировка автоматического закрытия приемок, удерживается весь проход (см. LockAutoClose)
+}
+
+// pickupFailure - неудачная попытка ввода кода получения в ПВЗ.
+type pickupFailure struct {
+	pvzID    uuid.UUID
+	failedAt time.Time
+}
+
+// idempotencyKey - ключ идемпотентности, уникальный в пределах пользователя.
+type idempotencyKey struct {
+	userID uuid.UUID
+	key    string
+}
+
+// NewStore создает пустое хранилище.
+func NewStore() *Store {
+	return &Store{
+		pvzs:        make(map[uuid.UUID]*models.PVZ),
+		receptions:  make(map[uuid.UUID]*models.Reception),
+		products:    make(map[uuid.UUID]*models.Product),
+		issuances:   make(map[uuid.UUID]*models.Issuance),
+		shipments:   make(map[uuid.UUID]*models.Shipment),
+		returns:     make(map[uuid.UUID]*models.CustomerReturn),
+		users:       make(map[uuid.UUID]*models.User),
+		manifests:   make(map[uuid.UUID]*models.Manifest),
+		reports:     make(map[uuid.UUID]*models.DiscrepancyReport),
+		idempotency: make(map[idempotencyKey]*models.IdempotencyRecord),
+		pickupCodes: make(map[uuid.UUID]*models.PickupCode),
+	}
+}
+
+// lock захватывает блокировку хранилища. Как и запрос к базе данных,
+// операция с отмененным контекстом не выполняется и возвращает databaseerrors.ErrUnexpected.
+// При успехе блокировку нужно снять через unlock.
+func (s *Store) lock(ctx context.Context) error {
+	if ctx.Err() != nil {
+		return databaseerrors.ErrUnexpected
+	}
+
+	s.mu.Lock()
+
+	return nil
+}
+
+func (s *Store) unlock() {
+	s.mu.Unlock()
+}
+
+// checkPVZVersion проверяет существование ПВЗ и его версию. Вызывается под блокировкой хранилища.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией ПВЗ, возвращает domainerrors.ErrVersionMismatch.
+func (s *Store) checkPVZVersion(pvzID uuid.UUID, expectedVersion int) (*models.PVZ, error) {
+	pvz, ok := s.pvzs[pvzID]
+	if !ok {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	if expectedVersion != models.AnyVersion && pvz.Version != expectedVersion {
+		return nil, domainerrors.ErrVersionMismatch
+	}
+
+	return pvz, nil
+}
+
+// openReception возвращает последнюю по времени открытия открытую приемку ПВЗ или nil.
+func (s *Store) openReception(pvzID uuid.UUID) *models.Reception {
+	var open *models.Reception
+
+	for _, reception := range s.receptions {
+		if reception.PVZID != pvzID || reception.Status != models.ReceptionStatusInProgress {
+			continue
+		}
+
+		if open == nil || reception.DateTime.After(open.DateTime) {
+			open = reception
+		}
+	}
+
+	return open
+}
+
+// receptionProducts возвращает товары приемки.
+func (s *Store) receptionProducts(receptionID uuid.UUID) []*models.Product {
+	var products []*models.Product
+
+	for _, product := range s.products {
+		if product.ReceptionID == receptionID {
+			products = append(products, product)
+		}
+	}
+
+	return products
+}
+
+// compareIDs сравнивает айди так же, как PostgreSQL сравнивает значения uuid (побайтово).
+func compareIDs(a, b uuid.UUID) int {
+	return bytes.Compare(a[:], b[:])
+}
+
+func copyPVZ(pvz *models.PVZ) *models.PVZ {
+	c := *pvz
+	return &c
+}
+
+func copyReception(reception *models.Reception) *models.Reception {
+	c := *reception
+	c.Discrepancy = nil
+
+	return &c
+}
+
+func copyProduct(product *models.Product) *models.Product {
+	c := *product
+
+	if product.IssuanceID != nil {
+		issuanceID := *product.IssuanceID
+		c.IssuanceID = &issuanceID
+	}
+
+	if product.IssuedAt != nil {
+		issuedAt := *product.IssuedAt
+		c.IssuedAt = &issuedAt
+	}
+
+	if product.ShipmentID != nil {
+		shipmentID := *product.ShipmentID
+		c.ShipmentID = &shipmentID
+	}
+
+	if product.ReturnedAt != nil {
+		returnedAt := *product.ReturnedAt
+		c.ReturnedAt = &returnedAt
+	}
+
+	return &c
+}
+
+func copyShipment(shipment *models.Shipment) *models.Shipment {
+	c := *shipment
+	c.Products = nil
+	c.Returns = nil
+
+	if shipment.DispatchedAt != nil {
+		dispatchedAt := *shipment.DispatchedAt
+		c.DispatchedAt = &dispatchedAt
+	}
+
+	return &c
+}
+
+func copyCustomerReturn(ret *models.CustomerReturn) *models.CustomerReturn {
+	c := *ret
+
+	if ret.ProductID != nil {
+		productID := *ret.ProductID
+		c.ProductID = &productID
+	}
+
+	return &c
+}
@@ -0,0 +1,62 @@
+package memoryrepo
+
+import (
+	"context"
+
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// memoryUserRepository реализует интерфейс repositories.IUserRepo
+// для работы с пользователями в памяти.
+type memoryUserRepository struct {
+	store *Store
+}
+
+// NewMemoryUserRepository создает новый экземпляр memoryUserRepository.
+func NewMemoryUserRepository(store *Store) repositories.IUserRepo {
+	return &memoryUserRepository{store: store}
+}
+
+// Create сохраняет нового пользователя.
+// Если пользователь с таким айди или email уже существует, возвращает databaseerrors.ErrUniqueViolation.
+func (r *memoryUserRepository) Create(ctx context.Context, user *models.User) error {
+	if err := r.store.lock(ctx); err != nil {
+		return err
+	}
+	defer r.store.unlock()
+
+	if _, exists := r.store.users[user.ID]; exists {
+		return databaseerrors.ErrUniqueViolation
+	}
+
+	for _, existing := range r.store.users {
+		if existing.Email == user.Email {
+			return databaseerrors.ErrUniqueViolation
+		}
+	}
+
+	stored := *user
+	r.store.users[user.ID] = &stored
+
+	return nil
+}
+
+// GetByEmail находит пользователя по email.
+// Если пользователь не найден, возвращает databaseerrors.ErrNoRows.
+func (r *memoryUserRepository) GetByEmail(ctx context.Context, email string) (*models.User, error) {
+	if err := r.store.lock(ctx); err != nil {
+		return nil, err
+	}
+	defer r.store.unlock()
+
+	for _, user := range r.store.users {
+		if user.Email == email {
+			found := *user
+			return &found, nil
+		}
+	}
+
+	return nil, databaseerrors.ErrNoRows
+}
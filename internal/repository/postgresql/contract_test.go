@@ -0,0 +1,50 @@
+//go:build integration
+// +build integration
+
+package postgresqlrepo_test
+
+import (
+	"testing"
+
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/internal/pkg/testhelpers"
+	postgresqlrepo "github.com/maksemen2/pvz-service/internal/repository/postgresql"
+	"github.com/maksemen2/pvz-service/internal/repository/repotest"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/zap"
+)
+
+func TestContract(t *testing.T) {
+	cfg, cleanContainer := testhelpers.SetupPostgresContainer(t)
+	defer cleanContainer()
+
+	logger := zap.NewNop()
+
+	db, err := database.NewPostgresDB(cfg, logger)
+	require.NoError(t, err)
+
+	defer db.Close()
+
+	cleanDB, err := testhelpers.CreateTestDB(db)
+	require.NoError(t, err)
+
+	defer cleanDB()
+
+	repotest.Run(t, func(t *testing.T) repotest.Repositories {
+		// Очистка данных перед каждым тестом
+		for _, table := range []string{"reception_discrepancy_reports", "reception_manifests", "pickup_code_failures", "pickup_codes", "customer_returns", "products", "issuances", "shipments", "receptions", "pvzs", "users"} {
+			_, err := db.Exec("DELETE FROM " + table)
+			require.NoError(t, err)
+		}
+
+		return repotest.Repositories{
+			PVZ:        postgresqlrepo.NewPostgresqlPVZRepository(db, logger),
+			Reception:  postgresqlrepo.NewPostgresqlReceptionRepository(db, logger),
+			Product:    postgresqlrepo.NewPostgresqlProductRepository(db, logger),
+			User:       postgresqlrepo.NewPostgresqlUserRepository(db, logger),
+			PickupCode: postgresqlrepo.NewPostgresqlPickupCodeRepository(db, logger),
+			Return:     postgresqlrepo.NewPostgresqlReturnRepository(db, logger),
+			Manifest:   postgresqlrepo.NewPostgresqlManifestRepository(db, logger),
+		}
+	})
+}
@@ -0,0 +1,224 @@
+package postgresqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"fmt"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// postgresqlIdempotencyRepository реализует интерфейс repositories.IIdempotencyRepo
+type postgresqlIdempotencyRepository struct {
+	db     *database.PostgresDB
+	logger *zap.Logger
+}
+
+// NewPostgresqlIdempotencyRepository создает новый экземпляр postgresqlIdempotencyRepository.
+func NewPostgresqlIdempotencyRepository(db *database.PostgresDB, logger *zap.Logger) repositories.IIdempotencyRepo {
+	return &postgresqlIdempotencyRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// idempotencyColumns - список колонок таблицы idempotency_keys, соответствующий idempotencyRow.
+const idempotencyColumns = "user_id, idempotency_key, fingerprint, status_code, content_type, response_headers, response_body, created_at, expires_at"
+
+// idempotencyRow - строка таблицы idempotency_keys.
+type idempotencyRow struct {
+	UserID       uuid.UUID       `db:"user_id"`
+	Key          string          `db:"idempotency_key"`
+	Fingerprint  string          `db:"fingerprint"`
+	StatusCode   *int            `db:"status_code"`
+	ContentType  *string         `db:"content_type"`
+	Headers      responseHeaders `db:"response_headers"`
+	ResponseBody []byte          `db:"response_body"`
+	CreatedAt    time.Time       `db:"created_at"`
+	ExpiresAt    time.Time       `db:"expires_at"`
+}
+
+// responseHeaders - заголовки сохраненного ответа, хранящиеся в колонке JSONB.
+type responseHeaders map[string]string
+
+// Value сериализует заголовки в JSON. Пустые заголовки сохраняются как NULL.
+// JSON передается строкой: байты lib/pq отправляет как bytea, который PostgreSQL не приводит к JSONB.
+func (h responseHeaders) Value() (driver.Value, error) {
+	if len(h) == 0 {
+		return nil, nil
+	}
+
+	data, err := json.Marshal(map[string]string(h))
+	if err != nil {
+		return nil, err
+	}
+
+	return string(data), nil
+}
+
+// Scan читает заголовки из JSON. NULL читается как nil.
+func (h *responseHeaders) Scan(src any) error {
+	var data []byte
+
+	switch v := src.(type) {
+	case nil:
+		*h = nil
+		return nil
+	case []byte:
+		data = v
+	case string:
+		data = []byte(v)
+	default:
+		return fmt.Errorf("unsupported response headers type %T", src)
+	}
+
+	return json.Unmarshal(data, (*map[string]string)(h))
+}
+
+// toModel производит маппинг из строки таблицы idempotency_keys в доменную модель.
+func (r *postgresqlIdempotencyRepository) toModel(row idempotencyRow) *models.IdempotencyRecord {
+	record := &models.IdempotencyRecord{
+		UserID:       row.UserID,
+		Key:          row.Key,
+		Fingerprint:  row.Fingerprint,
+		Headers:      row.Headers,
+		ResponseBody: row.ResponseBody,
+		CreatedAt:    row.CreatedAt,
+		ExpiresAt:    row.ExpiresAt,
+	}
+
+	if row.StatusCode != nil {
+		record.StatusCode = *row.StatusCode
+	}
+
+	if row.ContentType != nil {
+		record.ContentType = *row.ContentType
+	}
+
+	return record
+}
+
+// Reserve резервирует ключ идемпотентности пользователя, сохраняя незавершенную запись.
+// Истекшая запись, а также незавершенная запись, созданная раньше staleBefore
+// (например, если процесс упал во время обработки запроса), заменяется новой.
+// Остальные истекшие записи удаляются фоновой задачей (см. DeleteExpired).
+// Возвращает true, если ключ зарезервирован, иначе - уже существующую запись и false.
+// Если существующая запись была удалена между попыткой вставки и чтением, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlIdempotencyRepository) Reserve(ctx context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, bool, error) {
+	result, err := r.db.ExecContext(ctx, `
+        INSERT INTO idempotency_keys (user_id, idempotency_key, fingerprint, created_at, expires_at)
+        VALUES ($1, $2, $3, $4, $5)
+        ON CONFLICT (user_id, idempotency_key) DO UPDATE
+        SET fingerprint = EXCLUDED.fingerprint,
+            status_code = NULL,
+            content_type = NULL,
+            response_headers = NULL,
+            response_body = NULL,
+            created_at = EXCLUDED.created_at,
+            expires_at = EXCLUDED.expires_at
+        WHERE idempotency_keys.expires_at <= EXCLUDED.created_at
+           OR (idempotency_keys.status_code IS NULL AND idempotency_keys.created_at < $6)`,
+		record.UserID, record.Key, record.Fingerprint, record.CreatedAt, record.ExpiresAt, staleBefore,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error reserving idempotency key", zap.Error(err))
+		return nil, false, databaseerrors.ErrUnexpected
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error getting affected rows", zap.Error(err))
+		return nil, false, databaseerrors.ErrUnexpected
+	}
+
+	if affected > 0 {
+		return nil, true, nil
+	}
+
+	var row idempotencyRow
+
+	err = r.db.GetContext(ctx, &row,
+		"SELECT "+idempotencyColumns+" FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2",
+		record.UserID, record.Key,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, false, databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("error getting idempotency key", zap.Error(err))
+
+		return nil, false, databaseerrors.ErrUnexpected
+	}
+
+	return r.toModel(row), false, nil
+}
+
+// Complete сохраняет ответ на запрос с ключом идемпотентности.
+// Если записи нет, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlIdempotencyRepository) Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, headers map[string]string, body []byte) error {
+	result, err := r.db.ExecContext(ctx, `
+        UPDATE idempotency_keys
+        SET status_code = $3, content_type = $4, response_headers = $5, response_body = $6
+        WHERE user_id = $1 AND idempotency_key = $2`,
+		userID, key, statusCode, contentType, responseHeaders(headers), body,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error completing idempotency key", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error getting affected rows", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	if affected == 0 {
+		return databaseerrors.ErrNoRows
+	}
+
+	return nil
+}
+
+// Release удаляет незавершенную запись с ключом идемпотентности.
+// Завершенные записи не удаляются.
+func (r *postgresqlIdempotencyRepository) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	_, err := r.db.ExecContext(ctx,
+		"DELETE FROM idempotency_keys WHERE user_id = $1 AND idempotency_key = $2 AND status_code IS NULL",
+		userID, key,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error releasing idempotency key", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// DeleteExpired удаляет истекшие к моменту now записи всех пользователей и возвращает их количество.
+func (r *postgresqlIdempotencyRepository) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM idempotency_keys WHERE expires_at <= $1", now)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error deleting expired idempotency keys", zap.Error(err))
+		return 0, databaseerrors.ErrUnexpected
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error getting affected rows", zap.Error(err))
+		return 0, databaseerrors.ErrUnexpected
+	}
+
+	return int(affected), nil
+}
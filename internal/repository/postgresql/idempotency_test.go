@@ -0,0 +1,165 @@
+//go:build integration
+// +build integration
+
+package postgresqlrepo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/internal/pkg/testhelpers"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	postgresqlrepo "github.com/maksemen2/pvz-service/internal/repository/postgresql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type IdempotencyRepoTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	db      *database.PostgresDB
+	repo    repositories.IIdempotencyRepo
+	cleanup func()
+}
+
+func TestIdempotencyRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(IdempotencyRepoTestSuite))
+}
+
+func (s *IdempotencyRepoTestSuite) SetupSuite() {
+	s.ctx = context.Background()
+	cfg, cleanContainer := testhelpers.SetupPostgresContainer(s.T())
+
+	logger := zap.NewNop()
+
+	var err error
+	s.db, err = database.NewPostgresDB(cfg, logger)
+	require.NoError(s.T(), err)
+
+	s.repo = postgresqlrepo.NewPostgresqlIdempotencyRepository(s.db, logger)
+
+	cleanDB, err := testhelpers.CreateTestDB(s.db)
+
+	s.cleanup = func() {
+		cleanDB()
+		cleanContainer()
+	}
+
+	require.NoError(s.T(), err)
+}
+
+func (s *IdempotencyRepoTestSuite) TearDownSuite() {
+	s.db.Close()
+	s.cleanup()
+}
+
+func (s *IdempotencyRepoTestSuite) SetupTest() {
+	_, err := s.db.Exec("DELETE FROM idempotency_keys")
+	require.NoError(s.T(), err)
+}
+
+func (s *IdempotencyRepoTestSuite) newRecord(userID uuid.UUID, fingerprint string, createdAt time.Time) *models.IdempotencyRecord {
+	return &models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         "key",
+		Fingerprint: fingerprint,
+		CreatedAt:   createdAt,
+		ExpiresAt:   createdAt.Add(time.Hour),
+	}
+}
+
+func (s *IdempotencyRepoTestSuite) TestReserveAndComplete() {
+	userID := uuid.New()
+	now := time.Now().UTC().Truncate(time.Microsecond)
+
+	_, reserved, err := s.repo.Reserve(s.ctx, s.newRecord(userID, "first", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.True(s.T(), reserved)
+
+	existing, reserved, err := s.repo.Reserve(s.ctx, s.newRecord(userID, "second", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.False(s.T(), reserved)
+	assert.Equal(s.T(), "first", existing.Fingerprint)
+	assert.False(s.T(), existing.Completed())
+
+	err = s.repo.Complete(s.ctx, userID, "key", 201, "application/json", map[string]string{"ETag": `"1"`, "Location": "/pvz/1"}, []byte(`{"id":"1"}`))
+	require.NoError(s.T(), err)
+
+	existing, reserved, err = s.repo.Reserve(s.ctx, s.newRecord(userID, "first", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.False(s.T(), reserved)
+	assert.Equal(s.T(), 201, existing.StatusCode)
+	assert.Equal(s.T(), "application/json", existing.ContentType)
+	assert.Equal(s.T(), map[string]string{"ETag": `"1"`, "Location": "/pvz/1"}, existing.Headers)
+	assert.Equal(s.T(), []byte(`{"id":"1"}`), existing.ResponseBody)
+
+	// Ключи разных пользователей не пересекаются
+	_, reserved, err = s.repo.Reserve(s.ctx, s.newRecord(uuid.New(), "first", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.True(s.T(), reserved)
+}
+
+func (s *IdempotencyRepoTestSuite) TestReserve_ReplacesExpiredAndStale() {
+	userID := uuid.New()
+	past := time.Now().UTC().Add(-2 * time.Hour)
+
+	_, reserved, err := s.repo.Reserve(s.ctx, s.newRecord(userID, "expired", past), past)
+	require.NoError(s.T(), err)
+	require.True(s.T(), reserved)
+	require.NoError(s.T(), s.repo.Complete(s.ctx, userID, "key", 201, "application/json", nil, nil))
+
+	now := time.Now().UTC()
+
+	_, reserved, err = s.repo.Reserve(s.ctx, s.newRecord(userID, "pending", now.Add(-10*time.Minute)), now)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), reserved, "expired record must be replaced")
+
+	_, reserved, err = s.repo.Reserve(s.ctx, s.newRecord(userID, "new", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.True(s.T(), reserved, "stale pending record must be replaced")
+}
+
+func (s *IdempotencyRepoTestSuite) TestRelease() {
+	userID := uuid.New()
+	now := time.Now().UTC()
+
+	_, _, err := s.repo.Reserve(s.ctx, s.newRecord(userID, "first", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+
+	require.NoError(s.T(), s.repo.Release(s.ctx, userID, "key"))
+
+	_, reserved, err := s.repo.Reserve(s.ctx, s.newRecord(userID, "second", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.True(s.T(), reserved)
+
+	err = s.repo.Complete(s.ctx, uuid.New(), "key", 201, "", nil, nil)
+	assert.ErrorIs(s.T(), err, databaseerrors.ErrNoRows)
+}
+
+func (s *IdempotencyRepoTestSuite) TestDeleteExpired() {
+	now := time.Now().UTC().Truncate(time.Microsecond)
+	expiredUser := uuid.New()
+	activeUser := uuid.New()
+
+	_, _, err := s.repo.Reserve(s.ctx, s.newRecord(expiredUser, "expired", now.Add(-2*time.Hour)), now.Add(-3*time.Hour))
+	require.NoError(s.T(), err)
+	_, _, err = s.repo.Reserve(s.ctx, s.newRecord(activeUser, "active", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+
+	// Удаляются истекшие записи всех пользователей, а не только того, кто резервирует ключ
+	deleted, err := s.repo.DeleteExpired(s.ctx, now)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, deleted)
+
+	existing, reserved, err := s.repo.Reserve(s.ctx, s.newRecord(activeUser, "other", now), now.Add(-time.Minute))
+	require.NoError(s.T(), err)
+	assert.False(s.T(), reserved)
+	assert.Equal(s.T(), "active", existing.Fingerprint)
+}
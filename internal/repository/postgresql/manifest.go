@@ -0,0 +1,345 @@
+package postgresqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// postgresqlManifestRepository - структура репозитория для работы с манифестами приемок в PostgreSQL.
+// Реализует интерфейс repositories.IManifestRepo
+type postgresqlManifestRepository struct {
+	db     *database.PostgresDB
+	logger *zap.Logger
+}
+
+// NewPostgresqlManifestRepository - конструктор для создания нового экземпляра postgresqlManifestRepository.
+// Принимает базу данных и логгер.
+func NewPostgresqlManifestRepository(db *database.PostgresDB, logger *zap.Logger) repositories.IManifestRepo {
+	return &postgresqlManifestRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// manifestRow - строка таблицы reception_manifests.
+type manifestRow struct {
+	ID          uuid.UUID  `db:"id"`
+	PVZID       uuid.UUID  `db:"pvz_id"`
+	ReceptionID *uuid.UUID `db:"reception_id"`
+	CreatedBy   *uuid.UUID `db:"created_by"`
+	CreatedAt   time.Time  `db:"created_at"`
+}
+
+// manifestItemRow - строка таблицы reception_manifest_items.
+type manifestItemRow struct {
+	ManifestID    uuid.UUID `db:"manifest_id"`
+	Type          string    `db:"type"`
+	ExpectedCount int       `db:"expected_count"`
+}
+
+// discrepancyItemRow - строка таблицы reception_discrepancy_items.
+type discrepancyItemRow struct {
+	ReceptionID   uuid.UUID `db:"reception_id"`
+	Type          string    `db:"type"`
+	Kind          string    `db:"kind"`
+	ExpectedCount int       `db:"expected_count"`
+	ActualCount   int       `db:"actual_count"`
+}
+
+// receivedCountRow - количество принятых товаров одного типа.
+type receivedCountRow struct {
+	Type  string `db:"type"`
+	Count int    `db:"count"`
+}
+
+// manifestToModel производит маппинг из строк таблиц манифеста в доменную модель.
+func manifestToModel(row manifestRow, itemRows []manifestItemRow) *models.Manifest {
+	manifest := &models.Manifest{
+		ID:          row.ID,
+		PVZID:       row.PVZID,
+		ReceptionID: row.ReceptionID,
+		CreatedAt:   row.CreatedAt,
+		Items:       make([]models.ManifestItem, 0, len(itemRows)),
+	}
+
+	if row.CreatedBy != nil {
+		manifest.CreatedBy = *row.CreatedBy
+	}
+
+	for _, item := range itemRows {
+		manifest.Items = append(manifest.Items, models.ManifestItem{
+			Type:  models.ProductType(item.Type),
+			Count: item.ExpectedCount,
+		})
+	}
+
+	return manifest
+}
+
+// Save сохраняет манифест в транзакции.
+// Если в ПВЗ есть открытая приемка, манифест привязывается к ней (manifest.ReceptionID заполняется),
+// иначе сохраняется как ожидающий и будет привязан к следующей приемке при её создании.
+// Ранее сохраненный манифест той же приемки или ожидающий манифест ПВЗ заменяется.
+// Строка ПВЗ блокируется до конца транзакции, после сохранения версия ПВЗ увеличивается.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Если expectedPVZVersion не равна models.AnyVersion и не совпадает с версией ПВЗ, возвращает domainerrors.ErrVersionMismatch.
+func (r *postgresqlManifestRepository) Save(ctx context.Context, manifest *models.Manifest, expectedPVZVersion int) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	if err := lockPVZVersion(ctx, tx, r.logger, manifest.PVZID, expectedPVZVersion); err != nil {
+		return err
+	}
+
+	var openReceptionID uuid.UUID
+
+	err = tx.GetContext(ctx, &openReceptionID,
+		"SELECT id FROM receptions WHERE pvz_id = $1 AND status = 'in_progress' LIMIT 1",
+		manifest.PVZID,
+	)
+
+	switch {
+	case err == nil:
+		manifest.ReceptionID = &openReceptionID
+	case errors.Is(err, sql.ErrNoRows):
+		manifest.ReceptionID = nil
+	default:
+		l.FromContext(ctx, r.logger).Error("error finding open reception", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM reception_manifests WHERE pvz_id = $1 AND reception_id IS NOT DISTINCT FROM $2::uuid",
+		manifest.PVZID, manifest.ReceptionID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error deleting previous manifest", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO reception_manifests (id, pvz_id, reception_id, created_by, created_at)
+        VALUES (:id, :pvz_id, :reception_id, :created_by, :created_at)`,
+		&manifestRow{
+			ID:          manifest.ID,
+			PVZID:       manifest.PVZID,
+			ReceptionID: manifest.ReceptionID,
+			CreatedBy:   &manifest.CreatedBy,
+			CreatedAt:   manifest.CreatedAt,
+		},
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error inserting manifest", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	itemRows := make([]manifestItemRow, 0, len(manifest.Items))
+	for _, item := range manifest.Items {
+		itemRows = append(itemRows, manifestItemRow{
+			ManifestID:    manifest.ID,
+			Type:          item.Type.String(),
+			ExpectedCount: item.Count,
+		})
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO reception_manifest_items (manifest_id, type, expected_count)
+        VALUES (:manifest_id, :type, :expected_count)`,
+		itemRows,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error inserting manifest items", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	if err := bumpPVZVersion(ctx, tx, r.logger, manifest.PVZID); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// GetByReception возвращает манифест, привязанный к приемке.
+// Если у приемки нет манифеста, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlManifestRepository) GetByReception(ctx context.Context, receptionID uuid.UUID) (*models.Manifest, error) {
+	return getManifestByReception(ctx, r.db, r.logger, receptionID)
+}
+
+// CountReceived возвращает количество товаров каждого типа, принятых в приемке.
+// Типы, товаров которых в приемке нет, в результат не попадают.
+func (r *postgresqlManifestRepository) CountReceived(ctx context.Context, receptionID uuid.UUID) (map[models.ProductType]int, error) {
+	return countReceived(ctx, r.db, r.logger, receptionID)
+}
+
+// SaveDiscrepancyReport сохраняет отчет о расхождениях в транзакции.
+// Отчет, ранее построенный для той же приемки (например, до её повторного открытия), заменяется.
+func (r *postgresqlManifestRepository) SaveDiscrepancyReport(ctx context.Context, report *models.DiscrepancyReport) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	if err := saveDiscrepancyReport(ctx, tx, r.logger, report); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// getManifestByReception - хелпер для получения манифеста приемки, в том числе внутри транзакции.
+// Если у приемки нет манифеста, возвращает databaseerrors.ErrNoRows.
+func getManifestByReception(ctx context.Context, q sqlx.QueryerContext, logger *zap.Logger, receptionID uuid.UUID) (*models.Manifest, error) {
+	var row manifestRow
+
+	err := sqlx.GetContext(ctx, q, &row, `
+        SELECT id, pvz_id, reception_id, created_by, created_at
+        FROM reception_manifests
+        WHERE reception_id = $1`,
+		receptionID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, logger).Error("failed to get manifest", zap.Error(err))
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	var itemRows []manifestItemRow
+
+	err = sqlx.SelectContext(ctx, q, &itemRows, `
+        SELECT manifest_id, type, expected_count
+        FROM reception_manifest_items
+        WHERE manifest_id = $1
+        ORDER BY type`,
+		row.ID,
+	)
+	if err != nil {
+		l.FromContext(ctx, logger).Error("failed to get manifest items", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return manifestToModel(row, itemRows), nil
+}
+
+// countReceived - хелпер для подсчета принятых товаров приемки по типам, в том числе внутри транзакции.
+func countReceived(ctx context.Context, q sqlx.QueryerContext, logger *zap.Logger, receptionID uuid.UUID) (map[models.ProductType]int, error) {
+	var rows []receivedCountRow
+
+	err := sqlx.SelectContext(ctx, q, &rows, `
+        SELECT type, COUNT(*) AS count
+        FROM products
+        WHERE reception_id = $1
+        GROUP BY type`,
+		receptionID,
+	)
+	if err != nil {
+		l.FromContext(ctx, logger).Error("failed to count received products", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	result := make(map[models.ProductType]int, len(rows))
+	for _, row := range rows {
+		result[models.ProductType(row.Type)] = row.Count
+	}
+
+	return result, nil
+}
+
+// saveDiscrepancyReport - хелпер для сохранения отчета о расхождениях в транзакции, заменяющий ранее построенный отчет приемки.
+func saveDiscrepancyReport(ctx context.Context, tx *sqlx.Tx, logger *zap.Logger, report *models.DiscrepancyReport) error {
+	_, err := tx.ExecContext(ctx, "DELETE FROM reception_discrepancy_reports WHERE reception_id = $1", report.ReceptionID)
+	if err != nil {
+		l.FromContext(ctx, logger).Error("error deleting previous discrepancy report", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO reception_discrepancy_reports (reception_id, created_at) VALUES ($1, $2)",
+		report.ReceptionID, report.CreatedAt,
+	)
+	if err != nil {
+		l.FromContext(ctx, logger).Error("error inserting discrepancy report", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	if len(report.Items) > 0 {
+		itemRows := make([]discrepancyItemRow, 0, len(report.Items))
+		for _, item := range report.Items {
+			itemRows = append(itemRows, discrepancyItemRow{
+				ReceptionID:   report.ReceptionID,
+				Type:          item.Type.String(),
+				Kind:          item.Kind.String(),
+				ExpectedCount: item.Expected,
+				ActualCount:   item.Actual,
+			})
+		}
+
+		_, err = tx.NamedExecContext(ctx, `
+            INSERT INTO reception_discrepancy_items (reception_id, type, kind, expected_count, actual_count)
+            VALUES (:reception_id, :type, :kind, :expected_count, :actual_count)`,
+			itemRows,
+		)
+		if err != nil {
+			l.FromContext(ctx, logger).Error("error inserting discrepancy items", zap.Error(err))
+			return databaseerrors.ErrUnexpected
+		}
+	}
+
+	return nil
+}
+
+// buildDiscrepancyReport строит и сохраняет в транзакции закрытия приемки отчет о расхождениях с её манифестом,
+// поэтому приемка не может остаться закрытой без отчета. Если манифеста нет, возвращает nil.
+func buildDiscrepancyReport(ctx context.Context, tx *sqlx.Tx, logger *zap.Logger, receptionID uuid.UUID, createdAt time.Time) (*models.DiscrepancyReport, error) {
+	manifest, err := getManifestByReception(ctx, tx, logger, receptionID)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrNoRows) {
+			return nil, nil
+		}
+
+		return nil, err
+	}
+
+	received, err := countReceived(ctx, tx, logger, receptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	report := models.NewDiscrepancyReport(receptionID, manifest, received, createdAt)
+
+	if err := saveDiscrepancyReport(ctx, tx, logger, report); err != nil {
+		return nil, err
+	}
+
+	return report, nil
+}
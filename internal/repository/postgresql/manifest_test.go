@@ -0,0 +1,200 @@
+//go:build integration
+// +build integration
+
+package postgresqlrepo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/internal/pkg/testhelpers"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	postgresqlrepo "github.com/maksemen2/pvz-service/internal/repository/postgresql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type ManifestRepoTestSuite struct {
+	suite.Suite
+	ctx           context.Context
+	db            *database.PostgresDB
+	repo          repositories.IManifestRepo
+	receptionRepo repositories.IReceptionRepo
+	cleanup       func()
+}
+
+func TestManifestRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(ManifestRepoTestSuite))
+}
+
+func (s *ManifestRepoTestSuite) SetupSuite() {
+	s.ctx = context.Background()
+	cfg, cleanContainer := testhelpers.SetupPostgresContainer(s.T())
+
+	logger := zap.NewNop()
+
+	var err error
+	s.db, err = database.NewPostgresDB(cfg, logger)
+	require.NoError(s.T(), err)
+
+	s.repo = postgresqlrepo.NewPostgresqlManifestRepository(s.db, logger)
+	s.receptionRepo = postgresqlrepo.NewPostgresqlReceptionRepository(s.db, logger)
+
+	cleanDB, err := testhelpers.CreateTestDB(s.db)
+
+	s.cleanup = func() {
+		cleanDB()
+		cleanContainer()
+	}
+
+	require.NoError(s.T(), err)
+}
+
+func (s *ManifestRepoTestSuite) TearDownSuite() {
+	s.db.Close()
+	s.cleanup()
+}
+
+func (s *ManifestRepoTestSuite) SetupTest() {
+	for _, table := range []string{"reception_discrepancy_reports", "reception_manifests", "products", "receptions", "pvzs"} {
+		_, err := s.db.Exec("DELETE FROM " + table)
+		require.NoError(s.T(), err)
+	}
+}
+
+func (s *ManifestRepoTestSuite) createTestPVZ() uuid.UUID {
+	pvzID := uuid.New()
+	_, err := s.db.Exec(
+		"INSERT INTO pvzs (id, registration_date, city) VALUES ($1, $2, $3)",
+		pvzID, time.Now(), models.CityTypeMoscow.String(),
+	)
+	require.NoError(s.T(), err)
+
+	return pvzID
+}
+
+func (s *ManifestRepoTestSuite) createTestReception(pvzID uuid.UUID) uuid.UUID {
+	reception := &models.Reception{
+		ID:       uuid.New(),
+		DateTime: time.Now(),
+		PVZID:    pvzID,
+		Status:   models.ReceptionStatusInProgress,
+	}
+
+	require.NoError(s.T(), s.receptionRepo.CreateIfNoOpen(s.ctx, reception, models.AnyVersion))
+
+	return reception.ID
+}
+
+func (s *ManifestRepoTestSuite) newManifest(pvzID uuid.UUID, count int) *models.Manifest {
+	return &models.Manifest{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		CreatedBy: uuid.New(),
+		CreatedAt: time.Now(),
+		Items: []models.ManifestItem{
+			{Type: models.ProductTypeShoes, Count: count},
+			{Type: models.ProductTypeClothes, Count: 1},
+		},
+	}
+}
+
+func (s *ManifestRepoTestSuite) TestSave_AttachesToOpenReception() {
+	pvzID := s.createTestPVZ()
+	receptionID := s.createTestReception(pvzID)
+
+	manifest := s.newManifest(pvzID, 2)
+	require.NoError(s.T(), s.repo.Save(s.ctx, manifest, models.AnyVersion))
+
+	require.NotNil(s.T(), manifest.ReceptionID)
+	assert.Equal(s.T(), receptionID, *manifest.ReceptionID)
+
+	saved, err := s.repo.GetByReception(s.ctx, receptionID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), manifest.ID, saved.ID)
+	assert.ElementsMatch(s.T(), manifest.Items, saved.Items)
+}
+
+func (s *ManifestRepoTestSuite) TestSave_PendingManifestAttachedOnCreate() {
+	pvzID := s.createTestPVZ()
+
+	manifest := s.newManifest(pvzID, 2)
+	require.NoError(s.T(), s.repo.Save(s.ctx, manifest, models.AnyVersion))
+	assert.Nil(s.T(), manifest.ReceptionID)
+
+	// Повторная загрузка заменяет ожидающий манифест
+	replacement := s.newManifest(pvzID, 5)
+	require.NoError(s.T(), s.repo.Save(s.ctx, replacement, models.AnyVersion))
+
+	receptionID := s.createTestReception(pvzID)
+
+	saved, err := s.repo.GetByReception(s.ctx, receptionID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), replacement.ID, saved.ID)
+
+	var count int
+	err = s.db.Get(&count, "SELECT COUNT(*) FROM reception_manifests WHERE pvz_id = $1", pvzID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, count)
+}
+
+func (s *ManifestRepoTestSuite) TestSave_PVZNotExists() {
+	err := s.repo.Save(s.ctx, s.newManifest(uuid.New(), 1), models.AnyVersion)
+	assert.ErrorIs(s.T(), err, databaseerrors.ErrNoRows)
+}
+
+func (s *ManifestRepoTestSuite) TestGetByReception_NoManifest() {
+	receptionID := s.createTestReception(s.createTestPVZ())
+
+	_, err := s.repo.GetByReception(s.ctx, receptionID)
+	assert.ErrorIs(s.T(), err, databaseerrors.ErrNoRows)
+}
+
+func (s *ManifestRepoTestSuite) TestCountReceived() {
+	receptionID := s.createTestReception(s.createTestPVZ())
+
+	for _, productType := range []models.ProductType{models.ProductTypeShoes, models.ProductTypeShoes, models.ProductTypeClothes} {
+		_, err := s.db.Exec(
+			"INSERT INTO products (id, date_time, type, reception_id, order_id) VALUES ($1, $2, $3, $4, $1)",
+			uuid.New(), time.Now(), productType.String(), receptionID,
+		)
+		require.NoError(s.T(), err)
+	}
+
+	received, err := s.repo.CountReceived(s.ctx, receptionID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), map[models.ProductType]int{
+		models.ProductTypeShoes:   2,
+		models.ProductTypeClothes: 1,
+	}, received)
+}
+
+func (s *ManifestRepoTestSuite) TestSaveDiscrepancyReport_Replaces() {
+	receptionID := s.createTestReception(s.createTestPVZ())
+
+	report := &models.DiscrepancyReport{
+		ReceptionID: receptionID,
+		CreatedAt:   time.Now(),
+		Items: []models.DiscrepancyItem{
+			{Type: models.ProductTypeShoes, Kind: models.DiscrepancyKindMissing, Expected: 2, Actual: 1},
+		},
+	}
+	require.NoError(s.T(), s.repo.SaveDiscrepancyReport(s.ctx, report))
+
+	// После повторного закрытия отчет пересчитывается и заменяется
+	report.Items = nil
+	require.NoError(s.T(), s.repo.SaveDiscrepancyReport(s.ctx, report))
+
+	var reports, items int
+	require.NoError(s.T(), s.db.Get(&reports, "SELECT COUNT(*) FROM reception_discrepancy_reports WHERE reception_id = $1", receptionID))
+	require.NoError(s.T(), s.db.Get(&items, "SELECT COUNT(*) FROM reception_discrepancy_items WHERE reception_id = $1", receptionID))
+	assert.Equal(s.T(), 1, reports)
+	assert.Equal(s.T(), 0, items)
+}
@@ -0,0 +1,249 @@
+package postgresqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// postgresqlPickupCodeRepository - структура репозитория для работы с кодами получения заказов в PostgreSQL.
+// Реализует интерфейс repositories.IPickupCodeRepo
+type postgresqlPickupCodeRepository struct {
+	db     *database.PostgresDB
+	logger *zap.Logger
+}
+
+// NewPostgresqlPickupCodeRepository - конструктор для создания нового экземпляра postgresqlPickupCodeRepository.
+// Принимает базу данных и логгер.
+func NewPostgresqlPickupCodeRepository(db *database.PostgresDB, logger *zap.Logger) repositories.IPickupCodeRepo {
+	return &postgresqlPickupCodeRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// pickupCodeRow - строка таблицы pickup_codes.
+type pickupCodeRow struct {
+	ID        uuid.UUID  `db:"id"`
+	PVZID     uuid.UUID  `db:"pvz_id"`
+	OrderID   uuid.UUID  `db:"order_id"`
+	CodeHash  string     `db:"code_hash"`
+	Attempts  int        `db:"attempts"`
+	CreatedAt time.Time  `db:"created_at"`
+	ExpiresAt time.Time  `db:"expires_at"`
+	UsedAt    *time.Time `db:"used_at"`
+}
+
+// toModel производит маппинг из строки таблицы pickup_codes в доменную модель.
+func (r *postgresqlPickupCodeRepository) toModel(row pickupCodeRow) *models.PickupCode {
+	return &models.PickupCode{
+		ID:        row.ID,
+		PVZID:     row.PVZID,
+		OrderID:   row.OrderID,
+		CodeHash:  row.CodeHash,
+		Attempts:  row.Attempts,
+		CreatedAt: row.CreatedAt,
+		ExpiresAt: row.ExpiresAt,
+		UsedAt:    row.UsedAt,
+	}
+}
+
+// OrdersWithoutCode возвращает заказы, хранящиеся товары которых есть в приемке,
+// но у которых в ПВЗ приемки нет неиспользованного кода получения. Заказы отсортированы по айди.
+func (r *postgresqlPickupCodeRepository) OrdersWithoutCode(ctx context.Context, receptionID uuid.UUID) ([]uuid.UUID, error) {
+	orderIDs := make([]uuid.UUID, 0)
+
+	err := r.db.SelectContext(ctx, &orderIDs, `
+        SELECT DISTINCT pr.order_id
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        WHERE
+            pr.reception_id = $1 AND
+            pr.status = $2 AND
+            NOT EXISTS (
+                SELECT 1 FROM pickup_codes c
+                WHERE c.pvz_id = r.pvz_id AND c.order_id = pr.order_id AND c.used_at IS NULL
+            )
+        ORDER BY pr.order_id`,
+		receptionID, models.ProductStatusStored.String(),
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to get orders without pickup code", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return orderIDs, nil
+}
+
+// Save сохраняет код получения в транзакции, заменяя неиспользованный код того же заказа в ПВЗ.
+// Если код того же заказа одновременно сохраняется в другой транзакции, возвращает databaseerrors.ErrUniqueViolation.
+func (r *postgresqlPickupCodeRepository) Save(ctx context.Context, code *models.PickupCode) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM pickup_codes WHERE pvz_id = $1 AND order_id = $2 AND used_at IS NULL",
+		code.PVZID, code.OrderID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error deleting previous pickup code", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO pickup_codes (id, pvz_id, order_id, code_hash, attempts, created_at, expires_at, used_at)
+        VALUES (:id, :pvz_id, :order_id, :code_hash, :attempts, :created_at, :expires_at, :used_at)`,
+		&pickupCodeRow{
+			ID:        code.ID,
+			PVZID:     code.PVZID,
+			OrderID:   code.OrderID,
+			CodeHash:  code.CodeHash,
+			Attempts:  code.Attempts,
+			CreatedAt: code.CreatedAt,
+			ExpiresAt: code.ExpiresAt,
+			UsedAt:    code.UsedAt,
+		},
+	)
+	if err != nil {
+		if database.IsPGError(err, database.PGUniqueViolationCode) {
+			return databaseerrors.ErrUniqueViolation
+		}
+
+		l.FromContext(ctx, r.logger).Error("error inserting pickup code", zap.Error(err))
+
+		return databaseerrors.ErrUnexpected
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// GetActive возвращает неиспользованный код получения заказа в ПВЗ.
+// Если такого кода нет, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlPickupCodeRepository) GetActive(ctx context.Context, pvzID, orderID uuid.UUID) (*models.PickupCode, error) {
+	var row pickupCodeRow
+
+	err := r.db.GetContext(ctx, &row, `
+        SELECT id, pvz_id, order_id, code_hash, attempts, created_at, expires_at, used_at
+        FROM pickup_codes
+        WHERE pvz_id = $1 AND order_id = $2 AND used_at IS NULL`,
+		pvzID, orderID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("failed to get pickup code", zap.Error(err))
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return r.toModel(row), nil
+}
+
+// ReserveAttempt занимает попытку ввода кода получения одним запросом: увеличивает счетчик попыток,
+// только если код не использован и попыток меньше maxAttempts.
+// Иначе возвращает databaseerrors.ErrNoRows, поэтому одновременные проверки не могут превысить лимит.
+func (r *postgresqlPickupCodeRepository) ReserveAttempt(ctx context.Context, codeID uuid.UUID, maxAttempts int) error {
+	var attempts int
+
+	err := r.db.GetContext(ctx, &attempts,
+		"UPDATE pickup_codes SET attempts = attempts + 1 WHERE id = $1 AND used_at IS NULL AND attempts < $2 RETURNING attempts",
+		codeID, maxAttempts,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("error reserving pickup code attempt", zap.Error(err))
+
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// ReleaseAttempt возвращает попытку, занятую ReserveAttempt, если код оказался верным.
+func (r *postgresqlPickupCodeRepository) ReleaseAttempt(ctx context.Context, codeID uuid.UUID) error {
+	_, err := r.db.ExecContext(ctx,
+		"UPDATE pickup_codes SET attempts = attempts - 1 WHERE id = $1 AND attempts > 0",
+		codeID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error releasing pickup code attempt", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// RegisterFailure сохраняет неудачную попытку ввода кода в ПВЗ в момент failedAt
+// и в той же транзакции удаляет попытки ПВЗ раньше prunedBefore, которые уже не влияют на ограничение.
+func (r *postgresqlPickupCodeRepository) RegisterFailure(ctx context.Context, pvzID uuid.UUID, failedAt, prunedBefore time.Time) error {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	_, err = tx.ExecContext(ctx,
+		"INSERT INTO pickup_code_failures (pvz_id, failed_at) VALUES ($1, $2)",
+		pvzID, failedAt,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error inserting pickup code failure", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"DELETE FROM pickup_code_failures WHERE pvz_id = $1 AND failed_at < $2",
+		pvzID, prunedBefore,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error pruning pickup code failures", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// CountFailures возвращает количество неудачных попыток ввода кодов в ПВЗ начиная с since включительно.
+func (r *postgresqlPickupCodeRepository) CountFailures(ctx context.Context, pvzID uuid.UUID, since time.Time) (int, error) {
+	var count int
+
+	err := r.db.GetContext(ctx, &count,
+		"SELECT COUNT(*) FROM pickup_code_failures WHERE pvz_id = $1 AND failed_at >= $2",
+		pvzID, since,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to count pickup code failures", zap.Error(err))
+		return 0, databaseerrors.ErrUnexpected
+	}
+
+	return count, nil
+}
@@ -4,10 +4,13 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"time"
 
 	"github.com/google/uuid"
 	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
@@ -34,10 +37,17 @@ func NewPostgresqlProductRepository(db *database.PostgresDB, logger *zap.Logger)
 
 // productRow - структура для представления строки товара в базе данных.
 type productRow struct {
-	ID          uuid.UUID `db:"id"`
-	DateTime    time.Time `db:"date_time"`
-	Type        string    `db:"type"`
-	ReceptionID uuid.UUID `db:"reception_id"`
+	ID          uuid.UUID  `db:"id"`
+	DateTime    time.Time  `db:"date_time"`
+	Type        string     `db:"type"`
+	ReceptionID uuid.UUID  `db:"reception_id"`
+	OrderID     uuid.UUID  `db:"order_id"`
+	Status      string     `db:"status"`
+	IssuanceID  *uuid.UUID `db:"issuance_id"`
+	IssuedAt    *time.Time `db:"issued_at"`
+	ShipmentID  *uuid.UUID `db:"shipment_id"`
+	ReturnedAt  *time.Time `db:"returned_at"`
+	Inserted    bool       `db:"inserted"` // Заполняется только в CreateBatch
 }
 
 // toModel - преобразует строку базы данных в доменную модель товара.
@@ -47,21 +57,37 @@ func (r *postgresqlProductRepository) toModel(row productRow) *models.Product {
 		DateTime:    row.DateTime,
 		Type:        models.ProductType(row.Type),
 		ReceptionID: row.ReceptionID,
+		OrderID:     row.OrderID,
+		Status:      models.ProductStatus(row.Status),
+		IssuanceID:  row.IssuanceID,
+		IssuedAt:    row.IssuedAt,
+		ShipmentID:  row.ShipmentID,
+		ReturnedAt:  row.ReturnedAt,
 	}
 }
 
+// openReceptionRow - айди и версия открытой приёмки.
+type openReceptionRow struct {
+	ID      uuid.UUID `db:"id"`
+	Version int       `db:"version"`
+}
+
 // getOpenReceptionID - хелпер для получения ID открытой приёмки в ПВЗ в транзакции.
-// Принимает контекст, транзакцию, ID ПВЗ и указатель на ID приёмки.
-// Возвращает ошибку, если не удалось найти открытые приёмки или произошла ошибка базы данных.
-func (r *postgresqlProductRepository) getOpenReceptionID(ctx context.Context, tx *sqlx.Tx, pvzID uuid.UUID, target *uuid.UUID) error {
-	err := tx.GetContext(ctx, target, `
-        SELECT id 
+// Блокирует строку приёмки до конца транзакции и проверяет её версию.
+// Принимает контекст, транзакцию, ID ПВЗ, ожидаемую версию приёмки (см. models.AnyVersion) и указатель на ID приёмки.
+// Возвращает ошибку, если не удалось найти открытые приёмки, версия не совпала или произошла ошибка базы данных.
+func (r *postgresqlProductRepository) getOpenReceptionID(ctx context.Context, tx *sqlx.Tx, pvzID uuid.UUID, expectedVersion int, target *uuid.UUID) error {
+	var reception openReceptionRow
+
+	err := tx.GetContext(ctx, &reception, `
+        SELECT id, version
         FROM receptions 
         WHERE 
             pvz_id = $1 AND 
             status = 'in_progress'
         ORDER BY date_time DESC 
-        LIMIT 1`,
+        LIMIT 1
+        FOR UPDATE`,
 		pvzID,
 	)
 
@@ -70,36 +96,42 @@ func (r *postgresqlProductRepository) getOpenReceptionID(ctx context.Context, tx
 			return domainerrors.ErrNoOpenReceptions
 		}
 
-		r.logger.Error("Failed to find open reception", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("Failed to find open reception", zap.Error(err))
 
 		return databaseerrors.ErrUnexpected
 	}
 
+	if expectedVersion != models.AnyVersion && reception.Version != expectedVersion {
+		return domainerrors.ErrVersionMismatch
+	}
+
+	*target = reception.ID
+
 	return nil
 }
 
 // Create - создает новый товар в базе данных.
 // В транзакции проверяет, существует ли для указанного ПВЗ открытая приёмка, если не существует - возвращает ошибку.
-// Если существует - создает новый товар в этой приёмке.
+// Если существует - проверяет её версию, создает новый товар в этой приёмке и увеличивает версию приёмки.
 // Возвращает созданный товар или ошибку, если она возникла.
-func (r *postgresqlProductRepository) Create(ctx context.Context, product *models.AddProduct) (*models.Product, error) {
+func (r *postgresqlProductRepository) Create(ctx context.Context, product *models.AddProduct, expectedReceptionVersion int) (*models.Product, error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("Failed to start transaction", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("Failed to start transaction", zap.Error(err))
 		return nil, databaseerrors.ErrUnexpected
 	}
 	defer database.TxRollback(tx, r.logger)
 
 	var receptionID uuid.UUID
 	// Ищем айди последней открытой приемки
-	err = r.getOpenReceptionID(ctx, tx, product.PVZID, &receptionID)
+	err = r.getOpenReceptionID(ctx, tx, product.PVZID, expectedReceptionVersion, &receptionID)
 	if err != nil {
 		return nil, err
 	}
 
 	query := `
-        INSERT INTO products (id, date_time, type, reception_id)
-        VALUES (:id, :date_time, :type, :reception_id)
+        INSERT INTO products (id, date_time, type, reception_id, order_id)
+        VALUES (:id, :date_time, :type, :reception_id, :order_id)
     `
 
 	row := productRow{
@@ -107,12 +139,14 @@ func (r *postgresqlProductRepository) Create(ctx context.Context, product *model
 		DateTime:    product.DateTime,
 		Type:        product.Type.String(),
 		ReceptionID: receptionID,
+		OrderID:     product.OrderID,
+		Status:      models.ProductStatusStored.String(),
 	}
 
 	_, err = tx.NamedExecContext(ctx, query, &row)
 
 	if err != nil {
-		r.logger.Error("Failed to create product",
+		l.FromContext(ctx, r.logger).Error("Failed to create product",
 			zap.Error(err),
 			zap.String("receptionID", receptionID.String()),
 		)
@@ -120,67 +154,609 @@ func (r *postgresqlProductRepository) Create(ctx context.Context, product *model
 		return nil, databaseerrors.ErrUnexpected
 	}
 
+	if err := bumpReceptionVersion(ctx, tx, r.logger, receptionID); err != nil {
+		return nil, err
+	}
+
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("Failed to commit transaction", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("Failed to commit transaction", zap.Error(err))
 		return nil, databaseerrors.ErrUnexpected
 	}
 
 	return r.toModel(row), nil
 }
 
+// CreateBatch - добавляет пакет товаров в открытую приёмку указанного ПВЗ в одной транзакции.
+// Открытая приёмка ищется один раз на весь пакет. Товары вставляются по одному с ON CONFLICT (id) DO NOTHING,
+// поэтому повтор пакета с теми же айди безопасен: уже добавленные в эту приёмку товары
+// получают статус models.BatchItemStatusDuplicate, а товары с айди из другой приёмки - ошибку domainerrors.ErrProductIDConflict.
+// В режиме models.BatchModeAllOrNothing при первой ошибке транзакция откатывается и результат возвращается с Applied = false.
+// Индексы в результате соответствуют позициям в products.
+// Версия приёмки проверяется перед добавлением и увеличивается, если был добавлен хотя бы один товар.
+func (r *postgresqlProductRepository) CreateBatch(ctx context.Context, pvzID uuid.UUID, products []*models.AddProduct, mode models.BatchMode, expectedReceptionVersion int) (*models.AddProductsBatchResult, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to start transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	var receptionID uuid.UUID
+
+	err = r.getOpenReceptionID(ctx, tx, pvzID, expectedReceptionVersion, &receptionID)
+	if err != nil {
+		return nil, err
+	}
+
+	var openedAt time.Time
+
+	err = tx.GetContext(ctx, &openedAt, `SELECT date_time FROM receptions WHERE id = $1`, receptionID)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to get reception date time", zap.Error(err), zap.String("receptionID", receptionID.String()))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	result := &models.AddProductsBatchResult{
+		ReceptionID: receptionID,
+		Applied:     true,
+		Items:       make([]*models.BatchItemResult, 0, len(products)),
+	}
+
+	for i, product := range products {
+		// Товар не может быть отсканирован раньше открытия приёмки
+		if product.DateTime.Before(openedAt) {
+			result.Items = append(result.Items, &models.BatchItemResult{
+				Index:  i,
+				Status: models.BatchItemStatusFailed,
+				Err:    fmt.Errorf("%w: %s", domainerrors.ErrInvalidProductTime, product.DateTime.Format(time.RFC3339)),
+			})
+
+			if mode == models.BatchModeAllOrNothing {
+				result.Applied = false
+			}
+
+			continue
+		}
+
+		row := productRow{
+			ID:          product.ID,
+			DateTime:    product.DateTime,
+			Type:        product.Type.String(),
+			ReceptionID: receptionID,
+			OrderID:     product.OrderID,
+		}
+
+		var existing productRow
+
+		// Если товар с таким айди уже есть, DO UPDATE без изменений возвращает существующую строку
+		err = tx.GetContext(ctx, &existing, `
+            INSERT INTO products (id, date_time, type, reception_id, order_id)
+            VALUES ($1, $2, $3, $4, $5)
+            ON CONFLICT (id) DO UPDATE SET id = EXCLUDED.id
+            RETURNING id, date_time, type, reception_id, order_id, status, issuance_id, issued_at, shipment_id, returned_at, (xmax = 0) AS inserted`,
+			row.ID, row.DateTime, row.Type, row.ReceptionID, row.OrderID,
+		)
+		if err != nil {
+			l.FromContext(ctx, r.logger).Error("Failed to create product in batch", zap.Error(err), zap.String("receptionID", receptionID.String()))
+			return nil, databaseerrors.ErrUnexpected
+		}
+
+		item := &models.BatchItemResult{Index: i}
+
+		switch {
+		case existing.Inserted:
+			item.Status = models.BatchItemStatusCreated
+			item.Product = r.toModel(existing)
+		case existing.ReceptionID == receptionID:
+			item.Status = models.BatchItemStatusDuplicate
+			item.Product = r.toModel(existing)
+		default:
+			item.Status = models.BatchItemStatusFailed
+			item.Err = domainerrors.ErrProductIDConflict
+		}
+
+		result.Items = append(result.Items, item)
+
+		if item.Status == models.BatchItemStatusFailed && mode == models.BatchModeAllOrNothing {
+			result.Applied = false
+		}
+	}
+
+	if !result.Applied {
+		// Транзакция будет откачена в defer, поэтому успешно обработанные товары помечаются как пропущенные
+		for _, item := range result.Items {
+			if item.Status != models.BatchItemStatusFailed {
+				item.Status = models.BatchItemStatusSkipped
+				item.Product = nil
+			}
+		}
+
+		return result, nil
+	}
+
+	for _, item := range result.Items {
+		if item.Status == models.BatchItemStatusCreated {
+			if err := bumpReceptionVersion(ctx, tx, r.logger, receptionID); err != nil {
+				return nil, err
+			}
+
+			break
+		}
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to commit transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return result, nil
+}
+
+// deletedProductRow - удаленный товар вместе с городом ПВЗ.
+type deletedProductRow struct {
+	productRow
+	City string `db:"city"`
+}
+
 // DeleteLast - удаляет последний товар из открытой приёмки в указанном ПВЗ.
 // Проверяет, есть ли открытая приёмка в ПВЗ и получает её айди.
 // Если открытая приёмка найдена - удаляет последний товар из неё.
 // Если товаров нет или нет открытой приёмки - возвращает ошибку.
-func (r *postgresqlProductRepository) DeleteLast(ctx context.Context, pvzID uuid.UUID) error {
+// Выданный или возвращенный отправителю товар (например, в переоткрытой приёмке) не удаляется,
+// в этом случае возвращается domainerrors.ErrProductNotStored.
+// Версия приёмки проверяется перед удалением и увеличивается после него.
+// Возвращает удаленный товар и город ПВЗ.
+func (r *postgresqlProductRepository) DeleteLast(ctx context.Context, pvzID uuid.UUID, expectedReceptionVersion int) (*models.Product, models.CityType, error) {
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("Error starting transaction", zap.Error(err))
-		return databaseerrors.ErrUnexpected
+		l.FromContext(ctx, r.logger).Error("Error starting transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
 	}
 	defer database.TxRollback(tx, r.logger)
 
 	var receptionID uuid.UUID
-	err = r.getOpenReceptionID(ctx, tx, pvzID, &receptionID)
+	err = r.getOpenReceptionID(ctx, tx, pvzID, expectedReceptionVersion, &receptionID)
 
 	if err != nil {
-		return err
+		return nil, "", err
 	}
 
-	// Сразу удаляем последний товар в приёмке
-	result, err := tx.ExecContext(ctx, `
-        DELETE FROM products
-        WHERE id = (
-            SELECT id FROM products
-            WHERE reception_id = $1
-            ORDER BY date_time DESC
-            LIMIT 1
-        )
+	var row deletedProductRow
+
+	// Блокируем последний товар в приёмке, чтобы его нельзя было выдать одновременно с удалением
+	err = tx.GetContext(ctx, &row, `
+        SELECT pr.id, pr.date_time, pr.type, pr.reception_id, pr.order_id, pr.status, pr.issuance_id, pr.issued_at, pr.shipment_id, pr.returned_at, p.city
+        FROM products pr
+        INNER JOIN pvzs p ON p.id = $2
+        WHERE pr.reception_id = $1
+        ORDER BY pr.date_time DESC
+        LIMIT 1
+        FOR UPDATE OF pr
     `,
-		receptionID,
+		receptionID, pvzID,
 	)
 	if err != nil {
-		r.logger.Error("Error deleting last product", zap.Error(err))
-		return databaseerrors.ErrUnexpected
+		if errors.Is(err, sql.ErrNoRows) {
+			// Если не нашли ни одного товара - значит, их и не было
+			return nil, "", domainerrors.ErrNoProductsInReception
+		}
+
+		l.FromContext(ctx, r.logger).Error("Error finding last product", zap.Error(err))
+
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	if models.ProductStatus(row.Status) != models.ProductStatusStored {
+		return nil, "", domainerrors.ErrProductNotStored
+	}
+
+	if _, err := tx.ExecContext(ctx, `DELETE FROM products WHERE id = $1`, row.ID); err != nil {
+		l.FromContext(ctx, r.logger).Error("Error deleting last product", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	if err := bumpReceptionVersion(ctx, tx, r.logger, receptionID); err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("Error committing transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	return r.toModel(row.productRow), models.CityType(row.City), nil
+}
+
+// issuableProductRow - товар вместе со статусом его приёмки.
+type issuableProductRow struct {
+	productRow
+	ReceptionStatus string `db:"reception_status"`
+}
+
+// Issue - выдает клиенту товары ПВЗ в одной транзакции и в ней же расходует код получения pickupCodeID.
+// Если код не принадлежит ПВЗ, уже использован или истек к моменту выдачи, возвращает domainerrors.ErrInvalidPickupCode.
+// Код расходуется, только когда в ПВЗ не осталось хранящихся товаров его заказа: при частичной выдаче
+// тем же кодом можно забрать остальные товары.
+// Выдать можно только хранящиеся в ПВЗ товары заказа кода из закрытых приёмок, при ошибке хотя бы в одном товаре
+// не выдается ни один и код не расходуется: товара нет в ПВЗ - domainerrors.ErrProductNotFound, товар из другого
+// заказа - domainerrors.ErrProductNotInOrder, его приёмка не закрыта - domainerrors.ErrProductNotReceived,
+// товар уже выдан или возвращен - domainerrors.ErrProductNotStored.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Строки кода и товаров блокируются до конца транзакции, а приёмки товаров - от повторного открытия,
+// поэтому ни код, ни товар не могут быть использованы дважды.
+// Возвращает выдачу с товарами в порядке productIDs и город ПВЗ.
+func (r *postgresqlProductRepository) Issue(ctx context.Context, issuance *models.Issuance, pickupCodeID uuid.UUID, productIDs []uuid.UUID) (*models.Issuance, models.CityType, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to start transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
 	}
+	defer database.TxRollback(tx, r.logger)
+
+	var city string
 
-	rowsAffected, err := result.RowsAffected()
+	err = tx.GetContext(ctx, &city, `SELECT city FROM pvzs WHERE id = $1`, issuance.PVZID)
 	if err != nil {
-		r.logger.Error("Error getting rows affected", zap.Error(err))
-		return databaseerrors.ErrUnexpected
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("Failed to get pvz city", zap.Error(err))
+
+		return nil, "", databaseerrors.ErrUnexpected
 	}
 
-	r.logger.Debug("Rows affected after deleting product", zap.Int64("rowsAffected", rowsAffected))
+	var orderID uuid.UUID
 
-	if rowsAffected == 0 {
-		// Если не удалили ни одного товара - значит, их и не было
-		return domainerrors.ErrNoProductsInReception
+	err = tx.GetContext(ctx, &orderID, `
+        SELECT order_id FROM pickup_codes
+        WHERE id = $1 AND pvz_id = $2 AND used_at IS NULL AND expires_at > $3
+        FOR UPDATE`,
+		pickupCodeID, issuance.PVZID, issuance.DateTime,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", domainerrors.ErrInvalidPickupCode
+		}
+
+		l.FromContext(ctx, r.logger).Error("Failed to lock pickup code", zap.Error(err))
+
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	var rows []issuableProductRow
+
+	err = tx.SelectContext(ctx, &rows, `
+        SELECT pr.id, pr.date_time, pr.type, pr.reception_id, pr.order_id, pr.status, pr.issuance_id, pr.issued_at, pr.shipment_id, pr.returned_at, r.status AS reception_status
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        WHERE pr.id = ANY($1) AND r.pvz_id = $2
+        FOR UPDATE OF pr
+        FOR SHARE OF r`,
+		pq.Array(productIDs), issuance.PVZID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to find products to issue", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	found := make(map[uuid.UUID]issuableProductRow, len(rows))
+	for _, row := range rows {
+		found[row.ID] = row
+	}
+
+	for _, id := range productIDs {
+		row, ok := found[id]
+
+		switch {
+		case !ok:
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotFound, id)
+		case row.OrderID != orderID:
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotInOrder, id)
+		case models.ReceptionStatus(row.ReceptionStatus) != models.ReceptionStatusClose:
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotReceived, id)
+		case models.ProductStatus(row.Status) != models.ProductStatusStored:
+			return nil, "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotStored, id)
+		}
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO issuances (id, pvz_id, issued_by, issued_at)
+        VALUES ($1, $2, $3, $4)`,
+		issuance.ID, issuance.PVZID, issuance.IssuedBy, issuance.DateTime,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to create issuance", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE products
+        SET status = $1, issuance_id = $2, issued_at = $3
+        WHERE id = ANY($4)`,
+		models.ProductStatusIssued.String(), issuance.ID, issuance.DateTime, pq.Array(productIDs),
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to mark products as issued", zap.Error(err), zap.String("issuanceID", issuance.ID.String()))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE pickup_codes SET used_at = $2
+        WHERE id = $1 AND NOT EXISTS (
+            SELECT 1 FROM products pr
+            INNER JOIN receptions r ON r.id = pr.reception_id
+            WHERE pr.order_id = $3 AND r.pvz_id = $4 AND pr.status = $5
+        )`,
+		pickupCodeID, issuance.DateTime, orderID, issuance.PVZID, models.ProductStatusStored.String(),
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to mark pickup code used", zap.Error(err), zap.String("issuanceID", issuance.ID.String()))
+		return nil, "", databaseerrors.ErrUnexpected
 	}
 
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("Error committing transaction", zap.Error(err))
-		return databaseerrors.ErrUnexpected
+		l.FromContext(ctx, r.logger).Error("Failed to commit transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
 	}
 
-	return nil
+	result := *issuance
+	result.Products = make([]*models.Product, 0, len(productIDs))
+
+	for _, id := range productIDs {
+		product := r.toModel(found[id].productRow)
+		product.Status = models.ProductStatusIssued
+		product.IssuanceID = &result.ID
+		product.IssuedAt = &result.DateTime
+
+		result.Products = append(result.Products, product)
+	}
+
+	return &result, models.CityType(city), nil
+}
+
+// ListStoredByOrder - возвращает хранящиеся в ПВЗ товары заказа из закрытых приёмок
+// в порядке приёмки (по времени, затем по айди). Если таких товаров нет, возвращает пустой список.
+func (r *postgresqlProductRepository) ListStoredByOrder(ctx context.Context, pvzID, orderID uuid.UUID) ([]*models.Product, error) {
+	var rows []productRow
+
+	err := r.db.SelectContext(ctx, &rows, `
+        SELECT pr.id, pr.date_time, pr.type, pr.reception_id, pr.order_id, pr.status, pr.issuance_id, pr.issued_at, pr.shipment_id, pr.returned_at
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        WHERE
+            r.pvz_id = $1 AND
+            r.status = $2 AND
+            pr.order_id = $3 AND
+            pr.status = $4
+        ORDER BY pr.date_time, pr.id`,
+		pvzID, models.ReceptionStatusClose.String(), orderID, models.ProductStatusStored.String(),
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to list order products", zap.Error(err), zap.String("orderID", orderID.String()))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	products := make([]*models.Product, 0, len(rows))
+	for _, row := range rows {
+		products = append(products, r.toModel(row))
+	}
+
+	return products, nil
+}
+
+// deadlinesQuery - общее табличное выражение с границами сроков хранения (см. deadlineArrays).
+// Занимает параметры $1, $2 и $3 запроса.
+const deadlinesQuery = `
+        WITH deadlines (city, type, received_by) AS (
+            SELECT * FROM unnest($1::varchar[], $2::varchar[], $3::timestamp[])
+        )`
+
+// deadlineArrays раскладывает границы сроков хранения на массивы городов, типов товаров и времени приёмки.
+// Время передается строкой без часового пояса, так же как драйвер передает время в столбцы TIMESTAMP.
+func deadlineArrays(deadlines []models.StorageDeadline) []interface{} {
+	cities := make([]string, 0, len(deadlines))
+	types := make([]string, 0, len(deadlines))
+	receivedBy := make([]string, 0, len(deadlines))
+
+	for _, deadline := range deadlines {
+		cities = append(cities, deadline.City.String())
+		types = append(types, deadline.Type.String())
+		receivedBy = append(receivedBy, deadline.ReceivedBy.Format("2006-01-02 15:04:05.999999"))
+	}
+
+	return []interface{}{pq.Array(cities), pq.Array(types), pq.Array(receivedBy)}
+}
+
+// getPVZCity - хелпер для получения города ПВЗ. Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlProductRepository) getPVZCity(ctx context.Context, q sqlx.QueryerContext, pvzID uuid.UUID) (models.CityType, error) {
+	var city string
+
+	err := sqlx.GetContext(ctx, q, &city, `SELECT city FROM pvzs WHERE id = $1`, pvzID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("Failed to get pvz city", zap.Error(err))
+
+		return "", databaseerrors.ErrUnexpected
+	}
+
+	return models.CityType(city), nil
+}
+
+// ListExpiring - возвращает хранящиеся в ПВЗ товары из закрытых приёмок, принятые не позже границы
+// для города ПВЗ и типа товара из deadlines, в порядке приёмки (по времени, затем по айди).
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows. Возвращает товары и город ПВЗ.
+func (r *postgresqlProductRepository) ListExpiring(ctx context.Context, pvzID uuid.UUID, deadlines []models.StorageDeadline) ([]*models.Product, models.CityType, error) {
+	city, err := r.getPVZCity(ctx, r.db, pvzID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var rows []productRow
+
+	err = r.db.SelectContext(ctx, &rows, deadlinesQuery+`
+        SELECT pr.id, pr.date_time, pr.type, pr.reception_id, pr.order_id, pr.status, pr.issuance_id, pr.issued_at, pr.shipment_id, pr.returned_at
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        INNER JOIN deadlines d ON d.city = p.city AND d.type = pr.type
+        WHERE
+            r.pvz_id = $4 AND
+            r.status = $5 AND
+            pr.status = $6 AND
+            pr.date_time <= d.received_by
+        ORDER BY pr.date_time, pr.id`,
+		append(deadlineArrays(deadlines), pvzID, models.ReceptionStatusClose.String(), models.ProductStatusStored.String())...,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to list expiring products", zap.Error(err), zap.String("pvzID", pvzID.String()))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	products := make([]*models.Product, 0, len(rows))
+	for _, row := range rows {
+		products = append(products, r.toModel(row))
+	}
+
+	return products, city, nil
+}
+
+// ReturnToSender - переносит хранящиеся в ПВЗ товары из закрытых приёмок, принятые не позже границы
+// для города ПВЗ и типа товара из deadlines, в новую отправку отправителю в одной транзакции.
+// Если таких товаров нет, возвращает domainerrors.ErrNoExpiredProducts и не создает отправку.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Строки товаров блокируются до конца транзакции, поэтому товар не может быть одновременно выдан
+// и возвращен или попасть в две отправки.
+// Возвращает отправку с товарами в порядке приёмки и город ПВЗ.
+func (r *postgresqlProductRepository) ReturnToSender(ctx context.Context, shipment *models.Shipment, deadlines []models.StorageDeadline) (*models.Shipment, models.CityType, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to start transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	city, err := r.getPVZCity(ctx, tx, shipment.PVZID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	var rows []productRow
+
+	err = tx.SelectContext(ctx, &rows, deadlinesQuery+`
+        SELECT pr.id, pr.date_time, pr.type, pr.reception_id, pr.order_id, pr.status, pr.issuance_id, pr.issued_at, pr.shipment_id, pr.returned_at
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        INNER JOIN deadlines d ON d.city = p.city AND d.type = pr.type
+        WHERE
+            r.pvz_id = $4 AND
+            r.status = $5 AND
+            pr.status = $6 AND
+            pr.date_time <= d.received_by
+        ORDER BY pr.date_time, pr.id
+        FOR UPDATE OF pr
+        FOR SHARE OF r`,
+		append(deadlineArrays(deadlines), shipment.PVZID, models.ReceptionStatusClose.String(), models.ProductStatusStored.String())...,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to find products to return", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	if len(rows) == 0 {
+		return nil, "", domainerrors.ErrNoExpiredProducts
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO shipments (id, pvz_id, kind, created_by, created_at, dispatched_at)
+        VALUES ($1, $2, $3, $4, $5, $6)`,
+		shipment.ID, shipment.PVZID, shipment.Kind.String(), shipment.CreatedBy, shipment.DateTime, shipment.DispatchedAt,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to create shipment", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	productIDs := make([]uuid.UUID, 0, len(rows))
+	for _, row := range rows {
+		productIDs = append(productIDs, row.ID)
+	}
+
+	_, err = tx.ExecContext(ctx, `
+        UPDATE products
+        SET status = $1, shipment_id = $2, returned_at = $3
+        WHERE id = ANY($4)`,
+		models.ProductStatusReturnedToSender.String(), shipment.ID, shipment.DateTime, pq.Array(productIDs),
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to mark products as returned", zap.Error(err), zap.String("shipmentID", shipment.ID.String()))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to commit transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	result := *shipment
+	result.Products = make([]*models.Product, 0, len(rows))
+
+	for _, row := range rows {
+		product := r.toModel(row)
+		product.Status = models.ProductStatusReturnedToSender
+		product.ShipmentID = &result.ID
+		product.ReturnedAt = &result.DateTime
+
+		result.Products = append(result.Products, product)
+	}
+
+	return &result, city, nil
+}
+
+// expiredCountRow - количество товаров с истекшим сроком хранения для города и типа товара.
+type expiredCountRow struct {
+	City  string `db:"city"`
+	Type  string `db:"type"`
+	Count int    `db:"count"`
+}
+
+// CountExpired - возвращает количество хранящихся товаров из закрытых приёмок, принятых не позже границы
+// для города ПВЗ и типа товара из deadlines, по городам и типам товаров. Города и типы без таких товаров не попадают в результат.
+func (r *postgresqlProductRepository) CountExpired(ctx context.Context, deadlines []models.StorageDeadline) (map[models.CityType]map[models.ProductType]int, error) {
+	var rows []expiredCountRow
+
+	err := r.db.SelectContext(ctx, &rows, deadlinesQuery+`
+        SELECT p.city, pr.type, COUNT(*) AS count
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        INNER JOIN deadlines d ON d.city = p.city AND d.type = pr.type
+        WHERE
+            r.status = $4 AND
+            pr.status = $5 AND
+            pr.date_time <= d.received_by
+        GROUP BY p.city, pr.type`,
+		append(deadlineArrays(deadlines), models.ReceptionStatusClose.String(), models.ProductStatusStored.String())...,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("Failed to count expired products", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	counts := make(map[models.CityType]map[models.ProductType]int)
+
+	for _, row := range rows {
+		city := models.CityType(row.City)
+		if counts[city] == nil {
+			counts[city] = make(map[models.ProductType]int)
+		}
+
+		counts[city][models.ProductType(row.Type)] = row.Count
+	}
+
+	return counts, nil
 }
@@ -63,6 +63,8 @@ func (s *ProductRepoTestSuite) TearDownSuite() {
 func (s *ProductRepoTestSuite) SetupTest() {
 	_, err := s.db.Exec("DELETE FROM products")
 	require.NoError(s.T(), err)
+	_, err = s.db.Exec("DELETE FROM issuances")
+	require.NoError(s.T(), err)
 	_, err = s.db.Exec("DELETE FROM receptions")
 	require.NoError(s.T(), err)
 }
@@ -98,12 +100,34 @@ func (s *ProductRepoTestSuite) TestCreateProduct_Success() {
 		PVZID:    pvzID,
 	}
 
-	created, err := s.repo.Create(s.ctx, product)
+	created, err := s.repo.Create(s.ctx, product, models.AnyVersion)
 	require.NoError(s.T(), err)
 	assert.Equal(s.T(), product.ID, created.ID)
 	assert.Equal(s.T(), product.Type, created.Type)
 }
 
+func (s *ProductRepoTestSuite) TestCreateProduct_ReceptionVersion() {
+	pvzID := s.createPVZ()
+	receptionID := s.createReception(pvzID, "in_progress")
+
+	product := &models.AddProduct{
+		ID:       uuid.New(),
+		DateTime: time.Now(),
+		Type:     models.ProductTypeElectronics,
+		PVZID:    pvzID,
+	}
+
+	_, err := s.repo.Create(s.ctx, product, 2)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrVersionMismatch)
+
+	_, err = s.repo.Create(s.ctx, product, 1)
+	require.NoError(s.T(), err)
+
+	var version int
+	require.NoError(s.T(), s.db.Get(&version, "SELECT version FROM receptions WHERE id = $1", receptionID))
+	assert.Equal(s.T(), 2, version)
+}
+
 func (s *ProductRepoTestSuite) TestCreateProduct_NoOpenReception() {
 	product := &models.AddProduct{
 		ID:       uuid.New(),
@@ -112,7 +136,7 @@ func (s *ProductRepoTestSuite) TestCreateProduct_NoOpenReception() {
 		PVZID:    uuid.New(),
 	}
 
-	_, err := s.repo.Create(s.ctx, product)
+	_, err := s.repo.Create(s.ctx, product, models.AnyVersion)
 	assert.ErrorIs(s.T(), err, domainerrors.ErrNoOpenReceptions)
 }
 
@@ -121,13 +145,17 @@ func (s *ProductRepoTestSuite) TestDeleteLast_Success() {
 	receptionID := s.createReception(pvzID, "in_progress")
 
 	productID := uuid.New()
-	query := `INSERT INTO products (id, date_time, type, reception_id) VALUES ($1, $2, $3, $4)`
+	query := `INSERT INTO products (id, date_time, type, reception_id, order_id) VALUES ($1, $2, $3, $4, $1)`
 	_, err := s.db.Exec(query, productID, time.Now(), "food", receptionID)
 	require.NoError(s.T(), err)
 
-	err = s.repo.DeleteLast(s.ctx, pvzID)
+	deleted, city, err := s.repo.DeleteLast(s.ctx, pvzID, models.AnyVersion)
 	require.NoError(s.T(), err)
 
+	assert.Equal(s.T(), productID, deleted.ID)
+	assert.Equal(s.T(), receptionID, deleted.ReceptionID)
+	assert.Equal(s.T(), models.CityTypeMoscow, city)
+
 	var count int
 	err = s.db.Get(&count, "SELECT COUNT(*) FROM products WHERE id = $1", productID)
 	require.NoError(s.T(), err)
@@ -135,7 +163,7 @@ func (s *ProductRepoTestSuite) TestDeleteLast_Success() {
 }
 
 func (s *ProductRepoTestSuite) TestDeleteLast_NoOpenReception() {
-	err := s.repo.DeleteLast(s.ctx, uuid.New())
+	_, _, err := s.repo.DeleteLast(s.ctx, uuid.New(), models.AnyVersion)
 	assert.ErrorIs(s.T(), err, domainerrors.ErrNoOpenReceptions)
 }
 
@@ -143,6 +171,89 @@ func (s *ProductRepoTestSuite) TestDeleteLast_NoProducts() {
 	pvzID := s.createPVZ()
 	s.createReception(pvzID, "in_progress")
 
-	err := s.repo.DeleteLast(s.ctx, pvzID)
+	_, _, err := s.repo.DeleteLast(s.ctx, pvzID, models.AnyVersion)
 	assert.ErrorIs(s.T(), err, domainerrors.ErrNoProductsInReception)
 }
+
+func (s *ProductRepoTestSuite) newBatch(pvzID uuid.UUID, n int) []*models.AddProduct {
+	products := make([]*models.AddProduct, 0, n)
+	for i := 0; i < n; i++ {
+		products = append(products, &models.AddProduct{
+			ID:       uuid.New(),
+			DateTime: time.Now(),
+			Type:     models.ProductTypeShoes,
+			PVZID:    pvzID,
+		})
+	}
+
+	return products
+}
+
+func (s *ProductRepoTestSuite) TestCreateBatch_ReplayIsIdempotent() {
+	pvzID := s.createPVZ()
+	receptionID := s.createReception(pvzID, "in_progress")
+	products := s.newBatch(pvzID, 3)
+
+	result, err := s.repo.CreateBatch(s.ctx, pvzID, products, models.BatchModeAllOrNothing, models.AnyVersion)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Applied)
+	assert.Equal(s.T(), receptionID, result.ReceptionID)
+
+	for _, item := range result.Items {
+		assert.Equal(s.T(), models.BatchItemStatusCreated, item.Status)
+	}
+
+	// Повтор того же пакета не создает дубликатов
+	result, err = s.repo.CreateBatch(s.ctx, pvzID, products, models.BatchModeAllOrNothing, models.AnyVersion)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Applied)
+
+	for _, item := range result.Items {
+		assert.Equal(s.T(), models.BatchItemStatusDuplicate, item.Status)
+		assert.NotNil(s.T(), item.Product)
+	}
+
+	var count int
+	require.NoError(s.T(), s.db.Get(&count, "SELECT COUNT(*) FROM products WHERE reception_id = $1", receptionID))
+	assert.Equal(s.T(), 3, count)
+}
+
+func (s *ProductRepoTestSuite) TestCreateBatch_IDConflict() {
+	otherPVZ := s.createPVZ()
+	s.createReception(otherPVZ, "in_progress")
+	conflicting := s.newBatch(otherPVZ, 1)
+
+	_, err := s.repo.CreateBatch(s.ctx, otherPVZ, conflicting, models.BatchModeAllOrNothing, models.AnyVersion)
+	require.NoError(s.T(), err)
+
+	pvzID := s.createPVZ()
+	receptionID := s.createReception(pvzID, "in_progress")
+	products := append(s.newBatch(pvzID, 1), &models.AddProduct{ID: conflicting[0].ID, DateTime: time.Now(), Type: models.ProductTypeShoes, PVZID: pvzID})
+
+	// all_or_nothing: пакет откатывается целиком
+	result, err := s.repo.CreateBatch(s.ctx, pvzID, products, models.BatchModeAllOrNothing, models.AnyVersion)
+	require.NoError(s.T(), err)
+	assert.False(s.T(), result.Applied)
+	assert.Equal(s.T(), models.BatchItemStatusSkipped, result.Items[0].Status)
+	assert.Equal(s.T(), models.BatchItemStatusFailed, result.Items[1].Status)
+	assert.ErrorIs(s.T(), result.Items[1].Err, domainerrors.ErrProductIDConflict)
+
+	var count int
+	require.NoError(s.T(), s.db.Get(&count, "SELECT COUNT(*) FROM products WHERE reception_id = $1", receptionID))
+	assert.Equal(s.T(), 0, count)
+
+	// best_effort: корректные товары добавляются
+	result, err = s.repo.CreateBatch(s.ctx, pvzID, products, models.BatchModeBestEffort, models.AnyVersion)
+	require.NoError(s.T(), err)
+	assert.True(s.T(), result.Applied)
+	assert.Equal(s.T(), models.BatchItemStatusCreated, result.Items[0].Status)
+	assert.Equal(s.T(), models.BatchItemStatusFailed, result.Items[1].Status)
+
+	require.NoError(s.T(), s.db.Get(&count, "SELECT COUNT(*) FROM products WHERE reception_id = $1", receptionID))
+	assert.Equal(s.T(), 1, count)
+}
+
+func (s *ProductRepoTestSuite) TestCreateBatch_NoOpenReception() {
+	_, err := s.repo.CreateBatch(s.ctx, uuid.New(), s.newBatch(uuid.New(), 1), models.BatchModeBestEffort, models.AnyVersion)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrNoOpenReceptions)
+}
@@ -3,6 +3,7 @@ package postgresqlrepo
 import (
 	"context"
 	"fmt"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"time"
 
 	"github.com/google/uuid"
@@ -33,20 +34,36 @@ type pvzRow struct {
 	ID               uuid.UUID `db:"id"`
 	RegistrationDate time.Time `db:"registration_date"`
 	City             string    `db:"city"`
+	Version          int       `db:"version"`
 }
 
 // listedPVZRow представляет собой строку из представления ПВЗ в базе данных,
 // которая включает в себя информацию о приемках и товарах.
 type listedPVZRow struct {
-	ID               uuid.UUID  `db:"id"` // айди пвз
-	RegistrationDate time.Time  `db:"registration_date"`
-	City             string     `db:"city"`
-	ReceptionID      *uuid.UUID `db:"reception_id"`
-	ReceptionDate    *time.Time `db:"reception_date"`
-	ReceptionStatus  *string    `db:"reception_status"`
-	ProductID        *uuid.UUID `db:"product_id"`
-	ProductDate      *time.Time `db:"product_date"`
-	ProductType      *string    `db:"product_type"`
+	ID                   uuid.UUID  `db:"id"` // айди пвз
+	RegistrationDate     time.Time  `db:"registration_date"`
+	City                 string     `db:"city"`
+	Version              int        `db:"version"`
+	ReceptionID          *uuid.UUID `db:"reception_id"`
+	ReceptionDate        *time.Time `db:"reception_date"`
+	ReceptionStatus      *string    `db:"reception_status"`
+	ReceptionOpenedBy    *uuid.UUID `db:"reception_opened_by"`
+	ReceptionClosedAt    *time.Time `db:"reception_closed_at"`
+	ReceptionClosedBy    *uuid.UUID `db:"reception_closed_by"`
+	ReceptionAutoClosed  *bool      `db:"reception_auto_closed"`
+	ReceptionCloseReason *string    `db:"reception_close_reason"`
+	ReceptionReopenedAt  *time.Time `db:"reception_reopened_at"`
+	ReceptionReopenedBy  *uuid.UUID `db:"reception_reopened_by"`
+	ReceptionVersion     *int       `db:"reception_version"`
+	ProductID            *uuid.UUID `db:"product_id"`
+	ProductDate          *time.Time `db:"product_date"`
+	ProductType          *string    `db:"product_type"`
+	ProductOrderID       *uuid.UUID `db:"product_order_id"`
+	ProductStatus        *string    `db:"product_status"`
+	ProductIssuanceID    *uuid.UUID `db:"product_issuance_id"`
+	ProductIssuedAt      *time.Time `db:"product_issued_at"`
+	ProductShipmentID    *uuid.UUID `db:"product_shipment_id"`
+	ProductReturnedAt    *time.Time `db:"product_returned_at"`
 }
 
 // toModel производит маппинг из представления ПВЗ в базе данных в доменную модель.
@@ -55,6 +72,7 @@ func (r *postgresqlPVZRepository) toModel(row pvzRow) *models.PVZ {
 		ID:               row.ID,
 		RegistrationDate: row.RegistrationDate,
 		City:             models.CityType(row.City),
+		Version:          row.Version,
 	}
 }
 
@@ -79,6 +97,7 @@ func (r *postgresqlPVZRepository) listedToModel(rows []*listedPVZRow) []*models.
 					ID:               row.ID,
 					RegistrationDate: row.RegistrationDate,
 					City:             models.CityType(row.City),
+					Version:          row.Version,
 				},
 				Receptions: []*models.ReceptionWithProducts{},
 			}
@@ -86,14 +105,36 @@ func (r *postgresqlPVZRepository) listedToModel(rows []*listedPVZRow) []*models.
 
 		if row.ReceptionID != nil {
 			if _, exists := receptionMap[*row.ReceptionID]; !exists {
+				reception := &models.Reception{
+					ID:         *row.ReceptionID,
+					DateTime:   *row.ReceptionDate,
+					PVZID:      row.ID,
+					Status:     models.ReceptionStatus(*row.ReceptionStatus),
+					ClosedAt:   row.ReceptionClosedAt,
+					ClosedBy:   row.ReceptionClosedBy,
+					ReopenedAt: row.ReceptionReopenedAt,
+					ReopenedBy: row.ReceptionReopenedBy,
+				}
+
+				if row.ReceptionOpenedBy != nil {
+					reception.OpenedBy = *row.ReceptionOpenedBy
+				}
+
+				if row.ReceptionAutoClosed != nil {
+					reception.AutoClosed = *row.ReceptionAutoClosed
+				}
+
+				if row.ReceptionCloseReason != nil {
+					reception.CloseReason = *row.ReceptionCloseReason
+				}
+
+				if row.ReceptionVersion != nil {
+					reception.Version = *row.ReceptionVersion
+				}
+
 				receptionMap[*row.ReceptionID] = &models.ReceptionWithProducts{
-					Reception: &models.Reception{
-						ID:       *row.ReceptionID,
-						DateTime: *row.ReceptionDate,
-						PVZID:    row.ID,
-						Status:   models.ReceptionStatus(*row.ReceptionStatus),
-					},
-					Products: []*models.Product{},
+					Reception: reception,
+					Products:  []*models.Product{},
 				}
 				pvzMap[row.ID].Receptions = append(pvzMap[row.ID].Receptions, receptionMap[*row.ReceptionID])
 			}
@@ -104,32 +145,49 @@ func (r *postgresqlPVZRepository) listedToModel(rows []*listedPVZRow) []*models.
 					DateTime:    *row.ProductDate,
 					Type:        models.ProductType(*row.ProductType),
 					ReceptionID: *row.ReceptionID,
+					OrderID:     *row.ProductOrderID,
+					Status:      models.ProductStatus(*row.ProductStatus),
+					IssuanceID:  row.ProductIssuanceID,
+					IssuedAt:    row.ProductIssuedAt,
+					ShipmentID:  row.ProductShipmentID,
+					ReturnedAt:  row.ProductReturnedAt,
 				})
 			}
 		}
 	}
 
+	// Результат собирается в порядке строк запроса, чтобы одинаковые данные
+	// всегда давали одинаковый ответ (от этого зависит ETag списка)
 	result := make([]*models.PVZWithReceptions, 0, len(pvzMap))
-	for _, pvz := range pvzMap {
-		result = append(result, pvz)
+	seen := make(map[uuid.UUID]struct{}, len(pvzMap))
+
+	for _, row := range rows {
+		if _, ok := seen[row.ID]; ok {
+			continue
+		}
+
+		seen[row.ID] = struct{}{}
+		result = append(result, pvzMap[row.ID])
 	}
 
 	return result
 }
 
-// Create создает новую запись о пвз в базе данных.
+// Create создает новую запись о пвз в базе данных и заполняет pvz.Version начальной версией.
 // Возвращает ошибку если что-то пошло не так или если пвз с указанным айди уже существует.
 func (r *postgresqlPVZRepository) Create(ctx context.Context, pvz *models.PVZ) error {
-	query := `INSERT INTO pvzs (id, registration_date, city) VALUES (:id, :registration_date, :city)`
+	query := `INSERT INTO pvzs (id, registration_date, city) VALUES ($1, $2, $3) RETURNING version`
+
+	row := r.toRow(pvz)
 
-	_, err := r.db.NamedExecContext(ctx, query, r.toRow(pvz))
+	err := r.db.GetContext(ctx, &pvz.Version, query, row.ID, row.RegistrationDate, row.City)
 
 	if err != nil {
 		if database.IsPGError(err, database.PGUniqueViolationCode) {
 			return databaseerrors.ErrUniqueViolation
 		}
 
-		r.logger.Error("failed to create PVZ", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to create PVZ", zap.Error(err))
 
 		return databaseerrors.ErrUnexpected
 	}
@@ -157,17 +215,32 @@ func (r *postgresqlPVZRepository) List(ctx context.Context, filter *models.PVZFi
             p.id,
             p.registration_date,
             p.city,
+            p.version,
             r.id as reception_id,
             r.date_time as reception_date,
             r.status as reception_status,
+            r.opened_by as reception_opened_by,
+            r.closed_at as reception_closed_at,
+            r.closed_by as reception_closed_by,
+            r.auto_closed as reception_auto_closed,
+            r.close_reason as reception_close_reason,
+            r.reopened_at as reception_reopened_at,
+            r.reopened_by as reception_reopened_by,
+            r.version as reception_version,
             pr.id as product_id,
             pr.date_time as product_date,
-            pr.type as product_type
+            pr.type as product_type,
+            pr.order_id as product_order_id,
+            pr.status as product_status,
+            pr.issuance_id as product_issuance_id,
+            pr.issued_at as product_issued_at,
+            pr.shipment_id as product_shipment_id,
+            pr.returned_at as product_returned_at
         FROM paginated_pvz pp
         INNER JOIN pvzs p ON pp.id = p.id
         %s
         %s
-        ORDER BY p.registration_date DESC, r.date_time DESC
+        ORDER BY p.registration_date DESC, p.id, r.date_time DESC, pr.date_time, pr.id
     `
 
 	var joinClause, whereClause string
@@ -205,7 +278,7 @@ func (r *postgresqlPVZRepository) List(ctx context.Context, filter *models.PVZFi
 
 	rows, err := r.db.QueryxContext(ctx, finalQuery, args...)
 	if err != nil {
-		r.logger.Error("failed to list PVZs", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to list PVZs", zap.Error(err))
 		return nil, databaseerrors.ErrUnexpected
 	}
 	defer rows.Close()
@@ -215,7 +288,7 @@ func (r *postgresqlPVZRepository) List(ctx context.Context, filter *models.PVZFi
 	for rows.Next() {
 		var row listedPVZRow
 		if err := rows.StructScan(&row); err != nil {
-			r.logger.Error("failed to scan PVZ row", zap.Error(err))
+			l.FromContext(ctx, r.logger).Error("failed to scan PVZ row", zap.Error(err))
 			return nil, databaseerrors.ErrUnexpected
 		}
 
@@ -228,11 +301,11 @@ func (r *postgresqlPVZRepository) List(ctx context.Context, filter *models.PVZFi
 // GetAll возвращает все существующие ПВЗ из базы данных.
 // Возвращает список доменных моделей models.PVZ или ошибку, если она была
 func (r *postgresqlPVZRepository) GetAll(ctx context.Context) ([]*models.PVZ, error) {
-	query := `SELECT id, registration_date, city FROM pvzs`
+	query := `SELECT id, registration_date, city, version FROM pvzs`
 
 	rows, err := r.db.QueryxContext(ctx, query)
 	if err != nil {
-		r.logger.Error("failed to get all PVZs", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to get all PVZs", zap.Error(err))
 		return nil, databaseerrors.ErrUnexpected
 	}
 	defer rows.Close()
@@ -242,7 +315,7 @@ func (r *postgresqlPVZRepository) GetAll(ctx context.Context) ([]*models.PVZ, er
 	for rows.Next() {
 		var row pvzRow
 		if err := rows.StructScan(&row); err != nil {
-			r.logger.Error("failed to scan PVZ row", zap.Error(err))
+			l.FromContext(ctx, r.logger).Error("failed to scan PVZ row", zap.Error(err))
 			return nil, databaseerrors.ErrUnexpected
 		}
 
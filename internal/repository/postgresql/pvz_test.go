@@ -100,7 +100,7 @@ func (s *PVZRepoTestSuite) createTestReception(pvzID uuid.UUID, status string, d
 // хелпер для создания тестового продукта
 func (s *PVZRepoTestSuite) createTestProduct(receptionID uuid.UUID, productType models.ProductType, date time.Time) {
 	_, err := s.db.Exec(
-		"INSERT INTO products (id, date_time, type, reception_id) VALUES ($1, $2, $3, $4)",
+		"INSERT INTO products (id, date_time, type, reception_id, order_id) VALUES ($1, $2, $3, $4, $1)",
 		uuid.New(), date, productType.String(), receptionID,
 	)
 	require.NoError(s.T(), err)
@@ -238,3 +238,28 @@ func (s *PVZRepoTestSuite) TestListPVZs_WithProducts() {
 	require.Len(s.T(), result[0].Receptions, 1)
 	assert.Len(s.T(), result[0].Receptions[0].Products, 1)
 }
+
+func (s *PVZRepoTestSuite) TestListPVZs_ReceptionAuthors() {
+	pvz := s.createTestPVZ()
+	openedBy := uuid.New()
+	closedBy := uuid.New()
+	closedAt := time.Now()
+
+	_, err := s.db.Exec(
+		"INSERT INTO receptions (id, pvz_id, date_time, status, opened_by, closed_at, closed_by) VALUES ($1, $2, $3, $4, $5, $6, $7)",
+		uuid.New(), pvz.ID, closedAt.Add(-time.Hour), "close", openedBy, closedAt, closedBy,
+	)
+	require.NoError(s.T(), err)
+
+	result, err := s.repo.List(s.ctx, &models.PVZFilter{Page: 1, PageSize: 10})
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), result, 1)
+	require.Len(s.T(), result[0].Receptions, 1)
+
+	reception := result[0].Receptions[0].Reception
+	assert.Equal(s.T(), openedBy, reception.OpenedBy)
+	require.NotNil(s.T(), reception.ClosedAt)
+	require.NotNil(s.T(), reception.ClosedBy)
+	assert.Equal(s.T(), closedBy, *reception.ClosedBy)
+}
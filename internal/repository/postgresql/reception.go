@@ -3,10 +3,13 @@ package postgresqlrepo
 import (
 	"context"
 	"database/sql"
+	"database/sql/driver"
 	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
@@ -29,62 +32,94 @@ func NewPostgresqlReceptionRepository(db *database.PostgresDB, logger *zap.Logge
 	}
 }
 
+// receptionColumns - список колонок таблицы receptions, соответствующий receptionRow.
+const receptionColumns = "id, date_time, pvz_id, status, opened_by, closed_at, closed_by, auto_closed, close_reason, reopened_at, reopened_by, version"
+
 // receptionRow - представляет собой строку из таблицы receptions в базе данных.
 type receptionRow struct {
-	ID       uuid.UUID `db:"id"`
-	DateTime time.Time `db:"date_time"`
-	PVZID    uuid.UUID `db:"pvz_id"`
-	Status   string    `db:"status"`
+	ID          uuid.UUID  `db:"id"`
+	DateTime    time.Time  `db:"date_time"`
+	PVZID       uuid.UUID  `db:"pvz_id"`
+	Status      string     `db:"status"`
+	OpenedBy    *uuid.UUID `db:"opened_by"`
+	ClosedAt    *time.Time `db:"closed_at"`
+	ClosedBy    *uuid.UUID `db:"closed_by"`
+	AutoClosed  bool       `db:"auto_closed"`
+	CloseReason *string    `db:"close_reason"`
+	ReopenedAt  *time.Time `db:"reopened_at"`
+	ReopenedBy  *uuid.UUID `db:"reopened_by"`
+	Version     int        `db:"version"`
 }
 
 // toModel производит маппинг из строки таблицы receptions в доменную модель.
+// Для приемок, созданных до появления opened_by, OpenedBy будет равен uuid.Nil.
 func (r *postgresqlReceptionRepository) toModel(row receptionRow) *models.Reception {
-	return &models.Reception{
-		ID:       row.ID,
-		DateTime: row.DateTime,
-		PVZID:    row.PVZID,
-		Status:   models.ReceptionStatus(row.Status),
+	reception := &models.Reception{
+		ID:         row.ID,
+		DateTime:   row.DateTime,
+		PVZID:      row.PVZID,
+		Status:     models.ReceptionStatus(row.Status),
+		ClosedAt:   row.ClosedAt,
+		ClosedBy:   row.ClosedBy,
+		AutoClosed: row.AutoClosed,
+		ReopenedAt: row.ReopenedAt,
+		ReopenedBy: row.ReopenedBy,
+		Version:    row.Version,
+	}
+
+	if row.OpenedBy != nil {
+		reception.OpenedBy = *row.OpenedBy
 	}
+
+	if row.CloseReason != nil {
+		reception.CloseReason = *row.CloseReason
+	}
+
+	return reception
 }
 
 // toRow производит маппинг из доменной модели в строку таблицы receptions.
 func (r *postgresqlReceptionRepository) toRow(reception *models.Reception) *receptionRow {
-	return &receptionRow{
-		ID:       reception.ID,
-		DateTime: reception.DateTime,
-		PVZID:    reception.PVZID,
-		Status:   reception.Status.String(),
+	row := &receptionRow{
+		ID:         reception.ID,
+		DateTime:   reception.DateTime,
+		PVZID:      reception.PVZID,
+		Status:     reception.Status.String(),
+		OpenedBy:   &reception.OpenedBy,
+		ClosedAt:   reception.ClosedAt,
+		ClosedBy:   reception.ClosedBy,
+		AutoClosed: reception.AutoClosed,
+		ReopenedAt: reception.ReopenedAt,
+		ReopenedBy: reception.ReopenedBy,
+	}
+
+	if reception.CloseReason != "" {
+		row.CloseReason = &reception.CloseReason
 	}
+
+	return row
 }
 
 // CreateIfNoOpen создает новую приемку, если в ПВЗ нет открытых приемок.
-// В транзакции проверяет наличие ПВЗ и открытых приемок.
+// В транзакции блокирует строку ПВЗ, проверяет его версию и наличие открытых приемок,
+// привязывает к новой приемке ожидающий манифест ПВЗ, если он был загружен заранее, и увеличивает версию ПВЗ.
+// Если expectedPVZVersion не равна models.AnyVersion и не совпадает с версией ПВЗ, возвращает domainerrors.ErrVersionMismatch.
 // Если открытая приёмка уже существует, возвращает ошибку.
-func (r *postgresqlReceptionRepository) CreateIfNoOpen(ctx context.Context, reception *models.Reception) error {
+// Заполняет reception.Version начальной версией приемки.
+func (r *postgresqlReceptionRepository) CreateIfNoOpen(ctx context.Context, reception *models.Reception, expectedPVZVersion int) error {
 	// Можно было бы использовать меньше запросов, но такой подход позволяет
 	// 1) Возвращать более детализованные ошибки
 	// 2) Улучшить читаемость кода
 	// 3) Улучшить производительность в определенных сценариях (например, когда на вход сразу подается айди не существующего ПВЗ)
 	tx, err := r.db.BeginTxx(ctx, nil)
 	if err != nil {
-		r.logger.Error("failed to begin transaction", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
 		return databaseerrors.ErrUnexpected
 	}
 	defer database.TxRollback(tx, r.logger)
 
-	var pvzExists bool
-
-	err = tx.GetContext(ctx, &pvzExists,
-		"SELECT EXISTS(SELECT 1 FROM pvzs WHERE id = $1)",
-		reception.PVZID,
-	)
-	if err != nil {
-		r.logger.Error("error checking PVZ existence", zap.Error(err))
-		return databaseerrors.ErrUnexpected
-	}
-
-	if !pvzExists {
-		return databaseerrors.ErrNoRows
+	if err := lockPVZVersion(ctx, tx, r.logger, reception.PVZID, expectedPVZVersion); err != nil {
+		return err
 	}
 
 	var openReceptionExists bool
@@ -94,7 +129,7 @@ func (r *postgresqlReceptionRepository) CreateIfNoOpen(ctx context.Context, rece
 		reception.PVZID,
 	)
 	if err != nil {
-		r.logger.Error("error checking open receptions", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("error checking open receptions", zap.Error(err))
 		return databaseerrors.ErrUnexpected
 	}
 
@@ -102,47 +137,432 @@ func (r *postgresqlReceptionRepository) CreateIfNoOpen(ctx context.Context, rece
 		return domainerrors.ErrOpenReceptionExists
 	}
 
-	_, err = tx.NamedExecContext(ctx,
-		`INSERT INTO receptions (id, date_time, pvz_id, status)
-         VALUES (:id, :date_time, :pvz_id, :status)`,
-		r.toRow(reception),
+	row := r.toRow(reception)
+
+	err = tx.GetContext(ctx, &reception.Version,
+		`INSERT INTO receptions (id, date_time, pvz_id, status, opened_by)
+         VALUES ($1, $2, $3, $4, $5)
+         RETURNING version`,
+		row.ID, row.DateTime, row.PVZID, row.Status, row.OpenedBy,
 	)
 	if err != nil {
-		r.logger.Error("error inserting reception", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("error inserting reception", zap.Error(err))
 		return databaseerrors.ErrUnexpected
 	}
 
+	_, err = tx.ExecContext(ctx,
+		"UPDATE reception_manifests SET reception_id = $1 WHERE pvz_id = $2 AND reception_id IS NULL",
+		reception.ID, reception.PVZID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error attaching pending manifest", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	if err := bumpPVZVersion(ctx, tx, r.logger, reception.PVZID); err != nil {
+		return err
+	}
+
 	if err := tx.Commit(); err != nil {
-		r.logger.Error("failed to commit transaction", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
 		return databaseerrors.ErrUnexpected
 	}
 
 	return nil
 }
 
-// CloseLast закрывает последнюю открывшуюся приемку в ПВЗ.
+// closedReceptionRow - закрытая приемка вместе с городом ПВЗ.
+type closedReceptionRow struct {
+	receptionRow
+	City string `db:"city"`
+}
+
+// CloseLast закрывает последнюю открывшуюся приемку в ПВЗ и сохраняет время её закрытия
+// и айди закрывшего её пользователя. Возвращает закрытую приемку и город ПВЗ.
+// Если к приемке привязан манифест, в той же транзакции строит и сохраняет отчет о расхождениях (см. buildDiscrepancyReport).
+// Строка ПВЗ блокируется до изменения приемки, как в CreateIfNoOpen, и версия ПВЗ увеличивается,
+// так как у ПВЗ больше нет открытой приемки.
 // Если приемка не найдена, возвращает ошибку.
-func (r *postgresqlReceptionRepository) CloseLast(ctx context.Context, pvzID uuid.UUID) (*models.Reception, error) {
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией приемки, возвращает domainerrors.ErrVersionMismatch.
+func (r *postgresqlReceptionRepository) CloseLast(ctx context.Context, pvzID, closedBy uuid.UUID, closedAt time.Time, expectedVersion int) (*models.Reception, models.CityType, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
 
-	var receptionRow receptionRow
+	if err := r.lockReceptionPVZ(ctx, tx, pvzID); err != nil {
+		return nil, "", err
+	}
 
-	err := r.db.GetContext(ctx, &receptionRow, `
+	var row closedReceptionRow
+
+	err = tx.GetContext(ctx, &row, `
         UPDATE receptions 
-        SET status = 'close' 
+        SET status = 'close', closed_at = $2, closed_by = $3, version = version + 1
         WHERE pvz_id = $1
 		AND status = 'in_progress'
-        RETURNING id, date_time, pvz_id, status`,
+        AND ($4 = 0 OR version = $4)
+        RETURNING `+receptionColumns+`, (SELECT city FROM pvzs WHERE id = $1) AS city`,
+		pvzID, closedAt, closedBy, expectedVersion,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", r.openReceptionNotUpdated(ctx, pvzID)
+		}
+
+		l.FromContext(ctx, r.logger).Error("failed to close reception", zap.Error(err))
+
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	if err := bumpPVZVersion(ctx, tx, r.logger, pvzID); err != nil {
+		return nil, "", err
+	}
+
+	reception := r.toModel(row.receptionRow)
+
+	reception.Discrepancy, err = buildDiscrepancyReport(ctx, tx, r.logger, reception.ID, closedAt)
+	if err != nil {
+		return nil, "", err
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	return reception, models.CityType(row.City), nil
+}
+
+// CancelLast отменяет открытую приемку в ПВЗ и сохраняет время отмены,
+// айди отменившего её пользователя и причину. Как и CloseLast, увеличивает версию ПВЗ.
+// Если открытая приемка не найдена, возвращает ошибку.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией приемки, возвращает domainerrors.ErrVersionMismatch.
+func (r *postgresqlReceptionRepository) CancelLast(ctx context.Context, pvzID, cancelledBy uuid.UUID, cancelledAt time.Time, reason string, expectedVersion int) (*models.Reception, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	if err := r.lockReceptionPVZ(ctx, tx, pvzID); err != nil {
+		return nil, err
+	}
+
+	var receptionRow receptionRow
+
+	err = tx.GetContext(ctx, &receptionRow, `
+        UPDATE receptions
+        SET status = 'cancelled', closed_at = $2, closed_by = $3, close_reason = $4, version = version + 1
+        WHERE pvz_id = $1
+        AND status = 'in_progress'
+        AND ($5 = 0 OR version = $5)
+        RETURNING `+receptionColumns,
+		pvzID, cancelledAt, cancelledBy, reason, expectedVersion,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.openReceptionNotUpdated(ctx, pvzID)
+		}
+
+		l.FromContext(ctx, r.logger).Error("failed to cancel reception", zap.Error(err))
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	if err := bumpPVZVersion(ctx, tx, r.logger, pvzID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return r.toModel(receptionRow), nil
+}
+
+// GetLast возвращает последнюю по времени открытия приемку в ПВЗ независимо от её статуса.
+// Если в ПВЗ нет приемок, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlReceptionRepository) GetLast(ctx context.Context, pvzID uuid.UUID) (*models.Reception, error) {
+	var receptionRow receptionRow
+
+	err := r.db.GetContext(ctx, &receptionRow, `
+        SELECT `+receptionColumns+`
+        FROM receptions
+        WHERE pvz_id = $1
+        ORDER BY date_time DESC
+        LIMIT 1`,
 		pvzID,
 	)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			return nil, domainerrors.ErrNoOpenReceptions
+			return nil, databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("failed to get last reception", zap.Error(err))
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return r.toModel(receptionRow), nil
+}
+
+// Reopen повторно открывает закрытую приемку и сохраняет время и айди переоткрывшего её пользователя.
+// Данные о закрытии сбрасываются. В транзакции блокирует строку ПВЗ, как и CreateIfNoOpen,
+// поэтому переоткрытие и создание новой приемки в одном ПВЗ выполняются по очереди, и увеличивает версию ПВЗ.
+// Приемка должна быть закрыта, быть последней в ПВЗ, и в ПВЗ не должно быть открытых приемок.
+// Если условия не выполнены, возвращает domainerrors.ErrReceptionNotReopenable.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией приемки, возвращает domainerrors.ErrVersionMismatch.
+func (r *postgresqlReceptionRepository) Reopen(ctx context.Context, receptionID, reopenedBy uuid.UUID, reopenedAt time.Time, expectedVersion int) (*models.Reception, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	var pvzID uuid.UUID
+
+	err = tx.GetContext(ctx, &pvzID, "SELECT pvz_id FROM receptions WHERE id = $1", receptionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domainerrors.ErrReceptionNotReopenable
+		}
+
+		l.FromContext(ctx, r.logger).Error("error getting reception PVZ", zap.Error(err))
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	if err := lockPVZVersion(ctx, tx, r.logger, pvzID, models.AnyVersion); err != nil {
+		return nil, err
+	}
+
+	var receptionRow receptionRow
+
+	err = tx.GetContext(ctx, &receptionRow, `
+        UPDATE receptions r
+        SET status = 'in_progress', closed_at = NULL, closed_by = NULL, auto_closed = FALSE, close_reason = NULL,
+            reopened_at = $2, reopened_by = $3, version = r.version + 1
+        WHERE r.id = $1
+        AND r.status = 'close'
+        AND ($4 = 0 OR r.version = $4)
+        AND NOT EXISTS (
+            SELECT 1 FROM receptions n
+            WHERE n.pvz_id = r.pvz_id
+            AND n.id <> r.id
+            AND (n.date_time > r.date_time OR n.status = 'in_progress')
+        )
+        RETURNING `+receptionColumns,
+		receptionID, reopenedAt, reopenedBy, expectedVersion,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, r.reopenNotUpdated(ctx, receptionID, expectedVersion)
 		}
 
-		r.logger.Error("failed to close reception", zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to reopen reception", zap.Error(err))
 
 		return nil, databaseerrors.ErrUnexpected
 	}
 
+	if err := bumpPVZVersion(ctx, tx, r.logger, pvzID); err != nil {
+		return nil, err
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
 	return r.toModel(receptionRow), nil
 }
+
+// CloseStale автоматически закрывает все приемки в ПВЗ указанного города, открытые раньше openedBefore.
+// Закрытые приемки помечаются как auto_closed с указанной причиной, closed_by остается пустым.
+// Для приемок с манифестом в той же транзакции строятся отчеты о расхождениях.
+// ПВЗ закрываемых приемок блокируются заранее в порядке айди, и их версии увеличиваются.
+// Вызывается под блокировкой LockAutoClose, чтобы задачу одновременно выполняла только одна реплика.
+// Возвращает список закрытых приемок.
+func (r *postgresqlReceptionRepository) CloseStale(ctx context.Context, city models.CityType, openedBefore, closedAt time.Time, reason string) ([]*models.Reception, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	_, err = tx.ExecContext(ctx, `
+        SELECT id FROM pvzs
+        WHERE city = $1
+        AND id IN (SELECT pvz_id FROM receptions WHERE status = 'in_progress' AND date_time < $2)
+        ORDER BY id
+        FOR UPDATE`,
+		city.String(), openedBefore,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to lock PVZs of stale receptions", zap.String("city", city.String()), zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	var rows []receptionRow
+
+	err = tx.SelectContext(ctx, &rows, `
+        UPDATE receptions
+        SET status = 'close', closed_at = $1, auto_closed = TRUE, close_reason = $2, version = version + 1
+        WHERE status = 'in_progress'
+        AND date_time < $3
+        AND pvz_id IN (SELECT id FROM pvzs WHERE city = $4)
+        RETURNING `+receptionColumns,
+		closedAt, reason, openedBefore, city.String(),
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to close stale receptions", zap.String("city", city.String()), zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	result := make([]*models.Reception, 0, len(rows))
+
+	for _, row := range rows {
+		reception := r.toModel(row)
+
+		if err := bumpPVZVersion(ctx, tx, r.logger, reception.PVZID); err != nil {
+			return nil, err
+		}
+
+		reception.Discrepancy, err = buildDiscrepancyReport(ctx, tx, r.logger, reception.ID, closedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, reception)
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return result, nil
+}
+
+// LockAutoClose захватывает сессионную advisory-блокировку автоматического закрытия приемок
+// на отдельном соединении и держит её, пока не будет вызвана возвращенная функция.
+// Так блокировка действует на весь проход по городам, а не на транзакцию одного города,
+// и другая реплика не может начать проход между городами.
+// Если блокировку удерживает другая реплика, возвращает databaseerrors.ErrLockNotAcquired.
+func (r *postgresqlReceptionRepository) LockAutoClose(ctx context.Context) (func(), error) {
+	conn, err := r.db.Connx(ctx)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to get connection for auto close lock", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	var locked bool
+
+	err = conn.GetContext(ctx, &locked, "SELECT pg_try_advisory_lock(hashtext('reception_auto_close'))")
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to acquire auto close lock", zap.Error(err))
+		r.discardConn(conn)
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	if !locked {
+		conn.Close()
+		return nil, databaseerrors.ErrLockNotAcquired
+	}
+
+	unlock := func() {
+		// Блокировка снимается и после отмены контекста прохода
+		var unlocked bool
+
+		err := conn.GetContext(context.Background(), &unlocked, "SELECT pg_advisory_unlock(hashtext('reception_auto_close'))")
+		if err != nil || !unlocked {
+			// Соединение с неснятой блокировкой нельзя возвращать в пул
+			l.FromContext(ctx, r.logger).Error("failed to release auto close lock", zap.Error(err))
+			r.discardConn(conn)
+
+			return
+		}
+
+		conn.Close()
+	}
+
+	return unlock, nil
+}
+
+// discardConn закрывает соединение, не возвращая его в пул.
+// Сессионные блокировки PostgreSQL при этом снимаются вместе с сессией.
+func (r *postgresqlReceptionRepository) discardConn(conn *sqlx.Conn) {
+	_ = conn.Raw(func(any) error {
+		return driver.ErrBadConn
+	})
+
+	conn.Close()
+}
+
+// openReceptionNotUpdated определяет, почему открытая приемка ПВЗ не была изменена условным UPDATE.
+// Если открытая приемка есть, значит не совпала её версия, и возвращается domainerrors.ErrVersionMismatch,
+// иначе - domainerrors.ErrNoOpenReceptions.
+func (r *postgresqlReceptionRepository) openReceptionNotUpdated(ctx context.Context, pvzID uuid.UUID) error {
+	var openReceptionExists bool
+
+	err := r.db.GetContext(ctx, &openReceptionExists,
+		"SELECT EXISTS(SELECT 1 FROM receptions WHERE pvz_id = $1 AND status = 'in_progress')",
+		pvzID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error checking open receptions", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	if openReceptionExists {
+		return domainerrors.ErrVersionMismatch
+	}
+
+	return domainerrors.ErrNoOpenReceptions
+}
+
+// lockReceptionPVZ блокирует строку ПВЗ перед изменением его открытой приемки.
+// Отсутствующий ПВЗ означает, что открытых приемок нет, поэтому возвращает domainerrors.ErrNoOpenReceptions.
+func (r *postgresqlReceptionRepository) lockReceptionPVZ(ctx context.Context, tx *sqlx.Tx, pvzID uuid.UUID) error {
+	err := lockPVZVersion(ctx, tx, r.logger, pvzID, models.AnyVersion)
+	if errors.Is(err, databaseerrors.ErrNoRows) {
+		return domainerrors.ErrNoOpenReceptions
+	}
+
+	return err
+}
+
+// reopenNotUpdated определяет, почему приемка не была переоткрыта.
+// Если версия приемки не совпадает с ожидаемой, возвращает domainerrors.ErrVersionMismatch,
+// иначе - domainerrors.ErrReceptionNotReopenable.
+func (r *postgresqlReceptionRepository) reopenNotUpdated(ctx context.Context, receptionID uuid.UUID, expectedVersion int) error {
+	if expectedVersion == models.AnyVersion {
+		return domainerrors.ErrReceptionNotReopenable
+	}
+
+	var version int
+
+	err := r.db.GetContext(ctx, &version, "SELECT version FROM receptions WHERE id = $1", receptionID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return domainerrors.ErrReceptionNotReopenable
+		}
+
+		l.FromContext(ctx, r.logger).Error("error getting reception version", zap.Error(err))
+
+		return databaseerrors.ErrUnexpected
+	}
+
+	if version != expectedVersion {
+		return domainerrors.ErrVersionMismatch
+	}
+
+	return domainerrors.ErrReceptionNotReopenable
+}
@@ -104,15 +104,16 @@ func (s *ReceptionRepoTestSuite) TestCreateIfNoOpen_Success() {
 		DateTime: time.Now(),
 		PVZID:    pvzID,
 		Status:   models.ReceptionStatusInProgress,
+		OpenedBy: uuid.New(),
 	}
 
-	err := s.repo.CreateIfNoOpen(s.ctx, reception)
+	err := s.repo.CreateIfNoOpen(s.ctx, reception, models.AnyVersion)
 	assert.NoError(s.T(), err)
 
-	var exists bool
-	err = s.db.Get(&exists, "SELECT EXISTS(SELECT 1 FROM receptions WHERE id = $1)", reception.ID)
+	var openedBy uuid.UUID
+	err = s.db.Get(&openedBy, "SELECT opened_by FROM receptions WHERE id = $1", reception.ID)
 	require.NoError(s.T(), err)
-	assert.True(s.T(), exists)
+	assert.Equal(s.T(), reception.OpenedBy, openedBy)
 }
 
 func (s *ReceptionRepoTestSuite) TestCreateIfNoOpen_OpenExists() {
@@ -126,7 +127,7 @@ func (s *ReceptionRepoTestSuite) TestCreateIfNoOpen_OpenExists() {
 		Status:   models.ReceptionStatusInProgress,
 	}
 
-	err := s.repo.CreateIfNoOpen(s.ctx, newReception)
+	err := s.repo.CreateIfNoOpen(s.ctx, newReception, models.AnyVersion)
 	assert.ErrorIs(s.T(), err, domainerrors.ErrOpenReceptionExists)
 }
 
@@ -138,7 +139,7 @@ func (s *ReceptionRepoTestSuite) TestCreateIfNoOpen_PVZNotExists() {
 		Status:   models.ReceptionStatusInProgress,
 	}
 
-	err := s.repo.CreateIfNoOpen(s.ctx, reception)
+	err := s.repo.CreateIfNoOpen(s.ctx, reception, models.AnyVersion)
 	assert.ErrorIs(s.T(), err, databaseerrors.ErrNoRows)
 }
 
@@ -146,11 +147,19 @@ func (s *ReceptionRepoTestSuite) TestCloseLast_Success() {
 	pvzID := s.createTestPVZ()
 	reception := s.createTestReception(pvzID, models.ReceptionStatusInProgress)
 
-	closedReception, err := s.repo.CloseLast(s.ctx, pvzID)
+	closedAt := time.Now()
+	closedBy := uuid.New()
+
+	closedReception, city, err := s.repo.CloseLast(s.ctx, pvzID, closedBy, closedAt, models.AnyVersion)
 	require.NoError(s.T(), err)
 
+	assert.Equal(s.T(), models.CityTypeMoscow, city)
 	assert.Equal(s.T(), models.ReceptionStatusClose, closedReception.Status)
 	assert.Equal(s.T(), reception.ID, closedReception.ID)
+	require.NotNil(s.T(), closedReception.ClosedAt)
+	assert.WithinDuration(s.T(), closedAt, *closedReception.ClosedAt, time.Millisecond)
+	require.NotNil(s.T(), closedReception.ClosedBy)
+	assert.Equal(s.T(), closedBy, *closedReception.ClosedBy)
 
 	var status string
 	err = s.db.Get(&status, "SELECT status FROM receptions WHERE id = $1", reception.ID)
@@ -161,11 +170,179 @@ func (s *ReceptionRepoTestSuite) TestCloseLast_Success() {
 func (s *ReceptionRepoTestSuite) TestCloseLast_NoOpenReceptions() {
 	pvzID := s.createTestPVZ()
 
-	_, err := s.repo.CloseLast(s.ctx, pvzID)
+	_, _, err := s.repo.CloseLast(s.ctx, pvzID, uuid.New(), time.Now(), models.AnyVersion)
 	assert.ErrorIs(s.T(), err, domainerrors.ErrNoOpenReceptions)
 }
 
 func (s *ReceptionRepoTestSuite) TestCloseLast_PVZNotExists() {
-	_, err := s.repo.CloseLast(s.ctx, uuid.New())
+	_, _, err := s.repo.CloseLast(s.ctx, uuid.New(), uuid.New(), time.Now(), models.AnyVersion)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrNoOpenReceptions)
+}
+
+func (s *ReceptionRepoTestSuite) TestCloseLast_VersionMismatch() {
+	pvzID := s.createTestPVZ()
+	s.createTestReception(pvzID, models.ReceptionStatusInProgress)
+
+	_, _, err := s.repo.CloseLast(s.ctx, pvzID, uuid.New(), time.Now(), 2)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrVersionMismatch)
+
+	closed, _, err := s.repo.CloseLast(s.ctx, pvzID, uuid.New(), time.Now(), 1)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, closed.Version)
+}
+
+func (s *ReceptionRepoTestSuite) TestCreateIfNoOpen_BumpsPVZVersion() {
+	pvzID := s.createTestPVZ()
+
+	reception := &models.Reception{ID: uuid.New(), DateTime: time.Now(), PVZID: pvzID, Status: models.ReceptionStatusInProgress}
+
+	err := s.repo.CreateIfNoOpen(s.ctx, reception, 2)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrVersionMismatch)
+
+	err = s.repo.CreateIfNoOpen(s.ctx, reception, 1)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 1, reception.Version)
+
+	var pvzVersion int
+	require.NoError(s.T(), s.db.Get(&pvzVersion, "SELECT version FROM pvzs WHERE id = $1", pvzID))
+	assert.Equal(s.T(), 2, pvzVersion)
+}
+
+func (s *ReceptionRepoTestSuite) TestCloseStale_ClosesOnlyOldReceptionsInCity() {
+	now := time.Now()
+	moscowPVZ := s.createTestPVZ()
+	kazanPVZ := uuid.New()
+
+	_, err := s.db.Exec(
+		"INSERT INTO pvzs (id, registration_date, city) VALUES ($1, $2, $3)",
+		kazanPVZ, now, models.CityTypeKazan.String(),
+	)
+	require.NoError(s.T(), err)
+
+	staleID := uuid.New()
+	freshPVZ := s.createTestPVZ()
+	freshID := uuid.New()
+	otherCityID := uuid.New()
+
+	for _, r := range []struct {
+		id, pvzID uuid.UUID
+		dateTime  time.Time
+	}{
+		{staleID, moscowPVZ, now.Add(-2 * time.Hour)},
+		{freshID, freshPVZ, now},
+		{otherCityID, kazanPVZ, now.Add(-2 * time.Hour)},
+	} {
+		_, err = s.db.Exec(
+			"INSERT INTO receptions (id, date_time, pvz_id, status) VALUES ($1, $2, $3, $4)",
+			r.id, r.dateTime, r.pvzID, models.ReceptionStatusInProgress.String(),
+		)
+		require.NoError(s.T(), err)
+	}
+
+	closed, err := s.repo.CloseStale(s.ctx, models.CityTypeMoscow, now.Add(-time.Hour), now, "too old")
+	require.NoError(s.T(), err)
+
+	require.Len(s.T(), closed, 1)
+	assert.Equal(s.T(), staleID, closed[0].ID)
+	assert.Equal(s.T(), models.ReceptionStatusClose, closed[0].Status)
+	assert.True(s.T(), closed[0].AutoClosed)
+	assert.Equal(s.T(), "too old", closed[0].CloseReason)
+	assert.NotNil(s.T(), closed[0].ClosedAt)
+	assert.Nil(s.T(), closed[0].ClosedBy)
+
+	var openCount int
+	err = s.db.Get(&openCount, "SELECT COUNT(*) FROM receptions WHERE status = 'in_progress'")
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), 2, openCount)
+}
+
+func (s *ReceptionRepoTestSuite) TestCancelLast_Success() {
+	pvzID := s.createTestPVZ()
+	reception := s.createTestReception(pvzID, models.ReceptionStatusInProgress)
+
+	cancelledAt := time.Now()
+	cancelledBy := uuid.New()
+
+	cancelledReception, err := s.repo.CancelLast(s.ctx, pvzID, cancelledBy, cancelledAt, "wrong pvz", models.AnyVersion)
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), reception.ID, cancelledReception.ID)
+	assert.Equal(s.T(), models.ReceptionStatusCancelled, cancelledReception.Status)
+	assert.Equal(s.T(), "wrong pvz", cancelledReception.CloseReason)
+	assert.False(s.T(), cancelledReception.AutoClosed)
+	require.NotNil(s.T(), cancelledReception.ClosedBy)
+	assert.Equal(s.T(), cancelledBy, *cancelledReception.ClosedBy)
+
+	// После отмены в ПВЗ можно открыть новую приемку
+	err = s.repo.CreateIfNoOpen(s.ctx, &models.Reception{
+		ID:       uuid.New(),
+		DateTime: time.Now(),
+		PVZID:    pvzID,
+		Status:   models.ReceptionStatusInProgress,
+	}, models.AnyVersion)
+	assert.NoError(s.T(), err)
+}
+
+func (s *ReceptionRepoTestSuite) TestCancelLast_NoOpenReceptions() {
+	pvzID := s.createTestPVZ()
+	s.createTestReception(pvzID, models.ReceptionStatusClose)
+
+	_, err := s.repo.CancelLast(s.ctx, pvzID, uuid.New(), time.Now(), "wrong pvz", models.AnyVersion)
 	assert.ErrorIs(s.T(), err, domainerrors.ErrNoOpenReceptions)
 }
+
+func (s *ReceptionRepoTestSuite) TestGetLast() {
+	pvzID := s.createTestPVZ()
+	s.createTestReception(pvzID, models.ReceptionStatusClose)
+	last := s.createTestReception(pvzID, models.ReceptionStatusInProgress)
+
+	reception, err := s.repo.GetLast(s.ctx, pvzID)
+	require.NoError(s.T(), err)
+	assert.Equal(s.T(), last.ID, reception.ID)
+
+	_, err = s.repo.GetLast(s.ctx, uuid.New())
+	assert.ErrorIs(s.T(), err, databaseerrors.ErrNoRows)
+}
+
+func (s *ReceptionRepoTestSuite) TestReopen_Success() {
+	pvzID := s.createTestPVZ()
+	s.createTestReception(pvzID, models.ReceptionStatusInProgress)
+
+	closed, _, err := s.repo.CloseLast(s.ctx, pvzID, uuid.New(), time.Now(), models.AnyVersion)
+	require.NoError(s.T(), err)
+
+	reopenedBy := uuid.New()
+	reopenedAt := time.Now()
+
+	reopened, err := s.repo.Reopen(s.ctx, closed.ID, reopenedBy, reopenedAt, models.AnyVersion)
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), models.ReceptionStatusInProgress, reopened.Status)
+	assert.Nil(s.T(), reopened.ClosedAt)
+	assert.Nil(s.T(), reopened.ClosedBy)
+	require.NotNil(s.T(), reopened.ReopenedBy)
+	assert.Equal(s.T(), reopenedBy, *reopened.ReopenedBy)
+	require.NotNil(s.T(), reopened.ReopenedAt)
+	assert.WithinDuration(s.T(), reopenedAt, *reopened.ReopenedAt, time.Millisecond)
+
+	// Переоткрытую приемку можно снова закрыть
+	_, _, err = s.repo.CloseLast(s.ctx, pvzID, uuid.New(), time.Now(), models.AnyVersion)
+	assert.NoError(s.T(), err)
+}
+
+func (s *ReceptionRepoTestSuite) TestReopen_NewerReceptionExists() {
+	pvzID := s.createTestPVZ()
+	older := s.createTestReception(pvzID, models.ReceptionStatusClose)
+	s.createTestReception(pvzID, models.ReceptionStatusClose)
+
+	_, err := s.repo.Reopen(s.ctx, older.ID, uuid.New(), time.Now(), models.AnyVersion)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrReceptionNotReopenable)
+}
+
+func (s *ReceptionRepoTestSuite) TestReopen_NotClosed() {
+	pvzID := s.createTestPVZ()
+	reception := s.createTestReception(pvzID, models.ReceptionStatusCancelled)
+
+	_, err := s.repo.Reopen(s.ctx, reception.ID, uuid.New(), time.Now(), models.AnyVersion)
+	assert.ErrorIs(s.T(), err, domainerrors.ErrReceptionNotReopenable)
+}
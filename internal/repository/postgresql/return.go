@@ -0,0 +1,356 @@
+package postgresqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	"github.com/lib/pq"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// postgresqlReturnRepository - структура репозитория для работы с возвратами товаров клиентами в PostgreSQL.
+// Реализует интерфейс repositories.IReturnRepo
+type postgresqlReturnRepository struct {
+	db     *database.PostgresDB
+	logger *zap.Logger
+}
+
+// NewPostgresqlReturnRepository - конструктор для создания нового экземпляра postgresqlReturnRepository.
+// Принимает базу данных и логгер.
+func NewPostgresqlReturnRepository(db *database.PostgresDB, logger *zap.Logger) repositories.IReturnRepo {
+	return &postgresqlReturnRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// customerReturnRow - строка таблицы customer_returns.
+type customerReturnRow struct {
+	ID         uuid.UUID  `db:"id"`
+	PVZID      uuid.UUID  `db:"pvz_id"`
+	ShipmentID uuid.UUID  `db:"shipment_id"`
+	ProductID  *uuid.UUID `db:"product_id"`
+	Barcode    *string    `db:"barcode"`
+	Type       string     `db:"type"`
+	Reason     string     `db:"reason"`
+	Condition  string     `db:"condition"`
+	CreatedBy  uuid.UUID  `db:"created_by"`
+	CreatedAt  time.Time  `db:"created_at"`
+}
+
+// shipmentRow - строка таблицы shipments.
+type shipmentRow struct {
+	ID           uuid.UUID  `db:"id"`
+	PVZID        uuid.UUID  `db:"pvz_id"`
+	Kind         string     `db:"kind"`
+	CreatedBy    uuid.UUID  `db:"created_by"`
+	CreatedAt    time.Time  `db:"created_at"`
+	DispatchedAt *time.Time `db:"dispatched_at"`
+}
+
+// customerReturnColumns - колонки таблицы customer_returns в порядке полей customerReturnRow.
+const customerReturnColumns = "id, pvz_id, shipment_id, product_id, barcode, type, reason, condition, created_by, created_at"
+
+// toModel производит маппинг из строки таблицы customer_returns в доменную модель.
+func (r *postgresqlReturnRepository) toModel(row customerReturnRow) *models.CustomerReturn {
+	ret := &models.CustomerReturn{
+		ID:         row.ID,
+		PVZID:      row.PVZID,
+		ProductID:  row.ProductID,
+		Type:       models.ProductType(row.Type),
+		Reason:     models.ReturnReason(row.Reason),
+		Condition:  models.ReturnCondition(row.Condition),
+		ShipmentID: row.ShipmentID,
+		CreatedBy:  row.CreatedBy,
+		DateTime:   row.CreatedAt,
+	}
+
+	if row.Barcode != nil {
+		ret.Barcode = *row.Barcode
+	}
+
+	return ret
+}
+
+// Register сохраняет возврат в транзакции.
+// Если ret.ProductID не nil, товар должен быть выдан клиенту в этом ПВЗ: иначе возвращается
+// domainerrors.ErrProductNotFound или domainerrors.ErrProductNotIssued. Тип возврата берется из товара,
+// а сам товар получает статус models.ProductStatusReturnedByCustomer.
+// Возврат добавляется в собираемую отправку возвратов ПВЗ, если её нет - она создается.
+// Строка ПВЗ блокируется до конца транзакции, поэтому у ПВЗ не может появиться две собираемые отправки.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows. Возвращает сохраненный возврат и город ПВЗ.
+func (r *postgresqlReturnRepository) Register(ctx context.Context, ret *models.CustomerReturn) (*models.CustomerReturn, models.CityType, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	var city string
+
+	err = tx.GetContext(ctx, &city, "SELECT city FROM pvzs WHERE id = $1 FOR UPDATE", ret.PVZID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, "", databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, r.logger).Error("error locking PVZ", zap.Error(err))
+
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	result := *ret
+
+	if ret.ProductID != nil {
+		productType, err := r.markProductReturned(ctx, tx, ret.PVZID, *ret.ProductID)
+		if err != nil {
+			return nil, "", err
+		}
+
+		result.Type = productType
+	}
+
+	shipmentID, err := r.pendingShipment(ctx, tx, ret)
+	if err != nil {
+		return nil, "", err
+	}
+
+	result.ShipmentID = shipmentID
+
+	var barcode *string
+	if result.Barcode != "" {
+		barcode = &result.Barcode
+	}
+
+	_, err = tx.NamedExecContext(ctx, `
+        INSERT INTO customer_returns (`+customerReturnColumns+`)
+        VALUES (:id, :pvz_id, :shipment_id, :product_id, :barcode, :type, :reason, :condition, :created_by, :created_at)`,
+		&customerReturnRow{
+			ID:         result.ID,
+			PVZID:      result.PVZID,
+			ShipmentID: result.ShipmentID,
+			ProductID:  result.ProductID,
+			Barcode:    barcode,
+			Type:       result.Type.String(),
+			Reason:     result.Reason.String(),
+			Condition:  result.Condition.String(),
+			CreatedBy:  result.CreatedBy,
+			CreatedAt:  result.DateTime,
+		},
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error inserting customer return", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return nil, "", databaseerrors.ErrUnexpected
+	}
+
+	return &result, models.CityType(city), nil
+}
+
+// markProductReturned отмечает выданный в ПВЗ товар возвращенным клиентом и возвращает его тип.
+func (r *postgresqlReturnRepository) markProductReturned(ctx context.Context, tx *sqlx.Tx, pvzID, productID uuid.UUID) (models.ProductType, error) {
+	var row struct {
+		Type   string `db:"type"`
+		Status string `db:"status"`
+	}
+
+	err := tx.GetContext(ctx, &row, `
+        SELECT pr.type, pr.status
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        WHERE pr.id = $1 AND r.pvz_id = $2
+        FOR UPDATE OF pr`,
+		productID, pvzID,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotFound, productID)
+		}
+
+		l.FromContext(ctx, r.logger).Error("error finding returned product", zap.Error(err))
+
+		return "", databaseerrors.ErrUnexpected
+	}
+
+	if models.ProductStatus(row.Status) != models.ProductStatusIssued {
+		return "", fmt.Errorf("%w: %s", domainerrors.ErrProductNotIssued, productID)
+	}
+
+	_, err = tx.ExecContext(ctx,
+		"UPDATE products SET status = $1 WHERE id = $2",
+		models.ProductStatusReturnedByCustomer.String(), productID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error marking product returned by customer", zap.Error(err))
+		return "", databaseerrors.ErrUnexpected
+	}
+
+	return models.ProductType(row.Type), nil
+}
+
+// pendingShipment возвращает айди собираемой отправки возвратов ПВЗ, создавая её от имени автора возврата,
+// если её нет. Вызывается под блокировкой строки ПВЗ.
+func (r *postgresqlReturnRepository) pendingShipment(ctx context.Context, tx *sqlx.Tx, ret *models.CustomerReturn) (uuid.UUID, error) {
+	var shipmentID uuid.UUID
+
+	err := tx.GetContext(ctx, &shipmentID,
+		"SELECT id FROM shipments WHERE pvz_id = $1 AND kind = $2 AND dispatched_at IS NULL",
+		ret.PVZID, models.ShipmentKindCustomerReturns.String(),
+	)
+
+	switch {
+	case err == nil:
+		return shipmentID, nil
+	case !errors.Is(err, sql.ErrNoRows):
+		l.FromContext(ctx, r.logger).Error("error finding pending returns shipment", zap.Error(err))
+		return uuid.Nil, databaseerrors.ErrUnexpected
+	}
+
+	shipmentID = uuid.New()
+
+	_, err = tx.ExecContext(ctx, `
+        INSERT INTO shipments (id, pvz_id, kind, created_by, created_at)
+        VALUES ($1, $2, $3, $4, $5)`,
+		shipmentID, ret.PVZID, models.ShipmentKindCustomerReturns.String(), ret.CreatedBy, ret.DateTime,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("error creating returns shipment", zap.Error(err))
+		return uuid.Nil, databaseerrors.ErrUnexpected
+	}
+
+	return shipmentID, nil
+}
+
+// ListByPVZ возвращает возвраты ПВЗ, зарегистрированные в период от startDate до endDate включительно,
+// в порядке регистрации. Границы периода необязательны.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+func (r *postgresqlReturnRepository) ListByPVZ(ctx context.Context, pvzID uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) {
+	var exists bool
+
+	err := r.db.GetContext(ctx, &exists, "SELECT EXISTS (SELECT 1 FROM pvzs WHERE id = $1)", pvzID)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to check PVZ", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	if !exists {
+		return nil, databaseerrors.ErrNoRows
+	}
+
+	return r.ListByPVZs(ctx, []uuid.UUID{pvzID}, startDate, endDate)
+}
+
+// ListByPVZs возвращает возвраты нескольких ПВЗ, зарегистрированные в период от startDate до endDate включительно,
+// в порядке регистрации. Границы периода необязательны.
+func (r *postgresqlReturnRepository) ListByPVZs(ctx context.Context, pvzIDs []uuid.UUID, startDate, endDate *time.Time) ([]*models.CustomerReturn, error) {
+	var rows []customerReturnRow
+
+	err := r.db.SelectContext(ctx, &rows, `
+        SELECT `+customerReturnColumns+`
+        FROM customer_returns
+        WHERE
+            pvz_id = ANY($1) AND
+            (created_at >= $2 OR $2 IS NULL) AND
+            (created_at <= $3 OR $3 IS NULL)
+        ORDER BY created_at, id`,
+		pq.Array(pvzIDs), startDate, endDate,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to list customer returns", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	returns := make([]*models.CustomerReturn, 0, len(rows))
+	for _, row := range rows {
+		returns = append(returns, r.toModel(row))
+	}
+
+	return returns, nil
+}
+
+// Dispatch отмечает собираемую отправку возвратов ПВЗ отправленной в момент dispatchedAt в транзакции.
+// Следующий возврат ПВЗ попадет уже в новую отправку.
+// Если собираемой отправки нет, возвращает domainerrors.ErrNoPendingReturns.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows. Возвращает отправку с возвратами в порядке регистрации.
+func (r *postgresqlReturnRepository) Dispatch(ctx context.Context, pvzID uuid.UUID, dispatchedAt time.Time) (*models.Shipment, error) {
+	tx, err := r.db.BeginTxx(ctx, nil)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to begin transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+	defer database.TxRollback(tx, r.logger)
+
+	if err := lockPVZVersion(ctx, tx, r.logger, pvzID, models.AnyVersion); err != nil {
+		return nil, err
+	}
+
+	var shipment shipmentRow
+
+	err = tx.GetContext(ctx, &shipment, `
+        UPDATE shipments
+        SET dispatched_at = $3
+        WHERE pvz_id = $1 AND kind = $2 AND dispatched_at IS NULL
+        RETURNING id, pvz_id, kind, created_by, created_at, dispatched_at`,
+		pvzID, models.ShipmentKindCustomerReturns.String(), dispatchedAt,
+	)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, domainerrors.ErrNoPendingReturns
+		}
+
+		l.FromContext(ctx, r.logger).Error("error dispatching returns shipment", zap.Error(err))
+
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	var rows []customerReturnRow
+
+	err = tx.SelectContext(ctx, &rows, `
+        SELECT `+customerReturnColumns+`
+        FROM customer_returns
+        WHERE shipment_id = $1
+        ORDER BY created_at, id`,
+		shipment.ID,
+	)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to list shipment returns", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	if err := tx.Commit(); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to commit transaction", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	result := &models.Shipment{
+		ID:           shipment.ID,
+		PVZID:        shipment.PVZID,
+		Kind:         models.ShipmentKind(shipment.Kind),
+		CreatedBy:    shipment.CreatedBy,
+		DateTime:     shipment.CreatedAt,
+		DispatchedAt: shipment.DispatchedAt,
+		Returns:      make([]*models.CustomerReturn, 0, len(rows)),
+	}
+
+	for _, row := range rows {
+		result.Returns = append(result.Returns, r.toModel(row))
+	}
+
+	return result, nil
+}
@@ -0,0 +1,219 @@
+package postgresqlrepo
+
+import (
+	"context"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// postgresqlStatsRepository реализует интерфейс repositories.IStatsRepo.
+// Вся статистика считается агрегатными запросами на стороне PostgreSQL.
+type postgresqlStatsRepository struct {
+	db     *database.PostgresDB
+	logger *zap.Logger
+}
+
+// NewPostgresqlStatsRepository создает новый экземпляр postgresqlStatsRepository.
+func NewPostgresqlStatsRepository(db *database.PostgresDB, logger *zap.Logger) repositories.IStatsRepo {
+	return &postgresqlStatsRepository{
+		db:     db,
+		logger: logger,
+	}
+}
+
+// pvzStatsRow - строка результата агрегации приемок по ПВЗ.
+type pvzStatsRow struct {
+	PVZID                   uuid.UUID `db:"pvz_id"`
+	City                    string    `db:"city"`
+	ReceptionsCount         int       `db:"receptions_count"`
+	ClosedReceptionsCount   int       `db:"closed_receptions_count"`
+	ProductsCount           int       `db:"products_count"`
+	AvgDurationSeconds      float64   `db:"avg_duration_seconds"`
+	AvgProductsPerReception float64   `db:"avg_products_per_reception"`
+}
+
+// productTypeStatsRow - строка результата агрегации товаров по городу, дню и типу.
+type productTypeStatsRow struct {
+	City  string    `db:"city"`
+	Day   time.Time `db:"day"`
+	Type  string    `db:"type"`
+	Count int       `db:"count"`
+}
+
+// pvzStatsToModel производит маппинг из строки агрегации в доменную модель.
+func (r *postgresqlStatsRepository) pvzStatsToModel(row pvzStatsRow) *models.PVZStats {
+	return &models.PVZStats{
+		PVZID:                   row.PVZID,
+		City:                    models.CityType(row.City),
+		ReceptionsCount:         row.ReceptionsCount,
+		ClosedReceptionsCount:   row.ClosedReceptionsCount,
+		ProductsCount:           row.ProductsCount,
+		AvgReceptionDuration:    time.Duration(row.AvgDurationSeconds * float64(time.Second)),
+		AvgProductsPerReception: row.AvgProductsPerReception,
+	}
+}
+
+// productTypeStatsToModel производит маппинг из строки агрегации в доменную модель.
+func (r *postgresqlStatsRepository) productTypeStatsToModel(row productTypeStatsRow) *models.ProductTypeStats {
+	return &models.ProductTypeStats{
+		City:  models.CityType(row.City),
+		Day:   row.Day,
+		Type:  models.ProductType(row.Type),
+		Count: row.Count,
+	}
+}
+
+// GetPVZStats возвращает статистику по приемкам, открытым в указанном диапазоне, для каждого ПВЗ.
+// Средняя длительность считается только по закрытым вручную приемкам: у автоматически закрытых
+// closed_at выставляет задача закрытия зависших приемок, а не конец смены. Среднее количество товаров - по всем приемкам, включая пустые.
+// Отмененные приемки не учитываются. ПВЗ без приемок в диапазоне в результат не попадают.
+func (r *postgresqlStatsRepository) GetPVZStats(ctx context.Context, filter *models.StatsFilter) ([]*models.PVZStats, error) {
+	query := `
+        SELECT
+            p.id AS pvz_id,
+            p.city,
+            COUNT(r.id) AS receptions_count,
+            COUNT(r.closed_at) AS closed_receptions_count,
+            COALESCE(SUM(pc.products_count), 0) AS products_count,
+            COALESCE(AVG(EXTRACT(EPOCH FROM (r.closed_at - r.date_time))) FILTER (WHERE NOT r.auto_closed), 0) AS avg_duration_seconds,
+            COALESCE(AVG(COALESCE(pc.products_count, 0)), 0) AS avg_products_per_reception
+        FROM receptions r
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        LEFT JOIN (
+            SELECT reception_id, COUNT(*) AS products_count
+            FROM products
+            GROUP BY reception_id
+        ) pc ON pc.reception_id = r.id
+        WHERE r.date_time >= $1 AND r.date_time <= $2
+        AND r.status <> 'cancelled'
+        GROUP BY p.id, p.city
+        ORDER BY receptions_count DESC, p.id
+    `
+
+	var rows []pvzStatsRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, filter.StartDate, filter.EndDate); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to get pvz stats", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	result := make([]*models.PVZStats, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, r.pvzStatsToModel(row))
+	}
+
+	return result, nil
+}
+
+// GetProductTypeStats возвращает количество товаров каждого типа, принятых в указанном диапазоне,
+// сгруппированное по городу ПВЗ и дню приемки товара. Товары из отмененных приемок не учитываются.
+func (r *postgresqlStatsRepository) GetProductTypeStats(ctx context.Context, filter *models.StatsFilter) ([]*models.ProductTypeStats, error) {
+	query := `
+        SELECT
+            p.city,
+            date_trunc('day', pr.date_time) AS day,
+            pr.type,
+            COUNT(*) AS count
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        WHERE pr.date_time >= $1 AND pr.date_time <= $2
+        AND r.status <> 'cancelled'
+        GROUP BY p.city, day, pr.type
+        ORDER BY day, p.city, pr.type
+    `
+
+	var rows []productTypeStatsRow
+
+	if err := r.db.SelectContext(ctx, &rows, query, filter.StartDate, filter.EndDate); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to get product type stats", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	result := make([]*models.ProductTypeStats, 0, len(rows))
+	for _, row := range rows {
+		result = append(result, r.productTypeStatsToModel(row))
+	}
+
+	return result, nil
+}
+
+// openReceptionsRow - количество открытых приемок в городе и время открытия самой старой из них.
+type openReceptionsRow struct {
+	City     string    `db:"city"`
+	Count    int       `db:"count"`
+	OldestAt time.Time `db:"oldest_at"`
+}
+
+// GetBusinessSnapshot возвращает количество открытых приемок по городам, время открытия самой старой из них,
+// количество товаров, принятых начиная с dayStart, по городам и типам, и общее количество ПВЗ.
+// Товары из отмененных приемок не учитываются. Запросы выполняются без транзакции,
+// так как снапшот используется только для метрик и небольшие расхождения между ними допустимы.
+func (r *postgresqlStatsRepository) GetBusinessSnapshot(ctx context.Context, dayStart time.Time) (*models.BusinessSnapshot, error) {
+	snapshot := &models.BusinessSnapshot{
+		OpenReceptions:   make(map[models.CityType]int),
+		ProductsReceived: make(map[models.CityType]map[models.ProductType]int),
+	}
+
+	var openRows []openReceptionsRow
+
+	err := r.db.SelectContext(ctx, &openRows, `
+        SELECT p.city, COUNT(*) AS count, MIN(r.date_time) AS oldest_at
+        FROM receptions r
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        WHERE r.status = 'in_progress'
+        GROUP BY p.city
+    `)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to count open receptions", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	for _, row := range openRows {
+		snapshot.OpenReceptions[models.CityType(row.City)] = row.Count
+
+		if snapshot.OldestOpenReceptionAt == nil || row.OldestAt.Before(*snapshot.OldestOpenReceptionAt) {
+			oldestAt := row.OldestAt
+			snapshot.OldestOpenReceptionAt = &oldestAt
+		}
+	}
+
+	var productRows []productTypeStatsRow
+
+	err = r.db.SelectContext(ctx, &productRows, `
+        SELECT p.city, pr.type, COUNT(*) AS count
+        FROM products pr
+        INNER JOIN receptions r ON r.id = pr.reception_id
+        INNER JOIN pvzs p ON p.id = r.pvz_id
+        WHERE pr.date_time >= $1
+        AND r.status <> 'cancelled'
+        GROUP BY p.city, pr.type
+    `, dayStart)
+	if err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to count products received today", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	for _, row := range productRows {
+		city := models.CityType(row.City)
+		if snapshot.ProductsReceived[city] == nil {
+			snapshot.ProductsReceived[city] = make(map[models.ProductType]int)
+		}
+
+		snapshot.ProductsReceived[city][models.ProductType(row.Type)] = row.Count
+	}
+
+	if err := r.db.GetContext(ctx, &snapshot.PVZCount, `SELECT COUNT(*) FROM pvzs`); err != nil {
+		l.FromContext(ctx, r.logger).Error("failed to count PVZs", zap.Error(err))
+		return nil, databaseerrors.ErrUnexpected
+	}
+
+	return snapshot, nil
+}
@@ -0,0 +1,271 @@
+//go:build integration
+// +build integration
+
+package postgresqlrepo_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/database"
+	"github.com/maksemen2/pvz-service/internal/pkg/testhelpers"
+	postgresqlrepo "github.com/maksemen2/pvz-service/internal/repository/postgresql"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"github.com/stretchr/testify/suite"
+	"go.uber.org/zap"
+)
+
+type StatsRepoTestSuite struct {
+	suite.Suite
+	ctx     context.Context
+	db      *database.PostgresDB
+	repo    repositories.IStatsRepo
+	cleanup func()
+}
+
+func TestStatsRepoTestSuite(t *testing.T) {
+	suite.Run(t, new(StatsRepoTestSuite))
+}
+
+func (s *StatsRepoTestSuite) SetupSuite() {
+	s.ctx = context.Background()
+	cfg, cleanContainer := testhelpers.SetupPostgresContainer(s.T())
+
+	logger := zap.NewNop()
+
+	var err error
+	s.db, err = database.NewPostgresDB(cfg, logger)
+	require.NoError(s.T(), err)
+
+	s.repo = postgresqlrepo.NewPostgresqlStatsRepository(s.db, logger)
+
+	cleanDB, err := testhelpers.CreateTestDB(s.db)
+
+	s.cleanup = func() {
+		cleanDB()
+		cleanContainer()
+	}
+
+	require.NoError(s.T(), err)
+}
+
+func (s *StatsRepoTestSuite) TearDownSuite() {
+	s.db.Close()
+	s.cleanup()
+}
+
+func (s *StatsRepoTestSuite) SetupTest() {
+	_, err := s.db.Exec("DELETE FROM products")
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec("DELETE FROM receptions")
+	require.NoError(s.T(), err)
+	_, err = s.db.Exec("DELETE FROM pvzs")
+	require.NoError(s.T(), err)
+}
+
+func (s *StatsRepoTestSuite) createTestPVZ(city models.CityType) uuid.UUID {
+	pvzID := uuid.New()
+	_, err := s.db.Exec(
+		"INSERT INTO pvzs (id, registration_date, city) VALUES ($1, $2, $3)",
+		pvzID, time.Now(), city.String(),
+	)
+	require.NoError(s.T(), err)
+
+	return pvzID
+}
+
+func (s *StatsRepoTestSuite) createTestReception(pvzID uuid.UUID, openedAt time.Time, closedAt *time.Time) uuid.UUID {
+	receptionID := uuid.New()
+	status := models.ReceptionStatusInProgress
+
+	if closedAt != nil {
+		status = models.ReceptionStatusClose
+	}
+
+	_, err := s.db.Exec(
+		"INSERT INTO receptions (id, pvz_id, date_time, status, closed_at) VALUES ($1, $2, $3, $4, $5)",
+		receptionID, pvzID, openedAt, status.String(), closedAt,
+	)
+	require.NoError(s.T(), err)
+
+	return receptionID
+}
+
+func (s *StatsRepoTestSuite) createTestProduct(receptionID uuid.UUID, productType models.ProductType, date time.Time) {
+	_, err := s.db.Exec(
+		"INSERT INTO products (id, date_time, type, reception_id, order_id) VALUES ($1, $2, $3, $4, $1)",
+		uuid.New(), date, productType.String(), receptionID,
+	)
+	require.NoError(s.T(), err)
+}
+
+func (s *StatsRepoTestSuite) TestGetPVZStats() {
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	pvzID := s.createTestPVZ(models.CityTypeMoscow)
+
+	closedAt := base.Add(30 * time.Minute)
+	closedReception := s.createTestReception(pvzID, base, &closedAt)
+	s.createTestProduct(closedReception, models.ProductTypeShoes, base.Add(time.Minute))
+	s.createTestProduct(closedReception, models.ProductTypeShoes, base.Add(2*time.Minute))
+
+	// Открытая приемка без товаров не влияет на среднюю длительность, но учитывается в среднем количестве товаров
+	s.createTestReception(pvzID, base.Add(time.Hour), nil)
+
+	// Приемка вне диапазона не учитывается
+	outOfRangeClosedAt := base.Add(-47 * time.Hour)
+	s.createTestReception(pvzID, base.Add(-48*time.Hour), &outOfRangeClosedAt)
+
+	stats, err := s.repo.GetPVZStats(s.ctx, &models.StatsFilter{
+		StartDate: base.Add(-time.Hour),
+		EndDate:   base.Add(2 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), stats, 1)
+
+	assert.Equal(s.T(), pvzID, stats[0].PVZID)
+	assert.Equal(s.T(), models.CityTypeMoscow, stats[0].City)
+	assert.Equal(s.T(), 2, stats[0].ReceptionsCount)
+	assert.Equal(s.T(), 1, stats[0].ClosedReceptionsCount)
+	assert.Equal(s.T(), 2, stats[0].ProductsCount)
+	assert.Equal(s.T(), 30*time.Minute, stats[0].AvgReceptionDuration)
+	assert.InDelta(s.T(), 1.0, stats[0].AvgProductsPerReception, 0.001)
+}
+
+// Автоматически закрытая приемка учитывается в количестве закрытых, но не в средней длительности.
+func (s *StatsRepoTestSuite) TestGetPVZStats_ExcludesAutoClosedFromDuration() {
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	pvzID := s.createTestPVZ(models.CityTypeMoscow)
+
+	closedAt := base.Add(30 * time.Minute)
+	s.createTestReception(pvzID, base, &closedAt)
+
+	autoClosedAt := base.Add(12 * time.Hour)
+	autoClosed := s.createTestReception(pvzID, base.Add(time.Minute), &autoClosedAt)
+
+	_, err := s.db.Exec("UPDATE receptions SET auto_closed = TRUE WHERE id = $1", autoClosed)
+	require.NoError(s.T(), err)
+
+	stats, err := s.repo.GetPVZStats(s.ctx, &models.StatsFilter{
+		StartDate: base.Add(-time.Hour),
+		EndDate:   base.Add(time.Hour),
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), stats, 1)
+
+	assert.Equal(s.T(), 2, stats[0].ClosedReceptionsCount)
+	assert.Equal(s.T(), 30*time.Minute, stats[0].AvgReceptionDuration)
+}
+
+func (s *StatsRepoTestSuite) TestGetPVZStats_Empty() {
+	stats, err := s.repo.GetPVZStats(s.ctx, &models.StatsFilter{
+		StartDate: time.Now().Add(-time.Hour),
+		EndDate:   time.Now(),
+	})
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), stats)
+}
+
+func (s *StatsRepoTestSuite) TestGetProductTypeStats() {
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+
+	moscowReception := s.createTestReception(s.createTestPVZ(models.CityTypeMoscow), base, nil)
+	kazanReception := s.createTestReception(s.createTestPVZ(models.CityTypeKazan), base, nil)
+
+	s.createTestProduct(moscowReception, models.ProductTypeShoes, base)
+	s.createTestProduct(moscowReception, models.ProductTypeShoes, base.Add(time.Hour))
+	s.createTestProduct(moscowReception, models.ProductTypeShoes, base.Add(24*time.Hour))
+	s.createTestProduct(kazanReception, models.ProductTypeElectronics, base)
+
+	stats, err := s.repo.GetProductTypeStats(s.ctx, &models.StatsFilter{
+		StartDate: base.Add(-time.Hour),
+		EndDate:   base.Add(48 * time.Hour),
+	})
+	require.NoError(s.T(), err)
+	require.Len(s.T(), stats, 3)
+
+	// Сортировка: день, город, тип
+	assert.Equal(s.T(), models.CityTypeKazan, stats[0].City)
+	assert.Equal(s.T(), models.ProductTypeElectronics, stats[0].Type)
+	assert.Equal(s.T(), 1, stats[0].Count)
+
+	assert.Equal(s.T(), models.CityTypeMoscow, stats[1].City)
+	assert.Equal(s.T(), models.ProductTypeShoes, stats[1].Type)
+	assert.Equal(s.T(), 2, stats[1].Count)
+	assert.Equal(s.T(), base.Truncate(24*time.Hour), stats[1].Day.UTC())
+
+	assert.Equal(s.T(), 1, stats[2].Count)
+	assert.Equal(s.T(), base.Add(24*time.Hour).Truncate(24*time.Hour), stats[2].Day.UTC())
+}
+
+func (s *StatsRepoTestSuite) TestStats_ExcludeCancelledReceptions() {
+	base := time.Date(2025, 1, 10, 10, 0, 0, 0, time.UTC)
+	pvzID := s.createTestPVZ(models.CityTypeMoscow)
+
+	cancelledReception := uuid.New()
+	_, err := s.db.Exec(
+		"INSERT INTO receptions (id, pvz_id, date_time, status, closed_at, close_reason) VALUES ($1, $2, $3, $4, $5, $6)",
+		cancelledReception, pvzID, base, models.ReceptionStatusCancelled.String(), base.Add(time.Minute), "wrong pvz",
+	)
+	require.NoError(s.T(), err)
+	s.createTestProduct(cancelledReception, models.ProductTypeShoes, base)
+
+	filter := &models.StatsFilter{
+		StartDate: base.Add(-time.Hour),
+		EndDate:   base.Add(time.Hour),
+	}
+
+	pvzStats, err := s.repo.GetPVZStats(s.ctx, filter)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), pvzStats)
+
+	productTypeStats, err := s.repo.GetProductTypeStats(s.ctx, filter)
+	require.NoError(s.T(), err)
+	assert.Empty(s.T(), productTypeStats)
+}
+
+func (s *StatsRepoTestSuite) TestGetBusinessSnapshot() {
+	dayStart := time.Date(2025, 1, 10, 0, 0, 0, 0, time.UTC)
+
+	moscowFirst := s.createTestPVZ(models.CityTypeMoscow)
+	moscowSecond := s.createTestPVZ(models.CityTypeMoscow)
+	kazan := s.createTestPVZ(models.CityTypeKazan)
+
+	oldest := dayStart.Add(-2 * time.Hour)
+	openReception := s.createTestReception(moscowFirst, oldest, nil)
+	s.createTestReception(moscowSecond, dayStart.Add(time.Hour), nil)
+
+	closedAt := dayStart.Add(3 * time.Hour)
+	closedReception := s.createTestReception(kazan, dayStart.Add(2*time.Hour), &closedAt)
+
+	s.createTestProduct(openReception, models.ProductTypeShoes, dayStart.Add(-time.Hour)) // Принят вчера
+	s.createTestProduct(openReception, models.ProductTypeShoes, dayStart.Add(time.Minute))
+	s.createTestProduct(closedReception, models.ProductTypeClothes, dayStart.Add(2*time.Hour+time.Minute))
+	s.createTestProduct(closedReception, models.ProductTypeClothes, dayStart.Add(2*time.Hour+2*time.Minute))
+
+	snapshot, err := s.repo.GetBusinessSnapshot(s.ctx, dayStart)
+	require.NoError(s.T(), err)
+
+	assert.Equal(s.T(), map[models.CityType]int{models.CityTypeMoscow: 2}, snapshot.OpenReceptions)
+	require.NotNil(s.T(), snapshot.OldestOpenReceptionAt)
+	assert.WithinDuration(s.T(), oldest, *snapshot.OldestOpenReceptionAt, time.Millisecond)
+	assert.Equal(s.T(), map[models.CityType]map[models.ProductType]int{
+		models.CityTypeMoscow: {models.ProductTypeShoes: 1},
+		models.CityTypeKazan:  {models.ProductTypeClothes: 2},
+	}, snapshot.ProductsReceived)
+	assert.Equal(s.T(), 3, snapshot.PVZCount)
+}
+
+func (s *StatsRepoTestSuite) TestGetBusinessSnapshot_Empty() {
+	snapshot, err := s.repo.GetBusinessSnapshot(s.ctx, time.Now())
+	require.NoError(s.T(), err)
+
+	assert.Empty(s.T(), snapshot.OpenReceptions)
+	assert.Nil(s.T(), snapshot.OldestOpenReceptionAt)
+	assert.Empty(s.T(), snapshot.ProductsReceived)
+	assert.Equal(s.T(), 0, snapshot.PVZCount)
+}
@@ -7,6 +7,7 @@ import (
 	"fmt"
 	"github.com/google/uuid"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/pkg/database"
@@ -69,7 +70,7 @@ func (r *postgresqlUserRepository) Create(ctx context.Context, user *models.User
 			return databaseerrors.ErrUniqueViolation
 		}
 
-		r.logger.Error("failed to create user", zap.String("email", user.Email), zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to create user", zap.String("email", user.Email), zap.Error(err))
 
 		return fmt.Errorf("%w: %v", databaseerrors.ErrUnexpected, err)
 	}
@@ -91,7 +92,7 @@ func (r *postgresqlUserRepository) GetByEmail(ctx context.Context, email string)
 			return nil, databaseerrors.ErrNoRows
 		}
 
-		r.logger.Error("failed to get user by email", zap.String("email", email), zap.Error(err))
+		l.FromContext(ctx, r.logger).Error("failed to get user by email", zap.String("email", email), zap.Error(err))
 
 		return nil, fmt.Errorf("%w: %v", databaseerrors.ErrUnexpected, err)
 	}
@@ -0,0 +1,61 @@
+package postgresqlrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+
+	"github.com/google/uuid"
+	"github.com/jmoiron/sqlx"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// lockPVZVersion блокирует строку ПВЗ до конца транзакции и проверяет его версию.
+// Если ПВЗ не существует, возвращает databaseerrors.ErrNoRows.
+// Если expectedVersion не равна models.AnyVersion и не совпадает с версией ПВЗ, возвращает domainerrors.ErrVersionMismatch.
+func lockPVZVersion(ctx context.Context, tx *sqlx.Tx, logger *zap.Logger, pvzID uuid.UUID, expectedVersion int) error {
+	var version int
+
+	err := tx.GetContext(ctx, &version, "SELECT version FROM pvzs WHERE id = $1 FOR UPDATE", pvzID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return databaseerrors.ErrNoRows
+		}
+
+		l.FromContext(ctx, logger).Error("error locking PVZ", zap.Error(err))
+
+		return databaseerrors.ErrUnexpected
+	}
+
+	if expectedVersion != models.AnyVersion && version != expectedVersion {
+		return domainerrors.ErrVersionMismatch
+	}
+
+	return nil
+}
+
+// bumpPVZVersion увеличивает версию ПВЗ в транзакции.
+func bumpPVZVersion(ctx context.Context, tx *sqlx.Tx, logger *zap.Logger, pvzID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, "UPDATE pvzs SET version = version + 1 WHERE id = $1", pvzID)
+	if err != nil {
+		l.FromContext(ctx, logger).Error("error updating PVZ version", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// bumpReceptionVersion увеличивает версию приемки в транзакции.
+func bumpReceptionVersion(ctx context.Context, tx *sqlx.Tx, logger *zap.Logger, receptionID uuid.UUID) error {
+	_, err := tx.ExecContext(ctx, "UPDATE receptions SET version = version + 1 WHERE id = $1", receptionID)
+	if err != nil {
+		l.FromContext(ctx, logger).Error("error updating reception version", zap.Error(err))
+		return databaseerrors.ErrUnexpected
+	}
+
+	return nil
+}
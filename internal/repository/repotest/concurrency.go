@@ -0,0 +1,336 @@
+package repotest
+
+import (
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// Количество одновременных вызовов в тестах конкурентного доступа.
+const concurrency = 10
+
+// parallel вызывает fn concurrency раз одновременно и возвращает ошибки вызовов по номерам.
+func parallel(fn func(i int) error) []error {
+	errs := make([]error, concurrency)
+	start := make(chan struct{})
+
+	var wg sync.WaitGroup
+
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+
+		go func(i int) {
+			defer wg.Done()
+
+			<-start
+			errs[i] = fn(i)
+		}(i)
+	}
+
+	close(start)
+	wg.Wait()
+
+	return errs
+}
+
+// expectOneSuccess проверяет, что ровно один вызов завершился успешно, а остальные - ошибкой expected.
+func (s *ContractSuite) expectOneSuccess(errs []error, expected error) {
+	s.T().Helper()
+
+	succeeded := 0
+
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+
+		s.ErrorIs(err, expected)
+	}
+
+	s.Equal(1, succeeded)
+}
+
+func (s *ContractSuite) TestConcurrency_OneOpenReception() {
+	pvz := s.createPVZ(s.now)
+
+	errs := parallel(func(i int) error {
+		reception := &models.Reception{
+			ID:       uuid.New(),
+			DateTime: s.at(time.Duration(i) * time.Second),
+			PVZID:    pvz.ID,
+			Status:   models.ReceptionStatusInProgress,
+		}
+
+		return s.repos.Reception.CreateIfNoOpen(s.ctx, reception, models.AnyVersion)
+	})
+
+	s.expectOneSuccess(errs, domainerrors.ErrOpenReceptionExists)
+	s.Equal(pvz.Version+1, s.pvzVersion(pvz.ID))
+}
+
+func (s *ContractSuite) TestConcurrency_ReopenAndCreate() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	closed := s.closeReception(pvz.ID, s.at(time.Minute))
+	pvzVersion := s.pvzVersion(pvz.ID)
+
+	// Переоткрытие и создание новой приемки не могут одновременно оставить в ПВЗ две открытые приемки
+	errs := parallel(func(i int) error {
+		if i%2 == 0 {
+			_, err := s.repos.Reception.Reopen(s.ctx, closed.ID, uuid.New(), s.at(time.Hour), models.AnyVersion)
+			return err
+		}
+
+		reception := &models.Reception{
+			ID:       uuid.New(),
+			DateTime: s.at(time.Hour + time.Duration(i)*time.Second),
+			PVZID:    pvz.ID,
+			Status:   models.ReceptionStatusInProgress,
+		}
+
+		return s.repos.Reception.CreateIfNoOpen(s.ctx, reception, models.AnyVersion)
+	})
+
+	succeeded := 0
+
+	for _, err := range errs {
+		if err == nil {
+			succeeded++
+			continue
+		}
+
+		s.True(errors.Is(err, domainerrors.ErrReceptionNotReopenable) || errors.Is(err, domainerrors.ErrOpenReceptionExists), err)
+	}
+
+	s.Equal(1, succeeded)
+	s.Equal(pvzVersion+1, s.pvzVersion(pvz.ID))
+	s.Equal(models.ReceptionStatusInProgress, s.lastReception(pvz.ID).Status)
+}
+
+func (s *ContractSuite) TestConcurrency_ReceptionOptimisticLocking() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+
+	// Все вызовы ожидают одну и ту же версию, поэтому изменить приемку может только один из них
+	errs := parallel(func(i int) error {
+		_, err := s.repos.Product.Create(s.ctx, &models.AddProduct{
+			ID:       uuid.New(),
+			DateTime: s.at(time.Duration(i+1) * time.Second),
+			Type:     models.ProductTypeClothes,
+			PVZID:    pvz.ID,
+		}, reception.Version)
+
+		return err
+	})
+
+	s.expectOneSuccess(errs, domainerrors.ErrVersionMismatch)
+	s.Equal(reception.Version+1, s.lastReception(pvz.ID).Version)
+}
+
+func (s *ContractSuite) TestConcurrency_ProductsWithoutVersion() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+
+	errs := parallel(func(i int) error {
+		_, err := s.repos.Product.Create(s.ctx, &models.AddProduct{
+			ID:       uuid.New(),
+			DateTime: s.at(time.Duration(i+1) * time.Second),
+			Type:     models.ProductTypeClothes,
+			PVZID:    pvz.ID,
+		}, models.AnyVersion)
+
+		return err
+	})
+
+	for _, err := range errs {
+		s.NoError(err)
+	}
+
+	// Каждое добавление увеличивает версию, ни одно не потеряно
+	s.Equal(reception.Version+concurrency, s.lastReception(pvz.ID).Version)
+
+	pvzs, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{Page: 1, PageSize: 10})
+	s.Require().NoError(err)
+	s.Require().Len(pvzs, 1)
+	s.Require().Len(pvzs[0].Receptions, 1)
+	s.Len(pvzs[0].Receptions[0].Products, concurrency)
+}
+
+func (s *ContractSuite) TestConcurrency_DeleteLast() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+
+	products := make(map[uuid.UUID]struct{}, concurrency/2)
+	for i := 0; i < concurrency/2; i++ {
+		products[s.addProduct(pvz.ID, s.at(time.Duration(i+1)*time.Second)).ID] = struct{}{}
+	}
+
+	var (
+		mu      sync.Mutex
+		deleted = make(map[uuid.UUID]struct{}, len(products))
+	)
+
+	// Удалений больше, чем товаров: каждый товар удаляется ровно один раз, остальные вызовы получают ошибку
+	errs := parallel(func(int) error {
+		product, _, err := s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+		if err != nil {
+			return err
+		}
+
+		mu.Lock()
+		defer mu.Unlock()
+
+		if _, ok := deleted[product.ID]; ok {
+			return fmt.Errorf("product %s deleted twice", product.ID)
+		}
+
+		deleted[product.ID] = struct{}{}
+
+		return nil
+	})
+
+	failed := 0
+
+	for _, err := range errs {
+		if err != nil {
+			s.ErrorIs(err, domainerrors.ErrNoProductsInReception)
+			failed++
+		}
+	}
+
+	s.Equal(concurrency-len(products), failed)
+	s.Equal(products, deleted)
+}
+
+func (s *ContractSuite) TestConcurrency_UniqueUserEmail() {
+	errs := parallel(func(int) error {
+		return s.repos.User.Create(s.ctx, &models.User{ID: uuid.New(), Email: "same@example.com", Role: models.RoleEmployee})
+	})
+
+	s.expectOneSuccess(errs, databaseerrors.ErrUniqueViolation)
+}
+
+func (s *ContractSuite) TestConcurrency_CreatePVZs() {
+	errs := parallel(func(i int) error {
+		return s.repos.PVZ.Create(s.ctx, &models.PVZ{
+			ID:               uuid.New(),
+			RegistrationDate: s.at(time.Duration(i) * time.Second),
+			City:             models.CityTypeSPB,
+		})
+	})
+
+	for _, err := range errs {
+		s.NoError(err)
+	}
+
+	pvzs, err := s.repos.PVZ.GetAll(s.ctx)
+	s.Require().NoError(err)
+	s.Len(pvzs, concurrency)
+}
+
+func (s *ContractSuite) TestConcurrency_IssueOnce() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	product := s.addProduct(pvz.ID, s.at(time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	code := s.savePickupCode(pvz.ID, product.OrderID, s.at(time.Hour))
+
+	// Один код не может подтвердить несколько выдач, поэтому товар выдается один раз
+	errs := parallel(func(i int) error {
+		_, _, err := s.issueWithCode(pvz.ID, code.ID, s.at(time.Hour+time.Duration(i+1)*time.Second), product.ID)
+		return err
+	})
+
+	s.expectOneSuccess(errs, domainerrors.ErrInvalidPickupCode)
+}
+
+func (s *ContractSuite) TestConcurrency_PickupCodeAttemptsLimit() {
+	pvz := s.createPVZ(s.now)
+	code := s.savePickupCode(pvz.ID, uuid.New(), s.now)
+
+	// Одновременные проверки не занимают больше попыток, чем разрешено
+	errs := parallel(func(int) error {
+		return s.repos.PickupCode.ReserveAttempt(s.ctx, code.ID, 1)
+	})
+
+	s.expectOneSuccess(errs, databaseerrors.ErrNoRows)
+
+	active, err := s.repos.PickupCode.GetActive(s.ctx, pvz.ID, code.OrderID)
+	s.Require().NoError(err)
+	s.Equal(1, active.Attempts)
+}
+
+func (s *ContractSuite) TestConcurrency_ReturnToSenderOnce() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	s.addProduct(pvz.ID, s.at(time.Second))
+	s.addProduct(pvz.ID, s.at(2*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	deadlines := []models.StorageDeadline{
+		{City: models.CityTypeMoscow, Type: models.ProductTypeElectronics, ReceivedBy: s.at(time.Hour)},
+	}
+
+	// Товар не может попасть в несколько отправок
+	errs := parallel(func(i int) error {
+		_, _, err := s.returnToSender(pvz.ID, s.at(24*time.Hour+time.Duration(i)*time.Second), deadlines)
+		return err
+	})
+
+	s.expectOneSuccess(errs, domainerrors.ErrNoExpiredProducts)
+}
+
+func (s *ContractSuite) TestConcurrency_CustomerReturnOnce() {
+	pvz := s.createPVZ(s.now)
+	product := s.issuedProduct(pvz.ID, models.ProductTypeElectronics)
+
+	// Один выданный товар нельзя принять в возврат несколько раз
+	errs := parallel(func(i int) error {
+		_, _, err := s.registerReturn(pvz.ID, product.ID, s.at(2*time.Hour+time.Duration(i)*time.Second))
+		return err
+	})
+
+	s.expectOneSuccess(errs, domainerrors.ErrProductNotIssued)
+}
+
+func (s *ContractSuite) TestConcurrency_OnePendingReturnsShipment() {
+	pvz := s.createPVZ(s.now)
+
+	shipmentIDs := make([]uuid.UUID, concurrency)
+
+	// Одновременные возвраты попадают в одну собираемую отправку
+	errs := parallel(func(i int) error {
+		ret, _, err := s.repos.Return.Register(s.ctx, &models.CustomerReturn{
+			ID:        uuid.New(),
+			PVZID:     pvz.ID,
+			Barcode:   fmt.Sprintf("barcode-%d", i),
+			Type:      models.ProductTypeClothes,
+			Reason:    models.ReturnReasonChangedMind,
+			Condition: models.ReturnConditionNew,
+			CreatedBy: uuid.New(),
+			DateTime:  s.at(time.Duration(i) * time.Second),
+		})
+		if err == nil {
+			shipmentIDs[i] = ret.ShipmentID
+		}
+
+		return err
+	})
+
+	for i, err := range errs {
+		s.Require().NoError(err)
+		s.Equal(shipmentIDs[0], shipmentIDs[i])
+	}
+
+	shipment, err := s.repos.Return.Dispatch(s.ctx, pvz.ID, s.at(time.Hour))
+	s.Require().NoError(err)
+	s.Len(shipment.Returns, concurrency)
+}
@@ -0,0 +1,227 @@
+package repotest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// issueWithCode выдает товары ПВЗ в момент issuedAt по коду получения pickupCodeID.
+func (s *ContractSuite) issueWithCode(pvzID, pickupCodeID uuid.UUID, issuedAt time.Time, productIDs ...uuid.UUID) (*models.Issuance, models.CityType, error) {
+	return s.repos.Product.Issue(s.ctx, &models.Issuance{
+		ID:       uuid.New(),
+		PVZID:    pvzID,
+		IssuedBy: uuid.New(),
+		DateTime: issuedAt,
+	}, pickupCodeID, productIDs)
+}
+
+// issueOrder выдает товары заказа orderID в ПВЗ в момент issuedAt по новому коду получения.
+func (s *ContractSuite) issueOrder(pvzID, orderID uuid.UUID, issuedAt time.Time, productIDs ...uuid.UUID) (*models.Issuance, models.CityType, error) {
+	s.T().Helper()
+
+	code := s.savePickupCode(pvzID, orderID, issuedAt.Add(-time.Minute))
+
+	return s.issueWithCode(pvzID, code.ID, issuedAt, productIDs...)
+}
+
+// issue выдает в ПВЗ в момент issuedAt товар, добавленный как отдельный заказ (см. addProduct).
+func (s *ContractSuite) issue(pvzID uuid.UUID, issuedAt time.Time, productID uuid.UUID) (*models.Issuance, models.CityType, error) {
+	s.T().Helper()
+
+	return s.issueOrder(pvzID, productID, issuedAt, productID)
+}
+
+// listedProducts возвращает товары ПВЗ из результата List по айди.
+func (s *ContractSuite) listedProducts(pvzID uuid.UUID) map[uuid.UUID]*models.Product {
+	s.T().Helper()
+
+	pvzs, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{Page: 1, PageSize: 30})
+	s.Require().NoError(err)
+
+	products := make(map[uuid.UUID]*models.Product)
+
+	for _, pvz := range pvzs {
+		if pvz.PVZ.ID != pvzID {
+			continue
+		}
+
+		for _, reception := range pvz.Receptions {
+			for _, product := range reception.Products {
+				products[product.ID] = product
+			}
+		}
+	}
+
+	return products
+}
+
+func (s *ContractSuite) TestIssue() {
+	pvz := s.createPVZInCity(models.CityTypeKazan, s.now)
+	reception := s.openReception(pvz.ID, s.now)
+	orderID := uuid.New()
+	first := s.addOrderProduct(pvz.ID, orderID, s.at(time.Second))
+	second := s.addOrderProduct(pvz.ID, orderID, s.at(2*time.Second))
+	kept := s.addOrderProduct(pvz.ID, orderID, s.at(3*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	s.Equal(models.ProductStatusStored, first.Status)
+
+	issuedAt := s.at(time.Hour)
+
+	issuance, city, err := s.issueOrder(pvz.ID, orderID, issuedAt, second.ID, first.ID)
+	s.Require().NoError(err)
+	s.Equal(models.CityTypeKazan, city)
+	s.Equal(pvz.ID, issuance.PVZID)
+	s.equalTime(issuedAt, issuance.DateTime)
+
+	// Товары возвращаются в порядке запроса
+	s.Require().Len(issuance.Products, 2)
+	s.Equal(second.ID, issuance.Products[0].ID)
+	s.Equal(first.ID, issuance.Products[1].ID)
+
+	for _, product := range issuance.Products {
+		s.Equal(models.ProductStatusIssued, product.Status)
+		s.Equal(reception.ID, product.ReceptionID)
+		s.Require().NotNil(product.IssuanceID)
+		s.Equal(issuance.ID, *product.IssuanceID)
+		s.Require().NotNil(product.IssuedAt)
+		s.equalTime(issuedAt, *product.IssuedAt)
+	}
+
+	listed := s.listedProducts(pvz.ID)
+	s.Equal(models.ProductStatusIssued, listed[first.ID].Status)
+	s.Require().NotNil(listed[first.ID].IssuanceID)
+	s.Equal(issuance.ID, *listed[first.ID].IssuanceID)
+	s.Equal(models.ProductStatusStored, listed[kept.ID].Status)
+	s.Nil(listed[kept.ID].IssuanceID)
+	s.Nil(listed[kept.ID].IssuedAt)
+}
+
+func (s *ContractSuite) TestIssue_Errors() {
+	pvz := s.createPVZ(s.now)
+	orderID := uuid.New()
+	s.openReception(pvz.ID, s.now)
+	stored := s.addOrderProduct(pvz.ID, orderID, s.at(time.Second))
+	issued := s.addOrderProduct(pvz.ID, orderID, s.at(2*time.Second))
+	otherOrder := s.addProduct(pvz.ID, s.at(3*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	s.openReception(pvz.ID, s.at(2*time.Minute))
+	notReceived := s.addOrderProduct(pvz.ID, orderID, s.at(3*time.Minute))
+
+	other := s.createPVZ(s.now)
+	s.openReception(other.ID, s.now)
+	foreign := s.addOrderProduct(other.ID, orderID, s.at(time.Second))
+	s.closeReception(other.ID, s.at(time.Minute))
+
+	code := s.savePickupCode(pvz.ID, orderID, s.at(time.Hour))
+	foreignCode := s.savePickupCode(other.ID, orderID, s.at(time.Hour))
+
+	_, _, err := s.issueWithCode(pvz.ID, code.ID, s.at(time.Hour+time.Minute), issued.ID)
+	s.Require().NoError(err)
+
+	tests := []struct {
+		name       string
+		pvzID      uuid.UUID
+		codeID     uuid.UUID
+		productIDs []uuid.UUID
+		expected   error
+	}{
+		{name: "PVZ not found", pvzID: uuid.New(), codeID: code.ID, productIDs: []uuid.UUID{stored.ID}, expected: databaseerrors.ErrNoRows},
+		{name: "Unknown pickup code", pvzID: pvz.ID, codeID: uuid.New(), productIDs: []uuid.UUID{stored.ID}, expected: domainerrors.ErrInvalidPickupCode},
+		{name: "Pickup code of another PVZ", pvzID: pvz.ID, codeID: foreignCode.ID, productIDs: []uuid.UUID{stored.ID}, expected: domainerrors.ErrInvalidPickupCode},
+		{name: "Unknown product", pvzID: pvz.ID, codeID: code.ID, productIDs: []uuid.UUID{stored.ID, uuid.New()}, expected: domainerrors.ErrProductNotFound},
+		{name: "Product of another PVZ", pvzID: pvz.ID, codeID: code.ID, productIDs: []uuid.UUID{stored.ID, foreign.ID}, expected: domainerrors.ErrProductNotFound},
+		{name: "Product of another order", pvzID: pvz.ID, codeID: code.ID, productIDs: []uuid.UUID{stored.ID, otherOrder.ID}, expected: domainerrors.ErrProductNotInOrder},
+		{name: "Reception is not closed", pvzID: pvz.ID, codeID: code.ID, productIDs: []uuid.UUID{stored.ID, notReceived.ID}, expected: domainerrors.ErrProductNotReceived},
+		{name: "Product already issued", pvzID: pvz.ID, codeID: code.ID, productIDs: []uuid.UUID{stored.ID, issued.ID}, expected: domainerrors.ErrProductNotStored},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			_, _, err := s.issueWithCode(tt.pvzID, tt.codeID, s.at(time.Hour+2*time.Minute), tt.productIDs...)
+			s.ErrorIs(err, tt.expected)
+
+			// Выдача атомарна: корректный товар из отклоненной выдачи остается на хранении, а код - действующим
+			s.Equal(models.ProductStatusStored, s.listedProducts(pvz.ID)[stored.ID].Status)
+
+			active, err := s.repos.PickupCode.GetActive(s.ctx, pvz.ID, orderID)
+			s.Require().NoError(err)
+			s.Equal(code.ID, active.ID)
+		})
+	}
+}
+
+// Код получения расходуется выдачей последних хранящихся товаров заказа и не подходит для следующей.
+func (s *ContractSuite) TestIssue_ConsumesPickupCode() {
+	pvz := s.createPVZ(s.now)
+	orderID := uuid.New()
+	s.openReception(pvz.ID, s.now)
+	first := s.addOrderProduct(pvz.ID, orderID, s.at(time.Second))
+	second := s.addOrderProduct(pvz.ID, orderID, s.at(2*time.Second))
+	third := s.addOrderProduct(pvz.ID, orderID, s.at(3*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	code := s.savePickupCode(pvz.ID, orderID, s.at(time.Hour))
+
+	// Частичная выдача оставляет код действующим для остальных товаров заказа
+	_, _, err := s.issueWithCode(pvz.ID, code.ID, s.at(time.Hour+time.Minute), first.ID)
+	s.Require().NoError(err)
+
+	active, err := s.repos.PickupCode.GetActive(s.ctx, pvz.ID, orderID)
+	s.Require().NoError(err)
+	s.Equal(code.ID, active.ID)
+
+	_, _, err = s.issueWithCode(pvz.ID, code.ID, s.at(time.Hour+2*time.Minute), second.ID, third.ID)
+	s.Require().NoError(err)
+
+	_, err = s.repos.PickupCode.GetActive(s.ctx, pvz.ID, orderID)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+
+	_, _, err = s.issueWithCode(pvz.ID, code.ID, s.at(time.Hour+3*time.Minute), third.ID)
+	s.ErrorIs(err, domainerrors.ErrInvalidPickupCode)
+}
+
+// Истекший к моменту выдачи код не подходит, даже если он прошел проверку раньше.
+func (s *ContractSuite) TestIssue_ExpiredPickupCode() {
+	pvz := s.createPVZ(s.now)
+	orderID := uuid.New()
+	s.openReception(pvz.ID, s.now)
+	product := s.addOrderProduct(pvz.ID, orderID, s.at(time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	code := s.savePickupCode(pvz.ID, orderID, s.at(time.Hour))
+
+	_, _, err := s.issueWithCode(pvz.ID, code.ID, code.ExpiresAt, product.ID)
+	s.ErrorIs(err, domainerrors.ErrInvalidPickupCode)
+	s.Equal(models.ProductStatusStored, s.listedProducts(pvz.ID)[product.ID].Status)
+
+	_, _, err = s.issueWithCode(pvz.ID, code.ID, code.ExpiresAt.Add(-time.Second), product.ID)
+	s.Require().NoError(err)
+}
+
+// Выданный товар нельзя удалить, даже если его приемку открыли повторно.
+func (s *ContractSuite) TestIssue_IssuedProductNotDeletable() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+	product := s.addProduct(pvz.ID, s.at(time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	_, _, err := s.issue(pvz.ID, s.at(2*time.Minute), product.ID)
+	s.Require().NoError(err)
+
+	_, err = s.repos.Reception.Reopen(s.ctx, reception.ID, uuid.New(), s.at(3*time.Minute), models.AnyVersion)
+	s.Require().NoError(err)
+
+	version := s.lastReception(pvz.ID).Version
+
+	_, _, err = s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrProductNotStored)
+
+	s.Equal(version, s.lastReception(pvz.ID).Version)
+	s.Contains(s.listedProducts(pvz.ID), product.ID)
+}
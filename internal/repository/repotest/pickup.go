@@ -0,0 +1,217 @@
+package repotest
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// savePickupCode сохраняет код получения заказа в ПВЗ, созданный в момент createdAt.
+func (s *ContractSuite) savePickupCode(pvzID, orderID uuid.UUID, createdAt time.Time) *models.PickupCode {
+	s.T().Helper()
+
+	code := &models.PickupCode{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		OrderID:   orderID,
+		CodeHash:  "hash-" + uuid.NewString(),
+		CreatedAt: createdAt,
+		ExpiresAt: createdAt.Add(time.Hour),
+	}
+	s.Require().NoError(s.repos.PickupCode.Save(s.ctx, code))
+
+	return code
+}
+
+// sortedIDs возвращает айди, отсортированные так же, как их сортирует PostgreSQL.
+func sortedIDs(ids ...uuid.UUID) []uuid.UUID {
+	sorted := append([]uuid.UUID(nil), ids...)
+	sort.Slice(sorted, func(i, j int) bool {
+		return bytes.Compare(sorted[i][:], sorted[j][:]) < 0
+	})
+
+	return sorted
+}
+
+func (s *ContractSuite) TestPickupCode_OrdersWithoutCode() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+
+	withCode, withoutCode := uuid.New(), uuid.New()
+	first := s.addOrderProduct(pvz.ID, withCode, s.at(time.Second))
+	second := s.addOrderProduct(pvz.ID, withCode, s.at(time.Second))
+	s.addOrderProduct(pvz.ID, withoutCode, s.at(2*time.Second))
+	s.addOrderProduct(pvz.ID, withoutCode, s.at(3*time.Second))
+	issued := s.addProduct(pvz.ID, s.at(4*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	_, _, err := s.issue(pvz.ID, s.at(time.Hour), issued.ID)
+	s.Require().NoError(err)
+
+	// Выданные товары не требуют кода
+	orderIDs, err := s.repos.PickupCode.OrdersWithoutCode(s.ctx, reception.ID)
+	s.Require().NoError(err)
+	s.Equal(sortedIDs(withCode, withoutCode), orderIDs)
+
+	code := s.savePickupCode(pvz.ID, withCode, s.at(time.Minute))
+
+	orderIDs, err = s.repos.PickupCode.OrdersWithoutCode(s.ctx, reception.ID)
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{withoutCode}, orderIDs)
+
+	// Частичная выдача не расходует код, а выдача всех товаров заказа расходует
+	_, _, err = s.issueWithCode(pvz.ID, code.ID, s.at(30*time.Minute), first.ID)
+	s.Require().NoError(err)
+
+	orderIDs, err = s.repos.PickupCode.OrdersWithoutCode(s.ctx, reception.ID)
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{withoutCode}, orderIDs)
+
+	_, _, err = s.issueWithCode(pvz.ID, code.ID, s.at(31*time.Minute), second.ID)
+	s.Require().NoError(err)
+
+	orderIDs, err = s.repos.PickupCode.OrdersWithoutCode(s.ctx, reception.ID)
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{withoutCode}, orderIDs)
+
+	orderIDs, err = s.repos.PickupCode.OrdersWithoutCode(s.ctx, uuid.New())
+	s.Require().NoError(err)
+	s.Empty(orderIDs)
+}
+
+func (s *ContractSuite) TestPickupCode_SaveReplacesActive() {
+	pvz := s.createPVZ(s.now)
+	other := s.createPVZ(s.now)
+	orderID := uuid.New()
+
+	s.savePickupCode(pvz.ID, orderID, s.now)
+	replacement := s.savePickupCode(pvz.ID, orderID, s.at(time.Minute))
+	foreign := s.savePickupCode(other.ID, orderID, s.now)
+
+	active, err := s.repos.PickupCode.GetActive(s.ctx, pvz.ID, orderID)
+	s.Require().NoError(err)
+	s.Equal(replacement.ID, active.ID)
+	s.Equal(replacement.CodeHash, active.CodeHash)
+	s.Equal(0, active.Attempts)
+	s.equalTime(replacement.CreatedAt, active.CreatedAt)
+	s.equalTime(replacement.ExpiresAt, active.ExpiresAt)
+	s.Nil(active.UsedAt)
+
+	// Коды одного заказа в разных ПВЗ независимы
+	active, err = s.repos.PickupCode.GetActive(s.ctx, other.ID, orderID)
+	s.Require().NoError(err)
+	s.Equal(foreign.ID, active.ID)
+
+	_, err = s.repos.PickupCode.GetActive(s.ctx, pvz.ID, uuid.New())
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestPickupCode_ReserveAttempt() {
+	pvz := s.createPVZ(s.now)
+	orderID := uuid.New()
+	code := s.savePickupCode(pvz.ID, orderID, s.now)
+
+	s.Require().NoError(s.repos.PickupCode.ReserveAttempt(s.ctx, code.ID, 2))
+	s.Require().NoError(s.repos.PickupCode.ReserveAttempt(s.ctx, code.ID, 2))
+	s.ErrorIs(s.repos.PickupCode.ReserveAttempt(s.ctx, code.ID, 2), databaseerrors.ErrNoRows)
+	s.ErrorIs(s.repos.PickupCode.ReserveAttempt(s.ctx, uuid.New(), 2), databaseerrors.ErrNoRows)
+
+	active, err := s.repos.PickupCode.GetActive(s.ctx, pvz.ID, orderID)
+	s.Require().NoError(err)
+	s.Equal(2, active.Attempts)
+
+	// Возвращенная попытка снова доступна
+	s.Require().NoError(s.repos.PickupCode.ReleaseAttempt(s.ctx, code.ID))
+	s.Require().NoError(s.repos.PickupCode.ReserveAttempt(s.ctx, code.ID, 2))
+
+	// Попытки использованного кода не занимаются
+	s.openReception(pvz.ID, s.now)
+	product := s.addOrderProduct(pvz.ID, orderID, s.at(time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	_, _, err = s.issueWithCode(pvz.ID, code.ID, s.at(30*time.Minute), product.ID)
+	s.Require().NoError(err)
+	s.ErrorIs(s.repos.PickupCode.ReserveAttempt(s.ctx, code.ID, 10), databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestPickupCode_Failures() {
+	pvz := s.createPVZ(s.now)
+	other := s.createPVZ(s.now)
+
+	s.Require().NoError(s.repos.PickupCode.RegisterFailure(s.ctx, pvz.ID, s.at(time.Second), s.now))
+	s.Require().NoError(s.repos.PickupCode.RegisterFailure(s.ctx, pvz.ID, s.at(2*time.Second), s.now))
+	s.Require().NoError(s.repos.PickupCode.RegisterFailure(s.ctx, pvz.ID, s.at(3*time.Second), s.now))
+	s.Require().NoError(s.repos.PickupCode.RegisterFailure(s.ctx, other.ID, s.at(3*time.Second), s.now))
+
+	count, err := s.repos.PickupCode.CountFailures(s.ctx, pvz.ID, s.now)
+	s.Require().NoError(err)
+	s.Equal(3, count)
+
+	// Граница окна включается
+	count, err = s.repos.PickupCode.CountFailures(s.ctx, pvz.ID, s.at(2*time.Second))
+	s.Require().NoError(err)
+	s.Equal(2, count)
+
+	count, err = s.repos.PickupCode.CountFailures(s.ctx, other.ID, s.now)
+	s.Require().NoError(err)
+	s.Equal(1, count)
+
+	count, err = s.repos.PickupCode.CountFailures(s.ctx, uuid.New(), s.now)
+	s.Require().NoError(err)
+	s.Equal(0, count)
+
+	// Новая попытка удаляет попытки ПВЗ раньше окна, не затрагивая другие ПВЗ
+	s.Require().NoError(s.repos.PickupCode.RegisterFailure(s.ctx, pvz.ID, s.at(time.Hour), s.at(3*time.Second)))
+	s.Require().NoError(s.repos.PickupCode.RegisterFailure(s.ctx, other.ID, s.at(time.Hour), s.at(time.Second)))
+
+	count, err = s.repos.PickupCode.CountFailures(s.ctx, pvz.ID, s.now)
+	s.Require().NoError(err)
+	s.Equal(2, count)
+
+	count, err = s.repos.PickupCode.CountFailures(s.ctx, other.ID, s.now)
+	s.Require().NoError(err)
+	s.Equal(2, count)
+}
+
+func (s *ContractSuite) TestListStoredByOrder() {
+	pvz := s.createPVZ(s.now)
+	orderID := uuid.New()
+
+	s.openReception(pvz.ID, s.now)
+	second := s.addOrderProduct(pvz.ID, orderID, s.at(2*time.Second))
+	first := s.addOrderProduct(pvz.ID, orderID, s.at(time.Second))
+	issued := s.addOrderProduct(pvz.ID, orderID, s.at(3*time.Second))
+	s.addProduct(pvz.ID, s.at(4*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	_, _, err := s.issueOrder(pvz.ID, orderID, s.at(2*time.Minute), issued.ID)
+	s.Require().NoError(err)
+
+	// Товары незакрытой приемки еще нельзя выдать
+	s.openReception(pvz.ID, s.at(3*time.Minute))
+	s.addOrderProduct(pvz.ID, orderID, s.at(4*time.Minute))
+
+	other := s.createPVZ(s.now)
+	s.openReception(other.ID, s.now)
+	s.addOrderProduct(other.ID, orderID, s.at(time.Second))
+	s.closeReception(other.ID, s.at(time.Minute))
+
+	products, err := s.repos.Product.ListStoredByOrder(s.ctx, pvz.ID, orderID)
+	s.Require().NoError(err)
+	s.Require().Len(products, 2)
+	s.Equal(first.ID, products[0].ID)
+	s.Equal(second.ID, products[1].ID)
+
+	for _, product := range products {
+		s.Equal(orderID, product.OrderID)
+		s.Equal(models.ProductStatusStored, product.Status)
+	}
+
+	products, err = s.repos.Product.ListStoredByOrder(s.ctx, pvz.ID, uuid.New())
+	s.Require().NoError(err)
+	s.Empty(products)
+}
@@ -0,0 +1,236 @@
+package repotest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+)
+
+func (s *ContractSuite) TestProduct_Create() {
+	pvz := s.createPVZ(s.now)
+
+	_, err := s.repos.Product.Create(s.ctx, &models.AddProduct{ID: uuid.New(), DateTime: s.now, Type: models.ProductTypeShoes, PVZID: pvz.ID}, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+
+	reception := s.openReception(pvz.ID, s.now)
+
+	product, err := s.repos.Product.Create(s.ctx, &models.AddProduct{ID: uuid.New(), DateTime: s.at(time.Second), Type: models.ProductTypeShoes, PVZID: pvz.ID}, reception.Version)
+	s.Require().NoError(err)
+	s.Equal(reception.ID, product.ReceptionID)
+	s.Equal(models.ProductTypeShoes, product.Type)
+
+	s.Equal(reception.Version+1, s.lastReception(pvz.ID).Version)
+}
+
+func (s *ContractSuite) TestProduct_DeleteLast_LIFO() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+
+	first := s.addProduct(pvz.ID, s.at(time.Second))
+	second := s.addProduct(pvz.ID, s.at(2*time.Second))
+	third := s.addProduct(pvz.ID, s.at(3*time.Second))
+
+	for _, expected := range []*models.Product{third, second, first} {
+		deleted, city, err := s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+		s.Require().NoError(err)
+		s.Equal(expected.ID, deleted.ID)
+		s.Equal(pvz.City, city)
+	}
+
+	_, _, err := s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoProductsInReception)
+}
+
+func (s *ContractSuite) TestProduct_CreateBatch() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+	existing := s.addProduct(pvz.ID, s.at(time.Second))
+
+	result, err := s.repos.Product.CreateBatch(s.ctx, pvz.ID, []*models.AddProduct{
+		{ID: uuid.New(), DateTime: s.at(2 * time.Second), Type: models.ProductTypeClothes, PVZID: pvz.ID},
+		{ID: existing.ID, DateTime: s.at(3 * time.Second), Type: models.ProductTypeClothes, PVZID: pvz.ID},
+	}, models.BatchModeBestEffort, reception.Version+1)
+	s.Require().NoError(err)
+
+	s.True(result.Applied)
+	s.Equal(reception.ID, result.ReceptionID)
+	s.Require().Len(result.Items, 2)
+	s.Equal(models.BatchItemStatusCreated, result.Items[0].Status)
+	s.Equal(models.BatchItemStatusDuplicate, result.Items[1].Status)
+	s.Equal(existing.Type, result.Items[1].Product.Type)
+
+	s.Equal(reception.Version+2, s.lastReception(pvz.ID).Version)
+}
+
+func (s *ContractSuite) TestProduct_Create_VersionMismatch() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+
+	_, err := s.repos.Product.Create(s.ctx, &models.AddProduct{ID: uuid.New(), DateTime: s.now, Type: models.ProductTypeShoes, PVZID: pvz.ID}, reception.Version+1)
+	s.ErrorIs(err, domainerrors.ErrVersionMismatch)
+	s.Equal(reception.Version, s.lastReception(pvz.ID).Version)
+}
+
+func (s *ContractSuite) TestProduct_ClosedReception() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	s.addProduct(pvz.ID, s.at(time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	_, err := s.repos.Product.Create(s.ctx, &models.AddProduct{ID: uuid.New(), DateTime: s.now, Type: models.ProductTypeShoes, PVZID: pvz.ID}, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+
+	_, err = s.repos.Product.CreateBatch(s.ctx, pvz.ID, []*models.AddProduct{{ID: uuid.New(), DateTime: s.now, Type: models.ProductTypeShoes, PVZID: pvz.ID}}, models.BatchModeBestEffort, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+
+	// Товары закрытой приемки не удаляются
+	_, _, err = s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+}
+
+func (s *ContractSuite) TestProduct_DeleteLast_Errors() {
+	s.Run("PVZ not found", func() {
+		_, _, err := s.repos.Product.DeleteLast(s.ctx, uuid.New(), models.AnyVersion)
+		s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+	})
+
+	s.Run("Version mismatch", func() {
+		pvz := s.createPVZ(s.now)
+		s.openReception(pvz.ID, s.now)
+		product := s.addProduct(pvz.ID, s.at(time.Second))
+
+		reception := s.lastReception(pvz.ID)
+
+		_, _, err := s.repos.Product.DeleteLast(s.ctx, pvz.ID, reception.Version+1)
+		s.ErrorIs(err, domainerrors.ErrVersionMismatch)
+
+		deleted, _, err := s.repos.Product.DeleteLast(s.ctx, pvz.ID, reception.Version)
+		s.Require().NoError(err)
+		s.Equal(product.ID, deleted.ID)
+		s.Equal(reception.Version+1, s.lastReception(pvz.ID).Version)
+	})
+}
+
+// Последним считается товар с самым поздним временем приемки, а не добавленный последним.
+func (s *ContractSuite) TestProduct_DeleteLast_ByTime() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+
+	latest := s.addProduct(pvz.ID, s.at(2*time.Second))
+	s.addProduct(pvz.ID, s.at(time.Second))
+
+	deleted, _, err := s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+	s.Require().NoError(err)
+	s.Equal(latest.ID, deleted.ID)
+	s.equalTime(latest.DateTime, deleted.DateTime)
+}
+
+func (s *ContractSuite) TestProduct_CreateBatch_Conflict() {
+	other := s.createPVZ(s.now)
+	s.openReception(other.ID, s.now)
+	foreign := s.addProduct(other.ID, s.at(time.Second))
+
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+
+	batch := func() []*models.AddProduct {
+		return []*models.AddProduct{
+			{ID: uuid.New(), DateTime: s.at(time.Second), Type: models.ProductTypeShoes, PVZID: pvz.ID},
+			{ID: foreign.ID, DateTime: s.at(2 * time.Second), Type: models.ProductTypeShoes, PVZID: pvz.ID},
+		}
+	}
+
+	s.Run("All or nothing", func() {
+		result, err := s.repos.Product.CreateBatch(s.ctx, pvz.ID, batch(), models.BatchModeAllOrNothing, models.AnyVersion)
+		s.Require().NoError(err)
+
+		s.False(result.Applied)
+		s.Require().Len(result.Items, 2)
+		s.Equal(models.BatchItemStatusSkipped, result.Items[0].Status)
+		s.Nil(result.Items[0].Product)
+		s.Equal(models.BatchItemStatusFailed, result.Items[1].Status)
+		s.ErrorIs(result.Items[1].Err, domainerrors.ErrProductIDConflict)
+
+		// Отклоненный пакет не оставляет товаров и не меняет версию приемки
+		s.Equal(reception.Version, s.lastReception(pvz.ID).Version)
+
+		_, _, err = s.repos.Product.DeleteLast(s.ctx, pvz.ID, models.AnyVersion)
+		s.ErrorIs(err, domainerrors.ErrNoProductsInReception)
+	})
+
+	s.Run("Best effort", func() {
+		result, err := s.repos.Product.CreateBatch(s.ctx, pvz.ID, batch(), models.BatchModeBestEffort, reception.Version)
+		s.Require().NoError(err)
+
+		s.True(result.Applied)
+		s.Require().Len(result.Items, 2)
+		s.Equal(1, result.Items[1].Index)
+		s.Equal(models.BatchItemStatusCreated, result.Items[0].Status)
+		s.Equal(reception.ID, result.Items[0].Product.ReceptionID)
+		s.Equal(models.BatchItemStatusFailed, result.Items[1].Status)
+		s.ErrorIs(result.Items[1].Err, domainerrors.ErrProductIDConflict)
+
+		s.Equal(reception.Version+1, s.lastReception(pvz.ID).Version)
+	})
+}
+
+// Товар не может быть отсканирован раньше открытия приемки.
+func (s *ContractSuite) TestProduct_CreateBatch_BeforeReception() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.at(time.Minute))
+
+	batch := func() []*models.AddProduct {
+		return []*models.AddProduct{
+			{ID: uuid.New(), DateTime: s.at(time.Minute), Type: models.ProductTypeShoes, PVZID: pvz.ID},
+			{ID: uuid.New(), DateTime: s.at(time.Second), Type: models.ProductTypeShoes, PVZID: pvz.ID},
+		}
+	}
+
+	result, err := s.repos.Product.CreateBatch(s.ctx, pvz.ID, batch(), models.BatchModeAllOrNothing, models.AnyVersion)
+	s.Require().NoError(err)
+	s.False(result.Applied)
+	s.Require().Len(result.Items, 2)
+	s.Equal(models.BatchItemStatusSkipped, result.Items[0].Status)
+	s.Equal(models.BatchItemStatusFailed, result.Items[1].Status)
+	s.ErrorIs(result.Items[1].Err, domainerrors.ErrInvalidProductTime)
+	s.Equal(reception.Version, s.lastReception(pvz.ID).Version)
+
+	result, err = s.repos.Product.CreateBatch(s.ctx, pvz.ID, batch(), models.BatchModeBestEffort, models.AnyVersion)
+	s.Require().NoError(err)
+	s.True(result.Applied)
+	s.Equal(models.BatchItemStatusCreated, result.Items[0].Status)
+	s.Equal(models.BatchItemStatusFailed, result.Items[1].Status)
+	s.Len(s.listedProducts(pvz.ID), 1)
+}
+
+func (s *ContractSuite) TestProduct_CreateBatch_RepeatedID() {
+	pvz := s.createPVZ(s.now)
+	reception := s.openReception(pvz.ID, s.now)
+
+	id := uuid.New()
+
+	result, err := s.repos.Product.CreateBatch(s.ctx, pvz.ID, []*models.AddProduct{
+		{ID: id, DateTime: s.at(time.Second), Type: models.ProductTypeShoes, PVZID: pvz.ID},
+		{ID: id, DateTime: s.at(2 * time.Second), Type: models.ProductTypeClothes, PVZID: pvz.ID},
+	}, models.BatchModeAllOrNothing, reception.Version)
+	s.Require().NoError(err)
+
+	s.True(result.Applied)
+	s.Require().Len(result.Items, 2)
+	s.Equal(models.BatchItemStatusCreated, result.Items[0].Status)
+	s.Equal(models.BatchItemStatusDuplicate, result.Items[1].Status)
+	s.Equal(models.ProductTypeShoes, result.Items[1].Product.Type)
+
+	// Повтор всего пакета не добавляет товаров и не меняет версию приемки
+	version := s.lastReception(pvz.ID).Version
+	s.Equal(reception.Version+1, version)
+
+	result, err = s.repos.Product.CreateBatch(s.ctx, pvz.ID, []*models.AddProduct{
+		{ID: id, DateTime: s.at(time.Second), Type: models.ProductTypeShoes, PVZID: pvz.ID},
+	}, models.BatchModeAllOrNothing, version)
+	s.Require().NoError(err)
+	s.Equal(models.BatchItemStatusDuplicate, result.Items[0].Status)
+	s.Equal(version, s.lastReception(pvz.ID).Version)
+}
@@ -0,0 +1,219 @@
+package repotest
+
+import (
+	"bytes"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+func (s *ContractSuite) TestPVZ_Create() {
+	pvz := s.createPVZ(s.now)
+	s.Equal(1, pvz.Version)
+
+	duplicate := &models.PVZ{ID: pvz.ID, RegistrationDate: s.now, City: models.CityTypeKazan}
+	s.ErrorIs(s.repos.PVZ.Create(s.ctx, duplicate), databaseerrors.ErrUniqueViolation)
+}
+
+func (s *ContractSuite) TestPVZ_GetAll() {
+	first := s.createPVZ(s.at(-time.Hour))
+	second := s.createPVZ(s.now)
+
+	pvzs, err := s.repos.PVZ.GetAll(s.ctx)
+	s.Require().NoError(err)
+
+	ids := make([]uuid.UUID, 0, len(pvzs))
+	for _, pvz := range pvzs {
+		ids = append(ids, pvz.ID)
+	}
+
+	s.ElementsMatch([]uuid.UUID{first.ID, second.ID}, ids)
+}
+
+func (s *ContractSuite) TestPVZ_List_Pagination() {
+	oldest := s.createPVZ(s.at(-2 * time.Hour))
+	middle := s.createPVZ(s.at(-time.Hour))
+	newest := s.createPVZ(s.now)
+
+	firstPage, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{Page: 1, PageSize: 2})
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{newest.ID, middle.ID}, pvzIDs(firstPage))
+
+	secondPage, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{Page: 2, PageSize: 2})
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{oldest.ID}, pvzIDs(secondPage))
+
+	// Без фильтра по дате ПВЗ без приемок тоже попадают в результат
+	s.Empty(secondPage[0].Receptions)
+	s.Equal(oldest.City, secondPage[0].PVZ.City)
+	s.Equal(oldest.Version, secondPage[0].PVZ.Version)
+}
+
+func (s *ContractSuite) TestPVZ_List_DateFilter() {
+	withReception := s.createPVZ(s.at(-time.Hour))
+	s.createPVZ(s.now) // Без приемок
+
+	s.openReception(withReception.ID, s.at(-2*time.Hour))
+	s.closeReception(withReception.ID, s.at(-90*time.Minute))
+
+	inRange := s.openReception(withReception.ID, s.at(-30*time.Minute))
+	product := s.addProduct(withReception.ID, s.at(-20*time.Minute))
+
+	start := s.at(-time.Hour)
+	end := s.now
+
+	pvzs, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{StartDate: &start, EndDate: &end, Page: 1, PageSize: 10})
+	s.Require().NoError(err)
+
+	s.Require().Equal([]uuid.UUID{withReception.ID}, pvzIDs(pvzs))
+	s.Require().Len(pvzs[0].Receptions, 1)
+
+	reception := pvzs[0].Receptions[0]
+	s.Equal(inRange.ID, reception.Reception.ID)
+	s.Equal(models.ReceptionStatusInProgress, reception.Reception.Status)
+	s.Equal(inRange.OpenedBy, reception.Reception.OpenedBy)
+
+	s.Require().Len(reception.Products, 1)
+	s.Equal(product.ID, reception.Products[0].ID)
+	s.Equal(inRange.ID, reception.Products[0].ReceptionID)
+	s.equalTime(product.DateTime, reception.Products[0].DateTime)
+}
+
+func (s *ContractSuite) TestPVZ_List_Empty() {
+	pvzs, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{Page: 1, PageSize: 10})
+	s.Require().NoError(err)
+	s.NotNil(pvzs)
+	s.Empty(pvzs)
+}
+
+func (s *ContractSuite) TestPVZ_List_PaginationEdges() {
+	older := s.createPVZ(s.at(-time.Hour))
+	newer := s.createPVZ(s.now)
+
+	tests := []struct {
+		name     string
+		filter   models.PVZFilter
+		expected []uuid.UUID
+	}{
+		{name: "Page size larger than total", filter: models.PVZFilter{Page: 1, PageSize: 30}, expected: []uuid.UUID{newer.ID, older.ID}},
+		{name: "Page size equal to total", filter: models.PVZFilter{Page: 1, PageSize: 2}, expected: []uuid.UUID{newer.ID, older.ID}},
+		{name: "Page of one", filter: models.PVZFilter{Page: 2, PageSize: 1}, expected: []uuid.UUID{older.ID}},
+		{name: "Page right after the end", filter: models.PVZFilter{Page: 2, PageSize: 2}, expected: []uuid.UUID{}},
+		{name: "Page far after the end", filter: models.PVZFilter{Page: 100, PageSize: 30}, expected: []uuid.UUID{}},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			filter := tt.filter
+
+			pvzs, err := s.repos.PVZ.List(s.ctx, &filter)
+			s.Require().NoError(err)
+			s.Equal(tt.expected, pvzIDs(pvzs))
+		})
+	}
+}
+
+// Страница выбирается до фильтрации по дате, поэтому ПВЗ без подходящих приемок
+// не заменяются следующими, и страница может оказаться неполной или пустой.
+func (s *ContractSuite) TestPVZ_List_DateFilterAppliedAfterPagination() {
+	withReception := s.createPVZ(s.at(-time.Hour))
+	s.createPVZ(s.now) // Самый новый ПВЗ без приемок занимает первую страницу
+
+	s.openReception(withReception.ID, s.now)
+
+	start := s.at(-time.Minute)
+
+	firstPage, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{StartDate: &start, Page: 1, PageSize: 1})
+	s.Require().NoError(err)
+	s.Empty(firstPage)
+
+	secondPage, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{StartDate: &start, Page: 2, PageSize: 1})
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{withReception.ID}, pvzIDs(secondPage))
+}
+
+func (s *ContractSuite) TestPVZ_List_DateBounds() {
+	pvz := s.createPVZ(s.now)
+
+	first := s.openReception(pvz.ID, s.at(-2*time.Hour))
+	s.closeReception(pvz.ID, s.at(-90*time.Minute))
+
+	second := s.openReception(pvz.ID, s.at(-time.Hour))
+	s.closeReception(pvz.ID, s.at(-30*time.Minute))
+
+	at := func(offset time.Duration) *time.Time {
+		t := s.at(offset)
+		return &t
+	}
+
+	tests := []struct {
+		name     string
+		start    *time.Time
+		end      *time.Time
+		expected []uuid.UUID
+	}{
+		{name: "Both bounds are inclusive", start: at(-2 * time.Hour), end: at(-time.Hour), expected: []uuid.UUID{second.ID, first.ID}},
+		{name: "Only start date", start: at(-time.Hour), expected: []uuid.UUID{second.ID}},
+		{name: "Only end date", end: at(-2 * time.Hour), expected: []uuid.UUID{first.ID}},
+		{name: "No receptions in range", start: at(-3 * time.Hour), end: at(-150 * time.Minute), expected: nil},
+	}
+
+	for _, tt := range tests {
+		s.Run(tt.name, func() {
+			pvzs, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{StartDate: tt.start, EndDate: tt.end, Page: 1, PageSize: 10})
+			s.Require().NoError(err)
+
+			if tt.expected == nil {
+				s.Empty(pvzs)
+				return
+			}
+
+			s.Require().Len(pvzs, 1)
+
+			ids := make([]uuid.UUID, 0, len(pvzs[0].Receptions))
+			for _, reception := range pvzs[0].Receptions {
+				ids = append(ids, reception.Reception.ID)
+			}
+
+			s.Equal(tt.expected, ids)
+		})
+	}
+}
+
+func (s *ContractSuite) TestPVZ_List_Ordering() {
+	registeredAt := s.at(-time.Hour)
+
+	// ПВЗ с одинаковой датой регистрации упорядочены по айди
+	sameDate := []*models.PVZ{s.createPVZ(registeredAt), s.createPVZ(registeredAt)}
+	sort.Slice(sameDate, func(i, j int) bool {
+		return bytes.Compare(sameDate[i].ID[:], sameDate[j].ID[:]) < 0
+	})
+
+	newest := s.createPVZ(s.now)
+
+	// Приемки от новых к старым, товары от старых к новым независимо от порядка добавления
+	older := s.openReception(newest.ID, s.at(time.Minute))
+	s.closeReception(newest.ID, s.at(2*time.Minute))
+
+	newer := s.openReception(newest.ID, s.at(3*time.Minute))
+	lateProduct := s.addProduct(newest.ID, s.at(5*time.Minute))
+	earlyProduct := s.addProduct(newest.ID, s.at(4*time.Minute))
+
+	pvzs, err := s.repos.PVZ.List(s.ctx, &models.PVZFilter{Page: 1, PageSize: 10})
+	s.Require().NoError(err)
+
+	s.Equal([]uuid.UUID{newest.ID, sameDate[0].ID, sameDate[1].ID}, pvzIDs(pvzs))
+
+	receptions := pvzs[0].Receptions
+	s.Require().Len(receptions, 2)
+	s.Equal(newer.ID, receptions[0].Reception.ID)
+	s.Equal(older.ID, receptions[1].Reception.ID)
+	s.Empty(receptions[1].Products)
+
+	s.Require().Len(receptions[0].Products, 2)
+	s.Equal(earlyProduct.ID, receptions[0].Products[0].ID)
+	s.Equal(lateProduct.ID, receptions[0].Products[1].ID)
+}
@@ -0,0 +1,352 @@
+package repotest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+func (s *ContractSuite) TestReception_CreateIfNoOpen() {
+	pvz := s.createPVZ(s.now)
+
+	reception := s.openReception(pvz.ID, s.now)
+	s.Equal(1, reception.Version)
+	s.Equal(2, s.pvzVersion(pvz.ID))
+
+	second := &models.Reception{ID: uuid.New(), DateTime: s.at(time.Minute), PVZID: pvz.ID, Status: models.ReceptionStatusInProgress}
+	s.ErrorIs(s.repos.Reception.CreateIfNoOpen(s.ctx, second, models.AnyVersion), domainerrors.ErrOpenReceptionExists)
+
+	// Неудачная попытка не меняет версию ПВЗ
+	s.Equal(2, s.pvzVersion(pvz.ID))
+}
+
+func (s *ContractSuite) TestReception_CreateIfNoOpen_PVZNotFound() {
+	reception := &models.Reception{ID: uuid.New(), DateTime: s.now, PVZID: uuid.New(), Status: models.ReceptionStatusInProgress}
+
+	s.ErrorIs(s.repos.Reception.CreateIfNoOpen(s.ctx, reception, models.AnyVersion), databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestReception_CreateIfNoOpen_AfterClose() {
+	pvz := s.createPVZ(s.now)
+
+	s.openReception(pvz.ID, s.now)
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	// Закрытие приемки меняет версию ПВЗ, поэтому версия, полученная до закрытия, устарела
+	reception := &models.Reception{ID: uuid.New(), DateTime: s.at(2 * time.Minute), PVZID: pvz.ID, Status: models.ReceptionStatusInProgress}
+	s.ErrorIs(s.repos.Reception.CreateIfNoOpen(s.ctx, reception, 2), domainerrors.ErrVersionMismatch)
+	s.NoError(s.repos.Reception.CreateIfNoOpen(s.ctx, reception, 3))
+}
+
+func (s *ContractSuite) TestReception_CloseLast() {
+	pvz := s.createPVZ(s.now)
+	opened := s.openReception(pvz.ID, s.now)
+
+	closedBy := uuid.New()
+	closedAt := s.at(time.Minute)
+	pvzVersion := s.pvzVersion(pvz.ID)
+
+	closed, city, err := s.repos.Reception.CloseLast(s.ctx, pvz.ID, closedBy, closedAt, opened.Version)
+	s.Require().NoError(err)
+
+	// У ПВЗ больше нет открытой приемки, поэтому его версия меняется
+	s.Equal(pvzVersion+1, s.pvzVersion(pvz.ID))
+
+	s.Equal(pvz.City, city)
+	s.Equal(opened.ID, closed.ID)
+	s.Equal(models.ReceptionStatusClose, closed.Status)
+	s.Equal(opened.Version+1, closed.Version)
+	s.Require().NotNil(closed.ClosedAt)
+	s.equalTime(closedAt, *closed.ClosedAt)
+	s.Require().NotNil(closed.ClosedBy)
+	s.Equal(closedBy, *closed.ClosedBy)
+
+	// Без манифеста отчет о расхождениях не строится
+	s.Nil(closed.Discrepancy)
+
+	_, _, err = s.repos.Reception.CloseLast(s.ctx, pvz.ID, closedBy, closedAt, models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+	s.Equal(pvzVersion+1, s.pvzVersion(pvz.ID))
+}
+
+func (s *ContractSuite) TestReception_CloseLast_BuildsDiscrepancyReport() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	s.attachManifest(pvz.ID, models.ManifestItem{Type: models.ProductTypeShoes, Count: 2})
+	s.addTypedProduct(pvz.ID, models.ProductTypeShoes, s.at(time.Second))
+	s.addTypedProduct(pvz.ID, models.ProductTypeClothes, s.at(2*time.Second))
+
+	closedAt := s.at(time.Minute)
+
+	closed, _, err := s.repos.Reception.CloseLast(s.ctx, pvz.ID, uuid.New(), closedAt, models.AnyVersion)
+	s.Require().NoError(err)
+
+	s.Require().NotNil(closed.Discrepancy)
+	s.Equal(closed.ID, closed.Discrepancy.ReceptionID)
+	s.equalTime(closedAt, closed.Discrepancy.CreatedAt)
+	s.ElementsMatch([]models.DiscrepancyItem{
+		{Type: models.ProductTypeShoes, Kind: models.DiscrepancyKindMissing, Expected: 2, Actual: 1},
+		{Type: models.ProductTypeClothes, Kind: models.DiscrepancyKindUnexpected, Expected: 0, Actual: 1},
+	}, closed.Discrepancy.Items)
+}
+
+func (s *ContractSuite) TestReception_GetLast() {
+	pvz := s.createPVZ(s.now)
+
+	_, err := s.repos.Reception.GetLast(s.ctx, pvz.ID)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+
+	s.openReception(pvz.ID, s.now)
+	s.closeReception(pvz.ID, s.at(time.Minute))
+	last := s.openReception(pvz.ID, s.at(2*time.Minute))
+
+	reception, err := s.repos.Reception.GetLast(s.ctx, pvz.ID)
+	s.Require().NoError(err)
+	s.Equal(last.ID, reception.ID)
+	s.Equal(pvz.ID, reception.PVZID)
+	s.equalTime(last.DateTime, reception.DateTime)
+}
+
+func (s *ContractSuite) TestReception_Reopen() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	closed := s.closeReception(pvz.ID, s.at(time.Minute))
+
+	reopenedBy := uuid.New()
+	pvzVersion := s.pvzVersion(pvz.ID)
+
+	reopened, err := s.repos.Reception.Reopen(s.ctx, closed.ID, reopenedBy, s.at(2*time.Minute), closed.Version)
+	s.Require().NoError(err)
+	s.Equal(pvzVersion+1, s.pvzVersion(pvz.ID))
+
+	s.Equal(models.ReceptionStatusInProgress, reopened.Status)
+	s.Equal(closed.Version+1, reopened.Version)
+	s.Nil(reopened.ClosedAt)
+	s.Nil(reopened.ClosedBy)
+	s.Require().NotNil(reopened.ReopenedBy)
+	s.Equal(reopenedBy, *reopened.ReopenedBy)
+
+	// Переоткрытая приемка снова открыта, поэтому новую открыть нельзя
+	second := &models.Reception{ID: uuid.New(), DateTime: s.at(3 * time.Minute), PVZID: pvz.ID, Status: models.ReceptionStatusInProgress}
+	s.ErrorIs(s.repos.Reception.CreateIfNoOpen(s.ctx, second, models.AnyVersion), domainerrors.ErrOpenReceptionExists)
+}
+
+func (s *ContractSuite) TestReception_CreateIfNoOpen_VersionMismatch() {
+	pvz := s.createPVZ(s.now)
+
+	reception := &models.Reception{ID: uuid.New(), DateTime: s.now, PVZID: pvz.ID, Status: models.ReceptionStatusInProgress}
+	s.ErrorIs(s.repos.Reception.CreateIfNoOpen(s.ctx, reception, pvz.Version+1), domainerrors.ErrVersionMismatch)
+
+	_, err := s.repos.Reception.GetLast(s.ctx, pvz.ID)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+
+	s.NoError(s.repos.Reception.CreateIfNoOpen(s.ctx, reception, pvz.Version))
+}
+
+func (s *ContractSuite) TestReception_CloseLast_Errors() {
+	pvz := s.createPVZ(s.now)
+
+	s.Run("PVZ not found", func() {
+		_, _, err := s.repos.Reception.CloseLast(s.ctx, uuid.New(), uuid.New(), s.now, models.AnyVersion)
+		s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+	})
+
+	s.Run("No receptions", func() {
+		_, _, err := s.repos.Reception.CloseLast(s.ctx, pvz.ID, uuid.New(), s.now, models.AnyVersion)
+		s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+	})
+
+	s.Run("Version mismatch", func() {
+		opened := s.openReception(pvz.ID, s.now)
+
+		_, _, err := s.repos.Reception.CloseLast(s.ctx, pvz.ID, uuid.New(), s.at(time.Minute), opened.Version+1)
+		s.ErrorIs(err, domainerrors.ErrVersionMismatch)
+		s.Equal(models.ReceptionStatusInProgress, s.lastReception(pvz.ID).Status)
+	})
+}
+
+func (s *ContractSuite) TestReception_CancelLast() {
+	pvz := s.createPVZ(s.now)
+
+	_, err := s.repos.Reception.CancelLast(s.ctx, pvz.ID, uuid.New(), s.now, "opened by mistake", models.AnyVersion)
+	s.ErrorIs(err, domainerrors.ErrNoOpenReceptions)
+
+	opened := s.openReception(pvz.ID, s.now)
+
+	_, err = s.repos.Reception.CancelLast(s.ctx, pvz.ID, uuid.New(), s.now, "opened by mistake", opened.Version+1)
+	s.ErrorIs(err, domainerrors.ErrVersionMismatch)
+
+	cancelledBy := uuid.New()
+	pvzVersion := s.pvzVersion(pvz.ID)
+
+	cancelled, err := s.repos.Reception.CancelLast(s.ctx, pvz.ID, cancelledBy, s.at(time.Minute), "opened by mistake", opened.Version)
+	s.Require().NoError(err)
+	s.Equal(pvzVersion+1, s.pvzVersion(pvz.ID))
+
+	s.Equal(opened.ID, cancelled.ID)
+	s.Equal(models.ReceptionStatusCancelled, cancelled.Status)
+	s.Equal("opened by mistake", cancelled.CloseReason)
+	s.Equal(opened.Version+1, cancelled.Version)
+	s.Require().NotNil(cancelled.ClosedBy)
+	s.Equal(cancelledBy, *cancelled.ClosedBy)
+	s.Require().NotNil(cancelled.ClosedAt)
+	s.equalTime(s.at(time.Minute), *cancelled.ClosedAt)
+
+	// Отмененная приемка остается последней, но новую уже можно открыть
+	s.Equal(models.ReceptionStatusCancelled, s.lastReception(pvz.ID).Status)
+	s.openReception(pvz.ID, s.at(2*time.Minute))
+}
+
+func (s *ContractSuite) TestReception_GetLast_ByOpeningTime() {
+	pvz := s.createPVZ(s.now)
+
+	later := s.openReception(pvz.ID, s.at(time.Hour))
+	s.closeReception(pvz.ID, s.at(2*time.Hour))
+
+	// Приемка, открытая позже по времени добавления, но раньше по времени открытия, последней не считается
+	s.openReception(pvz.ID, s.now)
+
+	s.Equal(later.ID, s.lastReception(pvz.ID).ID)
+}
+
+func (s *ContractSuite) TestReception_Reopen_Errors() {
+	s.Run("Reception not found", func() {
+		_, err := s.repos.Reception.Reopen(s.ctx, uuid.New(), uuid.New(), s.now, models.AnyVersion)
+		s.ErrorIs(err, domainerrors.ErrReceptionNotReopenable)
+
+		_, err = s.repos.Reception.Reopen(s.ctx, uuid.New(), uuid.New(), s.now, 1)
+		s.ErrorIs(err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	s.Run("Reception is open", func() {
+		pvz := s.createPVZ(s.now)
+		opened := s.openReception(pvz.ID, s.now)
+
+		_, err := s.repos.Reception.Reopen(s.ctx, opened.ID, uuid.New(), s.now, opened.Version)
+		s.ErrorIs(err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	s.Run("Reception is cancelled", func() {
+		pvz := s.createPVZ(s.now)
+		opened := s.openReception(pvz.ID, s.now)
+
+		cancelled, err := s.repos.Reception.CancelLast(s.ctx, pvz.ID, uuid.New(), s.now, "mistake", models.AnyVersion)
+		s.Require().NoError(err)
+
+		_, err = s.repos.Reception.Reopen(s.ctx, opened.ID, uuid.New(), s.now, cancelled.Version)
+		s.ErrorIs(err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	s.Run("Reception is not the last one", func() {
+		pvz := s.createPVZ(s.now)
+		s.openReception(pvz.ID, s.now)
+		first := s.closeReception(pvz.ID, s.at(time.Minute))
+
+		s.openReception(pvz.ID, s.at(2*time.Minute))
+		s.closeReception(pvz.ID, s.at(3*time.Minute))
+
+		_, err := s.repos.Reception.Reopen(s.ctx, first.ID, uuid.New(), s.now, first.Version)
+		s.ErrorIs(err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	s.Run("Another reception is open", func() {
+		pvz := s.createPVZ(s.now)
+		s.openReception(pvz.ID, s.at(time.Hour))
+		closed := s.closeReception(pvz.ID, s.at(2*time.Hour))
+
+		// Открытая приемка раньше по времени открытия, но все равно мешает переоткрытию
+		s.openReception(pvz.ID, s.now)
+
+		_, err := s.repos.Reception.Reopen(s.ctx, closed.ID, uuid.New(), s.now, closed.Version)
+		s.ErrorIs(err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	s.Run("Version mismatch is reported first", func() {
+		pvz := s.createPVZ(s.now)
+		s.openReception(pvz.ID, s.now)
+		closed := s.closeReception(pvz.ID, s.at(time.Minute))
+
+		_, err := s.repos.Reception.Reopen(s.ctx, closed.ID, uuid.New(), s.now, closed.Version+1)
+		s.ErrorIs(err, domainerrors.ErrVersionMismatch)
+
+		reopened, err := s.repos.Reception.Reopen(s.ctx, closed.ID, uuid.New(), s.now, closed.Version)
+		s.Require().NoError(err)
+
+		_, err = s.repos.Reception.CancelLast(s.ctx, pvz.ID, uuid.New(), s.now, "mistake", reopened.Version)
+		s.Require().NoError(err)
+
+		_, err = s.repos.Reception.Reopen(s.ctx, closed.ID, uuid.New(), s.now, 1)
+		s.ErrorIs(err, domainerrors.ErrVersionMismatch)
+	})
+}
+
+func (s *ContractSuite) TestReception_CloseStale() {
+	moscow := s.createPVZInCity(models.CityTypeMoscow, s.now)
+	fresh := s.createPVZInCity(models.CityTypeMoscow, s.now)
+	kazan := s.createPVZInCity(models.CityTypeKazan, s.now)
+
+	stale := s.openReception(moscow.ID, s.at(-2*time.Hour))
+	s.openReception(fresh.ID, s.at(-time.Hour)) // Открыта ровно в openedBefore, поэтому не закрывается
+	s.openReception(kazan.ID, s.at(-2*time.Hour))
+
+	closedAt := s.now
+	moscowVersion, freshVersion := s.pvzVersion(moscow.ID), s.pvzVersion(fresh.ID)
+
+	closed, err := s.repos.Reception.CloseStale(s.ctx, models.CityTypeMoscow, s.at(-time.Hour), closedAt, "forgotten")
+	s.Require().NoError(err)
+
+	// Версия меняется только у ПВЗ с закрытой приемкой
+	s.Equal(moscowVersion+1, s.pvzVersion(moscow.ID))
+	s.Equal(freshVersion, s.pvzVersion(fresh.ID))
+
+	s.Require().Len(closed, 1)
+	s.Equal(stale.ID, closed[0].ID)
+	s.Equal(models.ReceptionStatusClose, closed[0].Status)
+	s.True(closed[0].AutoClosed)
+	s.Equal("forgotten", closed[0].CloseReason)
+	s.Nil(closed[0].ClosedBy)
+	s.Equal(stale.Version+1, closed[0].Version)
+
+	s.Equal(models.ReceptionStatusInProgress, s.lastReception(fresh.ID).Status)
+	s.Equal(models.ReceptionStatusInProgress, s.lastReception(kazan.ID).Status)
+
+	s.Nil(closed[0].Discrepancy)
+
+	// Повторный запуск ничего не закрывает
+	closed, err = s.repos.Reception.CloseStale(s.ctx, models.CityTypeMoscow, s.at(-time.Hour), closedAt, "forgotten")
+	s.Require().NoError(err)
+	s.Empty(closed)
+}
+
+func (s *ContractSuite) TestReception_CloseStale_BuildsDiscrepancyReport() {
+	pvz := s.createPVZInCity(models.CityTypeMoscow, s.now)
+	s.openReception(pvz.ID, s.at(-2*time.Hour))
+	s.attachManifest(pvz.ID, models.ManifestItem{Type: models.ProductTypeElectronics, Count: 1})
+
+	closed, err := s.repos.Reception.CloseStale(s.ctx, models.CityTypeMoscow, s.at(-time.Hour), s.now, "forgotten")
+	s.Require().NoError(err)
+
+	s.Require().Len(closed, 1)
+	s.Require().NotNil(closed[0].Discrepancy)
+	s.Equal([]models.DiscrepancyItem{
+		{Type: models.ProductTypeElectronics, Kind: models.DiscrepancyKindMissing, Expected: 1, Actual: 0},
+	}, closed[0].Discrepancy.Items)
+}
+
+func (s *ContractSuite) TestReception_LockAutoClose() {
+	unlock, err := s.repos.Reception.LockAutoClose(s.ctx)
+	s.Require().NoError(err)
+
+	// Пока проход не завершен, второй проход начать нельзя
+	_, err = s.repos.Reception.LockAutoClose(s.ctx)
+	s.ErrorIs(err, databaseerrors.ErrLockNotAcquired)
+
+	unlock()
+
+	unlock, err = s.repos.Reception.LockAutoClose(s.ctx)
+	s.Require().NoError(err)
+	unlock()
+}
@@ -0,0 +1,189 @@
+package repotest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// registerReturn регистрирует возврат выданного товара productID в ПВЗ в момент returnedAt.
+func (s *ContractSuite) registerReturn(pvzID, productID uuid.UUID, returnedAt time.Time) (*models.CustomerReturn, models.CityType, error) {
+	return s.repos.Return.Register(s.ctx, &models.CustomerReturn{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		ProductID: &productID,
+		Reason:    models.ReturnReasonDefective,
+		Condition: models.ReturnConditionOpened,
+		CreatedBy: uuid.New(),
+		DateTime:  returnedAt,
+	})
+}
+
+// registerUnknownReturn регистрирует возврат неизвестного товара в ПВЗ в момент returnedAt.
+func (s *ContractSuite) registerUnknownReturn(pvzID uuid.UUID, returnedAt time.Time) *models.CustomerReturn {
+	s.T().Helper()
+
+	ret, _, err := s.repos.Return.Register(s.ctx, &models.CustomerReturn{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		Barcode:   "4600000" + uuid.NewString()[:6],
+		Type:      models.ProductTypeShoes,
+		Reason:    models.ReturnReasonDoesNotFit,
+		Condition: models.ReturnConditionNew,
+		CreatedBy: uuid.New(),
+		DateTime:  returnedAt,
+	})
+	s.Require().NoError(err)
+
+	return ret
+}
+
+// issuedProduct добавляет в ПВЗ товар типа productType и выдает его клиенту.
+func (s *ContractSuite) issuedProduct(pvzID uuid.UUID, productType models.ProductType) *models.Product {
+	s.T().Helper()
+
+	s.openReception(pvzID, s.now)
+	product := s.addTypedProduct(pvzID, productType, s.at(time.Second))
+	s.closeReception(pvzID, s.at(time.Minute))
+
+	_, _, err := s.issue(pvzID, s.at(time.Hour), product.ID)
+	s.Require().NoError(err)
+
+	return product
+}
+
+// returnIDs возвращает айди возвратов в исходном порядке.
+func returnIDs(returns []*models.CustomerReturn) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(returns))
+	for _, ret := range returns {
+		ids = append(ids, ret.ID)
+	}
+
+	return ids
+}
+
+func (s *ContractSuite) TestReturn_RegisterIssuedProduct() {
+	pvz := s.createPVZInCity(models.CityTypeKazan, s.now)
+	product := s.issuedProduct(pvz.ID, models.ProductTypeElectronics)
+
+	ret, city, err := s.registerReturn(pvz.ID, product.ID, s.at(2*time.Hour))
+	s.Require().NoError(err)
+	s.Equal(models.CityTypeKazan, city)
+	s.Require().NotNil(ret.ProductID)
+	s.Equal(product.ID, *ret.ProductID)
+	s.NotEqual(uuid.Nil, ret.ShipmentID)
+	s.equalTime(s.at(2*time.Hour), ret.DateTime)
+
+	// Тип возврата берется из товара
+	s.Equal(models.ProductTypeElectronics, ret.Type)
+	s.Equal(models.ProductStatusReturnedByCustomer, s.listedProducts(pvz.ID)[product.ID].Status)
+
+	// Товар нельзя вернуть дважды
+	_, _, err = s.registerReturn(pvz.ID, product.ID, s.at(3*time.Hour))
+	s.ErrorIs(err, domainerrors.ErrProductNotIssued)
+}
+
+func (s *ContractSuite) TestReturn_RegisterErrors() {
+	pvz := s.createPVZ(s.now)
+	other := s.createPVZ(s.now)
+	foreign := s.issuedProduct(other.ID, models.ProductTypeClothes)
+
+	s.openReception(pvz.ID, s.now)
+	stored := s.addProduct(pvz.ID, s.at(time.Second))
+
+	_, _, err := s.registerReturn(pvz.ID, stored.ID, s.at(time.Hour))
+	s.ErrorIs(err, domainerrors.ErrProductNotIssued)
+
+	// Товар, выданный в другом ПВЗ, не найден среди товаров этого ПВЗ
+	_, _, err = s.registerReturn(pvz.ID, foreign.ID, s.at(time.Hour))
+	s.ErrorIs(err, domainerrors.ErrProductNotFound)
+
+	_, _, err = s.registerReturn(pvz.ID, uuid.New(), s.at(time.Hour))
+	s.ErrorIs(err, domainerrors.ErrProductNotFound)
+
+	_, _, err = s.registerReturn(uuid.New(), foreign.ID, s.at(time.Hour))
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+
+	// Неудачные попытки не создают отправку возвратов
+	_, err = s.repos.Return.Dispatch(s.ctx, pvz.ID, s.at(2*time.Hour))
+	s.ErrorIs(err, domainerrors.ErrNoPendingReturns)
+
+	s.Equal(models.ProductStatusStored, s.listedProducts(pvz.ID)[stored.ID].Status)
+}
+
+func (s *ContractSuite) TestReturn_PendingShipment() {
+	pvz := s.createPVZ(s.now)
+	other := s.createPVZ(s.now)
+
+	first := s.registerUnknownReturn(pvz.ID, s.at(time.Minute))
+	second := s.registerUnknownReturn(pvz.ID, s.at(2*time.Minute))
+	foreign := s.registerUnknownReturn(other.ID, s.at(time.Minute))
+
+	// Возвраты ПВЗ собираются в одну отправку, у каждого ПВЗ она своя
+	s.Equal(first.ShipmentID, second.ShipmentID)
+	s.NotEqual(first.ShipmentID, foreign.ShipmentID)
+	s.Nil(first.ProductID)
+	s.Equal(models.ProductTypeShoes, first.Type)
+	s.NotEmpty(first.Barcode)
+
+	shipment, err := s.repos.Return.Dispatch(s.ctx, pvz.ID, s.at(time.Hour))
+	s.Require().NoError(err)
+	s.Equal(first.ShipmentID, shipment.ID)
+	s.Equal(pvz.ID, shipment.PVZID)
+	s.Equal(models.ShipmentKindCustomerReturns, shipment.Kind)
+	s.equalTime(s.at(time.Minute), shipment.DateTime)
+	s.Require().NotNil(shipment.DispatchedAt)
+	s.equalTime(s.at(time.Hour), *shipment.DispatchedAt)
+	s.Equal([]uuid.UUID{first.ID, second.ID}, returnIDs(shipment.Returns))
+	s.Equal(first.Barcode, shipment.Returns[0].Barcode)
+
+	_, err = s.repos.Return.Dispatch(s.ctx, pvz.ID, s.at(2*time.Hour))
+	s.ErrorIs(err, domainerrors.ErrNoPendingReturns)
+
+	// После отправки возвраты попадают в новую отправку
+	next := s.registerUnknownReturn(pvz.ID, s.at(2*time.Hour))
+	s.NotEqual(first.ShipmentID, next.ShipmentID)
+
+	_, err = s.repos.Return.Dispatch(s.ctx, uuid.New(), s.at(time.Hour))
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestReturn_List() {
+	pvz := s.createPVZ(s.now)
+	other := s.createPVZ(s.now)
+
+	second := s.registerUnknownReturn(pvz.ID, s.at(2*time.Minute))
+	first := s.registerUnknownReturn(pvz.ID, s.at(time.Minute))
+	third := s.registerUnknownReturn(pvz.ID, s.at(3*time.Minute))
+	foreign := s.registerUnknownReturn(other.ID, s.at(time.Minute))
+
+	// Возвраты отсортированы по времени регистрации
+	returns, err := s.repos.Return.ListByPVZ(s.ctx, pvz.ID, nil, nil)
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{first.ID, second.ID, third.ID}, returnIDs(returns))
+
+	// Границы периода включаются
+	start, end := s.at(2*time.Minute), s.at(3*time.Minute)
+
+	returns, err = s.repos.Return.ListByPVZ(s.ctx, pvz.ID, &start, &end)
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{second.ID, third.ID}, returnIDs(returns))
+
+	returns, err = s.repos.Return.ListByPVZ(s.ctx, pvz.ID, nil, &start)
+	s.Require().NoError(err)
+	s.Equal([]uuid.UUID{first.ID, second.ID}, returnIDs(returns))
+
+	returns, err = s.repos.Return.ListByPVZs(s.ctx, []uuid.UUID{pvz.ID, other.ID}, nil, &start)
+	s.Require().NoError(err)
+	s.ElementsMatch([]uuid.UUID{first.ID, second.ID, foreign.ID}, returnIDs(returns))
+
+	returns, err = s.repos.Return.ListByPVZs(s.ctx, []uuid.UUID{uuid.New()}, nil, nil)
+	s.Require().NoError(err)
+	s.Empty(returns)
+
+	_, err = s.repos.Return.ListByPVZ(s.ctx, uuid.New(), nil, nil)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
@@ -0,0 +1,189 @@
+package repotest
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+// addTypedProduct добавляет товар типа productType отдельным заказом в открытую приемку ПВЗ в момент addedAt.
+func (s *ContractSuite) addTypedProduct(pvzID uuid.UUID, productType models.ProductType, addedAt time.Time) *models.Product {
+	s.T().Helper()
+
+	id := uuid.New()
+
+	product, err := s.repos.Product.Create(s.ctx, &models.AddProduct{
+		ID:       id,
+		DateTime: addedAt,
+		Type:     productType,
+		PVZID:    pvzID,
+		OrderID:  id,
+	}, models.AnyVersion)
+	s.Require().NoError(err)
+
+	return product
+}
+
+// returnToSender возвращает отправителю товары ПВЗ, принятые не позже границ deadlines, в момент returnedAt.
+func (s *ContractSuite) returnToSender(pvzID uuid.UUID, returnedAt time.Time, deadlines []models.StorageDeadline) (*models.Shipment, models.CityType, error) {
+	return s.repos.Product.ReturnToSender(s.ctx, &models.Shipment{
+		ID:           uuid.New(),
+		PVZID:        pvzID,
+		Kind:         models.ShipmentKindReturnToSender,
+		CreatedBy:    uuid.New(),
+		DateTime:     returnedAt,
+		DispatchedAt: &returnedAt,
+	}, deadlines)
+}
+
+// productIDs возвращает айди товаров в исходном порядке.
+func productIDs(products []*models.Product) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(products))
+	for _, product := range products {
+		ids = append(ids, product.ID)
+	}
+
+	return ids
+}
+
+func (s *ContractSuite) TestListExpiring() {
+	pvz := s.createPVZ(s.now)
+	s.openReception(pvz.ID, s.now)
+	expired := s.addTypedProduct(pvz.ID, models.ProductTypeElectronics, s.at(time.Second))
+	s.addTypedProduct(pvz.ID, models.ProductTypeElectronics, s.at(2*time.Second))
+	clothes := s.addTypedProduct(pvz.ID, models.ProductTypeClothes, s.at(3*time.Second))
+	issued := s.addTypedProduct(pvz.ID, models.ProductTypeElectronics, s.at(4*time.Second))
+	s.addTypedProduct(pvz.ID, models.ProductTypeShoes, s.at(5*time.Second))
+	s.closeReception(pvz.ID, s.at(time.Minute))
+
+	_, _, err := s.issue(pvz.ID, s.at(2*time.Minute), issued.ID)
+	s.Require().NoError(err)
+
+	// Товары незакрытой приемки еще не хранятся в ПВЗ
+	s.openReception(pvz.ID, s.at(3*time.Minute))
+	s.addTypedProduct(pvz.ID, models.ProductTypeClothes, s.at(4*time.Minute))
+
+	deadlines := []models.StorageDeadline{
+		// Граница включается
+		{City: models.CityTypeMoscow, Type: models.ProductTypeElectronics, ReceivedBy: s.at(time.Second)},
+		{City: models.CityTypeMoscow, Type: models.ProductTypeClothes, ReceivedBy: s.at(time.Hour)},
+		{City: models.CityTypeKazan, Type: models.ProductTypeShoes, ReceivedBy: s.at(time.Hour)},
+	}
+
+	products, city, err := s.repos.Product.ListExpiring(s.ctx, pvz.ID, deadlines)
+	s.Require().NoError(err)
+	s.Equal(models.CityTypeMoscow, city)
+	s.Equal([]uuid.UUID{expired.ID, clothes.ID}, productIDs(products))
+
+	for _, product := range products {
+		s.Equal(models.ProductStatusStored, product.Status)
+		s.Nil(product.ShipmentID)
+		s.Nil(product.ReturnedAt)
+	}
+
+	other := s.createPVZInCity(models.CityTypeKazan, s.now)
+
+	products, city, err = s.repos.Product.ListExpiring(s.ctx, other.ID, deadlines)
+	s.Require().NoError(err)
+	s.Equal(models.CityTypeKazan, city)
+	s.Empty(products)
+
+	_, _, err = s.repos.Product.ListExpiring(s.ctx, uuid.New(), deadlines)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestReturnToSender() {
+	pvz := s.createPVZInCity(models.CityTypeSPB, s.now)
+	reception := s.openReception(pvz.ID, s.now)
+	second := s.addTypedProduct(pvz.ID, models.ProductTypeShoes, s.at(2*time.Second))
+	first := s.addTypedProduct(pvz.ID, models.ProductTypeElectronics, s.at(time.Second))
+	kept := s.addTypedProduct(pvz.ID, models.ProductTypeElectronics, s.at(time.Hour))
+	s.closeReception(pvz.ID, s.at(2*time.Hour))
+
+	deadlines := []models.StorageDeadline{
+		{City: models.CityTypeSPB, Type: models.ProductTypeElectronics, ReceivedBy: s.at(time.Minute)},
+		{City: models.CityTypeSPB, Type: models.ProductTypeShoes, ReceivedBy: s.at(time.Minute)},
+	}
+	returnedAt := s.at(24 * time.Hour)
+
+	shipment, city, err := s.returnToSender(pvz.ID, returnedAt, deadlines)
+	s.Require().NoError(err)
+	s.Equal(models.CityTypeSPB, city)
+	s.Equal(pvz.ID, shipment.PVZID)
+	s.Equal(models.ShipmentKindReturnToSender, shipment.Kind)
+	s.equalTime(returnedAt, shipment.DateTime)
+
+	// Товары возвращаются в порядке приемки
+	s.Equal([]uuid.UUID{first.ID, second.ID}, productIDs(shipment.Products))
+
+	for _, product := range shipment.Products {
+		s.Equal(models.ProductStatusReturnedToSender, product.Status)
+		s.Equal(reception.ID, product.ReceptionID)
+		s.Require().NotNil(product.ShipmentID)
+		s.Equal(shipment.ID, *product.ShipmentID)
+		s.Require().NotNil(product.ReturnedAt)
+		s.equalTime(returnedAt, *product.ReturnedAt)
+	}
+
+	listed := s.listedProducts(pvz.ID)
+	s.Equal(models.ProductStatusReturnedToSender, listed[first.ID].Status)
+	s.Require().NotNil(listed[first.ID].ShipmentID)
+	s.Equal(shipment.ID, *listed[first.ID].ShipmentID)
+	s.Equal(models.ProductStatusStored, listed[kept.ID].Status)
+	s.Nil(listed[kept.ID].ShipmentID)
+	s.Nil(listed[kept.ID].ReturnedAt)
+
+	// Возвращенный товар нельзя выдать, и он больше не считается истекающим
+	_, _, err = s.issue(pvz.ID, s.at(25*time.Hour), first.ID)
+	s.ErrorIs(err, domainerrors.ErrProductNotStored)
+
+	products, _, err := s.repos.Product.ListExpiring(s.ctx, pvz.ID, deadlines)
+	s.Require().NoError(err)
+	s.Empty(products)
+
+	_, _, err = s.returnToSender(pvz.ID, s.at(25*time.Hour), deadlines)
+	s.ErrorIs(err, domainerrors.ErrNoExpiredProducts)
+
+	_, _, err = s.returnToSender(uuid.New(), returnedAt, deadlines)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestCountExpired() {
+	moscow := s.createPVZ(s.now)
+	s.openReception(moscow.ID, s.now)
+	s.addTypedProduct(moscow.ID, models.ProductTypeElectronics, s.at(time.Second))
+	s.addTypedProduct(moscow.ID, models.ProductTypeElectronics, s.at(2*time.Second))
+	s.addTypedProduct(moscow.ID, models.ProductTypeClothes, s.at(3*time.Second))
+	issued := s.addTypedProduct(moscow.ID, models.ProductTypeElectronics, s.at(4*time.Second))
+	s.closeReception(moscow.ID, s.at(time.Minute))
+
+	_, _, err := s.issue(moscow.ID, s.at(2*time.Minute), issued.ID)
+	s.Require().NoError(err)
+
+	kazan := s.createPVZInCity(models.CityTypeKazan, s.now)
+	s.openReception(kazan.ID, s.now)
+	s.addTypedProduct(kazan.ID, models.ProductTypeElectronics, s.at(time.Second))
+	s.addTypedProduct(kazan.ID, models.ProductTypeShoes, s.at(time.Second))
+	s.closeReception(kazan.ID, s.at(time.Minute))
+
+	// В незакрытой приемке товары не считаются
+	spb := s.createPVZInCity(models.CityTypeSPB, s.now)
+	s.openReception(spb.ID, s.now)
+	s.addTypedProduct(spb.ID, models.ProductTypeElectronics, s.at(time.Second))
+
+	counts, err := s.repos.Product.CountExpired(s.ctx, []models.StorageDeadline{
+		{City: models.CityTypeMoscow, Type: models.ProductTypeElectronics, ReceivedBy: s.at(time.Hour)},
+		{City: models.CityTypeMoscow, Type: models.ProductTypeClothes, ReceivedBy: s.now},
+		{City: models.CityTypeKazan, Type: models.ProductTypeElectronics, ReceivedBy: s.at(time.Second)},
+		{City: models.CityTypeKazan, Type: models.ProductTypeShoes, ReceivedBy: s.now},
+		{City: models.CityTypeSPB, Type: models.ProductTypeElectronics, ReceivedBy: s.at(time.Hour)},
+	})
+	s.Require().NoError(err)
+	s.Equal(map[models.CityType]map[models.ProductType]int{
+		models.CityTypeMoscow: {models.ProductTypeElectronics: 2},
+		models.CityTypeKazan:  {models.ProductTypeElectronics: 1},
+	}, counts)
+}
@@ -0,0 +1,186 @@
+// Пакет repotest содержит общий набор тестов контракта репозиториев.
+// Набор запускается для каждой реализации (PostgreSQL, память) и проверяет,
+// что они одинаково ведут себя с точки зрения сервисов: возвращают одни и те же ошибки,
+// одинаково сортируют и разбивают на страницы результаты и одинаково меняют версии ресурсов.
+package repotest
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/stretchr/testify/suite"
+)
+
+// Repositories - проверяемые репозитории одного хранилища.
+type Repositories struct {
+	PVZ        repositories.IPVZRepo
+	Reception  repositories.IReceptionRepo
+	Product    repositories.IProductRepo
+	User       repositories.IUserRepo
+	PickupCode repositories.IPickupCodeRepo
+	Return     repositories.IReturnRepo
+	Manifest   repositories.IManifestRepo
+}
+
+// Factory возвращает репозитории над пустым хранилищем. Вызывается перед каждым тестом.
+type Factory func(t *testing.T) Repositories
+
+// ContractSuite - набор тестов контракта репозиториев.
+type ContractSuite struct {
+	suite.Suite
+	factory Factory
+	ctx     context.Context
+	repos   Repositories
+	now     time.Time
+}
+
+// Run запускает набор тестов контракта для репозиториев, создаваемых factory.
+func Run(t *testing.T, factory Factory) {
+	suite.Run(t, &ContractSuite{factory: factory})
+}
+
+func (s *ContractSuite) SetupTest() {
+	s.ctx = context.Background()
+	s.repos = s.factory(s.T())
+	// PostgreSQL хранит время с точностью до микросекунд
+	s.now = time.Now().UTC().Truncate(time.Microsecond)
+}
+
+// at возвращает момент времени, смещенный от начала теста на offset.
+func (s *ContractSuite) at(offset time.Duration) time.Time {
+	return s.now.Add(offset)
+}
+
+// equalTime проверяет, что моменты времени совпадают, не сравнивая часовые пояса.
+func (s *ContractSuite) equalTime(expected, actual time.Time) {
+	s.T().Helper()
+	s.True(expected.Equal(actual), "expected %s, got %s", expected, actual)
+}
+
+// createPVZ создает ПВЗ в Москве, зарегистрированный в момент registeredAt.
+func (s *ContractSuite) createPVZ(registeredAt time.Time) *models.PVZ {
+	s.T().Helper()
+
+	return s.createPVZInCity(models.CityTypeMoscow, registeredAt)
+}
+
+// createPVZInCity создает ПВЗ в городе city, зарегистрированный в момент registeredAt.
+func (s *ContractSuite) createPVZInCity(city models.CityType, registeredAt time.Time) *models.PVZ {
+	s.T().Helper()
+
+	pvz := &models.PVZ{
+		ID:               uuid.New(),
+		RegistrationDate: registeredAt,
+		City:             city,
+	}
+	s.Require().NoError(s.repos.PVZ.Create(s.ctx, pvz))
+
+	return pvz
+}
+
+// openReception открывает приемку в ПВЗ в момент openedAt.
+func (s *ContractSuite) openReception(pvzID uuid.UUID, openedAt time.Time) *models.Reception {
+	s.T().Helper()
+
+	reception := &models.Reception{
+		ID:       uuid.New(),
+		DateTime: openedAt,
+		PVZID:    pvzID,
+		Status:   models.ReceptionStatusInProgress,
+		OpenedBy: uuid.New(),
+	}
+	s.Require().NoError(s.repos.Reception.CreateIfNoOpen(s.ctx, reception, models.AnyVersion))
+
+	return reception
+}
+
+// closeReception закрывает открытую приемку ПВЗ в момент closedAt.
+func (s *ContractSuite) closeReception(pvzID uuid.UUID, closedAt time.Time) *models.Reception {
+	s.T().Helper()
+
+	reception, _, err := s.repos.Reception.CloseLast(s.ctx, pvzID, uuid.New(), closedAt, models.AnyVersion)
+	s.Require().NoError(err)
+
+	return reception
+}
+
+// attachManifest привязывает манифест с позициями items к открытой приемке ПВЗ.
+func (s *ContractSuite) attachManifest(pvzID uuid.UUID, items ...models.ManifestItem) *models.Manifest {
+	s.T().Helper()
+
+	manifest := &models.Manifest{ID: uuid.New(), PVZID: pvzID, CreatedBy: uuid.New(), CreatedAt: s.now, Items: items}
+	s.Require().NoError(s.repos.Manifest.Save(s.ctx, manifest, models.AnyVersion))
+
+	return manifest
+}
+
+// addProduct добавляет товар отдельным заказом в открытую приемку ПВЗ в момент addedAt.
+func (s *ContractSuite) addProduct(pvzID uuid.UUID, addedAt time.Time) *models.Product {
+	s.T().Helper()
+
+	return s.addOrderProduct(pvzID, uuid.Nil, addedAt)
+}
+
+// addOrderProduct добавляет товар заказа orderID в открытую приемку ПВЗ в момент addedAt.
+// Если orderID равен uuid.Nil, товар считается отдельным заказом с айди товара.
+func (s *ContractSuite) addOrderProduct(pvzID, orderID uuid.UUID, addedAt time.Time) *models.Product {
+	s.T().Helper()
+
+	id := uuid.New()
+	if orderID == uuid.Nil {
+		orderID = id
+	}
+
+	product, err := s.repos.Product.Create(s.ctx, &models.AddProduct{
+		ID:       id,
+		DateTime: addedAt,
+		Type:     models.ProductTypeElectronics,
+		PVZID:    pvzID,
+		OrderID:  orderID,
+	}, models.AnyVersion)
+	s.Require().NoError(err)
+
+	return product
+}
+
+// pvzVersion возвращает текущую версию ПВЗ.
+func (s *ContractSuite) pvzVersion(pvzID uuid.UUID) int {
+	s.T().Helper()
+
+	pvzs, err := s.repos.PVZ.GetAll(s.ctx)
+	s.Require().NoError(err)
+
+	for _, pvz := range pvzs {
+		if pvz.ID == pvzID {
+			return pvz.Version
+		}
+	}
+
+	s.FailNow("PVZ not found", pvzID.String())
+
+	return 0
+}
+
+// lastReception возвращает последнюю приемку ПВЗ.
+func (s *ContractSuite) lastReception(pvzID uuid.UUID) *models.Reception {
+	s.T().Helper()
+
+	reception, err := s.repos.Reception.GetLast(s.ctx, pvzID)
+	s.Require().NoError(err)
+
+	return reception
+}
+
+// pvzIDs возвращает айди ПВЗ из результата List в порядке следования.
+func pvzIDs(pvzs []*models.PVZWithReceptions) []uuid.UUID {
+	ids := make([]uuid.UUID, 0, len(pvzs))
+	for _, pvz := range pvzs {
+		ids = append(ids, pvz.PVZ.ID)
+	}
+
+	return ids
+}
@@ -0,0 +1,38 @@
+package repotest
+
+import (
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+)
+
+func (s *ContractSuite) TestUser_CreateAndGetByEmail() {
+	user := &models.User{ID: uuid.New(), Email: "employee@example.com", PasswordHash: "hash", Role: models.RoleEmployee}
+	s.Require().NoError(s.repos.User.Create(s.ctx, user))
+
+	found, err := s.repos.User.GetByEmail(s.ctx, user.Email)
+	s.Require().NoError(err)
+	s.Equal(user, found)
+
+	_, err = s.repos.User.GetByEmail(s.ctx, "unknown@example.com")
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
+
+func (s *ContractSuite) TestUser_Create_DuplicateEmail() {
+	user := &models.User{ID: uuid.New(), Email: "moderator@example.com", Role: models.RoleModerator}
+	s.Require().NoError(s.repos.User.Create(s.ctx, user))
+
+	duplicate := &models.User{ID: uuid.New(), Email: user.Email, Role: models.RoleEmployee}
+	s.ErrorIs(s.repos.User.Create(s.ctx, duplicate), databaseerrors.ErrUniqueViolation)
+}
+
+func (s *ContractSuite) TestUser_Create_DuplicateID() {
+	user := &models.User{ID: uuid.New(), Email: "first@example.com", Role: models.RoleEmployee}
+	s.Require().NoError(s.repos.User.Create(s.ctx, user))
+
+	duplicate := &models.User{ID: user.ID, Email: "second@example.com", Role: models.RoleEmployee}
+	s.ErrorIs(s.repos.User.Create(s.ctx, duplicate), databaseerrors.ErrUniqueViolation)
+
+	_, err := s.repos.User.GetByEmail(s.ctx, duplicate.Email)
+	s.ErrorIs(err, databaseerrors.ErrNoRows)
+}
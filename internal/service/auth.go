@@ -4,6 +4,7 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 
 	"github.com/google/uuid"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
@@ -61,7 +62,7 @@ func (a *authServiceImpl) RegisterUser(ctx context.Context, email, password stri
 
 	passwordHash, err := auth.HashPassword(password)
 	if err != nil {
-		a.logger.Error("failed to hash password", zap.Error(err)) // сам пароль логировать нельзя
+		l.FromContext(ctx, a.logger).Error("failed to hash password", zap.Error(err)) // сам пароль логировать нельзя
 		return nil, fmt.Errorf("%w: %v", domainerrors.ErrUnexpected, err)
 	}
 
@@ -77,7 +78,7 @@ func (a *authServiceImpl) RegisterUser(ctx context.Context, email, password stri
 	if err != nil {
 		switch {
 		case errors.Is(err, databaseerrors.ErrUniqueViolation):
-			a.logger.Debug("user already exists", zap.String("email", email), zap.Error(err)) // Это ошибка на стороне пользователя, поэтому логируем ее с уровнем Debug
+			l.FromContext(ctx, a.logger).Debug("user already exists", zap.String("email", email), zap.Error(err)) // Это ошибка на стороне пользователя, поэтому логируем ее с уровнем Debug
 			return nil, domainerrors.ErrUserExists
 		case errors.Is(err, databaseerrors.ErrUnexpected):
 			return nil, fmt.Errorf("%w: %v", domainerrors.ErrUnexpected, err)
@@ -104,7 +105,7 @@ func (a *authServiceImpl) AuthenticateUser(ctx context.Context, email, password
 	if err != nil {
 		switch {
 		case errors.Is(err, databaseerrors.ErrNoRows):
-			a.logger.Debug("user does not exist", zap.String("email", email))
+			l.FromContext(ctx, a.logger).Debug("user does not exist", zap.String("email", email))
 			return "", domainerrors.ErrUserNotFound
 		case errors.Is(err, databaseerrors.ErrUnexpected):
 			return "", domainerrors.ErrUnexpected
@@ -114,18 +115,18 @@ func (a *authServiceImpl) AuthenticateUser(ctx context.Context, email, password
 	}
 
 	if !auth.ComparePassword(password, user.PasswordHash) {
-		a.logger.Debug("invalid password", zap.String("email", email))
+		l.FromContext(ctx, a.logger).Debug("invalid password", zap.String("email", email))
 		return "", domainerrors.ErrInvalidCredentials
 	}
 
 	token, err := a.tokenManager.Generate(user.ID, user.Role.String())
 
 	if err != nil {
-		a.logger.Error("failed to generate token", zap.Error(err))
+		l.FromContext(ctx, a.logger).Error("failed to generate token", zap.Error(err))
 		return "", fmt.Errorf("%w: %v", domainerrors.ErrUnexpected, err)
 	}
 
-	a.logger.Debug("successfully authenticated user", zap.String("email", email))
+	l.FromContext(ctx, a.logger).Debug("successfully authenticated user", zap.String("email", email))
 
 	return models.Token(token), nil
 }
@@ -135,7 +136,7 @@ func (a *authServiceImpl) AuthenticateUser(ctx context.Context, email, password
 func (a *authServiceImpl) DummyLogin(ctx context.Context, role string) (models.Token, error) {
 	roleType := models.RoleType(role)
 	if !roleType.Valid() {
-		a.logger.Debug("invalid role", zap.String("role", role))
+		l.FromContext(ctx, a.logger).Debug("invalid role", zap.String("role", role))
 		return "", domainerrors.ErrInvalidRole
 	}
 
@@ -143,7 +144,7 @@ func (a *authServiceImpl) DummyLogin(ctx context.Context, role string) (models.T
 
 	token, err := a.tokenManager.Generate(userID, role)
 	if err != nil {
-		a.logger.Error("failed to generate token", zap.Error(err))
+		l.FromContext(ctx, a.logger).Error("failed to generate token", zap.Error(err))
 		return "", fmt.Errorf("%w: %v", domainerrors.ErrUnexpected, err)
 	}
 
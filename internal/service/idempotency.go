@@ -0,0 +1,154 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// IdempotencyService - интерфейс для обработки запросов с ключом идемпотентности.
+type IdempotencyService interface {
+	Begin(ctx context.Context, userID uuid.UUID, key, method, path string, body []byte) (*models.IdempotencyRecord, error)                        // Резервирует ключ или возвращает сохраненный ответ для повтора.
+	Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, headers map[string]string, body []byte) error // Сохраняет ответ на запрос вместе с заголовками для повтора.
+	Release(ctx context.Context, userID uuid.UUID, key string) error                                                                              // Освобождает ключ, если ответ сохранять не нужно.
+	CleanupExpired(ctx context.Context) (int, error)                                                                                              // Удаляет истекшие ключи всех пользователей и возвращает их количество.
+}
+
+// Значения по умолчанию, если они не заданы в конфиге.
+const (
+	defaultIdempotencyTTL         = 24 * time.Hour
+	defaultIdempotencyLockTimeout = time.Minute
+)
+
+// idempotencyServiceImpl реализует интерфейс IdempotencyService.
+type idempotencyServiceImpl struct {
+	logger      *zap.Logger
+	repo        repositories.IIdempotencyRepo
+	ttl         time.Duration
+	lockTimeout time.Duration
+}
+
+// NewIdempotencyService - конструктор для создания нового экземпляра IdempotencyService.
+// Принимает логгер, репозиторий ключей идемпотентности и конфиг идемпотентности.
+func NewIdempotencyService(logger *zap.Logger, repo repositories.IIdempotencyRepo, cfg config.IdempotencyConfig) IdempotencyService {
+	ttl := time.Duration(cfg.TTLSeconds) * time.Second
+	if ttl <= 0 {
+		ttl = defaultIdempotencyTTL
+	}
+
+	lockTimeout := time.Duration(cfg.LockTimeoutSeconds) * time.Second
+	if lockTimeout <= 0 {
+		lockTimeout = defaultIdempotencyLockTimeout
+	}
+
+	return &idempotencyServiceImpl{
+		logger:      logger,
+		repo:        repo,
+		ttl:         ttl,
+		lockTimeout: lockTimeout,
+	}
+}
+
+// fingerprint возвращает хеш метода, пути и тела запроса.
+func fingerprint(method, path string, body []byte) string {
+	hash := sha256.New()
+	hash.Write([]byte(method))
+	hash.Write([]byte{0})
+	hash.Write([]byte(path))
+	hash.Write([]byte{0})
+	hash.Write(body)
+
+	return hex.EncodeToString(hash.Sum(nil))
+}
+
+// Begin резервирует ключ идемпотентности пользователя для запроса.
+// Если ключ зарезервирован, возвращает nil - запрос нужно обработать и затем вызвать Complete или Release.
+// Если на такой же запрос с этим ключом уже сохранен ответ, возвращает его для повтора.
+// Возвращает domainerrors.ErrIdempotencyKeyReused, если ключ использован с другим запросом,
+// и domainerrors.ErrIdempotencyRequestInProgress, если запрос с этим ключом еще обрабатывается.
+func (s *idempotencyServiceImpl) Begin(ctx context.Context, userID uuid.UUID, key, method, path string, body []byte) (*models.IdempotencyRecord, error) {
+	if !models.ValidIdempotencyKey(key) {
+		return nil, domainerrors.ErrInvalidIdempotencyKey
+	}
+
+	now := time.Now()
+	record := &models.IdempotencyRecord{
+		UserID:      userID,
+		Key:         key,
+		Fingerprint: fingerprint(method, path, body),
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(s.ttl),
+	}
+
+	existing, reserved, err := s.repo.Reserve(ctx, record, now.Add(-s.lockTimeout))
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrNoRows) {
+			return nil, domainerrors.ErrIdempotencyRequestInProgress
+		}
+
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	if reserved {
+		return nil, nil
+	}
+
+	if existing.Fingerprint != record.Fingerprint {
+		l.FromContext(ctx, s.logger).Debug("Idempotency key reused with different request", zap.String("key", key))
+		return nil, domainerrors.ErrIdempotencyKeyReused
+	}
+
+	if !existing.Completed() {
+		return nil, domainerrors.ErrIdempotencyRequestInProgress
+	}
+
+	return existing, nil
+}
+
+// Complete сохраняет ответ на запрос с ключом идемпотентности.
+// headers - заголовки ответа, которые нужно отдать при повторе вместе с телом.
+func (s *idempotencyServiceImpl) Complete(ctx context.Context, userID uuid.UUID, key string, statusCode int, contentType string, headers map[string]string, body []byte) error {
+	if err := s.repo.Complete(ctx, userID, key, statusCode, contentType, headers, body); err != nil {
+		if errors.Is(err, databaseerrors.ErrNoRows) {
+			// Запись была заменена после истечения таймаута блокировки
+			l.FromContext(ctx, s.logger).Warn("Idempotency key was taken over before completion", zap.String("key", key))
+			return nil
+		}
+
+		return domainerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// Release удаляет незавершенную запись, чтобы запрос с этим ключом можно было повторить.
+func (s *idempotencyServiceImpl) Release(ctx context.Context, userID uuid.UUID, key string) error {
+	if err := s.repo.Release(ctx, userID, key); err != nil {
+		return domainerrors.ErrUnexpected
+	}
+
+	return nil
+}
+
+// CleanupExpired удаляет истекшие ключи идемпотентности всех пользователей.
+// Вызывается фоновой задачей, так как Begin заменяет только истекшую запись с тем же ключом.
+// Возвращает количество удаленных ключей.
+func (s *idempotencyServiceImpl) CleanupExpired(ctx context.Context) (int, error) {
+	deleted, err := s.repo.DeleteExpired(ctx, time.Now())
+	if err != nil {
+		return 0, domainerrors.ErrUnexpected
+	}
+
+	return deleted, nil
+}
@@ -0,0 +1,188 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestIdempotencyBegin(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIIdempotencyRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewIdempotencyService(logger, mockRepo, config.IdempotencyConfig{TTLSeconds: 3600, LockTimeoutSeconds: 60})
+
+	userID := uuid.New()
+	body := []byte(`{"type":"обувь"}`)
+
+	// reservedFingerprint сохраняет отпечаток запроса, с которым был зарезервирован ключ
+	var reservedFingerprint string
+
+	t.Run("Key reserved", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, record *models.IdempotencyRecord, staleBefore time.Time) (*models.IdempotencyRecord, bool, error) {
+				assert.Equal(t, userID, record.UserID)
+				assert.Equal(t, "key", record.Key)
+				assert.Equal(t, time.Hour, record.ExpiresAt.Sub(record.CreatedAt))
+				assert.Equal(t, time.Minute, record.CreatedAt.Sub(staleBefore))
+
+				reservedFingerprint = record.Fingerprint
+
+				return nil, true, nil
+			})
+
+		record, err := svc.Begin(context.Background(), userID, "key", "POST", "/products", body)
+
+		assert.NoError(t, err)
+		assert.Nil(t, record)
+	})
+
+	t.Run("Completed request replayed", func(t *testing.T) {
+		existing := &models.IdempotencyRecord{Fingerprint: reservedFingerprint, StatusCode: 201, ResponseBody: []byte("{}")}
+
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(existing, false, nil)
+
+		record, err := svc.Begin(context.Background(), userID, "key", "POST", "/products", body)
+
+		assert.NoError(t, err)
+		assert.Equal(t, existing, record)
+	})
+
+	t.Run("Key reused with different body", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&models.IdempotencyRecord{Fingerprint: reservedFingerprint, StatusCode: 201}, false, nil)
+
+		_, err := svc.Begin(context.Background(), userID, "key", "POST", "/products", []byte(`{"type":"одежда"}`))
+		assert.ErrorIs(t, err, domainerrors.ErrIdempotencyKeyReused)
+	})
+
+	t.Run("Key reused with different path", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&models.IdempotencyRecord{Fingerprint: reservedFingerprint, StatusCode: 201}, false, nil)
+
+		_, err := svc.Begin(context.Background(), userID, "key", "POST", "/receptions", body)
+		assert.ErrorIs(t, err, domainerrors.ErrIdempotencyKeyReused)
+	})
+
+	t.Run("Request in progress", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(&models.IdempotencyRecord{Fingerprint: reservedFingerprint}, false, nil)
+
+		_, err := svc.Begin(context.Background(), userID, "key", "POST", "/products", body)
+		assert.ErrorIs(t, err, domainerrors.ErrIdempotencyRequestInProgress)
+	})
+
+	t.Run("Record released concurrently", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, false, databaseerrors.ErrNoRows)
+
+		_, err := svc.Begin(context.Background(), userID, "key", "POST", "/products", body)
+		assert.ErrorIs(t, err, domainerrors.ErrIdempotencyRequestInProgress)
+	})
+
+	t.Run("Invalid key", func(t *testing.T) {
+		_, err := svc.Begin(context.Background(), userID, string(make([]byte, models.IdempotencyKeyMaxLength+1)), "POST", "/products", body)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidIdempotencyKey)
+	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Reserve(gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, false, databaseerrors.ErrUnexpected)
+
+		_, err := svc.Begin(context.Background(), userID, "key", "POST", "/products", body)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestIdempotencyComplete(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIIdempotencyRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewIdempotencyService(logger, mockRepo, config.IdempotencyConfig{})
+
+	userID := uuid.New()
+
+	t.Run("Successful complete", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Complete(gomock.Any(), userID, "key", 201, "application/json", map[string]string{"ETag": `"1"`}, []byte("{}")).
+			Return(nil)
+
+		assert.NoError(t, svc.Complete(context.Background(), userID, "key", 201, "application/json", map[string]string{"ETag": `"1"`}, []byte("{}")))
+	})
+
+	t.Run("Record taken over", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Complete(gomock.Any(), userID, "key", 201, "application/json", gomock.Any(), gomock.Any()).
+			Return(databaseerrors.ErrNoRows)
+
+		assert.NoError(t, svc.Complete(context.Background(), userID, "key", 201, "application/json", nil, nil))
+	})
+
+	t.Run("Release unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Release(gomock.Any(), userID, "key").
+			Return(databaseerrors.ErrUnexpected)
+
+		assert.ErrorIs(t, svc.Release(context.Background(), userID, "key"), domainerrors.ErrUnexpected)
+	})
+}
+
+func TestIdempotencyCleanupExpired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIIdempotencyRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewIdempotencyService(logger, mockRepo, config.IdempotencyConfig{})
+
+	t.Run("Successful cleanup", func(t *testing.T) {
+		before := time.Now()
+
+		mockRepo.EXPECT().
+			DeleteExpired(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, now time.Time) (int, error) {
+				assert.False(t, now.Before(before))
+				return 3, nil
+			})
+
+		deleted, err := svc.CleanupExpired(context.Background())
+
+		assert.NoError(t, err)
+		assert.Equal(t, 3, deleted)
+	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().
+			DeleteExpired(gomock.Any(), gomock.Any()).
+			Return(0, databaseerrors.ErrUnexpected)
+
+		_, err := svc.CleanupExpired(context.Background())
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
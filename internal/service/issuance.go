@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// IssuanceService - интерфейс для бизнес-логики выдачи товаров клиентам.
+type IssuanceService interface {
+	IssueProducts(ctx context.Context, userID uuid.UUID, userRole string, pvzID, orderID uuid.UUID, code string, productIDs []uuid.UUID) (*models.Issuance, error) // Выдает клиенту товары заказа, хранящиеся в указанном ПВЗ, по коду получения
+}
+
+// defaultIssueMaxItems - максимальное количество товаров в одной выдаче, если оно не задано в конфиге.
+const defaultIssueMaxItems = 50
+
+// issuanceServiceImpl реализует интерфейс IssuanceService.
+type issuanceServiceImpl struct {
+	logger        *zap.Logger
+	productRepo   repositories.IProductRepo
+	codeVerifier  *pickupCodeVerifier
+	issueMaxItems int
+}
+
+// NewIssuanceService - конструктор для создания нового экземпляра IssuanceService.
+// Принимает логгер, репозиторий товаров, репозиторий кодов получения, конфиг товаров и конфиг кодов получения.
+func NewIssuanceService(logger *zap.Logger, productRepo repositories.IProductRepo, pickupRepo repositories.IPickupCodeRepo, cfg config.ProductConfig, pickupCfg config.PickupConfig) IssuanceService {
+	issueMaxItems := cfg.IssueMaxItems
+	if issueMaxItems <= 0 {
+		issueMaxItems = defaultIssueMaxItems
+	}
+
+	return &issuanceServiceImpl{
+		logger:        logger,
+		productRepo:   productRepo,
+		codeVerifier:  newPickupCodeVerifier(logger, pickupRepo, pickupCfg),
+		issueMaxItems: issueMaxItems,
+	}
+}
+
+// IssueProducts выдает клиенту один или несколько товаров заказа, хранящихся в ПВЗ.
+// Принимает айди и роль пользователя, айди ПВЗ, айди заказа, названный клиентом код получения и айди выдаваемых товаров.
+// Пользователь сохраняется как выдавший товары.
+// Проводит валидацию роли пользователя (только models.RoleEmployee может выдавать товары)
+// и количества товаров, айди товаров не должны повторяться.
+// Код проверяется так же, как в PickupService.VerifyCode, и расходуется в одной транзакции с выдачей
+// последних хранящихся в ПВЗ товаров заказа.
+// Выдача атомарна: если хотя бы один товар нельзя выдать, не выдается ни один и код не расходуется.
+// Выданные товары получают статус models.ProductStatusIssued и больше не могут быть удалены.
+// Возвращает выдачу с товарами или ошибку.
+func (s *issuanceServiceImpl) IssueProducts(ctx context.Context, userID uuid.UUID, userRole string, pvzID, orderID uuid.UUID, code string, productIDs []uuid.UUID) (*models.Issuance, error) {
+	if models.RoleType(userRole) != models.RoleEmployee {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	if len(productIDs) == 0 || len(productIDs) > s.issueMaxItems {
+		return nil, fmt.Errorf("%w: expected 1 to %d products, got %d", domainerrors.ErrInvalidIssuanceSize, s.issueMaxItems, len(productIDs))
+	}
+
+	seen := make(map[uuid.UUID]struct{}, len(productIDs))
+
+	for _, id := range productIDs {
+		if _, ok := seen[id]; ok {
+			return nil, fmt.Errorf("%w: %s", domainerrors.ErrDuplicateProductID, id)
+		}
+
+		seen[id] = struct{}{}
+	}
+
+	pickupCode, err := s.codeVerifier.verify(ctx, pvzID, orderID, code)
+	if err != nil {
+		return nil, err
+	}
+
+	issuance := &models.Issuance{
+		ID:       uuid.New(),
+		PVZID:    pvzID,
+		IssuedBy: userID,
+		DateTime: time.Now(),
+	}
+
+	issued, city, err := s.productRepo.Issue(ctx, issuance, pickupCode.ID, productIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		l.FromContext(ctx, s.logger).Debug("Products can not be issued", zap.String("pvzID", pvzID.String()), zap.Error(err))
+
+		return nil, err
+	}
+
+	for _, product := range issued.Products {
+		metrics.ProductsIssued.WithLabelValues(city.String(), product.Type.String()).Inc()
+	}
+
+	return issued, nil
+}
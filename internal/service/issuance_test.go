@@ -0,0 +1,155 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestIssueProducts(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	svc := service.NewIssuanceService(zap.NewNop(), mockRepo, mockPickupRepo, config.ProductConfig{IssueMaxItems: 2}, config.PickupConfig{MaxAttempts: 3})
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	orderID := uuid.New()
+	productIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	hash, err := auth.HashPassword("123456")
+	require.NoError(t, err)
+
+	// expectVerified ожидает успешную проверку кода получения и возвращает проверенный код
+	expectVerified := func() *models.PickupCode {
+		code := &models.PickupCode{
+			ID:        uuid.New(),
+			PVZID:     pvzID,
+			OrderID:   orderID,
+			CodeHash:  hash,
+			CreatedAt: time.Now().Add(-time.Minute),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+
+		mockPickupRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockPickupRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+		mockPickupRepo.EXPECT().ReserveAttempt(gomock.Any(), code.ID, 3).Return(nil)
+		mockPickupRepo.EXPECT().ReleaseAttempt(gomock.Any(), code.ID).Return(nil)
+
+		return code
+	}
+
+	t.Run("Successful issue", func(t *testing.T) {
+		counter := metrics.ProductsIssued.WithLabelValues(models.CityTypeKazan.String(), models.ProductTypeShoes.String())
+		issuedBefore := testutil.ToFloat64(counter)
+
+		code := expectVerified()
+
+		mockRepo.
+			EXPECT().
+			Issue(gomock.Any(), gomock.Any(), code.ID, productIDs).
+			DoAndReturn(func(_ context.Context, issuance *models.Issuance, _ uuid.UUID, ids []uuid.UUID) (*models.Issuance, models.CityType, error) {
+				assert.NotEqual(t, uuid.Nil, issuance.ID)
+				assert.Equal(t, pvzID, issuance.PVZID)
+				assert.Equal(t, userID, issuance.IssuedBy)
+				assert.False(t, issuance.DateTime.IsZero())
+
+				result := *issuance
+				for _, id := range ids {
+					result.Products = append(result.Products, &models.Product{ID: id, Type: models.ProductTypeShoes, Status: models.ProductStatusIssued})
+				}
+
+				return &result, models.CityTypeKazan, nil
+			})
+
+		issuance, err := svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "123456", productIDs)
+		require.NoError(t, err)
+		assert.Len(t, issuance.Products, 2)
+		assert.Equal(t, issuedBefore+2, testutil.ToFloat64(counter))
+	})
+
+	// Только сотрудник может выдавать товары
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.IssueProducts(context.Background(), userID, models.RoleModerator.String(), pvzID, orderID, "123456", productIDs)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Invalid issuance size", func(t *testing.T) {
+		_, err := svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "123456", nil)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidIssuanceSize)
+
+		_, err = svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "123456", []uuid.UUID{uuid.New(), uuid.New(), uuid.New()})
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidIssuanceSize)
+	})
+
+	t.Run("Repeated product id", func(t *testing.T) {
+		_, err := svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "123456", []uuid.UUID{productIDs[0], productIDs[0]})
+		assert.ErrorIs(t, err, domainerrors.ErrDuplicateProductID)
+	})
+
+	// Без верного кода получения товары не выдаются
+	t.Run("Wrong pickup code", func(t *testing.T) {
+		code := &models.PickupCode{ID: uuid.New(), PVZID: pvzID, OrderID: orderID, CodeHash: hash, ExpiresAt: time.Now().Add(time.Hour)}
+
+		mockPickupRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockPickupRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+		mockPickupRepo.EXPECT().ReserveAttempt(gomock.Any(), code.ID, 3).Return(nil)
+		mockPickupRepo.EXPECT().RegisterFailure(gomock.Any(), pvzID, gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "654321", productIDs)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidPickupCode)
+	})
+
+	t.Run("No pickup code", func(t *testing.T) {
+		mockPickupRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockPickupRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(nil, databaseerrors.ErrNoRows)
+		mockPickupRepo.EXPECT().RegisterFailure(gomock.Any(), pvzID, gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "123456", productIDs)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidPickupCode)
+	})
+
+	t.Run("Errors", func(t *testing.T) {
+		tests := []struct {
+			name     string
+			repoErr  error
+			expected error
+		}{
+			{name: "PVZ not found", repoErr: databaseerrors.ErrNoRows, expected: domainerrors.ErrPVZNotFound},
+			{name: "Unexpected error", repoErr: databaseerrors.ErrUnexpected, expected: domainerrors.ErrUnexpected},
+			{name: "Product not found", repoErr: domainerrors.ErrProductNotFound, expected: domainerrors.ErrProductNotFound},
+			{name: "Product already issued", repoErr: domainerrors.ErrProductNotStored, expected: domainerrors.ErrProductNotStored},
+			{name: "Product of another order", repoErr: domainerrors.ErrProductNotInOrder, expected: domainerrors.ErrProductNotInOrder},
+			{name: "Pickup code used concurrently", repoErr: domainerrors.ErrInvalidPickupCode, expected: domainerrors.ErrInvalidPickupCode},
+		}
+
+		for _, tt := range tests {
+			t.Run(tt.name, func(t *testing.T) {
+				code := expectVerified()
+				mockRepo.EXPECT().Issue(gomock.Any(), gomock.Any(), code.ID, productIDs).Return(nil, models.CityType(""), tt.repoErr)
+
+				_, err := svc.IssueProducts(context.Background(), userID, models.RoleEmployee.String(), pvzID, orderID, "123456", productIDs)
+				assert.ErrorIs(t, err, tt.expected)
+			})
+		}
+	})
+}
@@ -0,0 +1,278 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// PickupService - интерфейс для работы с кодами получения заказов.
+type PickupService interface {
+	VerifyCode(ctx context.Context, userRole string, pvzID, orderID uuid.UUID, code string) (*models.PickupOrder, error) // Проверяет код получения и возвращает товары заказа для выдачи.
+	ReissueCode(ctx context.Context, userRole string, pvzID, orderID uuid.UUID) (*models.PickupCode, error)              // Выпускает новый код получения заказа взамен неиспользованного.
+}
+
+// Значения по умолчанию для параметров кодов получения, не заданных в конфиге.
+const (
+	defaultPickupCodeTTL          = 168 * time.Hour
+	defaultPickupMaxAttempts      = 5
+	defaultPickupPVZMaxFailures   = 20
+	defaultPickupPVZFailureWindow = 10 * time.Minute
+)
+
+// pickupCodeDigits - количество цифр в коде получения.
+const pickupCodeDigits = 6
+
+// Результаты проверки кода получения для метрики metrics.PickupCodeVerifications.
+const (
+	pickupResultSuccess   = "success"
+	pickupResultInvalid   = "invalid"
+	pickupResultExpired   = "expired"
+	pickupResultLocked    = "locked"
+	pickupResultThrottled = "throttled"
+)
+
+// pickupCodeTTL возвращает срок действия кодов получения из конфига или значение по умолчанию.
+func pickupCodeTTL(cfg config.PickupConfig) time.Duration {
+	if cfg.CodeTTLHours <= 0 {
+		return defaultPickupCodeTTL
+	}
+
+	return time.Duration(cfg.CodeTTLHours) * time.Hour
+}
+
+// generatePickupCode создает код получения заказа в ПВЗ со сроком действия ttl.
+// Код из pickupCodeDigits цифр генерируется криптографически стойким генератором
+// и хэшируется так же, как пароли пользователей (см. auth.HashPassword).
+// Открытый код возвращается в поле Code и не сохраняется.
+func generatePickupCode(pvzID, orderID uuid.UUID, ttl time.Duration, now time.Time) (*models.PickupCode, error) {
+	limit := new(big.Int).Exp(big.NewInt(10), big.NewInt(pickupCodeDigits), nil)
+
+	n, err := rand.Int(rand.Reader, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to generate pickup code: %w", err)
+	}
+
+	code := fmt.Sprintf("%0*d", pickupCodeDigits, n)
+
+	hash, err := auth.HashPassword(code)
+	if err != nil {
+		return nil, err
+	}
+
+	return &models.PickupCode{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		OrderID:   orderID,
+		Code:      code,
+		CodeHash:  hash,
+		CreatedAt: now,
+		ExpiresAt: now.Add(ttl),
+	}, nil
+}
+
+// pickupCodeVerifier проверяет коды получения с учетом лимита попыток кода и ограничения неудачных попыток в ПВЗ.
+// Используется и для проверки кода перед выдачей, и при самой выдаче товаров.
+type pickupCodeVerifier struct {
+	logger           *zap.Logger
+	repo             repositories.IPickupCodeRepo
+	maxAttempts      int
+	pvzMaxFailures   int
+	pvzFailureWindow time.Duration
+}
+
+// newPickupCodeVerifier создает pickupCodeVerifier с параметрами из конфига или значениями по умолчанию.
+func newPickupCodeVerifier(logger *zap.Logger, repo repositories.IPickupCodeRepo, cfg config.PickupConfig) *pickupCodeVerifier {
+	v := &pickupCodeVerifier{
+		logger:           logger,
+		repo:             repo,
+		maxAttempts:      cfg.MaxAttempts,
+		pvzMaxFailures:   cfg.PVZMaxFailures,
+		pvzFailureWindow: time.Duration(cfg.PVZFailureWindowSeconds) * time.Second,
+	}
+
+	if v.maxAttempts <= 0 {
+		v.maxAttempts = defaultPickupMaxAttempts
+	}
+
+	if v.pvzMaxFailures <= 0 {
+		v.pvzMaxFailures = defaultPickupPVZMaxFailures
+	}
+
+	if v.pvzFailureWindow <= 0 {
+		v.pvzFailureWindow = defaultPickupPVZFailureWindow
+	}
+
+	return v
+}
+
+// verify проверяет код получения заказа в ПВЗ и возвращает подошедший неиспользованный код.
+// Если за последнее окно в ПВЗ было слишком много неудачных попыток, код не проверяется (domainerrors.ErrPickupCodeThrottled).
+// Каждая проверка занимает попытку, верный код ее возвращает; после превышения лимита код блокируется (domainerrors.ErrPickupCodeLocked).
+// Код не расходуется: его расходует выдача товаров (см. repositories.IProductRepo.Issue).
+func (v *pickupCodeVerifier) verify(ctx context.Context, pvzID, orderID uuid.UUID, code string) (*models.PickupCode, error) {
+	now := time.Now()
+
+	failures, err := v.repo.CountFailures(ctx, pvzID, now.Add(-v.pvzFailureWindow))
+	if err != nil {
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	if failures >= v.pvzMaxFailures {
+		metrics.PickupCodeVerifications.WithLabelValues(pickupResultThrottled).Inc()
+		l.FromContext(ctx, v.logger).Warn("Pickup code verification throttled", zap.String("pvzID", pvzID.String()), zap.Int("failures", failures))
+
+		return nil, domainerrors.ErrPickupCodeThrottled
+	}
+
+	pickupCode, err := v.repo.GetActive(ctx, pvzID, orderID)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrNoRows) {
+			return nil, v.registerFailure(ctx, pvzID, now)
+		}
+
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	if pickupCode.Attempts >= v.maxAttempts {
+		metrics.PickupCodeVerifications.WithLabelValues(pickupResultLocked).Inc()
+		return nil, domainerrors.ErrPickupCodeLocked
+	}
+
+	if pickupCode.Expired(now) {
+		metrics.PickupCodeVerifications.WithLabelValues(pickupResultExpired).Inc()
+		return nil, domainerrors.ErrPickupCodeExpired
+	}
+
+	// Попытка занимается одним запросом до сравнения кода, поэтому одновременные проверки не превышают лимит
+	if err := v.repo.ReserveAttempt(ctx, pickupCode.ID, v.maxAttempts); err != nil {
+		if errors.Is(err, databaseerrors.ErrNoRows) {
+			metrics.PickupCodeVerifications.WithLabelValues(pickupResultLocked).Inc()
+			return nil, domainerrors.ErrPickupCodeLocked
+		}
+
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	if !auth.ComparePassword(strings.TrimSpace(code), pickupCode.CodeHash) {
+		return nil, v.registerFailure(ctx, pvzID, now)
+	}
+
+	// Верный код не расходует попытку
+	if err := v.repo.ReleaseAttempt(ctx, pickupCode.ID); err != nil {
+		l.FromContext(ctx, v.logger).Warn("Failed to release pickup code attempt", zap.String("codeID", pickupCode.ID.String()), zap.Error(err))
+	}
+
+	metrics.PickupCodeVerifications.WithLabelValues(pickupResultSuccess).Inc()
+
+	return pickupCode, nil
+}
+
+// registerFailure сохраняет неудачную попытку ввода кода и возвращает ошибку, которую нужно вернуть пользователю.
+// Попытки старше окна ограничения удаляются, чтобы таблица попыток не росла бесконечно.
+func (v *pickupCodeVerifier) registerFailure(ctx context.Context, pvzID uuid.UUID, now time.Time) error {
+	metrics.PickupCodeVerifications.WithLabelValues(pickupResultInvalid).Inc()
+
+	if err := v.repo.RegisterFailure(ctx, pvzID, now, now.Add(-v.pvzFailureWindow)); err != nil {
+		// Для неизвестного ПВЗ попытку сохранить нельзя, но пользователь получает тот же ответ
+		l.FromContext(ctx, v.logger).Warn("Failed to register pickup code failure", zap.String("pvzID", pvzID.String()), zap.Error(err))
+	}
+
+	return domainerrors.ErrInvalidPickupCode
+}
+
+// pickupServiceImpl реализует интерфейс PickupService.
+type pickupServiceImpl struct {
+	logger      *zap.Logger
+	repo        repositories.IPickupCodeRepo
+	productRepo repositories.IProductRepo
+	verifier    *pickupCodeVerifier
+	codeTTL     time.Duration
+}
+
+// NewPickupService - конструктор для создания нового экземпляра PickupService.
+// Принимает логгер, репозиторий кодов получения, репозиторий товаров и конфиг кодов получения.
+func NewPickupService(logger *zap.Logger, repo repositories.IPickupCodeRepo, productRepo repositories.IProductRepo, cfg config.PickupConfig) PickupService {
+	return &pickupServiceImpl{
+		logger:      logger,
+		repo:        repo,
+		productRepo: productRepo,
+		verifier:    newPickupCodeVerifier(logger, repo, cfg),
+		codeTTL:     pickupCodeTTL(cfg),
+	}
+}
+
+// VerifyCode проверяет код получения заказа, который клиент назвал в ПВЗ.
+// Принимает роль пользователя, айди ПВЗ, айди заказа и код.
+// Проводит валидацию роли пользователя (только models.RoleEmployee может проверять коды).
+// Ограничения попыток описаны в pickupCodeVerifier.verify.
+// Проверка не расходует код: его нужно передать при выдаче товаров (см. IssuanceService.IssueProducts).
+// Возвращает заказ с хранящимися в ПВЗ товарами, которые нужно выдать клиенту, или ошибку.
+func (s *pickupServiceImpl) VerifyCode(ctx context.Context, userRole string, pvzID, orderID uuid.UUID, code string) (*models.PickupOrder, error) {
+	if models.RoleType(userRole) != models.RoleEmployee {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	if _, err := s.verifier.verify(ctx, pvzID, orderID, code); err != nil {
+		return nil, err
+	}
+
+	products, err := s.productRepo.ListStoredByOrder(ctx, pvzID, orderID)
+	if err != nil {
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	return &models.PickupOrder{
+		OrderID:  orderID,
+		PVZID:    pvzID,
+		Products: products,
+	}, nil
+}
+
+// ReissueCode выпускает новый код получения заказа, заменяя неиспользованный (например, истекший или заблокированный).
+// Принимает роль пользователя, айди ПВЗ и айди заказа.
+// Проводит валидацию роли пользователя (только models.RoleModerator может выпускать коды).
+// Если в ПВЗ нет хранящихся товаров заказа из закрытых приемок, возвращает domainerrors.ErrOrderNotFound.
+// Возвращает код с заполненным открытым значением или ошибку.
+func (s *pickupServiceImpl) ReissueCode(ctx context.Context, userRole string, pvzID, orderID uuid.UUID) (*models.PickupCode, error) {
+	if models.RoleType(userRole) != models.RoleModerator {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	products, err := s.productRepo.ListStoredByOrder(ctx, pvzID, orderID)
+	if err != nil {
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	if len(products) == 0 {
+		return nil, domainerrors.ErrOrderNotFound
+	}
+
+	code, err := generatePickupCode(pvzID, orderID, s.codeTTL, time.Now())
+	if err != nil {
+		l.FromContext(ctx, s.logger).Error("Failed to generate pickup code", zap.Error(err))
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	if err := s.repo.Save(ctx, code); err != nil {
+		l.FromContext(ctx, s.logger).Error("Failed to save pickup code", zap.String("orderID", orderID.String()), zap.Error(err))
+		return nil, domainerrors.ErrUnexpected
+	}
+
+	return code, nil
+}
@@ -0,0 +1,199 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+func TestVerifyCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	mockProductRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	svc := service.NewPickupService(zap.NewNop(), mockRepo, mockProductRepo, config.PickupConfig{
+		CodeTTLHours:            1,
+		MaxAttempts:             3,
+		PVZMaxFailures:          10,
+		PVZFailureWindowSeconds: 60,
+	})
+
+	pvzID := uuid.New()
+	orderID := uuid.New()
+
+	hash, err := auth.HashPassword("123456")
+	require.NoError(t, err)
+
+	activeCode := func() *models.PickupCode {
+		return &models.PickupCode{
+			ID:        uuid.New(),
+			PVZID:     pvzID,
+			OrderID:   orderID,
+			CodeHash:  hash,
+			CreatedAt: time.Now().Add(-time.Minute),
+			ExpiresAt: time.Now().Add(time.Hour),
+		}
+	}
+
+	t.Run("Successful verification", func(t *testing.T) {
+		code := activeCode()
+		products := []*models.Product{{ID: uuid.New(), OrderID: orderID, Status: models.ProductStatusStored}}
+
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+		mockRepo.EXPECT().ReserveAttempt(gomock.Any(), code.ID, 3).Return(nil)
+		mockRepo.EXPECT().ReleaseAttempt(gomock.Any(), code.ID).Return(nil)
+		mockProductRepo.EXPECT().ListStoredByOrder(gomock.Any(), pvzID, orderID).Return(products, nil)
+
+		order, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, " 123456 ")
+		require.NoError(t, err)
+		assert.Equal(t, orderID, order.OrderID)
+		assert.Equal(t, pvzID, order.PVZID)
+		assert.Equal(t, products, order.Products)
+	})
+
+	// Только сотрудник может проверять коды
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.VerifyCode(context.Background(), models.RoleModerator.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Throttled", func(t *testing.T) {
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(10, nil)
+
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrPickupCodeThrottled)
+	})
+
+	t.Run("Wrong code", func(t *testing.T) {
+		code := activeCode()
+
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(9, nil)
+		mockRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+		mockRepo.EXPECT().ReserveAttempt(gomock.Any(), code.ID, 3).Return(nil)
+		mockRepo.EXPECT().
+			RegisterFailure(gomock.Any(), pvzID, gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, failedAt, prunedBefore time.Time) error {
+				// Удаляются только попытки старше окна ограничения
+				assert.Equal(t, failedAt.Add(-time.Minute), prunedBefore)
+				return nil
+			})
+
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "654321")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidPickupCode)
+	})
+
+	// Попытка подобрать код для заказа без кода тоже учитывается в ограничении ПВЗ
+	t.Run("No active code", func(t *testing.T) {
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(nil, databaseerrors.ErrNoRows)
+		mockRepo.EXPECT().RegisterFailure(gomock.Any(), pvzID, gomock.Any(), gomock.Any()).Return(nil)
+
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidPickupCode)
+	})
+
+	t.Run("Locked code", func(t *testing.T) {
+		code := activeCode()
+		code.Attempts = 3
+
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+
+		// Даже верный код не принимается после превышения количества попыток
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrPickupCodeLocked)
+	})
+
+	// Лимит попыток исчерпан одновременными проверками после чтения кода
+	t.Run("Attempts exhausted concurrently", func(t *testing.T) {
+		code := activeCode()
+		code.Attempts = 2
+
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+		mockRepo.EXPECT().ReserveAttempt(gomock.Any(), code.ID, 3).Return(databaseerrors.ErrNoRows)
+
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrPickupCodeLocked)
+	})
+
+	t.Run("Expired code", func(t *testing.T) {
+		code := activeCode()
+		code.ExpiresAt = time.Now().Add(-time.Second)
+
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, nil)
+		mockRepo.EXPECT().GetActive(gomock.Any(), pvzID, orderID).Return(code, nil)
+
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrPickupCodeExpired)
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().CountFailures(gomock.Any(), pvzID, gomock.Any()).Return(0, databaseerrors.ErrUnexpected)
+
+		_, err := svc.VerifyCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID, "123456")
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestReissueCode(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	mockProductRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	svc := service.NewPickupService(zap.NewNop(), mockRepo, mockProductRepo, config.PickupConfig{CodeTTLHours: 2})
+
+	pvzID := uuid.New()
+	orderID := uuid.New()
+
+	t.Run("Successful reissue", func(t *testing.T) {
+		mockProductRepo.EXPECT().ListStoredByOrder(gomock.Any(), pvzID, orderID).Return([]*models.Product{{ID: uuid.New()}}, nil)
+		mockRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+
+		code, err := svc.ReissueCode(context.Background(), models.RoleModerator.String(), pvzID, orderID)
+		require.NoError(t, err)
+		assert.Equal(t, pvzID, code.PVZID)
+		assert.Equal(t, orderID, code.OrderID)
+		assert.Len(t, code.Code, 6)
+		assert.True(t, auth.ComparePassword(code.Code, code.CodeHash))
+		assert.Equal(t, code.CreatedAt.Add(2*time.Hour), code.ExpiresAt)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.ReissueCode(context.Background(), models.RoleEmployee.String(), pvzID, orderID)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Order not found", func(t *testing.T) {
+		mockProductRepo.EXPECT().ListStoredByOrder(gomock.Any(), pvzID, orderID).Return([]*models.Product{}, nil)
+
+		_, err := svc.ReissueCode(context.Background(), models.RoleModerator.String(), pvzID, orderID)
+		assert.ErrorIs(t, err, domainerrors.ErrOrderNotFound)
+	})
+
+	t.Run("Save error", func(t *testing.T) {
+		mockProductRepo.EXPECT().ListStoredByOrder(gomock.Any(), pvzID, orderID).Return([]*models.Product{{ID: uuid.New()}}, nil)
+		mockRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(databaseerrors.ErrUnexpected)
+
+		_, err := svc.ReissueCode(context.Background(), models.RoleModerator.String(), pvzID, orderID)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
@@ -16,31 +17,43 @@ import (
 
 // ProductService - интерфейс для бизнес-логики работы с товарами.
 type ProductService interface {
-	AddProduct(ctx context.Context, userRole string, productType string, pvzID uuid.UUID) (*models.Product, error) // Добавляет товар в открытую приёмку в указанном ПВЗ
-	DeleteLastProduct(ctx context.Context, userRole string, pvzID uuid.UUID) error                                 // Удаляет последний продукт из открытой приёмки в указанном ПВЗ
+	AddProduct(ctx context.Context, userRole string, productType string, pvzID, orderID uuid.UUID, expectedReceptionVersion int) (*models.Product, error)                                     // Добавляет товар в открытую приёмку в указанном ПВЗ
+	DeleteLastProduct(ctx context.Context, userRole string, pvzID uuid.UUID, expectedReceptionVersion int) error                                                                              // Удаляет последний продукт из открытой приёмки в указанном ПВЗ
+	AddProductsBatch(ctx context.Context, userRole string, pvzID uuid.UUID, mode string, products []*models.AddProduct, expectedReceptionVersion int) (*models.AddProductsBatchResult, error) // Добавляет пакет товаров в открытую приёмку в указанном ПВЗ
 }
 
+// defaultBatchMaxItems - максимальный размер пакета товаров, если он не задан в конфиге.
+const defaultBatchMaxItems = 100
+
 // productServiceImpl реализует интерфейс ProductService
 type productServiceImpl struct {
-	logger *zap.Logger
-	repo   repositories.IProductRepo
+	logger        *zap.Logger
+	repo          repositories.IProductRepo
+	batchMaxItems int
 }
 
 // NewProductService - конструктор для создания нового экземпляра ProductService
-// Принимает логгер и репозиторий товаров.
-func NewProductService(logger *zap.Logger, repo repositories.IProductRepo) ProductService {
+// Принимает логгер, репозиторий товаров и конфиг товаров.
+func NewProductService(logger *zap.Logger, repo repositories.IProductRepo, cfg config.ProductConfig) ProductService {
+	batchMaxItems := cfg.BatchMaxItems
+	if batchMaxItems <= 0 {
+		batchMaxItems = defaultBatchMaxItems
+	}
+
 	return &productServiceImpl{
-		logger: logger,
-		repo:   repo,
+		logger:        logger,
+		repo:          repo,
+		batchMaxItems: batchMaxItems,
 	}
 }
 
 // AddProduct добавляет товар в открытую приёмку в указанном ПВЗ.
-// Принимает роль пользователя, тип продукта и айди ПВЗ.
+// Принимает роль пользователя, тип продукта, айди ПВЗ, айди заказа и ожидаемую версию приёмки (см. models.AnyVersion).
+// Если айди заказа не передан (uuid.Nil), товар считается отдельным заказом с айди самого товара.
 // Проводит валидацию роли пользователя (только models.RoleEmployee может добавлять товары)
 // Проводит валидацию входного товара (см. models.ProductType)
 // Возвращает доменную модель созданного товара или ошибку.
-func (s *productServiceImpl) AddProduct(ctx context.Context, userRole string, productType string, pvzID uuid.UUID) (*models.Product, error) {
+func (s *productServiceImpl) AddProduct(ctx context.Context, userRole string, productType string, pvzID, orderID uuid.UUID, expectedReceptionVersion int) (*models.Product, error) {
 	roleType := models.RoleType(userRole)
 
 	if roleType != models.RoleEmployee {
@@ -58,9 +71,14 @@ func (s *productServiceImpl) AddProduct(ctx context.Context, userRole string, pr
 		DateTime: time.Now(),
 		Type:     productTypeValue,
 		PVZID:    pvzID,
+		OrderID:  orderID,
 	}
 
-	product, err := s.repo.Create(ctx, addProduct)
+	if addProduct.OrderID == uuid.Nil {
+		addProduct.OrderID = addProduct.ID
+	}
+
+	product, err := s.repo.Create(ctx, addProduct, expectedReceptionVersion)
 
 	if err != nil {
 		if errors.Is(err, databaseerrors.ErrUnexpected) {
@@ -76,16 +94,17 @@ func (s *productServiceImpl) AddProduct(ctx context.Context, userRole string, pr
 }
 
 // DeleteLastProduct удаляет последний товар из открытой приёмки в указанном ПВЗ.
+// Принимает роль пользователя, айди ПВЗ и ожидаемую версию приёмки (см. models.AnyVersion).
 // Проводит валидацию роли пользователя (только models.RoleEmployee может удалять товары)
 // Возвращает ошибку, если не удалось удалить товар.
-func (s *productServiceImpl) DeleteLastProduct(ctx context.Context, userRole string, pvzID uuid.UUID) error {
+func (s *productServiceImpl) DeleteLastProduct(ctx context.Context, userRole string, pvzID uuid.UUID, expectedReceptionVersion int) error {
 	roleType := models.RoleType(userRole)
 
 	if roleType != models.RoleEmployee {
 		return domainerrors.ErrNotEnoughRights
 	}
 
-	err := s.repo.DeleteLast(ctx, pvzID)
+	product, city, err := s.repo.DeleteLast(ctx, pvzID, expectedReceptionVersion)
 
 	if err != nil {
 		if errors.Is(err, databaseerrors.ErrUnexpected) {
@@ -95,5 +114,122 @@ func (s *productServiceImpl) DeleteLastProduct(ctx context.Context, userRole str
 		return err
 	}
 
+	metrics.ProductsDeleted.WithLabelValues(city.String(), product.Type.String()).Inc()
+
 	return nil
 }
+
+// AddProductsBatch добавляет пакет товаров в открытую приёмку в указанном ПВЗ в одной транзакции.
+// Принимает роль пользователя, айди ПВЗ, режим (см. models.BatchMode), товары и ожидаемую версию приёмки (см. models.AnyVersion).
+// Айди товара может быть передан клиентом, тогда повтор пакета не создаст дубликатов;
+// если айди не передан, он генерируется. Если не передано время, используется текущее,
+// если не передан айди заказа - айди товара.
+// Проводит валидацию роли пользователя (только models.RoleEmployee может добавлять товары),
+// размера пакета и каждого товара. Ошибки отдельных товаров возвращаются в результате,
+// а не как ошибка метода. В режиме models.BatchModeAllOrNothing при любой ошибке товара
+// пакет отклоняется целиком и в репозиторий не передается.
+// Возвращает результат обработки каждого товара и ошибку, если пакет не может быть обработан.
+func (s *productServiceImpl) AddProductsBatch(ctx context.Context, userRole string, pvzID uuid.UUID, mode string, products []*models.AddProduct, expectedReceptionVersion int) (*models.AddProductsBatchResult, error) {
+	if models.RoleType(userRole) != models.RoleEmployee {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	batchMode := models.BatchMode(mode)
+	if !batchMode.Valid() {
+		return nil, fmt.Errorf("%w: %s", domainerrors.ErrInvalidBatchMode, mode)
+	}
+
+	if len(products) == 0 || len(products) > s.batchMaxItems {
+		return nil, fmt.Errorf("%w: expected 1 to %d items, got %d", domainerrors.ErrInvalidBatchSize, s.batchMaxItems, len(products))
+	}
+
+	now := time.Now()
+	items := make([]*models.BatchItemResult, len(products))
+	valid := make([]*models.AddProduct, 0, len(products))
+	validIndexes := make([]int, 0, len(products))
+	seen := make(map[uuid.UUID]struct{}, len(products))
+	hasFailed := false
+
+	for i, product := range products {
+		if !product.Type.Valid() {
+			items[i] = &models.BatchItemResult{Index: i, Status: models.BatchItemStatusFailed, Err: fmt.Errorf("%w: %s", domainerrors.ErrInvalidProductType, product.Type)}
+			hasFailed = true
+
+			continue
+		}
+
+		// Время сканирования задает клиент, поэтому товар не может быть отсканирован позже обработки пакета.
+		// Нижнюю границу (открытие приемки) проверяет репозиторий
+		if product.DateTime.After(now) {
+			items[i] = &models.BatchItemResult{Index: i, Status: models.BatchItemStatusFailed, Err: fmt.Errorf("%w: %s", domainerrors.ErrInvalidProductTime, product.DateTime.Format(time.RFC3339))}
+			hasFailed = true
+
+			continue
+		}
+
+		if product.ID == uuid.Nil {
+			product.ID = uuid.New()
+		}
+
+		if _, ok := seen[product.ID]; ok {
+			items[i] = &models.BatchItemResult{Index: i, Status: models.BatchItemStatusFailed, Err: domainerrors.ErrDuplicateProductID}
+			hasFailed = true
+
+			continue
+		}
+
+		seen[product.ID] = struct{}{}
+
+		if product.DateTime.IsZero() {
+			product.DateTime = now
+		}
+
+		if product.OrderID == uuid.Nil {
+			product.OrderID = product.ID
+		}
+
+		product.PVZID = pvzID
+
+		valid = append(valid, product)
+		validIndexes = append(validIndexes, i)
+	}
+
+	if len(valid) == 0 || (hasFailed && batchMode == models.BatchModeAllOrNothing) {
+		for i, item := range items {
+			if item == nil {
+				items[i] = &models.BatchItemResult{Index: i, Status: models.BatchItemStatusSkipped}
+			}
+		}
+
+		return &models.AddProductsBatchResult{Applied: batchMode == models.BatchModeBestEffort, Items: items}, nil
+	}
+
+	batchResult, err := s.repo.CreateBatch(ctx, pvzID, valid, batchMode, expectedReceptionVersion)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	created := 0
+
+	// Индексы из репозитория относятся к отфильтрованным товарам, возвращаем их к индексам исходного пакета
+	for _, item := range batchResult.Items {
+		item.Index = validIndexes[item.Index]
+		items[item.Index] = item
+
+		if item.Status == models.BatchItemStatusCreated {
+			created++
+		}
+	}
+
+	metrics.ProductsAdded.Add(float64(created))
+
+	return &models.AddProductsBatchResult{
+		ReceptionID: batchResult.ReceptionID,
+		Applied:     batchResult.Applied,
+		Items:       items,
+	}, nil
+}
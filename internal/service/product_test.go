@@ -6,15 +6,19 @@ package service_test
 import (
 	"context"
 	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
 	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
 	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 	"testing"
+	"time"
 )
 
 func TestAddProduct(t *testing.T) {
@@ -23,7 +27,7 @@ func TestAddProduct(t *testing.T) {
 
 	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewProductService(logger, mockRepo)
+	svc := service.NewProductService(logger, mockRepo, config.ProductConfig{BatchMaxItems: 3})
 
 	pvzID := uuid.New()
 	productType := "электроника"
@@ -31,11 +35,13 @@ func TestAddProduct(t *testing.T) {
 	t.Run("Successful add", func(t *testing.T) {
 		mockRepo.
 			EXPECT().
-			Create(gomock.Any(), gomock.Any()).
-			DoAndReturn(func(ctx context.Context, addProduct *models.AddProduct) (*models.Product, error) {
+			Create(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, addProduct *models.AddProduct, _ int) (*models.Product, error) {
 				assert.Equal(t, models.ProductTypeElectronics, addProduct.Type)
 				assert.Equal(t, pvzID, addProduct.PVZID)
 				assert.NotEqual(t, uuid.Nil, addProduct.ID)
+				// Без айди заказа товар считается отдельным заказом
+				assert.Equal(t, addProduct.ID, addProduct.OrderID)
 				return &models.Product{
 					ID:          addProduct.ID,
 					DateTime:    addProduct.DateTime,
@@ -49,6 +55,8 @@ func TestAddProduct(t *testing.T) {
 			models.RoleEmployee.String(),
 			productType,
 			pvzID,
+			uuid.Nil,
+			models.AnyVersion,
 		)
 
 		assert.NoError(t, err)
@@ -57,6 +65,22 @@ func TestAddProduct(t *testing.T) {
 		assert.NotEqual(t, uuid.Nil, product.ID)
 	})
 
+	t.Run("Add with order", func(t *testing.T) {
+		orderID := uuid.New()
+
+		mockRepo.
+			EXPECT().
+			Create(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(ctx context.Context, addProduct *models.AddProduct, _ int) (*models.Product, error) {
+				assert.Equal(t, orderID, addProduct.OrderID)
+				return &models.Product{ID: addProduct.ID, Type: addProduct.Type, OrderID: addProduct.OrderID}, nil
+			})
+
+		product, err := svc.AddProduct(context.Background(), models.RoleEmployee.String(), productType, pvzID, orderID, models.AnyVersion)
+		assert.NoError(t, err)
+		assert.Equal(t, orderID, product.OrderID)
+	})
+
 	// Только сотрудник может добавлять товары
 	t.Run("Invalid role", func(t *testing.T) {
 		_, err := svc.AddProduct(
@@ -64,6 +88,8 @@ func TestAddProduct(t *testing.T) {
 			models.RoleModerator.String(),
 			productType,
 			pvzID,
+			uuid.Nil,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
 	})
@@ -74,6 +100,8 @@ func TestAddProduct(t *testing.T) {
 			models.RoleEmployee.String(),
 			"invalid_type",
 			pvzID,
+			uuid.Nil,
+			models.AnyVersion,
 		)
 		assert.ErrorContains(t, err, domainerrors.ErrInvalidProductType.Error())
 	})
@@ -81,7 +109,7 @@ func TestAddProduct(t *testing.T) {
 	t.Run("Repository error", func(t *testing.T) {
 		mockRepo.
 			EXPECT().
-			Create(gomock.Any(), gomock.Any()).
+			Create(gomock.Any(), gomock.Any(), gomock.Any()).
 			Return(nil, databaseerrors.ErrUnexpected)
 
 		_, err := svc.AddProduct(
@@ -89,6 +117,8 @@ func TestAddProduct(t *testing.T) {
 			models.RoleEmployee.String(),
 			productType,
 			pvzID,
+			uuid.Nil,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
 	})
@@ -100,19 +130,25 @@ func TestDeleteLastProduct(t *testing.T) {
 
 	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewProductService(logger, mockRepo)
+	svc := service.NewProductService(logger, mockRepo, config.ProductConfig{BatchMaxItems: 3})
 
 	pvzID := uuid.New()
 
 	t.Run("Successful delete", func(t *testing.T) {
-		mockRepo.EXPECT().DeleteLast(gomock.Any(), pvzID).Return(nil)
+		deleted := &models.Product{ID: uuid.New(), Type: models.ProductTypeShoes}
+		counter := metrics.ProductsDeleted.WithLabelValues(models.CityTypeKazan.String(), models.ProductTypeShoes.String())
+		deletedBefore := testutil.ToFloat64(counter)
+
+		mockRepo.EXPECT().DeleteLast(gomock.Any(), pvzID, gomock.Any()).Return(deleted, models.CityTypeKazan, nil)
 
 		err := svc.DeleteLastProduct(
 			context.Background(),
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.NoError(t, err)
+		assert.Equal(t, deletedBefore+1, testutil.ToFloat64(counter))
 	})
 
 	t.Run("Invalid role", func(t *testing.T) {
@@ -120,18 +156,161 @@ func TestDeleteLastProduct(t *testing.T) {
 			context.Background(),
 			models.RoleModerator.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
 	})
 
 	t.Run("Repository error", func(t *testing.T) {
-		mockRepo.EXPECT().DeleteLast(gomock.Any(), pvzID).Return(databaseerrors.ErrUnexpected)
+		mockRepo.EXPECT().DeleteLast(gomock.Any(), pvzID, gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrUnexpected)
 
 		err := svc.DeleteLastProduct(
 			context.Background(),
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
 	})
 }
+
+func TestAddProductsBatch(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewProductService(logger, mockRepo, config.ProductConfig{BatchMaxItems: 3})
+
+	pvzID := uuid.New()
+	receptionID := uuid.New()
+
+	// createdResult возвращает результат репозитория, в котором все товары добавлены
+	createdResult := func(_ context.Context, _ uuid.UUID, products []*models.AddProduct, _ models.BatchMode, _ int) (*models.AddProductsBatchResult, error) {
+		result := &models.AddProductsBatchResult{ReceptionID: receptionID, Applied: true}
+		for i, p := range products {
+			result.Items = append(result.Items, &models.BatchItemResult{
+				Index:   i,
+				Status:  models.BatchItemStatusCreated,
+				Product: &models.Product{ID: p.ID, Type: p.Type, ReceptionID: receptionID},
+			})
+		}
+
+		return result, nil
+	}
+
+	t.Run("Successful batch", func(t *testing.T) {
+		clientID := uuid.New()
+		orderID := uuid.New()
+
+		mockRepo.EXPECT().
+			CreateBatch(gomock.Any(), pvzID, gomock.Len(2), models.BatchModeAllOrNothing, gomock.Any()).
+			DoAndReturn(func(ctx context.Context, pvz uuid.UUID, products []*models.AddProduct, mode models.BatchMode, version int) (*models.AddProductsBatchResult, error) {
+				assert.Equal(t, clientID, products[0].ID)
+				assert.NotEqual(t, uuid.Nil, products[1].ID)
+				assert.False(t, products[1].DateTime.IsZero())
+				assert.Equal(t, pvzID, products[1].PVZID)
+				assert.Equal(t, orderID, products[0].OrderID)
+				assert.Equal(t, products[1].ID, products[1].OrderID)
+
+				return createdResult(ctx, pvz, products, mode, version)
+			})
+
+		result, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeAllOrNothing.String(), []*models.AddProduct{
+			{ID: clientID, Type: models.ProductTypeShoes, OrderID: orderID},
+			{Type: models.ProductTypeClothes},
+		}, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, receptionID, result.ReceptionID)
+		assert.Len(t, result.Items, 2)
+	})
+
+	t.Run("All or nothing rejects batch with invalid item", func(t *testing.T) {
+		result, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeAllOrNothing.String(), []*models.AddProduct{
+			{Type: models.ProductTypeShoes},
+			{Type: "invalid"},
+		}, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.False(t, result.Applied)
+		assert.Equal(t, models.BatchItemStatusSkipped, result.Items[0].Status)
+		assert.Equal(t, models.BatchItemStatusFailed, result.Items[1].Status)
+		assert.ErrorIs(t, result.Items[1].Err, domainerrors.ErrInvalidProductType)
+	})
+
+	t.Run("Best effort keeps original indexes", func(t *testing.T) {
+		duplicateID := uuid.New()
+
+		mockRepo.EXPECT().
+			CreateBatch(gomock.Any(), pvzID, gomock.Len(1), models.BatchModeBestEffort, gomock.Any()).
+			DoAndReturn(createdResult)
+
+		result, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeBestEffort.String(), []*models.AddProduct{
+			{Type: "invalid"},
+			{ID: duplicateID, Type: models.ProductTypeShoes},
+			{ID: duplicateID, Type: models.ProductTypeShoes},
+		}, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.True(t, result.Applied)
+		assert.Equal(t, models.BatchItemStatusFailed, result.Items[0].Status)
+		assert.Equal(t, models.BatchItemStatusCreated, result.Items[1].Status)
+		assert.Equal(t, 1, result.Items[1].Index)
+		assert.Equal(t, models.BatchItemStatusFailed, result.Items[2].Status)
+		assert.ErrorIs(t, result.Items[2].Err, domainerrors.ErrDuplicateProductID)
+	})
+
+	// Время сканирования из будущего отклоняется, не доходя до репозитория
+	t.Run("Future date time", func(t *testing.T) {
+		result, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeAllOrNothing.String(), []*models.AddProduct{
+			{Type: models.ProductTypeShoes, DateTime: time.Now().Add(-time.Minute)},
+			{Type: models.ProductTypeShoes, DateTime: time.Now().Add(time.Hour)},
+		}, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.False(t, result.Applied)
+		assert.Equal(t, models.BatchItemStatusSkipped, result.Items[0].Status)
+		assert.Equal(t, models.BatchItemStatusFailed, result.Items[1].Status)
+		assert.ErrorIs(t, result.Items[1].Err, domainerrors.ErrInvalidProductTime)
+	})
+
+	t.Run("Invalid batch size", func(t *testing.T) {
+		products := []*models.AddProduct{{Type: models.ProductTypeShoes}, {Type: models.ProductTypeShoes}, {Type: models.ProductTypeShoes}, {Type: models.ProductTypeShoes}}
+
+		_, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeBestEffort.String(), products, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidBatchSize)
+
+		_, err = svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeBestEffort.String(), nil, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidBatchSize)
+	})
+
+	t.Run("Invalid mode", func(t *testing.T) {
+		_, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, "invalid", []*models.AddProduct{{Type: models.ProductTypeShoes}}, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidBatchMode)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.AddProductsBatch(context.Background(), models.RoleModerator.String(), pvzID, models.BatchModeBestEffort.String(), []*models.AddProduct{{Type: models.ProductTypeShoes}}, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("No open reception", func(t *testing.T) {
+		mockRepo.EXPECT().
+			CreateBatch(gomock.Any(), pvzID, gomock.Any(), models.BatchModeBestEffort, gomock.Any()).
+			Return(nil, domainerrors.ErrNoOpenReceptions)
+
+		_, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeBestEffort.String(), []*models.AddProduct{{Type: models.ProductTypeShoes}}, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNoOpenReceptions)
+	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().
+			CreateBatch(gomock.Any(), pvzID, gomock.Any(), models.BatchModeBestEffort, gomock.Any()).
+			Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.AddProductsBatch(context.Background(), models.RoleEmployee.String(), pvzID, models.BatchModeBestEffort.String(), []*models.AddProduct{{Type: models.ProductTypeShoes}}, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
@@ -8,6 +8,7 @@ import (
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
 	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
 	"go.uber.org/zap"
@@ -17,22 +18,24 @@ import (
 // PVZService - интерфейс для бизнес-логики работы с ПВЗ (пунктами выдачи заказов).
 type PVZService interface {
 	CreatePVZ(ctx context.Context, userRole, city string, pvzID *uuid.UUID, registerDate *time.Time) (*models.PVZ, error)                      // Создает ПВЗ с указанием города, опциональных айди и даты регистрации.
-	ListPVZs(ctx context.Context, userRole string, startDate, endDate *time.Time, pageNumber, limit *int) ([]*models.PVZWithReceptions, error) // Возвращает список ПВЗ с приемками внутри них и товарами внутри приёмок.
+	ListPVZs(ctx context.Context, userRole string, startDate, endDate *time.Time, pageNumber, limit *int) ([]*models.PVZWithReceptions, error) // Возвращает список ПВЗ с приемками внутри них, товарами внутри приёмок и возвратами клиентов.
 	GetAllPVZs(ctx context.Context) ([]*models.PVZ, error)                                                                                     // Возвращает все ПВЗ из базы данных.
 }
 
 // pvzServiceImpl реализует интерфейс PVZService
 type pvzServiceImpl struct {
-	logger  *zap.Logger
-	pvzRepo repositories.IPVZRepo
+	logger     *zap.Logger
+	pvzRepo    repositories.IPVZRepo
+	returnRepo repositories.IReturnRepo
 }
 
 // NewPVZService - конструктор для создания нового экземпляра PVZService.
-// Принимает логгер и репозиторий ПВЗ.
-func NewPVZService(logger *zap.Logger, pvzRepo repositories.IPVZRepo) PVZService {
+// Принимает логгер, репозиторий ПВЗ и репозиторий возвратов.
+func NewPVZService(logger *zap.Logger, pvzRepo repositories.IPVZRepo, returnRepo repositories.IReturnRepo) PVZService {
 	return &pvzServiceImpl{
-		logger:  logger,
-		pvzRepo: pvzRepo,
+		logger:     logger,
+		pvzRepo:    pvzRepo,
+		returnRepo: returnRepo,
 	}
 }
 
@@ -44,13 +47,13 @@ func NewPVZService(logger *zap.Logger, pvzRepo repositories.IPVZRepo) PVZService
 func (p *pvzServiceImpl) CreatePVZ(ctx context.Context, userRole, city string, pvzID *uuid.UUID, registerDate *time.Time) (*models.PVZ, error) {
 	roleType := models.RoleType(userRole)
 	if roleType == models.RoleEmployee {
-		p.logger.Debug("User is not moderator", zap.String("userRole", userRole))
+		l.FromContext(ctx, p.logger).Debug("User is not moderator", zap.String("userRole", userRole))
 		return nil, domainerrors.ErrUserNotModerator
 	}
 
 	cityType := models.CityType(city)
 	if !cityType.Valid() {
-		p.logger.Debug("Invalid city type", zap.String("city", city))
+		l.FromContext(ctx, p.logger).Debug("Invalid city type", zap.String("city", city))
 		return nil, domainerrors.ErrInvalidCity
 	}
 
@@ -59,7 +62,7 @@ func (p *pvzServiceImpl) CreatePVZ(ctx context.Context, userRole, city string, p
 	}
 
 	if pvzID == nil {
-		p.logger.Debug("PVZ ID is not provided")
+		l.FromContext(ctx, p.logger).Debug("PVZ ID is not provided")
 
 		pvz.ID = uuid.New() // ???
 	} else {
@@ -67,7 +70,7 @@ func (p *pvzServiceImpl) CreatePVZ(ctx context.Context, userRole, city string, p
 	}
 
 	if registerDate == nil {
-		p.logger.Debug("RegisterDate is not provided")
+		l.FromContext(ctx, p.logger).Debug("RegisterDate is not provided")
 
 		pvz.RegistrationDate = time.Now() // ???
 	} else {
@@ -82,7 +85,7 @@ func (p *pvzServiceImpl) CreatePVZ(ctx context.Context, userRole, city string, p
 			// TODO: непонятно, что делать в этой ситуации. На вход, согласно openapi схеме,
 			// МОЖЕТ подаваться UUID ПВЗ, но не задокументировано поведение в случае,
 			// если он уже существует. Пока просто возвращается ошибка.
-			p.logger.Debug("PVZ already exists", zap.String("ID", pvz.ID.String()), zap.Error(err))
+			l.FromContext(ctx, p.logger).Debug("PVZ already exists", zap.String("ID", pvz.ID.String()), zap.Error(err))
 			return nil, domainerrors.ErrPVZAlreadyExists
 		case errors.Is(err, databaseerrors.ErrUnexpected):
 			return nil, domainerrors.ErrUnexpected
@@ -95,6 +98,7 @@ func (p *pvzServiceImpl) CreatePVZ(ctx context.Context, userRole, city string, p
 }
 
 // ListPVZs возвращает список ПВЗ с приемками внутри них с товарами внутри приёмок с фильтрацией по дате ПРИЁМКИ товаров.
+// Для каждого ПВЗ возвращаются и возвраты клиентов, зарегистрированные в тот же период.
 // Производит валидацию роли пользователя (только models.RoleEmployee и models.RoleModerator могут просматривать ПВЗ).
 // Принимает так же номер страницы и размер страницы.
 // Производит валидацию фильтра (см. models.PVZFilter). Возвращает ошибку в случае ошибки валидации или базы данных.
@@ -102,7 +106,7 @@ func (p *pvzServiceImpl) ListPVZs(ctx context.Context, userRole string, startDat
 	roleType := models.RoleType(userRole)
 	// Пока есть только две роли и, в принципе, смысла проверять нет, но это сделано на случай появления новых ролей
 	if roleType != models.RoleEmployee && roleType != models.RoleModerator {
-		p.logger.Debug("User is not moderator or employee", zap.String("userRole", userRole))
+		l.FromContext(ctx, p.logger).Debug("User is not moderator or employee", zap.String("userRole", userRole))
 		return nil, fmt.Errorf("%w: %v", domainerrors.ErrInvalidRole, roleType)
 	}
 
@@ -122,7 +126,7 @@ func (p *pvzServiceImpl) ListPVZs(ctx context.Context, userRole string, startDat
 
 	if err := filter.Valid(); err != nil {
 		// Валидация возвращает доменную ошибку, поэтому её можно сразу вернуть
-		p.logger.Debug("Invalid filter", zap.Error(err))
+		l.FromContext(ctx, p.logger).Debug("Invalid filter", zap.Error(err))
 		return nil, err
 	}
 
@@ -135,9 +139,48 @@ func (p *pvzServiceImpl) ListPVZs(ctx context.Context, userRole string, startDat
 		}
 	}
 
+	if err := p.attachReturns(ctx, result, startDate, endDate); err != nil {
+		return nil, err
+	}
+
 	return result, nil
 }
 
+// attachReturns заполняет возвраты клиентов, зарегистрированные в период от startDate до endDate, для ПВЗ страницы.
+func (p *pvzServiceImpl) attachReturns(ctx context.Context, pvzs []*models.PVZWithReceptions, startDate, endDate *time.Time) error {
+	if len(pvzs) == 0 {
+		return nil
+	}
+
+	pvzIDs := make([]uuid.UUID, 0, len(pvzs))
+	for _, pvz := range pvzs {
+		pvzIDs = append(pvzIDs, pvz.PVZ.ID)
+	}
+
+	returns, err := p.returnRepo.ListByPVZs(ctx, pvzIDs, startDate, endDate)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return domainerrors.ErrUnexpected
+		}
+
+		return err
+	}
+
+	byPVZ := make(map[uuid.UUID][]*models.CustomerReturn, len(pvzs))
+	for _, ret := range returns {
+		byPVZ[ret.PVZID] = append(byPVZ[ret.PVZID], ret)
+	}
+
+	for _, pvz := range pvzs {
+		pvz.Returns = byPVZ[pvz.PVZ.ID]
+		if pvz.Returns == nil {
+			pvz.Returns = make([]*models.CustomerReturn, 0)
+		}
+	}
+
+	return nil
+}
+
 // GetAllPVZs возвращает все когда-либо созданные ПВЗ.
 func (p *pvzServiceImpl) GetAllPVZs(ctx context.Context) ([]*models.PVZ, error) {
 	pvzs, err := p.pvzRepo.GetAll(ctx)
@@ -15,6 +15,7 @@ import (
 	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
 	"github.com/maksemen2/pvz-service/internal/service"
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.uber.org/mock/gomock"
 	"go.uber.org/zap"
 )
@@ -24,8 +25,9 @@ func TestCreatePVZ(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repositories.NewMockIPVZRepo(ctrl)
+	mockReturnRepo := mock_repositories.NewMockIReturnRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewPVZService(logger, mockRepo)
+	svc := service.NewPVZService(logger, mockRepo, mockReturnRepo)
 
 	now := time.Now()
 	testUUID := uuid.New()
@@ -132,8 +134,9 @@ func TestListPVZs(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repositories.NewMockIPVZRepo(ctrl)
+	mockReturnRepo := mock_repositories.NewMockIReturnRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewPVZService(logger, mockRepo)
+	svc := service.NewPVZService(logger, mockRepo, mockReturnRepo)
 
 	now := time.Now()
 	testPVZs := []*models.PVZWithReceptions{
@@ -160,6 +163,7 @@ func TestListPVZs(t *testing.T) {
 		}
 
 		mockRepo.EXPECT().List(gomock.Any(), expectedFilter).Return(testPVZs, nil)
+		mockReturnRepo.EXPECT().ListByPVZs(gomock.Any(), []uuid.UUID{testPVZs[0].PVZ.ID}, &start, &end).Return([]*models.CustomerReturn{}, nil)
 
 		result, err := svc.ListPVZs(
 			context.Background(),
@@ -206,6 +210,7 @@ func TestListPVZs(t *testing.T) {
 		}
 
 		mockRepo.EXPECT().List(gomock.Any(), expectedFilter).Return(testPVZs, nil)
+		mockReturnRepo.EXPECT().ListByPVZs(gomock.Any(), gomock.Any(), nil, nil).Return([]*models.CustomerReturn{}, nil)
 
 		result, err := svc.ListPVZs(
 			context.Background(),
@@ -220,6 +225,34 @@ func TestListPVZs(t *testing.T) {
 		assert.Equal(t, testPVZs, result)
 	})
 
+	// Возвраты раскладываются по своим ПВЗ, у ПВЗ без возвратов список пустой
+	t.Run("Returns attached", func(t *testing.T) {
+		first := &models.PVZWithReceptions{PVZ: &models.PVZ{ID: uuid.New(), City: models.CityTypeMoscow}}
+		second := &models.PVZWithReceptions{PVZ: &models.PVZ{ID: uuid.New(), City: models.CityTypeKazan}}
+		returns := []*models.CustomerReturn{
+			{ID: uuid.New(), PVZID: first.PVZ.ID},
+			{ID: uuid.New(), PVZID: first.PVZ.ID},
+		}
+
+		mockRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return([]*models.PVZWithReceptions{first, second}, nil)
+		mockReturnRepo.EXPECT().ListByPVZs(gomock.Any(), []uuid.UUID{first.PVZ.ID, second.PVZ.ID}, nil, nil).Return(returns, nil)
+
+		result, err := svc.ListPVZs(context.Background(), models.RoleEmployee.String(), nil, nil, nil, nil)
+		require.NoError(t, err)
+		require.Len(t, result, 2)
+		assert.Equal(t, returns, result[0].Returns)
+		assert.NotNil(t, result[1].Returns)
+		assert.Empty(t, result[1].Returns)
+	})
+
+	t.Run("Returns repository error", func(t *testing.T) {
+		mockRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return(testPVZs, nil)
+		mockReturnRepo.EXPECT().ListByPVZs(gomock.Any(), gomock.Any(), nil, nil).Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.ListPVZs(context.Background(), models.RoleEmployee.String(), nil, nil, nil, nil)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+
 	t.Run("Repository error", func(t *testing.T) {
 		mockRepo.EXPECT().List(gomock.Any(), gomock.Any()).Return(nil, databaseerrors.ErrUnexpected)
 
@@ -240,8 +273,9 @@ func TestGetAllPVZs(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repositories.NewMockIPVZRepo(ctrl)
+	mockReturnRepo := mock_repositories.NewMockIReturnRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewPVZService(logger, mockRepo)
+	svc := service.NewPVZService(logger, mockRepo, mockReturnRepo)
 
 	testPVZs := []*models.PVZ{
 		{
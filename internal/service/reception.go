@@ -3,48 +3,68 @@ package service
 import (
 	"context"
 	"errors"
+	"fmt"
 	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
 	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
 	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
 	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
 	"go.uber.org/zap"
+	"strings"
 	"time"
 )
 
 // ReceptionService - интерфейс для работы с приемами ПВЗ.
 type ReceptionService interface {
-	CloseLastReception(ctx context.Context, userRole string, pvzID uuid.UUID) (*models.Reception, error)
-	CreateReceptionIfNoOpen(ctx context.Context, userRole string, pvzID uuid.UUID) (*models.Reception, error)
+	CloseLastReception(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, expectedVersion int) (*models.Reception, error)
+	CreateReceptionIfNoOpen(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, expectedPVZVersion int) (*models.Reception, error)
+	CancelLastReception(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, reason string, expectedVersion int) (*models.Reception, error)            // Отменяет открытую приемку с обязательной причиной.
+	ReopenLastReception(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, expectedVersion int) (*models.Reception, error)                           // Повторно открывает последнюю закрытую приемку.
+	AttachManifest(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, items []models.ManifestItem, expectedPVZVersion int) (*models.Manifest, error) // Загружает ожидаемый манифест для открытой или следующей приемки ПВЗ.
+	CloseStaleReceptions(ctx context.Context, policy *models.StaleReceptionPolicy) (int, error)                                                                            // Автоматически закрывает забытые приемки во всех городах и возвращает их количество.
 }
 
 // receptionServiceImpl реализует интерфейс ReceptionService.
 type receptionServiceImpl struct {
-	logger *zap.Logger
-	repo   repositories.IReceptionRepo
+	logger            *zap.Logger
+	repo              repositories.IReceptionRepo
+	manifestRepo      repositories.IManifestRepo
+	pickupRepo        repositories.IPickupCodeRepo
+	reopenGracePeriod time.Duration
+	pickupCodeTTL     time.Duration
 }
 
 // NewReceptionService - конструктор для создания нового экземпляра ReceptionService.
-// Принимает логгер и репозиторий приемок.
-func NewReceptionService(logger *zap.Logger, repo repositories.IReceptionRepo) ReceptionService {
+// Принимает логгер, репозиторий приемок, репозиторий манифестов, репозиторий кодов получения,
+// конфиг приемок и конфиг кодов получения.
+func NewReceptionService(logger *zap.Logger, repo repositories.IReceptionRepo, manifestRepo repositories.IManifestRepo, pickupRepo repositories.IPickupCodeRepo, cfg config.ReceptionConfig, pickupCfg config.PickupConfig) ReceptionService {
 	return &receptionServiceImpl{
-		logger: logger,
-		repo:   repo,
+		logger:            logger,
+		repo:              repo,
+		manifestRepo:      manifestRepo,
+		pickupRepo:        pickupRepo,
+		reopenGracePeriod: time.Duration(cfg.ReopenGracePeriodSeconds) * time.Second,
+		pickupCodeTTL:     pickupCodeTTL(pickupCfg),
 	}
 }
 
 // CloseLastReception закрывает последнюю приемку в ПВЗ.
-// Принимает роль пользователя и айди ПВЗ.
+// Принимает айди и роль пользователя, айди ПВЗ и ожидаемую версию приемки (см. models.AnyVersion).
+// Пользователь сохраняется как закрывший приемку.
 // Проводит валидацию роли пользователя (только models.RoleEmployee может закрывать приемки).
+// Если к приемке привязан манифест, репозиторий в той же транзакции строит и сохраняет отчет о расхождениях.
+// Для заказов приемки, у которых еще нет кода получения, создает коды (см. afterClose).
 // Возвращает закрытую приемку с обновленными данными о ней и ошибку, если она возникла.
-func (s *receptionServiceImpl) CloseLastReception(ctx context.Context, userRole string, pvzID uuid.UUID) (*models.Reception, error) {
+func (s *receptionServiceImpl) CloseLastReception(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, expectedVersion int) (*models.Reception, error) {
 	userRoleType := models.RoleType(userRole)
 	if userRoleType != models.RoleEmployee {
 		return nil, domainerrors.ErrNotEnoughRights
 	}
 
-	reception, err := s.repo.CloseLast(ctx, pvzID)
+	reception, city, err := s.repo.CloseLast(ctx, pvzID, userID, time.Now(), expectedVersion)
 
 	if err != nil {
 		if errors.Is(err, databaseerrors.ErrUnexpected) {
@@ -54,14 +74,95 @@ func (s *receptionServiceImpl) CloseLastReception(ctx context.Context, userRole
 		return nil, err // Репозиторий может возвращать и доменные ошибки
 	}
 
+	s.afterClose(ctx, reception, city)
+
 	return reception, nil
 }
 
+// afterClose выполняет общие для ручного и автоматического закрытия шаги после коммита:
+// увеличивает метрику metrics.ReceptionsClosed и создает коды получения (см. issuePickupCodes).
+func (s *receptionServiceImpl) afterClose(ctx context.Context, reception *models.Reception, city models.CityType) {
+	metrics.ReceptionsClosed.WithLabelValues(city.String()).Inc()
+
+	reception.PickupCodes = s.issuePickupCodes(ctx, reception)
+}
+
+// issuePickupCodes создает коды получения для заказов закрытой приемки, у которых в ПВЗ нет неиспользованного кода.
+// Открытые коды возвращаются только в ответе на закрытие, чтобы сотрудник передал их клиентам.
+// Ошибки не возвращаются пользователю, а только логируются: код для заказа без кода
+// можно выпустить повторно (см. PickupService.ReissueCode). Коды автоматически закрытых
+// приемок никому не возвращаются, поэтому клиентам их выдают через повторный выпуск.
+func (s *receptionServiceImpl) issuePickupCodes(ctx context.Context, reception *models.Reception) []*models.PickupCode {
+	orderIDs, err := s.pickupRepo.OrdersWithoutCode(ctx, reception.ID)
+	if err != nil {
+		l.FromContext(ctx, s.logger).Error("failed to get orders without pickup code", zap.String("receptionID", reception.ID.String()), zap.Error(err))
+		return nil
+	}
+
+	codes := make([]*models.PickupCode, 0, len(orderIDs))
+	now := time.Now()
+
+	for _, orderID := range orderIDs {
+		code, err := generatePickupCode(reception.PVZID, orderID, s.pickupCodeTTL, now)
+		if err != nil {
+			l.FromContext(ctx, s.logger).Error("failed to generate pickup code", zap.String("orderID", orderID.String()), zap.Error(err))
+			continue
+		}
+
+		if err := s.pickupRepo.Save(ctx, code); err != nil {
+			l.FromContext(ctx, s.logger).Error("failed to save pickup code", zap.String("orderID", orderID.String()), zap.Error(err))
+			continue
+		}
+
+		codes = append(codes, code)
+	}
+
+	return codes
+}
+
+// AttachManifest загружает ожидаемый манифест приемки.
+// Принимает айди и роль пользователя, айди ПВЗ, ожидаемые количества товаров по типам и ожидаемую версию ПВЗ (см. models.AnyVersion).
+// Загружать манифесты может только models.RoleModerator (в том числе от имени внешних систем).
+// Если в ПВЗ есть открытая приемка, манифест привязывается к ней, иначе - к следующей созданной приемке.
+// Возвращает сохраненный манифест и ошибку, если она возникла.
+func (s *receptionServiceImpl) AttachManifest(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, items []models.ManifestItem, expectedPVZVersion int) (*models.Manifest, error) {
+	if models.RoleType(userRole) != models.RoleModerator {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	manifest := &models.Manifest{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		CreatedBy: userID,
+		CreatedAt: time.Now(),
+		Items:     items,
+	}
+
+	if !manifest.Valid() {
+		return nil, domainerrors.ErrInvalidManifest
+	}
+
+	err := s.manifestRepo.Save(ctx, manifest, expectedPVZVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	return manifest, nil
+}
+
 // CreateReceptionIfNoOpen создает новую приемку, если в ПВЗ нет открытой приемки.
-// Принимает роль пользователя и айди ПВЗ.
+// Принимает айди и роль пользователя, айди ПВЗ и ожидаемую версию ПВЗ (см. models.AnyVersion).
+// Пользователь сохраняется как открывший приемку.
 // Проводит валидацию роли пользователя (только models.RoleEmployee может создавать приемки).
 // Возвращает созданную приемку и ошибку, если она возникла.
-func (s *receptionServiceImpl) CreateReceptionIfNoOpen(ctx context.Context, userRole string, pvzID uuid.UUID) (*models.Reception, error) {
+func (s *receptionServiceImpl) CreateReceptionIfNoOpen(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, expectedPVZVersion int) (*models.Reception, error) {
 	userRoleType := models.RoleType(userRole)
 	if userRoleType != models.RoleEmployee {
 		return nil, domainerrors.ErrNotEnoughRights
@@ -72,9 +173,10 @@ func (s *receptionServiceImpl) CreateReceptionIfNoOpen(ctx context.Context, user
 		DateTime: time.Now(),
 		PVZID:    pvzID,
 		Status:   models.ReceptionStatusInProgress,
+		OpenedBy: userID,
 	}
 
-	err := s.repo.CreateIfNoOpen(ctx, reception)
+	err := s.repo.CreateIfNoOpen(ctx, reception, expectedPVZVersion)
 
 	if err != nil {
 		switch {
@@ -91,3 +193,167 @@ func (s *receptionServiceImpl) CreateReceptionIfNoOpen(ctx context.Context, user
 
 	return reception, nil
 }
+
+// CancelLastReception отменяет открытую приемку в ПВЗ, например если она была открыта по ошибке.
+// Принимает айди и роль пользователя, айди ПВЗ, причину отмены и ожидаемую версию приемки (см. models.AnyVersion).
+// Пользователь сохраняется как отменивший приемку.
+// Отменять приемки могут и models.RoleEmployee, и models.RoleModerator. Причина отмены обязательна.
+// Возвращает отмененную приемку и ошибку, если она возникла.
+func (s *receptionServiceImpl) CancelLastReception(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, reason string, expectedVersion int) (*models.Reception, error) {
+	if !models.RoleType(userRole).Valid() {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	reason = strings.TrimSpace(reason)
+	if reason == "" {
+		return nil, domainerrors.ErrCancelReasonEmpty
+	}
+
+	reception, err := s.repo.CancelLast(ctx, pvzID, userID, time.Now(), reason, expectedVersion)
+
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	metrics.ReceptionsCancelled.Inc()
+
+	l.FromContext(ctx, s.logger).Info("audit: reception cancelled",
+		zap.String("receptionID", reception.ID.String()),
+		zap.String("pvzID", reception.PVZID.String()),
+		zap.String("cancelledBy", userID.String()),
+		zap.String("reason", reason),
+	)
+
+	return reception, nil
+}
+
+// ReopenLastReception повторно открывает последнюю приемку в ПВЗ, если она закрыта.
+// Принимает айди и роль пользователя, айди ПВЗ и ожидаемую версию приемки (см. models.AnyVersion).
+// Пользователь сохраняется как переоткрывший приемку.
+// models.RoleModerator может переоткрыть приемку в любое время, models.RoleEmployee - только
+// закрытую им самим приемку и только в течение reopenGracePeriod после закрытия.
+// Отмененные и автоматически закрытые приемки сотрудник переоткрыть не может.
+// Возвращает переоткрытую приемку и ошибку, если она возникла.
+func (s *receptionServiceImpl) ReopenLastReception(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, expectedVersion int) (*models.Reception, error) {
+	userRoleType := models.RoleType(userRole)
+	if !userRoleType.Valid() {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	last, err := s.repo.GetLast(ctx, pvzID)
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrNoClosedReceptions
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	if expectedVersion != models.AnyVersion && last.Version != expectedVersion {
+		return nil, domainerrors.ErrVersionMismatch
+	}
+
+	switch last.Status {
+	case models.ReceptionStatusInProgress:
+		return nil, domainerrors.ErrOpenReceptionExists
+	case models.ReceptionStatusCancelled:
+		return nil, domainerrors.ErrReceptionNotReopenable
+	}
+
+	now := time.Now()
+
+	if userRoleType == models.RoleEmployee {
+		if last.ClosedBy == nil || *last.ClosedBy != userID {
+			return nil, domainerrors.ErrNotEnoughRights
+		}
+
+		if last.ClosedAt == nil || now.Sub(*last.ClosedAt) > s.reopenGracePeriod {
+			return nil, domainerrors.ErrReopenGracePeriodExpired
+		}
+	}
+
+	reception, err := s.repo.Reopen(ctx, last.ID, userID, now, expectedVersion)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	metrics.ReceptionsReopened.Inc()
+
+	l.FromContext(ctx, s.logger).Info("audit: reception reopened",
+		zap.String("receptionID", reception.ID.String()),
+		zap.String("pvzID", reception.PVZID.String()),
+		zap.String("reopenedBy", userID.String()),
+		zap.String("role", userRole),
+	)
+
+	return reception, nil
+}
+
+// CloseStaleReceptions автоматически закрывает приемки, которые открыты дольше, чем позволяет policy.
+// Вызывается фоновой задачей, а не пользователем, поэтому роль не проверяется.
+// Отчеты о расхождениях строятся репозиторием в транзакции закрытия, как и при ручном закрытии.
+// По каждой закрытой приемке пишет аудит-запись в лог, увеличивает метрику metrics.ReceptionsAutoClosed
+// и выполняет те же шаги, что и при ручном закрытии (см. afterClose).
+// Блокировка автоматического закрытия удерживается весь проход по городам, поэтому
+// если задачу уже выполняет другая реплика, возвращает domainerrors.ErrAutoCloseLocked.
+// Возвращает количество закрытых приемок и ошибку, если она возникла.
+func (s *receptionServiceImpl) CloseStaleReceptions(ctx context.Context, policy *models.StaleReceptionPolicy) (int, error) {
+	unlock, err := s.repo.LockAutoClose(ctx)
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrLockNotAcquired):
+			return 0, domainerrors.ErrAutoCloseLocked
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return 0, domainerrors.ErrUnexpected
+		}
+
+		return 0, err
+	}
+	defer unlock()
+
+	now := time.Now()
+	total := 0
+
+	for _, city := range models.AllCityTypes() {
+		maxOpenAge := policy.MaxOpenAgeFor(city)
+		reason := fmt.Sprintf("reception was open longer than %s", maxOpenAge)
+
+		closed, err := s.repo.CloseStale(ctx, city, now.Add(-maxOpenAge), now, reason)
+		if err != nil {
+			if errors.Is(err, databaseerrors.ErrUnexpected) {
+				return total, domainerrors.ErrUnexpected
+			}
+
+			return total, err
+		}
+
+		for _, reception := range closed {
+			l.FromContext(ctx, s.logger).Info("audit: reception auto-closed",
+				zap.String("receptionID", reception.ID.String()),
+				zap.String("pvzID", reception.PVZID.String()),
+				zap.String("city", city.String()),
+				zap.String("openedBy", reception.OpenedBy.String()),
+				zap.Time("openedAt", reception.DateTime),
+				zap.String("reason", reason),
+			)
+
+			metrics.ReceptionsAutoClosed.WithLabelValues(city.String()).Inc()
+			s.afterClose(ctx, reception, city)
+		}
+
+		total += len(closed)
+	}
+
+	return total, nil
+}
@@ -11,10 +11,14 @@ import (
 	"time"
 
 	"github.com/google/uuid"
+	"github.com/maksemen2/pvz-service/config"
 	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
 	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/pkg/auth"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
 	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
 	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/zap"
 )
@@ -24,10 +28,13 @@ func TestCloseLastReception(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repositories.NewMockIReceptionRepo(ctrl)
+	mockManifestRepo := mock_repositories.NewMockIManifestRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewReceptionService(logger, mockRepo)
+	svc := service.NewReceptionService(logger, mockRepo, mockManifestRepo, mockPickupRepo, config.ReceptionConfig{ReopenGracePeriodSeconds: 600}, config.PickupConfig{CodeTTLHours: 24})
 
 	pvzID := uuid.New()
+	userID := uuid.New()
 	expectedReception := &models.Reception{
 		ID:       uuid.New(),
 		DateTime: time.Now(),
@@ -36,34 +43,99 @@ func TestCloseLastReception(t *testing.T) {
 	}
 
 	t.Run("Successful close", func(t *testing.T) {
-		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID).Return(expectedReception, nil)
+		closedBefore := testutil.ToFloat64(metrics.ReceptionsClosed.WithLabelValues(models.CityTypeMoscow.String()))
+
+		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID, userID, gomock.Any(), gomock.Any()).Return(expectedReception, models.CityTypeMoscow, nil)
+		mockPickupRepo.EXPECT().OrdersWithoutCode(gomock.Any(), expectedReception.ID).Return([]uuid.UUID{}, nil)
 
 		reception, err := svc.CloseLastReception(
 			context.Background(),
+			userID,
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 
 		assert.NoError(t, err)
 		assert.Equal(t, expectedReception, reception)
+		assert.Nil(t, reception.Discrepancy)
+		assert.Equal(t, closedBefore+1, testutil.ToFloat64(metrics.ReceptionsClosed.WithLabelValues(models.CityTypeMoscow.String())))
+	})
+
+	t.Run("Successful close with manifest", func(t *testing.T) {
+		// Отчет строится репозиторием в транзакции закрытия, сервис возвращает его как есть
+		report := &models.DiscrepancyReport{
+			Items: []models.DiscrepancyItem{
+				{Type: models.ProductTypeShoes, Kind: models.DiscrepancyKindMissing, Expected: 3, Actual: 1},
+			},
+		}
+		reception := &models.Reception{ID: uuid.New(), PVZID: pvzID, Status: models.ReceptionStatusClose, Discrepancy: report}
+		report.ReceptionID = reception.ID
+
+		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID, userID, gomock.Any(), gomock.Any()).Return(reception, models.CityTypeMoscow, nil)
+		mockPickupRepo.EXPECT().OrdersWithoutCode(gomock.Any(), reception.ID).Return([]uuid.UUID{}, nil)
+
+		closed, err := svc.CloseLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.Equal(t, report, closed.Discrepancy)
+	})
+
+	t.Run("Close succeeds when pickup codes can not be issued", func(t *testing.T) {
+		reception := &models.Reception{ID: uuid.New(), PVZID: pvzID, Status: models.ReceptionStatusClose}
+
+		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID, userID, gomock.Any(), gomock.Any()).Return(reception, models.CityTypeMoscow, nil)
+		mockPickupRepo.EXPECT().OrdersWithoutCode(gomock.Any(), reception.ID).Return(nil, databaseerrors.ErrUnexpected)
+
+		closed, err := svc.CloseLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.Empty(t, closed.PickupCodes)
+	})
+
+	t.Run("Successful close issues pickup codes", func(t *testing.T) {
+		reception := &models.Reception{ID: uuid.New(), PVZID: pvzID, Status: models.ReceptionStatusClose}
+		orderIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID, userID, gomock.Any(), gomock.Any()).Return(reception, models.CityTypeMoscow, nil)
+		mockPickupRepo.EXPECT().OrdersWithoutCode(gomock.Any(), reception.ID).Return(orderIDs, nil)
+		mockPickupRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+		// Код, который не удалось сохранить, не возвращается, но приемка остается закрытой
+		mockPickupRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(databaseerrors.ErrUnexpected)
+
+		closed, err := svc.CloseLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.Len(t, closed.PickupCodes, 1)
+
+		code := closed.PickupCodes[0]
+		assert.Equal(t, orderIDs[0], code.OrderID)
+		assert.Equal(t, pvzID, code.PVZID)
+		assert.Len(t, code.Code, 6)
+		assert.True(t, auth.ComparePassword(code.Code, code.CodeHash))
+		assert.Equal(t, code.CreatedAt.Add(24*time.Hour), code.ExpiresAt)
 	})
 
 	t.Run("Invalid role", func(t *testing.T) {
 		_, err := svc.CloseLastReception(
 			context.Background(),
+			userID,
 			models.RoleModerator.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
 	})
 
 	t.Run("Repository unexpected error", func(t *testing.T) {
-		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID).Return(nil, databaseerrors.ErrUnexpected)
+		mockRepo.EXPECT().CloseLast(gomock.Any(), pvzID, userID, gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrUnexpected)
 
 		_, err := svc.CloseLastReception(
 			context.Background(),
+			userID,
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
 	})
@@ -75,18 +147,22 @@ func TestCreateReceptionIfNoOpen(t *testing.T) {
 	defer ctrl.Finish()
 
 	mockRepo := mock_repositories.NewMockIReceptionRepo(ctrl)
+	mockManifestRepo := mock_repositories.NewMockIManifestRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
 	logger := zap.NewNop()
-	svc := service.NewReceptionService(logger, mockRepo)
+	svc := service.NewReceptionService(logger, mockRepo, mockManifestRepo, mockPickupRepo, config.ReceptionConfig{ReopenGracePeriodSeconds: 600}, config.PickupConfig{CodeTTLHours: 24})
 
 	pvzID := uuid.New()
+	userID := uuid.New()
 
 	t.Run("Successful create", func(t *testing.T) {
-		mockRepo.EXPECT().CreateIfNoOpen(gomock.Any(), gomock.Any()).DoAndReturn(
-			func(_ context.Context, r *models.Reception) error {
+		mockRepo.EXPECT().CreateIfNoOpen(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, r *models.Reception, _ int) error {
 				assert.NotEqual(t, uuid.Nil, r.ID)
 				assert.False(t, r.DateTime.IsZero())
 				assert.Equal(t, pvzID, r.PVZID)
 				assert.Equal(t, models.ReceptionStatusInProgress, r.Status)
+				assert.Equal(t, userID, r.OpenedBy)
 
 				return nil
 			},
@@ -94,8 +170,10 @@ func TestCreateReceptionIfNoOpen(t *testing.T) {
 
 		reception, err := svc.CreateReceptionIfNoOpen(
 			context.Background(),
+			userID,
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 
 		assert.NoError(t, err)
@@ -106,31 +184,347 @@ func TestCreateReceptionIfNoOpen(t *testing.T) {
 	t.Run("Invalid role", func(t *testing.T) {
 		_, err := svc.CreateReceptionIfNoOpen(
 			context.Background(),
+			userID,
 			models.RoleModerator.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
 	})
 
 	t.Run("Repository unexpected error", func(t *testing.T) {
-		mockRepo.EXPECT().CreateIfNoOpen(gomock.Any(), gomock.Any()).Return(databaseerrors.ErrUnexpected)
+		mockRepo.EXPECT().CreateIfNoOpen(gomock.Any(), gomock.Any(), gomock.Any()).Return(databaseerrors.ErrUnexpected)
 
 		_, err := svc.CreateReceptionIfNoOpen(
 			context.Background(),
+			userID,
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
 		)
 		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
 	})
 
 	t.Run("No rows repository error", func(t *testing.T) {
-		mockRepo.EXPECT().CreateIfNoOpen(gomock.Any(), gomock.Any()).Return(databaseerrors.ErrNoRows)
+		mockRepo.EXPECT().CreateIfNoOpen(gomock.Any(), gomock.Any(), gomock.Any()).Return(databaseerrors.ErrNoRows)
 
 		_, err := svc.CreateReceptionIfNoOpen(
 			context.Background(),
+			userID,
 			models.RoleEmployee.String(),
 			pvzID,
+			models.AnyVersion,
+		)
+		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
+	})
+}
+
+func TestCloseStaleReceptions(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReceptionRepo(ctrl)
+	mockManifestRepo := mock_repositories.NewMockIManifestRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewReceptionService(logger, mockRepo, mockManifestRepo, mockPickupRepo, config.ReceptionConfig{ReopenGracePeriodSeconds: 600}, config.PickupConfig{CodeTTLHours: 24})
+
+	policy := &models.StaleReceptionPolicy{
+		MaxOpenAge: 12 * time.Hour,
+		CityMaxOpenAge: map[models.CityType]time.Duration{
+			models.CityTypeKazan: time.Hour,
+		},
+	}
+
+	t.Run("Successful close with city override", func(t *testing.T) {
+		before := time.Now()
+		unlocked := false
+
+		// Блокировка берется один раз на весь проход и снимается после последнего города
+		mockRepo.EXPECT().LockAutoClose(gomock.Any()).Return(func() { unlocked = true }, nil)
+
+		for _, city := range models.AllCityTypes() {
+			expectedAge := policy.MaxOpenAgeFor(city)
+			closed := []*models.Reception{}
+
+			if city == models.CityTypeKazan {
+				reception := &models.Reception{ID: uuid.New(), PVZID: uuid.New(), Status: models.ReceptionStatusClose, AutoClosed: true}
+				closed = append(closed, reception)
+
+				// Для автоматически закрытых приемок коды создаются так же, как при ручном закрытии
+				mockPickupRepo.EXPECT().OrdersWithoutCode(gomock.Any(), reception.ID).Return([]uuid.UUID{uuid.New()}, nil)
+				mockPickupRepo.EXPECT().Save(gomock.Any(), gomock.Any()).Return(nil)
+			}
+
+			mockRepo.EXPECT().
+				CloseStale(gomock.Any(), city, gomock.Any(), gomock.Any(), gomock.Any()).
+				DoAndReturn(func(_ context.Context, _ models.CityType, openedBefore, closedAt time.Time, reason string) ([]*models.Reception, error) {
+					assert.WithinDuration(t, closedAt.Add(-expectedAge), openedBefore, time.Millisecond)
+					assert.False(t, closedAt.Before(before))
+					assert.Contains(t, reason, expectedAge.String())
+
+					return closed, nil
+				})
+		}
+
+		count, err := svc.CloseStaleReceptions(context.Background(), policy)
+
+		assert.NoError(t, err)
+		assert.Equal(t, 1, count)
+		assert.True(t, unlocked)
+	})
+
+	t.Run("Lock held by another instance", func(t *testing.T) {
+		mockRepo.EXPECT().LockAutoClose(gomock.Any()).Return(nil, databaseerrors.ErrLockNotAcquired)
+
+		_, err := svc.CloseStaleReceptions(context.Background(), policy)
+		assert.ErrorIs(t, err, domainerrors.ErrAutoCloseLocked)
+	})
+
+	t.Run("Repository unexpected error releases lock", func(t *testing.T) {
+		unlocked := false
+
+		mockRepo.EXPECT().LockAutoClose(gomock.Any()).Return(func() { unlocked = true }, nil)
+		mockRepo.EXPECT().
+			CloseStale(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).
+			Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.CloseStaleReceptions(context.Background(), policy)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+		assert.True(t, unlocked)
+	})
+}
+
+func TestCancelLastReception(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReceptionRepo(ctrl)
+	mockManifestRepo := mock_repositories.NewMockIManifestRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewReceptionService(logger, mockRepo, mockManifestRepo, mockPickupRepo, config.ReceptionConfig{ReopenGracePeriodSeconds: 600}, config.PickupConfig{CodeTTLHours: 24})
+
+	pvzID := uuid.New()
+	userID := uuid.New()
+	expectedReception := &models.Reception{
+		ID:          uuid.New(),
+		PVZID:       pvzID,
+		Status:      models.ReceptionStatusCancelled,
+		CloseReason: "wrong pvz",
+	}
+
+	t.Run("Successful cancel by moderator", func(t *testing.T) {
+		mockRepo.EXPECT().CancelLast(gomock.Any(), pvzID, userID, gomock.Any(), "wrong pvz", gomock.Any()).Return(expectedReception, nil)
+
+		reception, err := svc.CancelLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, "  wrong pvz ", models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.Equal(t, expectedReception, reception)
+	})
+
+	t.Run("Successful cancel by employee", func(t *testing.T) {
+		mockRepo.EXPECT().CancelLast(gomock.Any(), pvzID, userID, gomock.Any(), "wrong pvz", gomock.Any()).Return(expectedReception, nil)
+
+		_, err := svc.CancelLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, "wrong pvz", models.AnyVersion)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Empty reason", func(t *testing.T) {
+		_, err := svc.CancelLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, "   ", models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrCancelReasonEmpty)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.CancelLastReception(context.Background(), userID, "invalid_role", pvzID, "wrong pvz", models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("No open receptions", func(t *testing.T) {
+		mockRepo.EXPECT().CancelLast(gomock.Any(), pvzID, userID, gomock.Any(), "wrong pvz", gomock.Any()).Return(nil, domainerrors.ErrNoOpenReceptions)
+
+		_, err := svc.CancelLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, "wrong pvz", models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNoOpenReceptions)
+	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().CancelLast(gomock.Any(), pvzID, userID, gomock.Any(), "wrong pvz", gomock.Any()).Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.CancelLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, "wrong pvz", models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestReopenLastReception(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReceptionRepo(ctrl)
+	mockManifestRepo := mock_repositories.NewMockIManifestRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewReceptionService(logger, mockRepo, mockManifestRepo, mockPickupRepo, config.ReceptionConfig{ReopenGracePeriodSeconds: 600}, config.PickupConfig{CodeTTLHours: 24})
+
+	pvzID := uuid.New()
+	userID := uuid.New()
+
+	closedReception := func(closedBy uuid.UUID, closedAgo time.Duration) *models.Reception {
+		closedAt := time.Now().Add(-closedAgo)
+
+		return &models.Reception{
+			ID:       uuid.New(),
+			PVZID:    pvzID,
+			Status:   models.ReceptionStatusClose,
+			ClosedAt: &closedAt,
+			ClosedBy: &closedBy,
+		}
+	}
+
+	t.Run("Employee reopens own reception within grace period", func(t *testing.T) {
+		last := closedReception(userID, time.Minute)
+		reopened := &models.Reception{ID: last.ID, PVZID: pvzID, Status: models.ReceptionStatusInProgress, ReopenedBy: &userID}
+
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(last, nil)
+		mockRepo.EXPECT().Reopen(gomock.Any(), last.ID, userID, gomock.Any(), gomock.Any()).Return(reopened, nil)
+
+		reception, err := svc.ReopenLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.Equal(t, reopened, reception)
+	})
+
+	t.Run("Version mismatch", func(t *testing.T) {
+		last := closedReception(userID, time.Minute)
+		last.Version = 3
+
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(last, nil)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, 2)
+		assert.ErrorIs(t, err, domainerrors.ErrVersionMismatch)
+	})
+
+	t.Run("Employee reopens after grace period", func(t *testing.T) {
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(closedReception(userID, time.Hour), nil)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrReopenGracePeriodExpired)
+	})
+
+	t.Run("Employee reopens reception closed by another user", func(t *testing.T) {
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(closedReception(uuid.New(), time.Minute), nil)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleEmployee.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Moderator reopens any closed reception", func(t *testing.T) {
+		last := closedReception(uuid.New(), 24*time.Hour)
+
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(last, nil)
+		mockRepo.EXPECT().Reopen(gomock.Any(), last.ID, userID, gomock.Any(), gomock.Any()).Return(last, nil)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, models.AnyVersion)
+		assert.NoError(t, err)
+	})
+
+	t.Run("Last reception is open", func(t *testing.T) {
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(&models.Reception{Status: models.ReceptionStatusInProgress}, nil)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrOpenReceptionExists)
+	})
+
+	t.Run("Last reception is cancelled", func(t *testing.T) {
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(&models.Reception{Status: models.ReceptionStatusCancelled}, nil)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	t.Run("No receptions", func(t *testing.T) {
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(nil, databaseerrors.ErrNoRows)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNoClosedReceptions)
+	})
+
+	t.Run("Newer reception appeared concurrently", func(t *testing.T) {
+		last := closedReception(uuid.New(), time.Minute)
+
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(last, nil)
+		mockRepo.EXPECT().Reopen(gomock.Any(), last.ID, userID, gomock.Any(), gomock.Any()).Return(nil, domainerrors.ErrReceptionNotReopenable)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrReceptionNotReopenable)
+	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().GetLast(gomock.Any(), pvzID).Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.ReopenLastReception(context.Background(), userID, models.RoleModerator.String(), pvzID, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestAttachManifest(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReceptionRepo(ctrl)
+	mockManifestRepo := mock_repositories.NewMockIManifestRepo(ctrl)
+	mockPickupRepo := mock_repositories.NewMockIPickupCodeRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewReceptionService(logger, mockRepo, mockManifestRepo, mockPickupRepo, config.ReceptionConfig{ReopenGracePeriodSeconds: 600}, config.PickupConfig{CodeTTLHours: 24})
+
+	pvzID := uuid.New()
+	userID := uuid.New()
+	items := []models.ManifestItem{{Type: models.ProductTypeShoes, Count: 2}}
+
+	t.Run("Successful attach", func(t *testing.T) {
+		mockManifestRepo.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+			func(_ context.Context, manifest *models.Manifest, _ int) error {
+				assert.Equal(t, pvzID, manifest.PVZID)
+				assert.Equal(t, userID, manifest.CreatedBy)
+				assert.Equal(t, items, manifest.Items)
+
+				return nil
+			},
 		)
+
+		manifest, err := svc.AttachManifest(context.Background(), userID, models.RoleModerator.String(), pvzID, items, models.AnyVersion)
+
+		assert.NoError(t, err)
+		assert.NotEqual(t, uuid.Nil, manifest.ID)
+	})
+
+	t.Run("Employee can not attach", func(t *testing.T) {
+		_, err := svc.AttachManifest(context.Background(), userID, models.RoleEmployee.String(), pvzID, items, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Invalid manifest", func(t *testing.T) {
+		for _, invalidItems := range [][]models.ManifestItem{
+			nil,
+			{{Type: "invalid", Count: 1}},
+			{{Type: models.ProductTypeShoes, Count: 0}},
+			{{Type: models.ProductTypeShoes, Count: 1}, {Type: models.ProductTypeShoes, Count: 2}},
+		} {
+			_, err := svc.AttachManifest(context.Background(), userID, models.RoleModerator.String(), pvzID, invalidItems, models.AnyVersion)
+			assert.ErrorIs(t, err, domainerrors.ErrInvalidManifest)
+		}
+	})
+
+	t.Run("PVZ not found", func(t *testing.T) {
+		mockManifestRepo.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any()).Return(databaseerrors.ErrNoRows)
+
+		_, err := svc.AttachManifest(context.Background(), userID, models.RoleModerator.String(), pvzID, items, models.AnyVersion)
 		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
 	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockManifestRepo.EXPECT().Save(gomock.Any(), gomock.Any(), gomock.Any()).Return(databaseerrors.ErrUnexpected)
+
+		_, err := svc.AttachManifest(context.Background(), userID, models.RoleModerator.String(), pvzID, items, models.AnyVersion)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
 }
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// maxBarcodeLength - максимальная длина штрихкода возвращенного товара.
+const maxBarcodeLength = 64
+
+// ReturnService - интерфейс для бизнес-логики возвратов товаров клиентами.
+type ReturnService interface {
+	RegisterReturn(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, productID *uuid.UUID, barcode, productType, reason, condition string) (*models.CustomerReturn, error) // Регистрирует возврат товара клиентом в ПВЗ
+	ListReturns(ctx context.Context, userRole string, pvzID uuid.UUID, startDate, endDate *time.Time) (*models.ReturnsReport, error)                                                              // Возвращает возвраты ПВЗ за период и отчет по ним
+	DispatchReturns(ctx context.Context, userRole string, pvzID uuid.UUID) (*models.Shipment, error)                                                                                              // Отправляет собранную отправку возвратов ПВЗ
+}
+
+// returnServiceImpl реализует интерфейс ReturnService.
+type returnServiceImpl struct {
+	logger     *zap.Logger
+	returnRepo repositories.IReturnRepo
+}
+
+// NewReturnService - конструктор для создания нового экземпляра ReturnService.
+// Принимает логгер и репозиторий возвратов.
+func NewReturnService(logger *zap.Logger, returnRepo repositories.IReturnRepo) ReturnService {
+	return &returnServiceImpl{
+		logger:     logger,
+		returnRepo: returnRepo,
+	}
+}
+
+// RegisterReturn регистрирует возврат товара клиентом в ПВЗ. Пользователь сохраняется как принявший возврат.
+// Проводит валидацию роли пользователя (только models.RoleEmployee может принимать возвраты).
+// Если productID не nil, возвращается выданный в этом ПВЗ товар, его тип берется из товара, а productType не используется.
+// Иначе товар неизвестен и для него обязательны штрихкод и тип (см. models.ProductType).
+// Проводит валидацию причины (см. models.ReturnReason) и состояния товара (см. models.ReturnCondition).
+// Возврат попадает в собираемую отправку возвратов ПВЗ.
+func (s *returnServiceImpl) RegisterReturn(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID, productID *uuid.UUID, barcode, productType, reason, condition string) (*models.CustomerReturn, error) {
+	if models.RoleType(userRole) != models.RoleEmployee {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	ret := &models.CustomerReturn{
+		ID:        uuid.New(),
+		PVZID:     pvzID,
+		ProductID: productID,
+		Barcode:   strings.TrimSpace(barcode),
+		Reason:    models.ReturnReason(reason),
+		Condition: models.ReturnCondition(condition),
+		CreatedBy: userID,
+		DateTime:  time.Now(),
+	}
+
+	if !ret.Reason.Valid() {
+		l.FromContext(ctx, s.logger).Debug("Invalid return reason", zap.String("reason", reason))
+		return nil, domainerrors.ErrInvalidReturnReason
+	}
+
+	if !ret.Condition.Valid() {
+		l.FromContext(ctx, s.logger).Debug("Invalid return condition", zap.String("condition", condition))
+		return nil, domainerrors.ErrInvalidReturnCondition
+	}
+
+	if len(ret.Barcode) > maxBarcodeLength {
+		return nil, domainerrors.ErrInvalidBarcode
+	}
+
+	// Неизвестный системе товар можно опознать только по штрихкоду
+	if productID == nil {
+		if ret.Barcode == "" {
+			return nil, domainerrors.ErrInvalidBarcode
+		}
+
+		ret.Type = models.ProductType(productType)
+		if !ret.Type.Valid() {
+			l.FromContext(ctx, s.logger).Debug("Invalid product type", zap.String("productType", productType))
+			return nil, domainerrors.ErrInvalidProductType
+		}
+	}
+
+	registered, city, err := s.returnRepo.Register(ctx, ret)
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		l.FromContext(ctx, s.logger).Debug("Return can not be registered", zap.String("pvzID", pvzID.String()), zap.Error(err))
+
+		return nil, err
+	}
+
+	metrics.CustomerReturns.WithLabelValues(city.String(), registered.Reason.String()).Inc()
+
+	return registered, nil
+}
+
+// ListReturns возвращает возвраты ПВЗ, зарегистрированные в период от startDate до endDate, и их количество
+// по причинам и состояниям (см. models.ReturnsReport). Границы периода необязательны.
+// Проводит валидацию роли пользователя (только models.RoleEmployee и models.RoleModerator могут просматривать возвраты).
+func (s *returnServiceImpl) ListReturns(ctx context.Context, userRole string, pvzID uuid.UUID, startDate, endDate *time.Time) (*models.ReturnsReport, error) {
+	roleType := models.RoleType(userRole)
+	if roleType != models.RoleEmployee && roleType != models.RoleModerator {
+		l.FromContext(ctx, s.logger).Debug("User is not moderator or employee", zap.String("userRole", userRole))
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	if startDate != nil && endDate != nil && startDate.After(*endDate) {
+		return nil, domainerrors.ErrInvalidDateRange
+	}
+
+	returns, err := s.returnRepo.ListByPVZ(ctx, pvzID, startDate, endDate)
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	return models.NewReturnsReport(pvzID, returns), nil
+}
+
+// DispatchReturns отправляет из ПВЗ собранную отправку возвратов. Следующие возвраты попадут в новую отправку.
+// Проводит валидацию роли пользователя (только models.RoleEmployee может отправлять возвраты).
+// Если в ПВЗ нет собираемой отправки, возвращает domainerrors.ErrNoPendingReturns.
+func (s *returnServiceImpl) DispatchReturns(ctx context.Context, userRole string, pvzID uuid.UUID) (*models.Shipment, error) {
+	if models.RoleType(userRole) != models.RoleEmployee {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	shipment, err := s.returnRepo.Dispatch(ctx, pvzID, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		l.FromContext(ctx, s.logger).Debug("Returns can not be dispatched", zap.String("pvzID", pvzID.String()), zap.Error(err))
+
+		return nil, err
+	}
+
+	return shipment, nil
+}
@@ -0,0 +1,231 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterReturn(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReturnRepo(ctrl)
+	svc := service.NewReturnService(zap.NewNop(), mockRepo)
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+	productID := uuid.New()
+	employee := models.RoleEmployee.String()
+
+	t.Run("Issued product", func(t *testing.T) {
+		counter := metrics.CustomerReturns.WithLabelValues(models.CityTypeKazan.String(), models.ReturnReasonDefective.String())
+		returnedBefore := testutil.ToFloat64(counter)
+
+		mockRepo.EXPECT().
+			Register(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, ret *models.CustomerReturn) (*models.CustomerReturn, models.CityType, error) {
+				assert.NotEqual(t, uuid.Nil, ret.ID)
+				assert.Equal(t, pvzID, ret.PVZID)
+				require.NotNil(t, ret.ProductID)
+				assert.Equal(t, productID, *ret.ProductID)
+				assert.Equal(t, userID, ret.CreatedBy)
+				assert.Equal(t, models.ReturnReasonDefective, ret.Reason)
+				assert.Equal(t, models.ReturnConditionOpened, ret.Condition)
+
+				// Тип известного товара берет репозиторий
+				assert.Empty(t, ret.Type)
+
+				result := *ret
+				result.Type = models.ProductTypeElectronics
+				result.ShipmentID = uuid.New()
+
+				return &result, models.CityTypeKazan, nil
+			})
+
+		ret, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, &productID, "", "", "defective", "opened")
+		require.NoError(t, err)
+		assert.Equal(t, models.ProductTypeElectronics, ret.Type)
+		assert.Equal(t, returnedBefore+1, testutil.ToFloat64(counter))
+	})
+
+	t.Run("Unknown product", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Register(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, ret *models.CustomerReturn) (*models.CustomerReturn, models.CityType, error) {
+				assert.Nil(t, ret.ProductID)
+				assert.Equal(t, "4600000000017", ret.Barcode)
+				assert.Equal(t, models.ProductTypeShoes, ret.Type)
+
+				return ret, models.CityTypeMoscow, nil
+			})
+
+		ret, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, nil, " 4600000000017 ", "обувь", "does_not_fit", "new")
+		require.NoError(t, err)
+		assert.Equal(t, models.ReturnConditionNew, ret.Condition)
+	})
+
+	// Только сотрудник может принимать возвраты
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.RegisterReturn(context.Background(), userID, models.RoleModerator.String(), pvzID, &productID, "", "", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Invalid reason", func(t *testing.T) {
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, &productID, "", "", "broken", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidReturnReason)
+	})
+
+	t.Run("Invalid condition", func(t *testing.T) {
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, &productID, "", "", "defective", "perfect")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidReturnCondition)
+	})
+
+	t.Run("Missing barcode", func(t *testing.T) {
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, nil, "  ", "обувь", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidBarcode)
+	})
+
+	t.Run("Barcode too long", func(t *testing.T) {
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, nil, strings.Repeat("1", 65), "обувь", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidBarcode)
+	})
+
+	t.Run("Invalid product type", func(t *testing.T) {
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, nil, "4600000000017", "мебель", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidProductType)
+	})
+
+	t.Run("Product not issued", func(t *testing.T) {
+		mockRepo.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), domainerrors.ErrProductNotIssued)
+
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, &productID, "", "", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrProductNotIssued)
+	})
+
+	t.Run("PVZ not found", func(t *testing.T) {
+		mockRepo.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrNoRows)
+
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, &productID, "", "", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().Register(gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrUnexpected)
+
+		_, err := svc.RegisterReturn(context.Background(), userID, employee, pvzID, &productID, "", "", "defective", "opened")
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestListReturns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReturnRepo(ctrl)
+	svc := service.NewReturnService(zap.NewNop(), mockRepo)
+
+	pvzID := uuid.New()
+
+	t.Run("Successful listing", func(t *testing.T) {
+		returns := []*models.CustomerReturn{
+			{ID: uuid.New(), Reason: models.ReturnReasonDefective, Condition: models.ReturnConditionDamaged},
+			{ID: uuid.New(), Reason: models.ReturnReasonDefective, Condition: models.ReturnConditionOpened},
+			{ID: uuid.New(), Reason: models.ReturnReasonChangedMind, Condition: models.ReturnConditionNew},
+		}
+
+		mockRepo.EXPECT().ListByPVZ(gomock.Any(), pvzID, nil, nil).Return(returns, nil)
+
+		report, err := svc.ListReturns(context.Background(), models.RoleModerator.String(), pvzID, nil, nil)
+		require.NoError(t, err)
+		assert.Equal(t, pvzID, report.PVZID)
+		assert.Equal(t, returns, report.Returns)
+		assert.Equal(t, 2, report.ByReason[models.ReturnReasonDefective])
+		assert.Equal(t, 1, report.ByReason[models.ReturnReasonChangedMind])
+		assert.Equal(t, 1, report.ByCondition[models.ReturnConditionDamaged])
+
+		// Причины и состояния без возвратов попадают в отчет с нулевым количеством
+		assert.Len(t, report.ByReason, len(models.AllReturnReasons()))
+		assert.Len(t, report.ByCondition, len(models.AllReturnConditions()))
+		assert.Zero(t, report.ByCondition[models.ReturnConditionUsed])
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.ListReturns(context.Background(), "client", pvzID, nil, nil)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Invalid date range", func(t *testing.T) {
+		start := time.Now()
+		end := start.Add(-time.Hour)
+
+		_, err := svc.ListReturns(context.Background(), models.RoleEmployee.String(), pvzID, &start, &end)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidDateRange)
+	})
+
+	t.Run("PVZ not found", func(t *testing.T) {
+		mockRepo.EXPECT().ListByPVZ(gomock.Any(), pvzID, nil, nil).Return(nil, databaseerrors.ErrNoRows)
+
+		_, err := svc.ListReturns(context.Background(), models.RoleEmployee.String(), pvzID, nil, nil)
+		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
+	})
+}
+
+func TestDispatchReturns(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIReturnRepo(ctrl)
+	svc := service.NewReturnService(zap.NewNop(), mockRepo)
+
+	pvzID := uuid.New()
+
+	t.Run("Successful dispatch", func(t *testing.T) {
+		mockRepo.EXPECT().
+			Dispatch(gomock.Any(), pvzID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, dispatchedAt time.Time) (*models.Shipment, error) {
+				assert.WithinDuration(t, time.Now(), dispatchedAt, time.Minute)
+
+				return &models.Shipment{ID: uuid.New(), PVZID: pvzID, Kind: models.ShipmentKindCustomerReturns, DispatchedAt: &dispatchedAt}, nil
+			})
+
+		shipment, err := svc.DispatchReturns(context.Background(), models.RoleEmployee.String(), pvzID)
+		require.NoError(t, err)
+		assert.NotNil(t, shipment.DispatchedAt)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.DispatchReturns(context.Background(), models.RoleModerator.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("No pending returns", func(t *testing.T) {
+		mockRepo.EXPECT().Dispatch(gomock.Any(), pvzID, gomock.Any()).Return(nil, domainerrors.ErrNoPendingReturns)
+
+		_, err := svc.DispatchReturns(context.Background(), models.RoleEmployee.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrNoPendingReturns)
+	})
+
+	t.Run("PVZ not found", func(t *testing.T) {
+		mockRepo.EXPECT().Dispatch(gomock.Any(), pvzID, gomock.Any()).Return(nil, databaseerrors.ErrNoRows)
+
+		_, err := svc.DispatchReturns(context.Background(), models.RoleEmployee.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
+	})
+}
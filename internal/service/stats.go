@@ -0,0 +1,113 @@
+package service
+
+import (
+	"context"
+	"errors"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"time"
+
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// StatsService - интерфейс для получения аналитики по приемкам и товарам.
+type StatsService interface {
+	GetStats(ctx context.Context, userRole string, startDate, endDate time.Time) (*models.Stats, error) // Возвращает статистику приемок по ПВЗ и товаров по типам за указанный период.
+	RefreshBusinessMetrics(ctx context.Context) error                                                   // Пересчитывает бизнес-метрики текущего состояния из базы данных.
+}
+
+// statsServiceImpl реализует интерфейс StatsService.
+type statsServiceImpl struct {
+	logger    *zap.Logger
+	statsRepo repositories.IStatsRepo
+}
+
+// NewStatsService - конструктор для создания нового экземпляра StatsService.
+// Принимает логгер и репозиторий статистики.
+func NewStatsService(logger *zap.Logger, statsRepo repositories.IStatsRepo) StatsService {
+	return &statsServiceImpl{
+		logger:    logger,
+		statsRepo: statsRepo,
+	}
+}
+
+// GetStats возвращает статистику за период.
+// Проводит валидацию роли пользователя (только models.RoleModerator может просматривать статистику).
+// Проводит валидацию периода (см. models.StatsFilter).
+// Возвращает доменную модель статистики или ошибку.
+func (s *statsServiceImpl) GetStats(ctx context.Context, userRole string, startDate, endDate time.Time) (*models.Stats, error) {
+	if models.RoleType(userRole) != models.RoleModerator {
+		l.FromContext(ctx, s.logger).Debug("User is not moderator", zap.String("userRole", userRole))
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	filter := models.StatsFilter{StartDate: startDate, EndDate: endDate}
+
+	if err := filter.Valid(); err != nil {
+		l.FromContext(ctx, s.logger).Debug("Invalid stats filter", zap.Error(err))
+		return nil, err
+	}
+
+	pvzStats, err := s.statsRepo.GetPVZStats(ctx, &filter)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	productTypeStats, err := s.statsRepo.GetProductTypeStats(ctx, &filter)
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	return &models.Stats{
+		PVZs:         pvzStats,
+		ProductTypes: productTypeStats,
+	}, nil
+}
+
+// RefreshBusinessMetrics пересчитывает метрики текущего состояния: открытые приемки по городам,
+// возраст самой старой открытой приемки, товары, принятые с начала текущего дня по UTC, и количество ПВЗ.
+// Для городов и типов товаров без данных метрики выставляются в 0, чтобы они не пропадали из выдачи.
+// Вызывается фоновой задачей, а не пользователем, поэтому роль не проверяется.
+func (s *statsServiceImpl) RefreshBusinessMetrics(ctx context.Context) error {
+	now := time.Now().UTC()
+
+	snapshot, err := s.statsRepo.GetBusinessSnapshot(ctx, now.Truncate(24*time.Hour))
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return domainerrors.ErrUnexpected
+		}
+
+		return err
+	}
+
+	for _, city := range models.AllCityTypes() {
+		metrics.OpenReceptions.WithLabelValues(city.String()).Set(float64(snapshot.OpenReceptions[city]))
+
+		for _, productType := range models.AllProductTypes() {
+			metrics.ProductsReceivedToday.WithLabelValues(city.String(), productType.String()).
+				Set(float64(snapshot.ProductsReceived[city][productType]))
+		}
+	}
+
+	oldestAge := 0.0
+	if snapshot.OldestOpenReceptionAt != nil {
+		oldestAge = now.Sub(*snapshot.OldestOpenReceptionAt).Seconds()
+	}
+
+	metrics.OldestOpenReceptionAge.Set(oldestAge)
+	metrics.PVZTotal.Set(float64(snapshot.PVZCount))
+
+	return nil
+}
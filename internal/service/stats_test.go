@@ -0,0 +1,137 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+)
+
+func TestGetStats(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIStatsRepo(ctrl)
+	logger := zap.NewNop()
+	svc := service.NewStatsService(logger, mockRepo)
+
+	startDate := time.Now().Add(-24 * time.Hour)
+	endDate := time.Now()
+	expectedFilter := &models.StatsFilter{StartDate: startDate, EndDate: endDate}
+
+	t.Run("Successful stats", func(t *testing.T) {
+		pvzStats := []*models.PVZStats{
+			{PVZID: uuid.New(), City: models.CityTypeKazan, ReceptionsCount: 2, ClosedReceptionsCount: 1},
+		}
+		productTypeStats := []*models.ProductTypeStats{
+			{City: models.CityTypeKazan, Day: startDate, Type: models.ProductTypeClothes, Count: 4},
+		}
+
+		mockRepo.EXPECT().GetPVZStats(gomock.Any(), expectedFilter).Return(pvzStats, nil)
+		mockRepo.EXPECT().GetProductTypeStats(gomock.Any(), expectedFilter).Return(productTypeStats, nil)
+
+		stats, err := svc.GetStats(context.Background(), models.RoleModerator.String(), startDate, endDate)
+
+		assert.NoError(t, err)
+		assert.Equal(t, pvzStats, stats.PVZs)
+		assert.Equal(t, productTypeStats, stats.ProductTypes)
+	})
+
+	t.Run("Employee is not allowed", func(t *testing.T) {
+		_, err := svc.GetStats(context.Background(), models.RoleEmployee.String(), startDate, endDate)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("Start date after end date", func(t *testing.T) {
+		_, err := svc.GetStats(context.Background(), models.RoleModerator.String(), endDate, startDate)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidDateRange)
+	})
+
+	t.Run("Missing dates", func(t *testing.T) {
+		_, err := svc.GetStats(context.Background(), models.RoleModerator.String(), time.Time{}, endDate)
+		assert.ErrorIs(t, err, domainerrors.ErrInvalidDateRange)
+	})
+
+	t.Run("Repository unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().GetPVZStats(gomock.Any(), expectedFilter).Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.GetStats(context.Background(), models.RoleModerator.String(), startDate, endDate)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+
+	t.Run("Product type stats unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().GetPVZStats(gomock.Any(), expectedFilter).Return([]*models.PVZStats{}, nil)
+		mockRepo.EXPECT().GetProductTypeStats(gomock.Any(), expectedFilter).Return(nil, databaseerrors.ErrUnexpected)
+
+		_, err := svc.GetStats(context.Background(), models.RoleModerator.String(), startDate, endDate)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestRefreshBusinessMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIStatsRepo(ctrl)
+	svc := service.NewStatsService(zap.NewNop(), mockRepo)
+
+	t.Run("Success", func(t *testing.T) {
+		oldestAt := time.Now().Add(-time.Hour)
+
+		mockRepo.EXPECT().GetBusinessSnapshot(gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, dayStart time.Time) (*models.BusinessSnapshot, error) {
+				assert.Equal(t, time.Now().UTC().Truncate(24*time.Hour), dayStart)
+
+				return &models.BusinessSnapshot{
+					OpenReceptions:        map[models.CityType]int{models.CityTypeMoscow: 2},
+					OldestOpenReceptionAt: &oldestAt,
+					ProductsReceived: map[models.CityType]map[models.ProductType]int{
+						models.CityTypeKazan: {models.ProductTypeShoes: 5},
+					},
+					PVZCount: 3,
+				}, nil
+			})
+
+		err := svc.RefreshBusinessMetrics(context.Background())
+		assert.NoError(t, err)
+
+		assert.Equal(t, 2.0, testutil.ToFloat64(metrics.OpenReceptions.WithLabelValues(models.CityTypeMoscow.String())))
+		// Для городов без открытых приемок метрика выставляется в 0
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.OpenReceptions.WithLabelValues(models.CityTypeSPB.String())))
+		assert.Equal(t, 5.0, testutil.ToFloat64(metrics.ProductsReceivedToday.WithLabelValues(models.CityTypeKazan.String(), models.ProductTypeShoes.String())))
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.ProductsReceivedToday.WithLabelValues(models.CityTypeMoscow.String(), models.ProductTypeClothes.String())))
+		assert.InDelta(t, time.Hour.Seconds(), testutil.ToFloat64(metrics.OldestOpenReceptionAge), 5)
+		assert.Equal(t, 3.0, testutil.ToFloat64(metrics.PVZTotal))
+	})
+
+	t.Run("No open receptions", func(t *testing.T) {
+		mockRepo.EXPECT().GetBusinessSnapshot(gomock.Any(), gomock.Any()).Return(&models.BusinessSnapshot{}, nil)
+
+		err := svc.RefreshBusinessMetrics(context.Background())
+		assert.NoError(t, err)
+
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.OpenReceptions.WithLabelValues(models.CityTypeMoscow.String())))
+		assert.Equal(t, 0.0, testutil.ToFloat64(metrics.OldestOpenReceptionAge))
+	})
+
+	t.Run("Repository error", func(t *testing.T) {
+		mockRepo.EXPECT().GetBusinessSnapshot(gomock.Any(), gomock.Any()).Return(nil, databaseerrors.ErrUnexpected)
+
+		err := svc.RefreshBusinessMetrics(context.Background())
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
@@ -0,0 +1,152 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	"github.com/maksemen2/pvz-service/internal/domain/repositories"
+	l "github.com/maksemen2/pvz-service/internal/pkg/logger"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"go.uber.org/zap"
+)
+
+// StorageService - интерфейс для бизнес-логики сроков хранения товаров в ПВЗ.
+type StorageService interface {
+	ListExpiring(ctx context.Context, userRole string, pvzID uuid.UUID) ([]*models.ExpiringProduct, error)            // Возвращает товары ПВЗ, срок хранения которых истек или скоро истечет
+	ReturnToSender(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID) (*models.Shipment, error) // Возвращает отправителю товары ПВЗ с истекшим сроком хранения
+	RefreshExpiryMetrics(ctx context.Context) error                                                                   // Пересчитывает метрику товаров с истекшим сроком хранения
+}
+
+// storageServiceImpl реализует интерфейс StorageService.
+type storageServiceImpl struct {
+	logger      *zap.Logger
+	productRepo repositories.IProductRepo
+	policy      *models.StoragePolicy
+}
+
+// NewStorageService - конструктор для создания нового экземпляра StorageService.
+// Принимает логгер, репозиторий товаров и сроки хранения товаров.
+func NewStorageService(logger *zap.Logger, productRepo repositories.IProductRepo, policy *models.StoragePolicy) StorageService {
+	return &storageServiceImpl{
+		logger:      logger,
+		productRepo: productRepo,
+		policy:      policy,
+	}
+}
+
+// ListExpiring возвращает хранящиеся в ПВЗ товары из закрытых приемок, срок хранения которых
+// уже истек или истечет в течение models.StoragePolicy.Warning.
+// Проводит валидацию роли пользователя (только models.RoleEmployee и models.RoleModerator могут просматривать товары).
+// Товары отсортированы по времени истечения срока хранения.
+func (s *storageServiceImpl) ListExpiring(ctx context.Context, userRole string, pvzID uuid.UUID) ([]*models.ExpiringProduct, error) {
+	roleType := models.RoleType(userRole)
+	if roleType != models.RoleEmployee && roleType != models.RoleModerator {
+		l.FromContext(ctx, s.logger).Debug("User is not moderator or employee", zap.String("userRole", userRole))
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	now := time.Now()
+
+	products, city, err := s.productRepo.ListExpiring(ctx, pvzID, s.policy.Deadlines(now.Add(s.policy.Warning)))
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		return nil, err
+	}
+
+	expiring := make([]*models.ExpiringProduct, 0, len(products))
+
+	for _, product := range products {
+		expiresAt := s.policy.ExpiresAt(city, product)
+
+		expiring = append(expiring, &models.ExpiringProduct{
+			Product:   product,
+			ExpiresAt: expiresAt,
+			Expired:   !now.Before(expiresAt),
+		})
+	}
+
+	// Товары разных типов хранятся разное время, поэтому порядок приемки не совпадает с порядком истечения срока
+	sort.SliceStable(expiring, func(i, j int) bool {
+		return expiring[i].ExpiresAt.Before(expiring[j].ExpiresAt)
+	})
+
+	return expiring, nil
+}
+
+// ReturnToSender переносит все хранящиеся в ПВЗ товары с истекшим сроком хранения
+// в исходящую отправку отправителю. Пользователь сохраняется как создавший отправку.
+// Проводит валидацию роли пользователя (только models.RoleEmployee может возвращать товары).
+// Возвращенные товары получают статус models.ProductStatusReturnedToSender и больше не могут быть выданы или удалены.
+// Если возвращать нечего, возвращает domainerrors.ErrNoExpiredProducts.
+func (s *storageServiceImpl) ReturnToSender(ctx context.Context, userID uuid.UUID, userRole string, pvzID uuid.UUID) (*models.Shipment, error) {
+	if models.RoleType(userRole) != models.RoleEmployee {
+		return nil, domainerrors.ErrNotEnoughRights
+	}
+
+	now := time.Now()
+
+	// Отправка собирается целиком и сразу уходит из ПВЗ
+	shipment := &models.Shipment{
+		ID:           uuid.New(),
+		PVZID:        pvzID,
+		Kind:         models.ShipmentKindReturnToSender,
+		CreatedBy:    userID,
+		DateTime:     now,
+		DispatchedAt: &now,
+	}
+
+	returned, city, err := s.productRepo.ReturnToSender(ctx, shipment, s.policy.Deadlines(now))
+	if err != nil {
+		switch {
+		case errors.Is(err, databaseerrors.ErrNoRows):
+			return nil, domainerrors.ErrPVZNotFound
+		case errors.Is(err, databaseerrors.ErrUnexpected):
+			return nil, domainerrors.ErrUnexpected
+		}
+
+		l.FromContext(ctx, s.logger).Debug("Products can not be returned to sender", zap.String("pvzID", pvzID.String()), zap.Error(err))
+
+		return nil, err
+	}
+
+	for _, product := range returned.Products {
+		metrics.ProductsReturnedToSender.WithLabelValues(city.String(), product.Type.String()).Inc()
+	}
+
+	return returned, nil
+}
+
+// RefreshExpiryMetrics пересчитывает количество хранящихся товаров с истекшим сроком хранения по городам и типам.
+// Для городов и типов товаров без таких товаров метрика выставляется в 0, чтобы она не пропадала из выдачи.
+// Вызывается фоновой задачей, а не пользователем, поэтому роль не проверяется.
+func (s *storageServiceImpl) RefreshExpiryMetrics(ctx context.Context) error {
+	counts, err := s.productRepo.CountExpired(ctx, s.policy.Deadlines(time.Now()))
+	if err != nil {
+		if errors.Is(err, databaseerrors.ErrUnexpected) {
+			return domainerrors.ErrUnexpected
+		}
+
+		return err
+	}
+
+	for _, city := range models.AllCityTypes() {
+		for _, productType := range models.AllProductTypes() {
+			metrics.ProductsStorageExpired.WithLabelValues(city.String(), productType.String()).
+				Set(float64(counts[city][productType]))
+		}
+	}
+
+	return nil
+}
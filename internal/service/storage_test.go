@@ -0,0 +1,202 @@
+//go:build unit
+// +build unit
+
+package service_test
+
+import (
+	"context"
+	"github.com/google/uuid"
+	domainerrors "github.com/maksemen2/pvz-service/internal/domain/errors"
+	"github.com/maksemen2/pvz-service/internal/domain/models"
+	mock_repositories "github.com/maksemen2/pvz-service/internal/domain/repositories/mocks"
+	"github.com/maksemen2/pvz-service/internal/pkg/metrics"
+	databaseerrors "github.com/maksemen2/pvz-service/internal/repository/errors"
+	"github.com/maksemen2/pvz-service/internal/service"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"go.uber.org/zap"
+	"testing"
+	"time"
+)
+
+const day = 24 * time.Hour
+
+func testStoragePolicy() *models.StoragePolicy {
+	return &models.StoragePolicy{
+		Period:     14 * day,
+		TypePeriod: map[models.ProductType]time.Duration{models.ProductTypeElectronics: 7 * day},
+		CityPeriod: map[models.CityType]time.Duration{models.CityTypeKazan: 10 * day},
+		Warning:    2 * day,
+	}
+}
+
+// deadlineFor возвращает границу для города и типа товара из списка границ.
+func deadlineFor(t *testing.T, deadlines []models.StorageDeadline, city models.CityType, productType models.ProductType) time.Time {
+	t.Helper()
+
+	for _, deadline := range deadlines {
+		if deadline.City == city && deadline.Type == productType {
+			return deadline.ReceivedBy
+		}
+	}
+
+	require.Failf(t, "deadline not found", "%s %s", city, productType)
+
+	return time.Time{}
+}
+
+func TestStoragePolicy_PeriodFor(t *testing.T) {
+	policy := testStoragePolicy()
+
+	// Срок для типа товара важнее срока для города
+	assert.Equal(t, 7*day, policy.PeriodFor(models.CityTypeKazan, models.ProductTypeElectronics))
+	assert.Equal(t, 10*day, policy.PeriodFor(models.CityTypeKazan, models.ProductTypeShoes))
+	assert.Equal(t, 14*day, policy.PeriodFor(models.CityTypeMoscow, models.ProductTypeShoes))
+
+	now := time.Now()
+	deadlines := policy.Deadlines(now)
+	assert.Len(t, deadlines, len(models.AllCityTypes())*len(models.AllProductTypes()))
+	assert.Equal(t, now.Add(-10*day), deadlineFor(t, deadlines, models.CityTypeKazan, models.ProductTypeClothes))
+	assert.Equal(t, now.Add(-7*day), deadlineFor(t, deadlines, models.CityTypeMoscow, models.ProductTypeElectronics))
+}
+
+func TestListExpiring(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	svc := service.NewStorageService(zap.NewNop(), mockRepo, testStoragePolicy())
+
+	pvzID := uuid.New()
+
+	t.Run("Successful listing", func(t *testing.T) {
+		now := time.Now()
+		shoes := &models.Product{ID: uuid.New(), Type: models.ProductTypeShoes, DateTime: now.Add(-9 * day)}
+		electronics := &models.Product{ID: uuid.New(), Type: models.ProductTypeElectronics, DateTime: now.Add(-8 * day)}
+
+		mockRepo.EXPECT().
+			ListExpiring(gomock.Any(), pvzID, gomock.Any()).
+			DoAndReturn(func(_ context.Context, _ uuid.UUID, deadlines []models.StorageDeadline) ([]*models.Product, models.CityType, error) {
+				// В список попадают товары, срок которых истечет в течение двух дней
+				receivedBy := deadlineFor(t, deadlines, models.CityTypeKazan, models.ProductTypeShoes)
+				assert.WithinDuration(t, now.Add(2*day-10*day), receivedBy, time.Minute)
+
+				return []*models.Product{shoes, electronics}, models.CityTypeKazan, nil
+			})
+
+		expiring, err := svc.ListExpiring(context.Background(), models.RoleModerator.String(), pvzID)
+		require.NoError(t, err)
+		require.Len(t, expiring, 2)
+
+		// Товары отсортированы по времени истечения срока, а не по времени приемки
+		assert.Equal(t, electronics, expiring[0].Product)
+		assert.Equal(t, electronics.DateTime.Add(7*day), expiring[0].ExpiresAt)
+		assert.True(t, expiring[0].Expired)
+
+		assert.Equal(t, shoes, expiring[1].Product)
+		assert.Equal(t, shoes.DateTime.Add(10*day), expiring[1].ExpiresAt)
+		assert.False(t, expiring[1].Expired)
+	})
+
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.ListExpiring(context.Background(), "client", pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("PVZ not found", func(t *testing.T) {
+		mockRepo.EXPECT().ListExpiring(gomock.Any(), pvzID, gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrNoRows)
+
+		_, err := svc.ListExpiring(context.Background(), models.RoleEmployee.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
+	})
+}
+
+func TestReturnToSender(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	svc := service.NewStorageService(zap.NewNop(), mockRepo, testStoragePolicy())
+
+	userID := uuid.New()
+	pvzID := uuid.New()
+
+	t.Run("Successful return", func(t *testing.T) {
+		counter := metrics.ProductsReturnedToSender.WithLabelValues(models.CityTypeMoscow.String(), models.ProductTypeClothes.String())
+		returnedBefore := testutil.ToFloat64(counter)
+
+		mockRepo.EXPECT().
+			ReturnToSender(gomock.Any(), gomock.Any(), gomock.Any()).
+			DoAndReturn(func(_ context.Context, shipment *models.Shipment, deadlines []models.StorageDeadline) (*models.Shipment, models.CityType, error) {
+				assert.NotEqual(t, uuid.Nil, shipment.ID)
+				assert.Equal(t, pvzID, shipment.PVZID)
+				assert.Equal(t, models.ShipmentKindReturnToSender, shipment.Kind)
+				assert.Equal(t, userID, shipment.CreatedBy)
+				require.NotNil(t, shipment.DispatchedAt)
+				assert.Equal(t, shipment.DateTime, *shipment.DispatchedAt)
+
+				// Возвращаются только товары с уже истекшим сроком
+				receivedBy := deadlineFor(t, deadlines, models.CityTypeMoscow, models.ProductTypeClothes)
+				assert.Equal(t, shipment.DateTime.Add(-14*day), receivedBy)
+
+				result := *shipment
+				result.Products = []*models.Product{{ID: uuid.New(), Type: models.ProductTypeClothes, Status: models.ProductStatusReturnedToSender}}
+
+				return &result, models.CityTypeMoscow, nil
+			})
+
+		shipment, err := svc.ReturnToSender(context.Background(), userID, models.RoleEmployee.String(), pvzID)
+		require.NoError(t, err)
+		assert.Len(t, shipment.Products, 1)
+		assert.Equal(t, returnedBefore+1, testutil.ToFloat64(counter))
+	})
+
+	// Только сотрудник может возвращать товары
+	t.Run("Invalid role", func(t *testing.T) {
+		_, err := svc.ReturnToSender(context.Background(), userID, models.RoleModerator.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrNotEnoughRights)
+	})
+
+	t.Run("No expired products", func(t *testing.T) {
+		mockRepo.EXPECT().ReturnToSender(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), domainerrors.ErrNoExpiredProducts)
+
+		_, err := svc.ReturnToSender(context.Background(), userID, models.RoleEmployee.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrNoExpiredProducts)
+	})
+
+	t.Run("PVZ not found", func(t *testing.T) {
+		mockRepo.EXPECT().ReturnToSender(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrNoRows)
+
+		_, err := svc.ReturnToSender(context.Background(), userID, models.RoleEmployee.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrPVZNotFound)
+	})
+
+	t.Run("Unexpected error", func(t *testing.T) {
+		mockRepo.EXPECT().ReturnToSender(gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, models.CityType(""), databaseerrors.ErrUnexpected)
+
+		_, err := svc.ReturnToSender(context.Background(), userID, models.RoleEmployee.String(), pvzID)
+		assert.ErrorIs(t, err, domainerrors.ErrUnexpected)
+	})
+}
+
+func TestRefreshExpiryMetrics(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockRepo := mock_repositories.NewMockIProductRepo(ctrl)
+	svc := service.NewStorageService(zap.NewNop(), mockRepo, testStoragePolicy())
+
+	mockRepo.EXPECT().CountExpired(gomock.Any(), gomock.Any()).Return(map[models.CityType]map[models.ProductType]int{
+		models.CityTypeMoscow: {models.ProductTypeShoes: 3},
+	}, nil)
+
+	require.NoError(t, svc.RefreshExpiryMetrics(context.Background()))
+	assert.Equal(t, 3.0, testutil.ToFloat64(metrics.ProductsStorageExpired.WithLabelValues(models.CityTypeMoscow.String(), models.ProductTypeShoes.String())))
+	// Города и типы без товаров выставляются в 0
+	assert.Equal(t, 0.0, testutil.ToFloat64(metrics.ProductsStorageExpired.WithLabelValues(models.CityTypeKazan.String(), models.ProductTypeClothes.String())))
+
+	mockRepo.EXPECT().CountExpired(gomock.Any(), gomock.Any()).Return(nil, databaseerrors.ErrUnexpected)
+	assert.ErrorIs(t, svc.RefreshExpiryMetrics(context.Background()), domainerrors.ErrUnexpected)
+}
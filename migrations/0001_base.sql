@@ -0,0 +1,96 @@
+-- Исходная схема вместе с изменениями, внесенными до появления версий схемы.
+-- Колонки, добавленные позже создания таблиц, добавляются через ALTER TABLE, чтобы обновить уже существующую базу.
+
+CREATE TABLE IF NOT EXISTS pvzs (
+    id UUID PRIMARY KEY,
+    registration_date TIMESTAMP NOT NULL,
+    city VARCHAR(50) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS receptions (
+  id UUID PRIMARY KEY,
+  pvz_id UUID NOT NULL REFERENCES pvzs(id),
+  date_time TIMESTAMP NOT NULL,
+  status VARCHAR(20) NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS products (
+    id UUID PRIMARY KEY,
+    date_time TIMESTAMP NOT NULL,
+    type VARCHAR(50) NOT NULL,
+    reception_id UUID NOT NULL REFERENCES receptions(id)
+);
+
+CREATE TABLE IF NOT EXISTS users (
+     id UUID PRIMARY KEY,
+     email VARCHAR(255) UNIQUE NOT NULL,
+     password_hash VARCHAR(255) NOT NULL,
+     role VARCHAR(50) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_reception_date_time ON receptions (date_time);
+CREATE INDEX IF NOT EXISTS idx_product_reception_id ON products (reception_id);
+CREATE INDEX IF NOT EXISTS idx_reception_pvz_id ON receptions (pvz_id);
+
+-- Жизненный цикл приемки: кто открыл и закрыл, автозакрытие, переоткрытие
+ALTER TABLE receptions
+    ADD COLUMN IF NOT EXISTS opened_by UUID,
+    ADD COLUMN IF NOT EXISTS closed_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS closed_by UUID,
+    ADD COLUMN IF NOT EXISTS auto_closed BOOLEAN NOT NULL DEFAULT FALSE,
+    ADD COLUMN IF NOT EXISTS close_reason TEXT,
+    ADD COLUMN IF NOT EXISTS reopened_at TIMESTAMP,
+    ADD COLUMN IF NOT EXISTS reopened_by UUID;
+
+CREATE INDEX IF NOT EXISTS idx_product_date_time ON products (date_time);
+CREATE INDEX IF NOT EXISTS idx_reception_status_date_time ON receptions (status, date_time);
+
+-- Манифесты и отчеты о расхождениях
+CREATE TABLE IF NOT EXISTS reception_manifests (
+    id UUID PRIMARY KEY,
+    pvz_id UUID NOT NULL REFERENCES pvzs(id),
+    reception_id UUID UNIQUE REFERENCES receptions(id),
+    created_by UUID,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reception_manifest_items (
+    manifest_id UUID NOT NULL REFERENCES reception_manifests(id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    expected_count INTEGER NOT NULL,
+    PRIMARY KEY (manifest_id, type)
+);
+
+CREATE TABLE IF NOT EXISTS reception_discrepancy_reports (
+    reception_id UUID PRIMARY KEY REFERENCES receptions(id),
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE TABLE IF NOT EXISTS reception_discrepancy_items (
+    reception_id UUID NOT NULL REFERENCES reception_discrepancy_reports(reception_id) ON DELETE CASCADE,
+    type VARCHAR(50) NOT NULL,
+    kind VARCHAR(20) NOT NULL,
+    expected_count INTEGER NOT NULL,
+    actual_count INTEGER NOT NULL,
+    PRIMARY KEY (reception_id, type)
+);
+
+-- У ПВЗ может быть только один манифест, ожидающий привязки к приемке
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reception_manifest_pending ON reception_manifests (pvz_id) WHERE reception_id IS NULL;
+
+-- Ключи идемпотентности
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    user_id UUID NOT NULL,
+    idempotency_key VARCHAR(255) NOT NULL,
+    fingerprint VARCHAR(64) NOT NULL,
+    status_code INTEGER,
+    content_type VARCHAR(255),
+    response_body BYTEA,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (user_id, idempotency_key)
+);
+
+-- Версии для ETag и If-Match
+ALTER TABLE pvzs ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
+ALTER TABLE receptions ADD COLUMN IF NOT EXISTS version INTEGER NOT NULL DEFAULT 1;
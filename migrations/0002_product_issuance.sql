@@ -0,0 +1,14 @@
+-- Статусы товаров и выдача клиентам
+CREATE TABLE IF NOT EXISTS issuances (
+    id UUID PRIMARY KEY,
+    pvz_id UUID NOT NULL REFERENCES pvzs(id),
+    issued_by UUID NOT NULL,
+    issued_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS status VARCHAR(20) NOT NULL DEFAULT 'stored',
+    ADD COLUMN IF NOT EXISTS issuance_id UUID REFERENCES issuances(id),
+    ADD COLUMN IF NOT EXISTS issued_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_product_issuance_id ON products (issuance_id);
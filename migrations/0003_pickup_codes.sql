@@ -0,0 +1,26 @@
+-- Заказы и коды получения
+ALTER TABLE products ADD COLUMN IF NOT EXISTS order_id UUID;
+-- Товары, принятые до появления заказов, считаются отдельными заказами
+UPDATE products SET order_id = id WHERE order_id IS NULL;
+ALTER TABLE products ALTER COLUMN order_id SET NOT NULL;
+
+CREATE TABLE IF NOT EXISTS pickup_codes (
+    id UUID PRIMARY KEY,
+    pvz_id UUID NOT NULL REFERENCES pvzs(id),
+    order_id UUID NOT NULL,
+    code_hash VARCHAR(255) NOT NULL,
+    attempts INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+
+CREATE TABLE IF NOT EXISTS pickup_code_failures (
+    pvz_id UUID NOT NULL REFERENCES pvzs(id),
+    failed_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_order_id ON products (order_id);
+CREATE INDEX IF NOT EXISTS idx_pickup_code_failure_pvz_failed_at ON pickup_code_failures (pvz_id, failed_at);
+-- У заказа в ПВЗ может быть только один неиспользованный код получения
+CREATE UNIQUE INDEX IF NOT EXISTS idx_pickup_code_active ON pickup_codes (pvz_id, order_id) WHERE used_at IS NULL;
@@ -0,0 +1,14 @@
+-- Сроки хранения и возврат отправителю
+CREATE TABLE IF NOT EXISTS shipments (
+    id UUID PRIMARY KEY,
+    pvz_id UUID NOT NULL REFERENCES pvzs(id),
+    kind VARCHAR(30) NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE products
+    ADD COLUMN IF NOT EXISTS shipment_id UUID REFERENCES shipments(id),
+    ADD COLUMN IF NOT EXISTS returned_at TIMESTAMP;
+
+CREATE INDEX IF NOT EXISTS idx_product_shipment_id ON products (shipment_id);
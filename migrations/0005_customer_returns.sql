@@ -0,0 +1,22 @@
+-- Возвраты клиентов
+ALTER TABLE shipments ADD COLUMN IF NOT EXISTS dispatched_at TIMESTAMP;
+-- Отправки возврата отправителю уходят из ПВЗ сразу после создания
+UPDATE shipments SET dispatched_at = created_at WHERE kind = 'return_to_sender' AND dispatched_at IS NULL;
+
+CREATE TABLE IF NOT EXISTS customer_returns (
+    id UUID PRIMARY KEY,
+    pvz_id UUID NOT NULL REFERENCES pvzs(id),
+    shipment_id UUID NOT NULL REFERENCES shipments(id),
+    product_id UUID UNIQUE REFERENCES products(id),
+    barcode VARCHAR(64),
+    type VARCHAR(50) NOT NULL,
+    reason VARCHAR(30) NOT NULL,
+    condition VARCHAR(20) NOT NULL,
+    created_by UUID NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_customer_return_pvz_created_at ON customer_returns (pvz_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_customer_return_shipment_id ON customer_returns (shipment_id);
+-- У ПВЗ может быть только одна собираемая отправка возвратов клиентов
+CREATE UNIQUE INDEX IF NOT EXISTS idx_shipment_pending_customer_returns ON shipments (pvz_id) WHERE kind = 'customer_returns' AND dispatched_at IS NULL;
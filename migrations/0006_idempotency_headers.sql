@@ -0,0 +1,5 @@
+-- Заголовки сохраненного ответа (ETag, Location и т.д.), которые повторяются вместе с ним
+ALTER TABLE idempotency_keys ADD COLUMN IF NOT EXISTS response_headers JSONB;
+
+-- Истекшие ключи всех пользователей периодически удаляются фоновой задачей
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys (expires_at);
@@ -0,0 +1,10 @@
+// Package migrations содержит миграции схемы базы данных.
+package migrations
+
+import "embed"
+
+// FS - файлы миграций вида NNNN_описание.sql. Номер в имени файла - версия схемы, которую создает миграция.
+// Примененные миграции не изменяются: любое изменение схемы добавляется новым файлом.
+//
+//go:embed *.sql
+var FS embed.FS